@@ -15,71 +15,86 @@ import (
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/bridge"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/config"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/health"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/logging"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/qr"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/webhook"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/whatsapp"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/api"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
-	"github.com/mdp/qrterminal/v3"
-	"github.com/skip2/go-qrcode"
 )
 
 var (
 	configPath = flag.String("config", "config.yaml", "Path to config file")
 	logLevel   = flag.String("log-level", "", "Log level (debug, info, warn, error)")
 	daemon     = flag.Bool("daemon", false, "Run as a background daemon (stay alive even without an MCP client)")
+	account    = flag.String("account", "", "Account ID; keeps this account's session and store in their own subdirectory")
+
+	storePath   = flag.String("store-path", "", "Path to the message store database (overrides store_path; used as given, even with --account)")
+	sessionPath = flag.String("session-path", "", "Path to the WhatsApp session database (overrides session_path; used as given, even with --account)")
 )
 
+// flagOverrides holds the command line settings that take precedence over
+// the config file and environment. Empty fields leave the config alone.
+type flagOverrides struct {
+	logLevel    string
+	account     string
+	storePath   string
+	sessionPath string
+}
+
+// apply merges the overrides into cfg, validates it and namespaces the
+// paths by account. Explicit path flags are applied last so they name the
+// exact file to use.
+func (o flagOverrides) apply(cfg *config.Config) error {
+	if o.logLevel != "" {
+		cfg.LogLevel = o.logLevel
+	}
+	if o.account != "" {
+		cfg.AccountID = o.account
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	cfg.ApplyAccount()
+
+	if o.storePath != "" {
+		cfg.StorePath = o.storePath
+	}
+	if o.sessionPath != "" {
+		cfg.SessionPath = o.sessionPath
+	}
+	return nil
+}
+
 func main() {
 	flag.Parse()
 
 	// Load configuration
-	cfg, err := config.LoadConfig(*configPath)
+	cfg, err := loadConfig()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
 
-	// Override log level from flag if provided
-	if *logLevel != "" {
-		cfg.LogLevel = *logLevel
-	}
-
-	// Validate config
-	if err := cfg.Validate(); err != nil {
-		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+	// Setup logging
+	logger, closeLog, err := logging.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up logging: %v\n", err)
 		os.Exit(1)
 	}
-
-	// Setup logging
-	var level slog.Level
-	switch cfg.LogLevel {
-	case "debug":
-		level = slog.LevelDebug
-	case "info":
-		level = slog.LevelInfo
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
-	default:
-		level = slog.LevelInfo
-	}
-
-	var logHandler slog.Handler
-	if cfg.LogFormat == "text" {
-		logHandler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})
-	} else {
-		logHandler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})
-	}
-	logger := slog.New(logHandler)
+	defer closeLog()
 	slog.SetDefault(logger)
 
 	logger.Info("WhatsApp Bridge V2 starting",
 		"config", *configPath,
 		"log_level", cfg.LogLevel,
+		"account", cfg.AccountID,
 	)
 
-	// Ensure data directory exists (needed when using default ~/.whatsapp-mcp/ path)
+	// Ensure data directories exist (needed for the default ~/.whatsapp-mcp/
+	// path and for per-account subdirectories)
 	if err := os.MkdirAll(filepath.Dir(cfg.StorePath), 0700); err != nil {
 		logger.Error("Failed to create data directory", "error", err)
 		os.Exit(1)
@@ -89,8 +104,17 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Fail early with a clear message if the data directories are read-only
+	// or the disk is full; SQLite's own errors don't name the cause.
+	for _, dir := range []string{filepath.Dir(cfg.StorePath), filepath.Dir(cfg.SessionPath)} {
+		if err := store.CheckWritable(dir); err != nil {
+			logger.Error("Data directory is not usable", "path", dir, "error", err)
+			os.Exit(1)
+		}
+	}
+
 	// Initialize store
-	storeDB, err := store.NewSQLiteStore(cfg.StorePath)
+	storeDB, err := store.NewSQLiteStoreWithOptions(cfg.StorePath, store.Options{BusyTimeout: cfg.StoreBusyTimeout})
 	if err != nil {
 		logger.Error("Failed to initialize store", "error", err)
 		os.Exit(1)
@@ -106,8 +130,11 @@ func main() {
 
 	// Initialize WhatsApp client
 	waConfig := &whatsapp.Config{
-		StorePath: cfg.SessionPath,
-		StateMgr:  nil,
+		StorePath:        cfg.SessionPath,
+		StateMgr:         nil,
+		MediaAllowedDirs: cfg.MediaAllowedDirs,
+		MediaLimit:       cfg.MediaLimit,
+		LogLevel:         cfg.WhatsmeowLogLevel,
 	}
 	waClient, err := whatsapp.NewClient(ctx, waConfig, logger)
 	if err != nil {
@@ -117,7 +144,7 @@ func main() {
 	defer waClient.Disconnect()
 
 	// Initialize bridge and state machine
-	bridgeClient := bridge.NewBridge(cfg, storeDB, waClient)
+	bridgeClient := bridge.NewBridge(cfg, storeDB.Store(), waClient)
 	bridgeSM := bridgeClient.GetStateMachine()
 
 	// Initialize health monitor
@@ -125,6 +152,29 @@ func main() {
 	hm.Start()
 	defer hm.Stop()
 
+	// Forward incoming messages and state changes to the webhook. The
+	// notifier idles while webhook_url is empty, so a reload can enable it.
+	notifier := webhook.NewNotifier(cfg, logger)
+	bridgeClient.OnEvent(notifier.HandleEvent)
+	notifier.Start()
+	defer notifier.Stop()
+
+	// Re-read the config on SIGHUP
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		running := cfg
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hupChan:
+				running = reloadConfig(logger, running, notifier, bridgeClient)
+			}
+		}
+	}()
+
+	// Safety net for early returns; a no-op once Shutdown has run below.
 	defer bridgeClient.Stop()
 
 	// Get QR channel before connecting
@@ -137,37 +187,23 @@ func main() {
 		}
 	}()
 
-	// Handle QR codes in background - save to file and print to stderr
-	qrFilePath := filepath.Join(filepath.Dir(cfg.StorePath), "qrcode.png")
-	go func() {
-		for qr := range qrChan {
-			// Save QR code as PNG image file
-			if err := qrcode.WriteFile(qr, qrcode.Medium, 256, qrFilePath); err == nil {
-				logger.Info("QR code saved to file - open this file to scan",
-					"path", qrFilePath,
-				)
-				fmt.Fprintf(os.Stderr, "\n╔══════════════════════════════════════════════════════╗\n")
-				fmt.Fprintf(os.Stderr, "║  QR CODE SAVED - Open this file to scan with phone:  ║\n")
-				fmt.Fprintf(os.Stderr, "║  %s\n", qrFilePath)
-				fmt.Fprintf(os.Stderr, "╚══════════════════════════════════════════════════════╝\n\n")
-			} else {
-				logger.Error("Failed to save QR code to file", "error", err)
-			}
-
-			// Also print to stderr for terminals that support it
-			fmt.Fprintln(os.Stderr, "╔══════════════════════════════════════════╗")
-			fmt.Fprintln(os.Stderr, "║  Scan this QR code with WhatsApp Mobile  ║")
-			fmt.Fprintln(os.Stderr, "╚══════════════════════════════════════════╝")
-			qrterminal.GenerateHalfBlock(qr, qrterminal.L, os.Stderr)
-			fmt.Fprintln(os.Stderr, "")
-		}
-	}()
+	// Handle QR codes in background according to qr_output
+	qrFilePath := cfg.QRFilePath
+	if qrFilePath == "" {
+		qrFilePath = filepath.Join(filepath.Dir(cfg.StorePath), "qrcode.png")
+	}
+	qrRenderer := qr.NewRenderer(cfg.QROutput, qrFilePath, os.Stderr, logger)
+	go qrRenderer.Run(ctx, qrChan)
 
 	// Initialize API handler with WhatsApp client
-	handler := api.NewHandler(storeDB, hm, bridgeClient, bridgeSM)
+	handler := api.NewHandler(cfg, storeDB.Store(), hm, bridgeClient, bridgeSM)
 
 	// Initialize MCP server with stdio transport
 	mcpServer := mcp.NewServer(os.Stdin, os.Stdout, handler, logger)
+	mcpServer.SetToolCallTimeout(cfg.ToolCallTimeout)
+
+	// Tell clients about new messages in chats they subscribed to
+	bridgeClient.OnEvent(api.ChatUpdateNotifier(mcpServer, logger))
 
 	logger.Info("Bridge initialized",
 		"store_path", cfg.StorePath,
@@ -184,9 +220,8 @@ func main() {
 	// Wait for shutdown signal or server error
 	select {
 	case sig := <-sigChan:
+		// The server keeps running until in-flight calls have drained below
 		logger.Info("Received shutdown signal", "signal", sig)
-		cancel() // Signal server to stop
-		bridgeClient.Disconnect()
 	case err := <-errChan:
 		if err != nil && err != context.Canceled {
 			logger.Error("MCP server error", "error", err)
@@ -209,10 +244,10 @@ func main() {
 			break
 		}
 
-		// Non-daemon mode: if still pairing, wait up to 5 min for QR scan.
+		// Non-daemon mode: if still pairing, wait up to pairing_timeout for QR scan.
 		if currentState == "qr_pending" || currentState == "connecting" || currentState == "authenticating" {
-			logger.Info("MCP client disconnected during QR pairing - waiting for scan (up to 5 min)")
-			timeout := time.NewTimer(5 * time.Minute)
+			logger.Info("MCP client disconnected during QR pairing - waiting for scan", "timeout", cfg.PairingTimeout)
+			timeout := time.NewTimer(cfg.PairingTimeout)
 			defer timeout.Stop()
 			ticker := time.NewTicker(1 * time.Second)
 			defer ticker.Stop()
@@ -239,6 +274,74 @@ func main() {
 		}
 	}
 
-	// Graceful shutdown
+	// Let in-flight tool calls finish before cancelling their context, so a
+	// send isn't cut off mid-upload. New calls are refused meanwhile.
+	if !handler.Drain(cfg.ShutdownGracePeriod) {
+		logger.Warn("Shutdown grace period elapsed with tool calls still running", "grace_period", cfg.ShutdownGracePeriod)
+	}
+	cancel()
+
+	// Graceful shutdown: disconnect cleanly before entering ShuttingDown
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+	if err := bridgeClient.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Bridge shutdown error", "error", err)
+	}
+
 	logger.Info("WhatsApp Bridge V2 stopped")
 }
+
+// loadConfig reads the config file, merges the command-line flags over it
+// and validates the result. It runs at startup and again on SIGHUP.
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	overrides := flagOverrides{
+		logLevel:    *logLevel,
+		account:     *account,
+		storePath:   *storePath,
+		sessionPath: *sessionPath,
+	}
+	if err := overrides.apply(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// reloadConfig re-reads the config and applies the settings that can change
+// while running: log_level, webhook_url and per_recipient_cooldown. Changes
+// to anything else, such as the store paths, are logged and ignored. It
+// returns the config now in effect, which is running with the reloaded
+// settings swapped in.
+func reloadConfig(logger *slog.Logger, running *config.Config, notifier *webhook.Notifier, b *bridge.Bridge) *config.Config {
+	next, err := loadConfig()
+	if err != nil {
+		logger.Error("Config reload failed, keeping current settings", "error", err)
+		return running
+	}
+
+	if keys := running.RestartRequired(next); len(keys) > 0 {
+		logger.Warn("Config reload ignored settings that only apply at startup", "settings", keys)
+	}
+
+	applied := *running
+	if next.LogLevel != running.LogLevel {
+		logging.SetLevel(next.LogLevel)
+		applied.LogLevel = next.LogLevel
+	}
+	if next.WebhookURL != running.WebhookURL {
+		notifier.SetURL(next.WebhookURL)
+		applied.WebhookURL = next.WebhookURL
+	}
+	if next.PerRecipientCooldown != running.PerRecipientCooldown {
+		b.SetPerRecipientCooldown(next.PerRecipientCooldown)
+		applied.PerRecipientCooldown = next.PerRecipientCooldown
+	}
+
+	logger.Info("Config reloaded", "log_level", applied.LogLevel, "webhook_enabled", applied.WebhookURL != "",
+		"per_recipient_cooldown", applied.PerRecipientCooldown)
+	return &applied
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/config"
+)
+
+func loadTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	yaml := "store_path: " + filepath.Join(dir, "from-config", "messages.db") + "\n" +
+		"session_path: " + filepath.Join(dir, "from-config", "whatsapp.db") + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(yaml), 0600))
+
+	cfg, err := config.LoadConfig(path)
+	require.NoError(t, err)
+	return cfg
+}
+
+func TestFlagOverrides_PathsOverrideConfig(t *testing.T) {
+	cfg := loadTestConfig(t)
+	configStore := cfg.StorePath
+
+	err := flagOverrides{storePath: "/tmp/flag/messages.db", sessionPath: "/tmp/flag/whatsapp.db"}.apply(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/flag/messages.db", cfg.StorePath)
+	assert.Equal(t, "/tmp/flag/whatsapp.db", cfg.SessionPath)
+	assert.NotEqual(t, configStore, cfg.StorePath)
+}
+
+func TestFlagOverrides_EmptyKeepsConfig(t *testing.T) {
+	cfg := loadTestConfig(t)
+	want := *cfg
+
+	require.NoError(t, flagOverrides{}.apply(cfg))
+	assert.Equal(t, want.StorePath, cfg.StorePath)
+	assert.Equal(t, want.SessionPath, cfg.SessionPath)
+}
+
+func TestFlagOverrides_AccountDoesNotNamespaceExplicitPaths(t *testing.T) {
+	cfg := loadTestConfig(t)
+	configSession := cfg.SessionPath
+
+	err := flagOverrides{account: "work", storePath: "/tmp/flag/messages.db"}.apply(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/flag/messages.db", cfg.StorePath)
+	assert.Equal(t, filepath.Join(filepath.Dir(configSession), "work", "whatsapp.db"), cfg.SessionPath,
+		"paths from the config are still namespaced by account")
+}
+
+func TestFlagOverrides_InvalidLogLevel(t *testing.T) {
+	cfg := loadTestConfig(t)
+	err := flagOverrides{logLevel: "loud"}.apply(cfg)
+	assert.ErrorContains(t, err, "invalid config")
+}
@@ -0,0 +1,53 @@
+package bridge
+
+import (
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// BanInfo describes the temporary ban WhatsApp reported for the account.
+type BanInfo struct {
+	Code   int    `json:"code"`
+	Reason string `json:"reason"`
+	// ExpiresAt is when the ban is expected to be lifted; nil if WhatsApp
+	// didn't say.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// recordBan keeps the details of a temporary ban for TemporaryBan.
+func (b *Bridge) recordBan(evt *events.TemporaryBan) {
+	info := BanInfo{Code: int(evt.Code), Reason: evt.Code.String()}
+	if evt.Expire > 0 {
+		expires := time.Now().Add(evt.Expire)
+		info.ExpiresAt = &expires
+	}
+
+	b.mu.Lock()
+	b.ban = &info
+	b.mu.Unlock()
+}
+
+// clearBan forgets the recorded ban once the account connects again.
+func (b *Bridge) clearBan() {
+	b.mu.Lock()
+	b.ban = nil
+	b.mu.Unlock()
+}
+
+// TemporaryBan returns the details of the temporary ban WhatsApp last
+// reported, for as long as it lasts: until its expiry passes or the account
+// connects again. A ban without an expiry lasts until the next connection.
+// The bridge may have left the temporary_ban state in the meantime, e.g. on
+// a manual disconnect, without the ban being lifted.
+func (b *Bridge) TemporaryBan() (BanInfo, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.ban == nil {
+		return BanInfo{}, false
+	}
+	if b.ban.ExpiresAt != nil && !time.Now().Before(*b.ban.ExpiresAt) {
+		return BanInfo{}, false
+	}
+	return *b.ban, true
+}
@@ -2,35 +2,58 @@ package bridge
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
 
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/config"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/state"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/whatsapp"
 )
 
 // Bridge is the core WhatsApp bridge that manages connection, state, and events.
 type Bridge struct {
 	client       WhatsAppClient
 	stateMachine *state.Machine
-	store        *store.SQLiteStore
+	store        *store.Store
 	config       *config.Config
 	log          *slog.Logger
 
 	events         chan Event
+	droppedEvents  atomic.Int64
 	eventListeners []func(Event)
 	stateListeners []func(from, to state.State)
 
-	ctx    context.Context
-	cancel context.CancelFunc
-	wg     sync.WaitGroup
-	mu     sync.RWMutex
+	ctx          context.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+	mu           sync.RWMutex
+	shutdownOnce sync.Once
+	shutdownErr  error
+
+	sent     *idempotencyCache
+	cooldown *recipientCooldown
+
+	// ban holds the details of the last temporary ban.
+	ban *BanInfo
+
+	// history counts the history sync batches stored since startup.
+	history historySyncCounts
+
+	// presenceCancel stops the PresenceMode loop started on the last
+	// transition to ready.
+	presenceCancel context.CancelFunc
 }
 
 // NewBridge creates a new WhatsApp bridge.
-func NewBridge(cfg *config.Config, storeDB *store.SQLiteStore, client WhatsAppClient) *Bridge {
+func NewBridge(cfg *config.Config, storeDB *store.Store, client WhatsAppClient) *Bridge {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	b := &Bridge{
@@ -39,9 +62,11 @@ func NewBridge(cfg *config.Config, storeDB *store.SQLiteStore, client WhatsAppCl
 		store:        storeDB,
 		config:       cfg,
 		log:          slog.Default(),
-		events:       make(chan Event, 100),
+		events:       make(chan Event, cfg.EventQueueSize),
 		ctx:          ctx,
 		cancel:       cancel,
+		sent:         newIdempotencyCache(idempotencyTTL, idempotencyMaxKeys),
+		cooldown:     newRecipientCooldown(cfg.PerRecipientCooldown),
 	}
 
 	// Register state transition callback
@@ -53,11 +78,25 @@ func NewBridge(cfg *config.Config, storeDB *store.SQLiteStore, client WhatsAppCl
 			b.log.Error("failed to save state", "error", err)
 		}
 
-		// Log transition
-		if err := b.store.State.LogTransition(ctx, from, to, string(trigger)); err != nil {
+		// Log transition, keeping the cause for failure triggers
+		var errText string
+		switch trigger {
+		case state.TriggerFatalError, state.TriggerSessionInvalid, state.TriggerBanDetected:
+			if cause := state.TransitionError(ctx); cause != nil {
+				errText = cause.Error()
+			}
+		}
+		if err := b.store.State.LogTransitionWithError(ctx, from, to, string(trigger), errText); err != nil {
 			b.log.Error("failed to log transition", "error", err)
 		}
 
+		b.EmitEvent(NewEvent(EventStateChange, StateChangePayload{
+			From:    string(from),
+			To:      string(to),
+			Trigger: string(trigger),
+			Error:   errText,
+		}))
+
 		// Notify listeners
 		b.mu.RLock()
 		listeners := make([]func(from, to state.State), len(b.stateListeners))
@@ -67,6 +106,10 @@ func NewBridge(cfg *config.Config, storeDB *store.SQLiteStore, client WhatsAppCl
 		for _, listener := range listeners {
 			listener(from, to)
 		}
+
+		if to == state.StateReady {
+			b.applyPresenceMode()
+		}
 	})
 
 	// Start event processor
@@ -76,7 +119,13 @@ func NewBridge(cfg *config.Config, storeDB *store.SQLiteStore, client WhatsAppCl
 	return b
 }
 
-// Connect initiates connection to WhatsApp.
+// ErrConnectTimeout is returned by Connect when the connection, or the QR
+// pairing of a new session, takes longer than the configured timeout.
+var ErrConnectTimeout = errors.New("timed out connecting to WhatsApp")
+
+// Connect initiates connection to WhatsApp. It gives up after
+// ConnectTimeout, or PairingTimeout when there is no stored session yet,
+// and moves the bridge to the fatal error state.
 func (b *Bridge) Connect(ctx context.Context) error {
 	if err := b.stateMachine.Fire(ctx, state.TriggerConnect); err != nil {
 		return fmt.Errorf("failed to transition to connecting: %w", err)
@@ -86,11 +135,29 @@ func (b *Bridge) Connect(ctx context.Context) error {
 	// This must be done before connecting so no events are missed.
 	b.registerWhatsAppEventHandler()
 
-	// Connect the client
-	if err := b.client.Connect(ctx); err != nil {
+	// Connect the client, waiting longer when a QR code must be scanned
+	timeout := b.config.ConnectTimeout
+	needsPairing, err := b.client.NeedsPairing(ctx)
+	if err != nil {
+		b.log.Warn("could not check for a stored session", "error", err)
+	} else if needsPairing {
+		timeout = b.config.PairingTimeout
+	}
+	connectCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := b.client.Connect(connectCtx); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			b.client.Disconnect()
+			if needsPairing {
+				err = fmt.Errorf("%w: QR code not scanned within %s", ErrConnectTimeout, timeout)
+			} else {
+				err = fmt.Errorf("%w: no connection within %s", ErrConnectTimeout, timeout)
+			}
+		}
 		// Don't fire fatal error on clean context cancellation (normal shutdown path)
 		if ctx.Err() == nil {
-			if smErr := b.stateMachine.Fire(context.Background(), state.TriggerFatalError); smErr != nil {
+			if smErr := b.stateMachine.FireWithError(context.Background(), state.TriggerFatalError, err); smErr != nil {
 				b.log.Error("state transition failed", "trigger", state.TriggerFatalError, "error", smErr)
 			}
 		}
@@ -122,14 +189,40 @@ func (b *Bridge) Disconnect() {
 	}
 }
 
-// Stop gracefully stops the bridge.
+// Stop gracefully stops the bridge. It is Shutdown with a background context.
 func (b *Bridge) Stop() {
-	if err := b.stateMachine.Fire(context.Background(), state.TriggerShutdown); err != nil {
-		b.log.Error("state transition failed", "trigger", state.TriggerShutdown, "error", err)
+	if err := b.Shutdown(context.Background()); err != nil {
+		b.log.Error("shutdown failed", "error", err)
 	}
-	b.cancel()
-	close(b.events)
-	b.wg.Wait()
+}
+
+// Shutdown disconnects from WhatsApp, moves the state machine through
+// Disconnected to ShuttingDown, stops event processing and checkpoints the
+// store. It is idempotent: only the first call does any work and later calls
+// return the same result.
+func (b *Bridge) Shutdown(ctx context.Context) error {
+	b.shutdownOnce.Do(func() {
+		b.client.Disconnect()
+
+		for _, trigger := range []state.Trigger{state.TriggerDisconnect, state.TriggerShutdown} {
+			if ok, _ := b.stateMachine.CanFire(ctx, trigger); !ok {
+				continue
+			}
+			if err := b.stateMachine.Fire(ctx, trigger); err != nil {
+				b.log.Error("state transition failed", "trigger", trigger, "error", err)
+			}
+		}
+
+		b.mu.Lock()
+		b.cancel()
+		b.mu.Unlock()
+		b.wg.Wait()
+
+		if err := b.store.Checkpoint(ctx); err != nil {
+			b.shutdownErr = fmt.Errorf("failed to checkpoint store: %w", err)
+		}
+	})
+	return b.shutdownErr
 }
 
 // CurrentState returns the current state of the bridge.
@@ -143,18 +236,120 @@ func (b *Bridge) IsReady() bool {
 	return b.CurrentState() == state.StateReady
 }
 
-// SendMessage sends a text message.
-func (b *Bridge) SendMessage(ctx context.Context, jid string, text string) (string, error) {
+// IsConnected returns true if the WhatsApp websocket is connected,
+// regardless of the bridge state.
+func (b *Bridge) IsConnected() bool {
+	return b.client.IsConnected()
+}
+
+// IsBusiness returns true if the linked account is a WhatsApp Business account.
+func (b *Bridge) IsBusiness() bool {
+	return b.client.IsBusiness()
+}
+
+// GetLinkedDevices lists the devices linked to the account.
+func (b *Bridge) GetLinkedDevices(ctx context.Context) ([]whatsapp.DeviceInfo, error) {
 	if !b.IsReady() {
-		return "", fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+		return nil, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.client.GetLinkedDevices(ctx)
+}
+
+// Ping measures the round-trip latency to the WhatsApp server.
+func (b *Bridge) Ping(ctx context.Context) (time.Duration, error) {
+	if !b.IsReady() {
+		return 0, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.client.Ping(ctx)
+}
+
+// SendMessage sends a text message, mentioning the given JIDs in group chats.
+func (b *Bridge) SendMessage(ctx context.Context, jid string, text string, mentions []string) (SendMessageResult, error) {
+	if !b.IsReady() {
+		return SendMessageResult{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
 
-	msgID, err := b.client.SendMessage(ctx, jid, text)
+	sent, err := b.sendOnce(ctx, jid, func() (whatsmeow.SendResponse, error) {
+		return b.client.SendMessage(ctx, jid, text, mentions)
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to send message: %w", err)
+		return SendMessageResult{}, fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return sent, nil
+}
+
+// SendQuotedMessage sends a text message quoting a message from any chat.
+func (b *Bridge) SendQuotedMessage(ctx context.Context, jid, text string, mentions []string, quotedChatJID, quotedID, quotedSender, quotedText string) (SendMessageResult, error) {
+	if !b.IsReady() {
+		return SendMessageResult{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+
+	sent, err := b.sendOnce(ctx, jid, func() (whatsmeow.SendResponse, error) {
+		return b.client.SendQuotedMessage(ctx, jid, text, mentions, quotedChatJID, quotedID, quotedSender, quotedText)
+	})
+	if err != nil {
+		return SendMessageResult{}, fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return sent, nil
+}
+
+// SendBroadcast sends the same text to each recipient individually, like a
+// WhatsApp broadcast list. Each send is retried and rate limited like
+// SendMessage. The returned message IDs and errors are aligned with
+// recipients; a failure for one recipient does not stop the others.
+func (b *Bridge) SendBroadcast(ctx context.Context, recipients []string, text string) ([]string, []error, error) {
+	if !b.IsReady() {
+		return nil, nil, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+
+	ids := make([]string, len(recipients))
+	errs := make([]error, len(recipients))
+	for i, jid := range recipients {
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			continue
+		}
+		sent, err := b.SendMessage(ctx, jid, text, nil)
+		ids[i], errs[i] = sent.ID, err
+	}
+	return ids, errs, nil
+}
+
+// ErrBatchStopped marks batch entries that were not attempted because an
+// earlier entry failed and the batch does not continue on error.
+var ErrBatchStopped = errors.New("not sent: batch stopped after an earlier failure")
+
+// SendBatch sends texts[i] to jids[i] in order, one message at a time. It
+// returns a message ID or error per entry. Unless continueOnError is set,
+// entries after the first failure are not sent and get ErrBatchStopped.
+func (b *Bridge) SendBatch(ctx context.Context, jids, texts []string, continueOnError bool) ([]string, []error, error) {
+	if !b.IsReady() {
+		return nil, nil, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	if len(jids) != len(texts) {
+		return nil, nil, fmt.Errorf("got %d recipients for %d messages", len(jids), len(texts))
 	}
 
-	return msgID, nil
+	// Every entry is a distinct message, so a key must not collapse them.
+	ctx = WithIdempotencyKey(ctx, "", "")
+
+	ids := make([]string, len(jids))
+	errs := make([]error, len(jids))
+	stopped := false
+	for i := range jids {
+		if stopped {
+			errs[i] = ErrBatchStopped
+			continue
+		}
+		sent, err := b.SendMessage(ctx, jids[i], texts[i], nil)
+		ids[i], errs[i] = sent.ID, err
+		if errs[i] != nil && !continueOnError {
+			stopped = true
+		}
+	}
+	return ids, errs, nil
 }
 
 // SendMedia is not used directly; use SendImage, SendVideo, SendAudio, or SendDocument instead.
@@ -162,13 +357,46 @@ func (b *Bridge) SendMedia(ctx context.Context, jid string, data []byte, mimeTyp
 	return "", fmt.Errorf("use SendImage, SendVideo, SendAudio, or SendDocument instead")
 }
 
-// EmitEvent adds an event to the processing queue.
+// EmitEvent adds an event to the processing queue. When the queue is full,
+// message events wait up to EventQueueTimeout for room, since listeners
+// can't recover a missed message; other events are dropped right away.
+// Dropped events are counted in DroppedEvents. Events emitted after
+// Shutdown, or still waiting for room when it runs, are discarded.
 func (b *Bridge) EmitEvent(evt Event) {
+	b.mu.RLock()
+	if b.ctx.Err() != nil {
+		b.mu.RUnlock()
+		return
+	}
+	events := b.events
+	b.mu.RUnlock()
+
 	select {
-	case b.events <- evt:
+	case events <- evt:
+		return
 	default:
-		b.log.Warn("event queue full, dropping event", "type", evt.Type)
 	}
+
+	if evt.Type == EventMessage && b.config.EventQueueTimeout > 0 {
+		timer := time.NewTimer(b.config.EventQueueTimeout)
+		defer timer.Stop()
+		select {
+		case events <- evt:
+			return
+		case <-b.ctx.Done():
+			return
+		case <-timer.C:
+		}
+	}
+
+	dropped := b.droppedEvents.Add(1)
+	b.log.Warn("event queue full, dropping event", "type", evt.Type, "dropped", dropped)
+}
+
+// DroppedEvents returns how many events were dropped because the event
+// queue was full.
+func (b *Bridge) DroppedEvents() int64 {
+	return b.droppedEvents.Load()
 }
 
 // OnEvent registers a callback for all events.
@@ -185,12 +413,26 @@ func (b *Bridge) OnStateChange(handler func(from, to state.State)) {
 	b.stateListeners = append(b.stateListeners, handler)
 }
 
-// processEvents is the event processing goroutine.
+// processEvents is the event processing goroutine. It runs until Shutdown,
+// then handles the events still queued. The channel is never closed, since
+// EmitEvent may be sending on it when Shutdown runs.
 func (b *Bridge) processEvents() {
 	defer b.wg.Done()
 
-	for evt := range b.events {
-		b.handleEvent(evt)
+	for {
+		select {
+		case evt := <-b.events:
+			b.handleEvent(evt)
+		case <-b.ctx.Done():
+			for {
+				select {
+				case evt := <-b.events:
+					b.handleEvent(evt)
+				default:
+					return
+				}
+			}
+		}
 	}
 }
 
@@ -222,6 +464,9 @@ func (b *Bridge) handleMessage(evt Event) {
 		b.log.Error("invalid message payload")
 		return
 	}
+	if payload.Persisted {
+		return
+	}
 
 	// Store the message
 	msg := &store.Message{
@@ -234,9 +479,7 @@ func (b *Bridge) handleMessage(evt Event) {
 		Timestamp: payload.Timestamp,
 	}
 
-	if err := b.store.Messages.Store(context.Background(), msg); err != nil {
-		b.log.Error("failed to store message", "error", err)
-	}
+	b.storeMessage(context.Background(), msg)
 
 	// Update chat last message time
 	if err := b.store.Chats.UpdateLastMessage(context.Background(), payload.ChatJID, payload.Timestamp); err != nil {
@@ -251,88 +494,221 @@ func (b *Bridge) GetStateMachine() *state.Machine {
 
 // --- Delegate methods to WhatsApp client ---
 
-func (b *Bridge) ReplyToMessage(ctx context.Context, chatJID, messageID, text string) (string, error) {
+func (b *Bridge) ReplyToMessage(ctx context.Context, chatJID, messageID, text string) (SendMessageResult, error) {
 	if !b.IsReady() {
-		return "", fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+		return SendMessageResult{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.ReplyToMessage(ctx, chatJID, messageID, text)
+	return b.sendOnce(ctx, chatJID, func() (whatsmeow.SendResponse, error) {
+		return b.client.ReplyToMessage(ctx, chatJID, messageID, text)
+	})
 }
 
-func (b *Bridge) ForwardMessage(ctx context.Context, sourceChatJID, messageID, targetJID string) (string, error) {
+// ReplyToStatus replies privately to senderJID's status statusID.
+func (b *Bridge) ReplyToStatus(ctx context.Context, statusID, senderJID, text string) (SendMessageResult, error) {
 	if !b.IsReady() {
-		return "", fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+		return SendMessageResult{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.ForwardMessage(ctx, sourceChatJID, messageID, targetJID)
+	return b.sendOnce(ctx, senderJID, func() (whatsmeow.SendResponse, error) {
+		return b.client.ReplyToStatus(ctx, statusID, senderJID, text)
+	})
+}
+
+func (b *Bridge) ForwardMessage(ctx context.Context, sourceChatJID, messageID, targetJID string) (SendMessageResult, error) {
+	if !b.IsReady() {
+		return SendMessageResult{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.sendOnce(ctx, targetJID, func() (whatsmeow.SendResponse, error) {
+		return b.client.ForwardMessage(ctx, sourceChatJID, messageID, targetJID)
+	})
 }
 
+// EditMessage edits a sent message. Media messages are looked up in the store
+// so the edit changes their caption instead of replacing them with text;
+// messages that were never stored are edited as text.
 func (b *Bridge) EditMessage(ctx context.Context, chatJID, messageID, newContent string) error {
 	if !b.IsReady() {
 		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.EditMessage(ctx, chatJID, messageID, newContent)
+
+	var mediaType string
+	msg, err := b.store.Messages.GetByID(ctx, chatJID, messageID)
+	switch {
+	case err == nil:
+		mediaType = msg.MediaType
+	case !errors.Is(err, store.ErrNotFound):
+		return fmt.Errorf("failed to look up message: %w", err)
+	}
+
+	return b.client.EditMessage(ctx, chatJID, messageID, newContent, mediaType)
 }
 
+// DeleteMessage revokes a message for everyone, or deletes it for this
+// account only. Delete-for-me removes the stored copy and then syncs the
+// deletion to other linked devices on a best-effort basis.
 func (b *Bridge) DeleteMessage(ctx context.Context, chatJID, messageID string, forEveryone bool) error {
 	if !b.IsReady() {
 		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.DeleteMessage(ctx, chatJID, messageID, forEveryone)
+	if forEveryone {
+		return b.client.DeleteMessage(ctx, chatJID, messageID, true)
+	}
+
+	msg, err := b.store.Messages.GetByID(ctx, chatJID, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to look up message: %w", err)
+	}
+	if err := b.store.Messages.Delete(ctx, chatJID, messageID); err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+
+	if err := b.client.DeleteMessageForMe(ctx, chatJID, msg.Sender, messageID, msg.IsFromMe, msg.Timestamp); err != nil {
+		b.log.Warn("failed to sync delete for me", "error", err, "chat", chatJID, "id", messageID)
+	}
+	return nil
 }
 
 func (b *Bridge) ReactToMessage(ctx context.Context, chatJID, messageID, emoji string) error {
 	if !b.IsReady() {
 		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.ReactToMessage(ctx, chatJID, messageID, emoji)
+	if err := b.client.ReactToMessage(ctx, chatJID, messageID, emoji); err != nil {
+		return err
+	}
+
+	// Our own reactions are not echoed back as events, so record them here.
+	err := b.store.Messages.SetReaction(ctx, chatJID, messageID, store.Reaction{
+		Sender:    "me",
+		Emoji:     emoji,
+		ReactedAt: time.Now(),
+	})
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		b.log.Debug("failed to store own reaction", "error", err, "id", messageID)
+	}
+	return nil
 }
 
-func (b *Bridge) SendImage(ctx context.Context, jid, imagePath, caption string) (string, error) {
+func (b *Bridge) SendImage(ctx context.Context, jid, imagePath, caption string, viewOnce bool) (SendMessageResult, error) {
 	if !b.IsReady() {
-		return "", fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+		return SendMessageResult{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.SendImage(ctx, jid, imagePath, caption)
+	return b.sendOnce(ctx, jid, func() (whatsmeow.SendResponse, error) {
+		return b.client.SendImage(ctx, jid, imagePath, caption, viewOnce)
+	})
 }
 
-func (b *Bridge) SendVideo(ctx context.Context, jid, videoPath, caption string) (string, error) {
+func (b *Bridge) SendVideo(ctx context.Context, jid, videoPath, caption string, viewOnce bool) (SendMessageResult, error) {
 	if !b.IsReady() {
-		return "", fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+		return SendMessageResult{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.SendVideo(ctx, jid, videoPath, caption)
+	return b.sendOnce(ctx, jid, func() (whatsmeow.SendResponse, error) {
+		return b.client.SendVideo(ctx, jid, videoPath, caption, viewOnce)
+	})
 }
 
-func (b *Bridge) SendAudio(ctx context.Context, jid, audioPath string, asVoice bool) (string, error) {
+func (b *Bridge) SendGIF(ctx context.Context, jid, gifPath, caption string) (SendMessageResult, error) {
 	if !b.IsReady() {
-		return "", fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+		return SendMessageResult{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.SendAudio(ctx, jid, audioPath, asVoice)
+	return b.sendOnce(ctx, jid, func() (whatsmeow.SendResponse, error) {
+		return b.client.SendGIF(ctx, jid, gifPath, caption)
+	})
 }
 
-func (b *Bridge) SendDocument(ctx context.Context, jid, filePath, filename string) (string, error) {
+func (b *Bridge) SendAudio(ctx context.Context, jid, audioPath string, asVoice bool) (SendMessageResult, error) {
 	if !b.IsReady() {
-		return "", fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+		return SendMessageResult{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.SendDocument(ctx, jid, filePath, filename)
+	return b.sendOnce(ctx, jid, func() (whatsmeow.SendResponse, error) {
+		return b.client.SendAudio(ctx, jid, audioPath, asVoice)
+	})
 }
 
-func (b *Bridge) SendLocation(ctx context.Context, jid string, lat, lon float64, name, address string) (string, error) {
+func (b *Bridge) SendDocument(ctx context.Context, jid, filePath, filename, caption string) (SendMessageResult, error) {
 	if !b.IsReady() {
-		return "", fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+		return SendMessageResult{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.SendLocation(ctx, jid, lat, lon, name, address)
+	return b.sendOnce(ctx, jid, func() (whatsmeow.SendResponse, error) {
+		return b.client.SendDocument(ctx, jid, filePath, filename, caption)
+	})
 }
 
-func (b *Bridge) SendContactCard(ctx context.Context, jid, contactJID string) (string, error) {
+func (b *Bridge) SendLocation(ctx context.Context, jid string, lat, lon float64, name, address string) (SendMessageResult, error) {
 	if !b.IsReady() {
-		return "", fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+		return SendMessageResult{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.sendOnce(ctx, jid, func() (whatsmeow.SendResponse, error) {
+		return b.client.SendLocation(ctx, jid, lat, lon, name, address)
+	})
+}
+
+func (b *Bridge) SendLiveLocation(ctx context.Context, jid string, lat, lon float64, durationSec int) (SendMessageResult, error) {
+	if !b.IsReady() {
+		return SendMessageResult{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.sendOnce(ctx, jid, func() (whatsmeow.SendResponse, error) {
+		return b.client.SendLiveLocation(ctx, jid, lat, lon, durationSec)
+	})
+}
+
+func (b *Bridge) SendContactCard(ctx context.Context, jid, contactJID string) (SendMessageResult, error) {
+	if !b.IsReady() {
+		return SendMessageResult{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.sendOnce(ctx, jid, func() (whatsmeow.SendResponse, error) {
+		return b.client.SendContactCard(ctx, jid, contactJID)
+	})
+}
+
+func (b *Bridge) SendVCard(ctx context.Context, jid string, card whatsapp.ContactCard) (SendMessageResult, error) {
+	if !b.IsReady() {
+		return SendMessageResult{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.SendContactCard(ctx, jid, contactJID)
+	return b.sendOnce(ctx, jid, func() (whatsmeow.SendResponse, error) {
+		return b.client.SendVCard(ctx, jid, card)
+	})
 }
 
+func (b *Bridge) SendContactCards(ctx context.Context, jid string, contactJIDs []string) (SendMessageResult, error) {
+	if !b.IsReady() {
+		return SendMessageResult{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.sendOnce(ctx, jid, func() (whatsmeow.SendResponse, error) {
+		return b.client.SendContactCards(ctx, jid, contactJIDs)
+	})
+}
+
+// ErrNoMedia is returned when downloading media from a message without any.
+var ErrNoMedia = errors.New("message has no media")
+
+// DownloadMedia saves a stored message's media and records the file's path
+// so the message drops out of list_pending_media. It returns
+// store.ErrNotFound for an unknown message.
 func (b *Bridge) DownloadMedia(ctx context.Context, chatJID, messageID, savePath string) (string, error) {
 	if !b.IsReady() {
 		return "", fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.DownloadMedia(ctx, chatJID, messageID, savePath)
+	msg, err := b.store.Messages.Media(ctx, chatJID, messageID)
+	if err != nil {
+		return "", err
+	}
+	if msg.MediaType == "" {
+		return "", ErrNoMedia
+	}
+	path, err := b.client.DownloadMedia(ctx, whatsapp.MediaFile{
+		MediaType:     msg.MediaType,
+		DirectPath:    msg.DirectPath,
+		MediaKey:      msg.MediaKey,
+		FileSHA256:    msg.FileSHA256,
+		FileEncSHA256: msg.FileEncSHA256,
+		FileLength:    msg.FileLength,
+	}, savePath)
+	if err != nil {
+		return "", err
+	}
+	if err := b.store.Messages.SetLocalPath(ctx, chatJID, messageID, path); err != nil {
+		b.log.Debug("failed to record downloaded media path", "error", err, "chat", chatJID, "id", messageID)
+	}
+	return path, nil
 }
 
 func (b *Bridge) ArchiveChat(ctx context.Context, jid string, archive bool) error {
@@ -342,11 +718,43 @@ func (b *Bridge) ArchiveChat(ctx context.Context, jid string, archive bool) erro
 	return b.client.ArchiveChat(ctx, jid, archive)
 }
 
+// maxPinnedChats is how many chats WhatsApp lets an account pin.
+const maxPinnedChats = 3
+
+// ErrTooManyPinnedChats is returned when pinning a chat would exceed
+// maxPinnedChats. WhatsApp drops such pins without an error.
+var ErrTooManyPinnedChats = fmt.Errorf("maximum %d pinned chats", maxPinnedChats)
+
+// PinChat pins or unpins a chat. Pinning fails with ErrTooManyPinnedChats
+// when maxPinnedChats other chats are already pinned.
 func (b *Bridge) PinChat(ctx context.Context, jid string, pin bool) error {
 	if !b.IsReady() {
 		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.PinChat(ctx, jid, pin)
+
+	if pin {
+		chat, err := b.store.Chats.GetByJID(ctx, jid)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("failed to look up chat: %w", err)
+		}
+		if chat == nil || !chat.Pinned {
+			pinned, err := b.store.Chats.CountPinned(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to count pinned chats: %w", err)
+			}
+			if pinned >= maxPinnedChats {
+				return ErrTooManyPinnedChats
+			}
+		}
+	}
+
+	if err := b.client.PinChat(ctx, jid, pin); err != nil {
+		return err
+	}
+	if err := b.store.Chats.Pin(ctx, jid, pin); err != nil {
+		b.log.Debug("failed to store pinned state", "error", err, "jid", jid)
+	}
+	return nil
 }
 
 func (b *Bridge) MuteChat(ctx context.Context, jid string, mute bool, duration string) error {
@@ -356,11 +764,37 @@ func (b *Bridge) MuteChat(ctx context.Context, jid string, mute bool, duration s
 	return b.client.MuteChat(ctx, jid, mute, duration)
 }
 
+func (b *Bridge) SetDisappearingTimer(ctx context.Context, jid string, duration time.Duration) error {
+	if !b.IsReady() {
+		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.client.SetDisappearingTimer(ctx, jid, duration)
+}
+
 func (b *Bridge) MarkChatRead(ctx context.Context, jid string) error {
 	if !b.IsReady() {
 		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.MarkChatRead(ctx, jid)
+	if err := b.client.MarkChatRead(ctx, jid); err != nil {
+		return err
+	}
+	if err := b.store.Chats.ResetUnread(ctx, jid); err != nil {
+		b.log.Debug("failed to reset unread count", "error", err, "jid", jid)
+	}
+	return nil
+}
+
+func (b *Bridge) MarkChatUnread(ctx context.Context, jid string) error {
+	if !b.IsReady() {
+		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	if err := b.client.MarkChatUnread(ctx, jid); err != nil {
+		return err
+	}
+	if err := b.store.Chats.MarkUnread(ctx, jid); err != nil {
+		b.log.Debug("failed to mark chat unread", "error", err, "jid", jid)
+	}
+	return nil
 }
 
 func (b *Bridge) DeleteChat(ctx context.Context, jid string) error {
@@ -370,6 +804,48 @@ func (b *Bridge) DeleteChat(ctx context.Context, jid string) error {
 	return b.client.DeleteChat(ctx, jid)
 }
 
+func (b *Bridge) GetChatSettings(ctx context.Context, jid string) (types.LocalChatSettings, error) {
+	if !b.IsReady() {
+		return types.LocalChatSettings{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.client.GetChatSettings(ctx, jid)
+}
+
+// RequestHistorySync asks the phone for up to count messages older than the
+// oldest one stored for the chat. They are persisted when the resulting
+// history sync event arrives.
+func (b *Bridge) RequestHistorySync(ctx context.Context, chatJID string, count int) error {
+	if !b.IsReady() {
+		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+
+	oldest, err := b.store.Messages.Oldest(ctx, chatJID)
+	if err != nil {
+		return fmt.Errorf("failed to look up oldest message: %w", err)
+	}
+	return b.client.RequestHistorySync(ctx, chatJID, oldest.ID, oldest.IsFromMe, oldest.Timestamp, count)
+}
+
+func (b *Bridge) AddChatLabel(ctx context.Context, chatJID, labelID string) error {
+	if !b.IsReady() {
+		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	if err := b.client.AddChatLabel(ctx, chatJID, labelID); err != nil {
+		return err
+	}
+	return b.store.Labels.SetChatLabel(ctx, chatJID, labelID, true)
+}
+
+func (b *Bridge) RemoveChatLabel(ctx context.Context, chatJID, labelID string) error {
+	if !b.IsReady() {
+		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	if err := b.client.RemoveChatLabel(ctx, chatJID, labelID); err != nil {
+		return err
+	}
+	return b.store.Labels.SetChatLabel(ctx, chatJID, labelID, false)
+}
+
 func (b *Bridge) BlockContact(ctx context.Context, jid string, block bool) error {
 	if !b.IsReady() {
 		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
@@ -391,7 +867,7 @@ func (b *Bridge) CreateGroup(ctx context.Context, name string, participants []st
 	return b.client.CreateGroup(ctx, name, participants)
 }
 
-func (b *Bridge) GetGroupInfo(ctx context.Context, jid string) (interface{}, error) {
+func (b *Bridge) GetGroupInfo(ctx context.Context, jid string) (*types.GroupInfo, error) {
 	if !b.IsReady() {
 		return nil, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
@@ -447,25 +923,32 @@ func (b *Bridge) SetGroupTopic(ctx context.Context, groupJID, topic string) erro
 	return b.client.SetGroupTopic(ctx, groupJID, topic)
 }
 
-func (b *Bridge) SetGroupPhoto(ctx context.Context, groupJID, imagePath string) error {
+func (b *Bridge) SetGroupAnnounce(ctx context.Context, groupJID string, announce bool) error {
 	if !b.IsReady() {
 		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.SetGroupPhoto(ctx, groupJID, imagePath)
+	return b.client.SetGroupAnnounce(ctx, groupJID, announce)
 }
 
-func (b *Bridge) GetInviteLink(ctx context.Context, groupJID string) (string, error) {
+func (b *Bridge) SetGroupLocked(ctx context.Context, groupJID string, locked bool) error {
 	if !b.IsReady() {
-		return "", fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.GetInviteLink(ctx, groupJID)
+	return b.client.SetGroupLocked(ctx, groupJID, locked)
 }
 
-func (b *Bridge) RevokeInviteLink(ctx context.Context, groupJID string) (string, error) {
+func (b *Bridge) SetGroupPhoto(ctx context.Context, groupJID, imagePath string) error {
 	if !b.IsReady() {
-		return "", fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
 	}
-	return b.client.RevokeInviteLink(ctx, groupJID)
+	return b.client.SetGroupPhoto(ctx, groupJID, imagePath)
+}
+
+func (b *Bridge) RemoveGroupPhoto(ctx context.Context, groupJID string) error {
+	if !b.IsReady() {
+		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.client.RemoveGroupPhoto(ctx, groupJID)
 }
 
 func (b *Bridge) JoinViaInvite(ctx context.Context, inviteLink string) (string, error) {
@@ -475,6 +958,41 @@ func (b *Bridge) JoinViaInvite(ctx context.Context, inviteLink string) (string,
 	return b.client.JoinViaInvite(ctx, inviteLink)
 }
 
+func (b *Bridge) GetGroupInfoFromLink(ctx context.Context, inviteLink string) (*types.GroupInfo, error) {
+	if !b.IsReady() {
+		return nil, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.client.GetGroupInfoFromLink(ctx, inviteLink)
+}
+
+func (b *Bridge) GetCommonGroups(ctx context.Context, jid string) ([]string, error) {
+	if !b.IsReady() {
+		return nil, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.client.GetCommonGroups(ctx, jid)
+}
+
+func (b *Bridge) GetGroupJoinRequests(ctx context.Context, groupJID string) ([]types.GroupParticipantRequest, error) {
+	if !b.IsReady() {
+		return nil, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.client.GetGroupJoinRequests(ctx, groupJID)
+}
+
+func (b *Bridge) ApproveGroupJoinRequest(ctx context.Context, groupJID string, participants []string) error {
+	if !b.IsReady() {
+		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.client.ApproveGroupJoinRequest(ctx, groupJID, participants)
+}
+
+func (b *Bridge) RejectGroupJoinRequest(ctx context.Context, groupJID string, participants []string) error {
+	if !b.IsReady() {
+		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.client.RejectGroupJoinRequest(ctx, groupJID, participants)
+}
+
 func (b *Bridge) SubscribePresence(ctx context.Context, jid string) error {
 	if !b.IsReady() {
 		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
@@ -482,6 +1000,34 @@ func (b *Bridge) SubscribePresence(ctx context.Context, jid string) error {
 	return b.client.SubscribePresence(ctx, jid)
 }
 
+// SubscribeGroupPresence subscribes to the presence of every participant of
+// a group. It returns the participant JIDs, preferring phone number JIDs
+// where the group uses LIDs, with the subscription error of each.
+func (b *Bridge) SubscribeGroupPresence(ctx context.Context, groupJID string) ([]string, []error, error) {
+	if !b.IsReady() {
+		return nil, nil, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	info, err := b.client.GetGroupInfo(ctx, groupJID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info == nil {
+		return nil, nil, fmt.Errorf("no group info for %s", groupJID)
+	}
+
+	participants := make([]string, len(info.Participants))
+	errs := make([]error, len(info.Participants))
+	for i, p := range info.Participants {
+		jid := p.JID
+		if !p.PhoneNumber.IsEmpty() {
+			jid = p.PhoneNumber
+		}
+		participants[i] = jid.ToNonAD().String()
+		errs[i] = b.client.SubscribePresence(ctx, participants[i])
+	}
+	return participants, errs, nil
+}
+
 func (b *Bridge) SendTyping(ctx context.Context, jid string) error {
 	if !b.IsReady() {
 		return fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
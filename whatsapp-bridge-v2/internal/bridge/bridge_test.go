@@ -2,6 +2,8 @@ package bridge
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -9,8 +11,18 @@ import (
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/config"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/state"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/whatsapp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/proto/waSyncAction"
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
 )
 
 // FakeClient implements WhatsAppClient for testing.
@@ -21,6 +33,25 @@ type FakeClient struct {
 	sentMessages []FakeMessage
 	qrChan       chan string
 	eventHandler func(interface{})
+	connectErr   error
+	connectHangs bool
+	revoked      []string
+	deletedForMe []string
+	historyReqs  []FakeHistoryRequest
+	edits        []FakeEdit
+	failJIDs     map[string]bool
+	presence     []string
+	archived     []string
+	archiveErrs  map[string]error
+	downloads    []whatsapp.MediaFile
+
+	inviteLinkFetches int
+
+	newsletterMessages []*types.NewsletterMessage
+	newsletterErr      error
+
+	// transientFailures is how many sends fail as if the websocket dropped
+	transientFailures int
 }
 
 type FakeMessage struct {
@@ -28,19 +59,45 @@ type FakeMessage struct {
 	Content string
 }
 
+type FakeEdit struct {
+	MessageID string
+	Content   string
+	MediaType string
+}
+
+type FakeHistoryRequest struct {
+	ChatJID  string
+	OldestID string
+	Count    int
+}
+
 func NewFakeClient() *FakeClient {
 	return &FakeClient{
 		qrChan: make(chan string, 1),
 	}
 }
 
-func (f *FakeClient) Connect(_ context.Context) error {
+func (f *FakeClient) Connect(ctx context.Context) error {
 	f.mu.Lock()
+	if f.connectHangs {
+		f.mu.Unlock()
+		<-ctx.Done()
+		return ctx.Err()
+	}
 	defer f.mu.Unlock()
+	if f.connectErr != nil {
+		return f.connectErr
+	}
 	f.connected = true
 	return nil
 }
 
+func (f *FakeClient) NeedsPairing(_ context.Context) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return !f.loggedIn, nil
+}
+
 func (f *FakeClient) Disconnect() {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -59,17 +116,36 @@ func (f *FakeClient) IsLoggedIn() bool {
 	return f.loggedIn
 }
 
+func (f *FakeClient) IsBusiness() bool {
+	return false
+}
+
+func (f *FakeClient) GetLinkedDevices(ctx context.Context) ([]whatsapp.DeviceInfo, error) {
+	return nil, nil
+}
+
 func (f *FakeClient) SetLoggedIn(v bool) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	f.loggedIn = v
 }
 
-func (f *FakeClient) SendMessage(ctx context.Context, jid string, text string) (string, error) {
+func (f *FakeClient) SendMessage(ctx context.Context, jid string, text string, mentions []string) (whatsmeow.SendResponse, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	if f.failJIDs[jid] {
+		return whatsmeow.SendResponse{}, errors.New("recipient unreachable")
+	}
+	if f.transientFailures > 0 {
+		f.transientFailures--
+		return whatsmeow.SendResponse{}, whatsapp.ErrNotConnected
+	}
 	f.sentMessages = append(f.sentMessages, FakeMessage{JID: jid, Content: text})
-	return "msg-" + jid, nil
+	return whatsmeow.SendResponse{ID: "msg-" + jid, Timestamp: time.Now()}, nil
+}
+
+func (f *FakeClient) SendQuotedMessage(ctx context.Context, jid, text string, mentions []string, quotedChatJID, quotedID, quotedSender, quotedText string) (whatsmeow.SendResponse, error) {
+	return f.SendMessage(ctx, jid, text, mentions)
 }
 
 func (f *FakeClient) SendMedia(ctx context.Context, jid string, data []byte, mimeType string, filename string) (string, error) {
@@ -86,19 +162,32 @@ func (f *FakeClient) AddEventHandler(handler func(interface{})) {
 	f.eventHandler = handler
 }
 
-func (f *FakeClient) ReplyToMessage(ctx context.Context, chatJID, messageID, text string) (string, error) {
-	return "", nil
+func (f *FakeClient) ReplyToMessage(ctx context.Context, chatJID, messageID, text string) (whatsmeow.SendResponse, error) {
+	return whatsmeow.SendResponse{}, nil
 }
 
-func (f *FakeClient) ForwardMessage(ctx context.Context, sourceChatJID, messageID, targetJID string) (string, error) {
-	return "", nil
+func (f *FakeClient) ForwardMessage(ctx context.Context, sourceChatJID, messageID, targetJID string) (whatsmeow.SendResponse, error) {
+	return whatsmeow.SendResponse{}, nil
 }
 
-func (f *FakeClient) EditMessage(ctx context.Context, chatJID, messageID, newContent string) error {
+func (f *FakeClient) EditMessage(ctx context.Context, chatJID, messageID, newContent, mediaType string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.edits = append(f.edits, FakeEdit{MessageID: messageID, Content: newContent, MediaType: mediaType})
 	return nil
 }
 
 func (f *FakeClient) DeleteMessage(ctx context.Context, chatJID, messageID string, forEveryone bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.revoked = append(f.revoked, messageID)
+	return nil
+}
+
+func (f *FakeClient) DeleteMessageForMe(ctx context.Context, chatJID, senderJID, messageID string, fromMe bool, timestamp time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deletedForMe = append(f.deletedForMe, messageID)
 	return nil
 }
 
@@ -106,35 +195,64 @@ func (f *FakeClient) ReactToMessage(ctx context.Context, chatJID, messageID, emo
 	return nil
 }
 
-func (f *FakeClient) SendImage(ctx context.Context, jid, imagePath, caption string) (string, error) {
-	return "", nil
+func (f *FakeClient) SendImage(ctx context.Context, jid, imagePath, caption string, viewOnce bool) (whatsmeow.SendResponse, error) {
+	return whatsmeow.SendResponse{}, nil
 }
 
-func (f *FakeClient) SendVideo(ctx context.Context, jid, videoPath, caption string) (string, error) {
-	return "", nil
+func (f *FakeClient) SendVideo(ctx context.Context, jid, videoPath, caption string, viewOnce bool) (whatsmeow.SendResponse, error) {
+	return whatsmeow.SendResponse{}, nil
 }
 
-func (f *FakeClient) SendAudio(ctx context.Context, jid, audioPath string, asVoice bool) (string, error) {
-	return "", nil
+func (f *FakeClient) SendGIF(ctx context.Context, jid, gifPath, caption string) (whatsmeow.SendResponse, error) {
+	return whatsmeow.SendResponse{}, nil
 }
 
-func (f *FakeClient) SendDocument(ctx context.Context, jid, filePath, filename string) (string, error) {
-	return "", nil
+func (f *FakeClient) SendAudio(ctx context.Context, jid, audioPath string, asVoice bool) (whatsmeow.SendResponse, error) {
+	return whatsmeow.SendResponse{}, nil
 }
 
-func (f *FakeClient) SendLocation(ctx context.Context, jid string, lat, lon float64, name, address string) (string, error) {
-	return "", nil
+func (f *FakeClient) SendDocument(ctx context.Context, jid, filePath, filename, caption string) (whatsmeow.SendResponse, error) {
+	return whatsmeow.SendResponse{}, nil
 }
 
-func (f *FakeClient) SendContactCard(ctx context.Context, jid, contactJID string) (string, error) {
-	return "", nil
+func (f *FakeClient) SendLocation(ctx context.Context, jid string, lat, lon float64, name, address string) (whatsmeow.SendResponse, error) {
+	return whatsmeow.SendResponse{}, nil
 }
 
-func (f *FakeClient) DownloadMedia(ctx context.Context, chatJID, messageID, savePath string) (string, error) {
-	return "", nil
+func (f *FakeClient) SendLiveLocation(ctx context.Context, jid string, lat, lon float64, durationSec int) (whatsmeow.SendResponse, error) {
+	return whatsmeow.SendResponse{}, nil
+}
+
+func (f *FakeClient) SendContactCard(ctx context.Context, jid, contactJID string) (whatsmeow.SendResponse, error) {
+	return whatsmeow.SendResponse{}, nil
+}
+
+func (f *FakeClient) SendVCard(ctx context.Context, jid string, card whatsapp.ContactCard) (whatsmeow.SendResponse, error) {
+	return whatsmeow.SendResponse{}, nil
+}
+
+func (f *FakeClient) SendContactCards(ctx context.Context, jid string, contactJIDs []string) (whatsmeow.SendResponse, error) {
+	return whatsmeow.SendResponse{}, nil
+}
+
+func (f *FakeClient) DownloadMedia(ctx context.Context, media whatsapp.MediaFile, savePath string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.downloads = append(f.downloads, media)
+	return savePath, nil
+}
+
+func (f *FakeClient) Ping(ctx context.Context) (time.Duration, error) {
+	return 42 * time.Millisecond, nil
 }
 
 func (f *FakeClient) ArchiveChat(ctx context.Context, jid string, archive bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.archiveErrs[jid]; err != nil {
+		return err
+	}
+	f.archived = append(f.archived, jid)
 	return nil
 }
 
@@ -142,6 +260,10 @@ func (f *FakeClient) PinChat(ctx context.Context, jid string, pin bool) error {
 	return nil
 }
 
+func (f *FakeClient) SetDisappearingTimer(ctx context.Context, jid string, duration time.Duration) error {
+	return nil
+}
+
 func (f *FakeClient) MuteChat(ctx context.Context, jid string, mute bool, duration string) error {
 	return nil
 }
@@ -150,10 +272,33 @@ func (f *FakeClient) MarkChatRead(ctx context.Context, jid string) error {
 	return nil
 }
 
+func (f *FakeClient) MarkChatUnread(ctx context.Context, jid string) error {
+	return nil
+}
+
 func (f *FakeClient) DeleteChat(ctx context.Context, jid string) error {
 	return nil
 }
 
+func (f *FakeClient) GetChatSettings(ctx context.Context, jid string) (types.LocalChatSettings, error) {
+	return types.LocalChatSettings{}, nil
+}
+
+func (f *FakeClient) RequestHistorySync(ctx context.Context, chatJID, oldestID string, oldestFromMe bool, oldestTimestamp time.Time, count int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.historyReqs = append(f.historyReqs, FakeHistoryRequest{ChatJID: chatJID, OldestID: oldestID, Count: count})
+	return nil
+}
+
+func (f *FakeClient) AddChatLabel(ctx context.Context, chatJID, labelID string) error {
+	return nil
+}
+
+func (f *FakeClient) RemoveChatLabel(ctx context.Context, chatJID, labelID string) error {
+	return nil
+}
+
 func (f *FakeClient) BlockContact(ctx context.Context, jid string, block bool) error {
 	return nil
 }
@@ -166,7 +311,7 @@ func (f *FakeClient) CreateGroup(ctx context.Context, name string, participants
 	return "", nil
 }
 
-func (f *FakeClient) GetGroupInfo(ctx context.Context, jid string) (interface{}, error) {
+func (f *FakeClient) GetGroupInfo(ctx context.Context, jid string) (*types.GroupInfo, error) {
 	return nil, nil
 }
 
@@ -202,18 +347,59 @@ func (f *FakeClient) SetGroupPhoto(ctx context.Context, groupJID, imagePath stri
 	return nil
 }
 
+func (f *FakeClient) RemoveGroupPhoto(ctx context.Context, groupJID string) error {
+	return nil
+}
+
+func (f *FakeClient) SetGroupAnnounce(ctx context.Context, groupJID string, announce bool) error {
+	return nil
+}
+
+func (f *FakeClient) SetGroupLocked(ctx context.Context, groupJID string, locked bool) error {
+	return nil
+}
+
 func (f *FakeClient) GetInviteLink(ctx context.Context, groupJID string) (string, error) {
-	return "", nil
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.inviteLinkFetches++
+	return fmt.Sprintf("https://chat.whatsapp.com/link%d", f.inviteLinkFetches), nil
 }
 
 func (f *FakeClient) RevokeInviteLink(ctx context.Context, groupJID string) (string, error) {
-	return "", nil
+	return "https://chat.whatsapp.com/revoked", nil
+}
+
+func (f *FakeClient) InviteLinkFetches() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.inviteLinkFetches
 }
 
 func (f *FakeClient) JoinViaInvite(ctx context.Context, inviteLink string) (string, error) {
 	return "", nil
 }
 
+func (f *FakeClient) GetGroupInfoFromLink(ctx context.Context, inviteLink string) (*types.GroupInfo, error) {
+	return &types.GroupInfo{}, nil
+}
+
+func (f *FakeClient) GetCommonGroups(ctx context.Context, jid string) ([]string, error) {
+	return []string{}, nil
+}
+
+func (f *FakeClient) GetGroupJoinRequests(ctx context.Context, groupJID string) ([]types.GroupParticipantRequest, error) {
+	return nil, nil
+}
+
+func (f *FakeClient) ApproveGroupJoinRequest(ctx context.Context, groupJID string, participants []string) error {
+	return nil
+}
+
+func (f *FakeClient) RejectGroupJoinRequest(ctx context.Context, groupJID string, participants []string) error {
+	return nil
+}
+
 func (f *FakeClient) SubscribePresence(ctx context.Context, jid string) error {
 	return nil
 }
@@ -227,13 +413,25 @@ func (f *FakeClient) SendRecording(ctx context.Context, jid string) error {
 }
 
 func (f *FakeClient) SetOnline(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.presence = append(f.presence, "online")
 	return nil
 }
 
 func (f *FakeClient) SetOffline(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.presence = append(f.presence, "offline")
 	return nil
 }
 
+func (f *FakeClient) GetPresenceCalls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.presence...)
+}
+
 func (f *FakeClient) PostTextStatus(ctx context.Context, text, backgroundColor string) error {
 	return nil
 }
@@ -246,6 +444,20 @@ func (f *FakeClient) DeleteStatus(ctx context.Context, statusID string) error {
 	return nil
 }
 
+func (f *FakeClient) ReplyToStatus(ctx context.Context, statusID, senderJID, text string) (whatsmeow.SendResponse, error) {
+	return whatsmeow.SendResponse{ID: "msg-" + senderJID, Timestamp: time.Now()}, nil
+}
+
+func (f *FakeClient) GetSubscribedNewsletters(ctx context.Context) ([]*types.NewsletterMetadata, error) {
+	return nil, nil
+}
+
+func (f *FakeClient) GetNewsletterMessages(ctx context.Context, newsletterJID string, count int) ([]*types.NewsletterMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.newsletterMessages, f.newsletterErr
+}
+
 func (f *FakeClient) SimulateEvent(evt interface{}) {
 	f.mu.Lock()
 	handler := f.eventHandler
@@ -271,12 +483,107 @@ func setupTestBridge(t *testing.T) (*Bridge, *FakeClient, *store.SQLiteStore) {
 	cfg := config.DefaultConfig()
 	fakeClient := NewFakeClient()
 
-	bridge := NewBridge(cfg, storeDB, fakeClient)
+	bridge := NewBridge(cfg, storeDB.Store(), fakeClient)
 	t.Cleanup(func() { bridge.Stop() })
 
 	return bridge, fakeClient, storeDB
 }
 
+func TestBridge_EmitEvent_CountsDropsWhenQueueFull(t *testing.T) {
+	storeDB, err := store.NewSQLiteStore(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { storeDB.Close() })
+
+	cfg := config.DefaultConfig()
+	cfg.EventQueueSize = 2
+	cfg.EventQueueTimeout = 20 * time.Millisecond
+	bridge := NewBridge(cfg, storeDB.Store(), NewFakeClient())
+
+	// Hold the event processor on the first event so the queue fills up
+	busy := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	bridge.OnEvent(func(Event) {
+		once.Do(func() {
+			close(busy)
+			<-release
+		})
+	})
+	t.Cleanup(func() { bridge.Stop() })
+
+	bridge.EmitEvent(NewEvent(EventStateChange, nil))
+	<-busy
+	for i := 0; i < 2+5; i++ {
+		bridge.EmitEvent(NewEvent(EventStateChange, nil))
+	}
+	assert.Equal(t, int64(5), bridge.DroppedEvents())
+
+	// A message event waits for room before it is dropped
+	start := time.Now()
+	bridge.EmitEvent(NewEvent(EventMessage, MessagePayload{Persisted: true}))
+	assert.GreaterOrEqual(t, time.Since(start), cfg.EventQueueTimeout)
+	assert.Equal(t, int64(6), bridge.DroppedEvents())
+
+	close(release)
+}
+
+func TestBridge_EmitEvent_WaitDoesNotHoldLock(t *testing.T) {
+	storeDB, err := store.NewSQLiteStore(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { storeDB.Close() })
+
+	cfg := config.DefaultConfig()
+	cfg.EventQueueSize = 1
+	cfg.EventQueueTimeout = time.Minute
+	bridge := NewBridge(cfg, storeDB.Store(), NewFakeClient())
+
+	busy := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	bridge.OnEvent(func(Event) {
+		once.Do(func() {
+			close(busy)
+			<-release
+		})
+	})
+
+	bridge.EmitEvent(NewEvent(EventStateChange, nil))
+	<-busy
+	bridge.EmitEvent(NewEvent(EventStateChange, nil))
+
+	// A message event waits for room in the full queue
+	emitted := make(chan struct{})
+	go func() {
+		bridge.EmitEvent(NewEvent(EventMessage, MessagePayload{Persisted: true}))
+		close(emitted)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// Registering a listener doesn't wait behind it
+	registered := make(chan struct{})
+	go func() {
+		bridge.OnEvent(func(Event) {})
+		close(registered)
+	}()
+	select {
+	case <-registered:
+	case <-time.After(time.Second):
+		t.Fatal("OnEvent blocked while EmitEvent waited for queue room")
+	}
+
+	// Shutdown ends the wait
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- bridge.Shutdown(context.Background()) }()
+	select {
+	case <-emitted:
+	case <-time.After(time.Second):
+		t.Fatal("EmitEvent kept waiting after Shutdown")
+	}
+
+	close(release)
+	require.NoError(t, <-shutdown)
+}
+
 func TestNewBridge(t *testing.T) {
 	bridge, _, _ := setupTestBridge(t)
 
@@ -316,9 +623,10 @@ func TestBridge_SendMessage(t *testing.T) {
 	assert.Equal(t, state.StateReady, bridge.CurrentState())
 
 	// Send message
-	msgID, err := bridge.SendMessage(ctx, "123@s.whatsapp.net", "Hello")
+	result, err := bridge.SendMessage(ctx, "123@s.whatsapp.net", "Hello", nil)
 	require.NoError(t, err)
-	assert.NotEmpty(t, msgID)
+	assert.NotEmpty(t, result.ID)
+	assert.False(t, result.Timestamp.IsZero(), "the server timestamp is passed through")
 
 	// Verify message was sent
 	sent := client.GetSentMessages()
@@ -332,7 +640,7 @@ func TestBridge_SendMessage_NotReady(t *testing.T) {
 	ctx := context.Background()
 
 	// Try to send while disconnected
-	_, err := bridge.SendMessage(ctx, "123@s.whatsapp.net", "Hello")
+	_, err := bridge.SendMessage(ctx, "123@s.whatsapp.net", "Hello", nil)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not ready")
 }
@@ -404,6 +712,74 @@ func TestBridge_StateTransitionCallback(t *testing.T) {
 	mu.Unlock()
 }
 
+func TestBridge_ConnectFailureRecordsError(t *testing.T) {
+	bridge, client, storeDB := setupTestBridge(t)
+	ctx := context.Background()
+
+	client.connectErr = errors.New("stream replaced")
+
+	err := bridge.Connect(ctx)
+	require.Error(t, err)
+	assert.Equal(t, state.StateFatalError, bridge.CurrentState())
+
+	history, err := storeDB.State.GetTransitionHistory(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, string(state.TriggerFatalError), history[0].Trigger)
+	assert.Equal(t, "stream replaced", history[0].Error)
+}
+
+func TestBridge_ConnectTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		loggedIn bool
+		want     time.Duration
+		wantMsg  string
+	}{
+		{"existing session", true, 50 * time.Millisecond, "no connection within 50ms"},
+		{"QR pairing", false, 150 * time.Millisecond, "QR code not scanned within 150ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bridge, client, storeDB := setupTestBridge(t)
+			bridge.config.ConnectTimeout = 50 * time.Millisecond
+			bridge.config.PairingTimeout = 150 * time.Millisecond
+			client.SetLoggedIn(tt.loggedIn)
+			client.connectHangs = true
+			ctx := context.Background()
+
+			start := time.Now()
+			err := bridge.Connect(ctx)
+			elapsed := time.Since(start)
+
+			require.ErrorIs(t, err, ErrConnectTimeout)
+			assert.Contains(t, err.Error(), tt.wantMsg)
+			assert.GreaterOrEqual(t, elapsed, tt.want)
+			assert.Less(t, elapsed, tt.want+time.Second)
+			assert.Equal(t, state.StateFatalError, bridge.CurrentState())
+
+			history, err := storeDB.State.GetTransitionHistory(ctx, 1)
+			require.NoError(t, err)
+			require.Len(t, history, 1)
+			assert.Equal(t, string(state.TriggerFatalError), history[0].Trigger)
+		})
+	}
+}
+
+func TestBridge_ConnectCancelledIsNotFatal(t *testing.T) {
+	bridge, client, _ := setupTestBridge(t)
+	client.SetLoggedIn(true)
+	client.connectHangs = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := bridge.Connect(ctx)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrConnectTimeout)
+	assert.NotEqual(t, state.StateFatalError, bridge.CurrentState())
+}
+
 func TestBridge_IsReady(t *testing.T) {
 	bridge, client, _ := setupTestBridge(t)
 	ctx := context.Background()
@@ -417,3 +793,1020 @@ func TestBridge_IsReady(t *testing.T) {
 
 	assert.True(t, bridge.IsReady())
 }
+
+func setupReadyBridge(t *testing.T) (*Bridge, *FakeClient, *store.SQLiteStore) {
+	bridge, client, storeDB := setupTestBridge(t)
+	client.SetLoggedIn(true)
+	require.NoError(t, bridge.Connect(context.Background()))
+	require.Equal(t, state.StateReady, bridge.CurrentState())
+	return bridge, client, storeDB
+}
+
+func TestBridge_ConnectionEvents(t *testing.T) {
+	t.Run("disconnected then reconnected", func(t *testing.T) {
+		bridge, client, _ := setupReadyBridge(t)
+
+		client.SimulateEvent(&events.Disconnected{})
+		assert.Equal(t, state.StateReconnecting, bridge.CurrentState())
+
+		client.SimulateEvent(&events.Connected{})
+		assert.Equal(t, state.StateReady, bridge.CurrentState())
+	})
+
+	t.Run("connected while ready is ignored", func(t *testing.T) {
+		bridge, client, _ := setupReadyBridge(t)
+
+		client.SimulateEvent(&events.Connected{})
+		assert.Equal(t, state.StateReady, bridge.CurrentState())
+	})
+
+	t.Run("logged out by stream error", func(t *testing.T) {
+		bridge, client, _ := setupReadyBridge(t)
+
+		client.SimulateEvent(&events.LoggedOut{OnConnect: false})
+		assert.Equal(t, state.StateLoggedOut, bridge.CurrentState())
+	})
+
+	t.Run("logged out on connect", func(t *testing.T) {
+		bridge, client, storeDB := setupReadyBridge(t)
+
+		client.SimulateEvent(&events.LoggedOut{OnConnect: true, Reason: events.ConnectFailureLoggedOut})
+		assert.Equal(t, state.StateSessionExpired, bridge.CurrentState())
+
+		history, err := storeDB.State.GetTransitionHistory(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Contains(t, history[0].Error, "401")
+	})
+
+	t.Run("temporary ban", func(t *testing.T) {
+		bridge, client, _ := setupReadyBridge(t)
+
+		client.SimulateEvent(&events.TemporaryBan{Code: events.TempBanSentToTooManyPeople, Expire: time.Hour})
+		assert.Equal(t, state.StateTemporaryBan, bridge.CurrentState())
+
+		ban, banned := bridge.TemporaryBan()
+		require.True(t, banned)
+		assert.Equal(t, int(events.TempBanSentToTooManyPeople), ban.Code)
+		assert.Equal(t, events.TempBanSentToTooManyPeople.String(), ban.Reason)
+		require.NotNil(t, ban.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *ban.ExpiresAt, time.Minute)
+
+		// Leaving the temporary_ban state doesn't lift the ban
+		bridge.Disconnect()
+		require.Equal(t, state.StateDisconnected, bridge.CurrentState())
+		_, banned = bridge.TemporaryBan()
+		assert.True(t, banned)
+
+		// Reconnecting does
+		client.SimulateEvent(&events.Connected{})
+		_, banned = bridge.TemporaryBan()
+		assert.False(t, banned)
+	})
+
+	t.Run("expired temporary ban", func(t *testing.T) {
+		bridge, client, _ := setupReadyBridge(t)
+
+		client.SimulateEvent(&events.TemporaryBan{Code: events.TempBanSentToTooManyPeople, Expire: time.Hour})
+		expired := time.Now().Add(-time.Second)
+		bridge.mu.Lock()
+		bridge.ban.ExpiresAt = &expired
+		bridge.mu.Unlock()
+
+		assert.Equal(t, state.StateTemporaryBan, bridge.CurrentState())
+		_, banned := bridge.TemporaryBan()
+		assert.False(t, banned, "the ban is over once it expires")
+	})
+
+	t.Run("disconnected after manual disconnect is ignored", func(t *testing.T) {
+		bridge, client, _ := setupReadyBridge(t)
+
+		bridge.Disconnect()
+		require.Equal(t, state.StateDisconnected, bridge.CurrentState())
+
+		client.SimulateEvent(&events.Disconnected{})
+		assert.Equal(t, state.StateDisconnected, bridge.CurrentState())
+	})
+}
+
+func TestBridge_ShutdownIsIdempotent(t *testing.T) {
+	bridge, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	require.NoError(t, bridge.Shutdown(ctx))
+	require.NoError(t, bridge.Shutdown(ctx))
+
+	assert.Equal(t, state.StateShuttingDown, bridge.CurrentState())
+	assert.False(t, client.IsConnected())
+
+	// Disconnect is recorded before ShuttingDown
+	history, err := storeDB.State.GetTransitionHistory(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, state.StateShuttingDown, history[0].ToState)
+	assert.Equal(t, state.StateDisconnected, history[1].ToState)
+}
+
+func TestBridge_EditMessage_MediaCaption(t *testing.T) {
+	bridge, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: "123@s.whatsapp.net"}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{
+		ID:        "img1",
+		ChatJID:   "123@s.whatsapp.net",
+		Sender:    "me",
+		Content:   "old caption",
+		Timestamp: time.Now(),
+		IsFromMe:  true,
+		MediaType: "image",
+	}))
+
+	require.NoError(t, bridge.EditMessage(ctx, "123@s.whatsapp.net", "img1", "new caption"))
+	require.NoError(t, bridge.EditMessage(ctx, "123@s.whatsapp.net", "unstored", "plain text"))
+
+	assert.Equal(t, []FakeEdit{
+		{MessageID: "img1", Content: "new caption", MediaType: "image"},
+		{MessageID: "unstored", Content: "plain text"},
+	}, client.edits)
+}
+
+func TestBridge_DeleteMessageForMe(t *testing.T) {
+	bridge, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: "123@s.whatsapp.net"}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{
+		ID:        "msg1",
+		ChatJID:   "123@s.whatsapp.net",
+		Sender:    "123@s.whatsapp.net",
+		Content:   "hello",
+		Timestamp: time.Now(),
+	}))
+
+	require.NoError(t, bridge.DeleteMessage(ctx, "123@s.whatsapp.net", "msg1", false))
+
+	_, err := storeDB.Messages.GetByID(ctx, "123@s.whatsapp.net", "msg1")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	assert.Empty(t, client.revoked, "delete for me must not revoke")
+	assert.Equal(t, []string{"msg1"}, client.deletedForMe)
+
+	// Unknown messages are reported rather than silently ignored
+	err = bridge.DeleteMessage(ctx, "123@s.whatsapp.net", "missing", false)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	// Delete for everyone still revokes
+	require.NoError(t, bridge.DeleteMessage(ctx, "123@s.whatsapp.net", "msg2", true))
+	assert.Equal(t, []string{"msg2"}, client.revoked)
+}
+
+func TestBridge_RequestHistorySync(t *testing.T) {
+	bridge, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	// Nothing stored yet, so there is no message to page back from
+	err := bridge.RequestHistorySync(ctx, "123@s.whatsapp.net", 50)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: "123@s.whatsapp.net"}))
+	now := time.Now()
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{
+		ID: "newer", ChatJID: "123@s.whatsapp.net", Sender: "123@s.whatsapp.net", Timestamp: now,
+	}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{
+		ID: "older", ChatJID: "123@s.whatsapp.net", Sender: "123@s.whatsapp.net", Timestamp: now.Add(-time.Hour),
+	}))
+
+	require.NoError(t, bridge.RequestHistorySync(ctx, "123@s.whatsapp.net", 50))
+	assert.Equal(t, []FakeHistoryRequest{{ChatJID: "123@s.whatsapp.net", OldestID: "older", Count: 50}}, client.historyReqs)
+}
+
+func TestBridge_PresenceEvents(t *testing.T) {
+	_, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	contact := types.NewJID("1234567890", types.DefaultUserServer)
+	lastSeen := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	client.SimulateEvent(&events.Presence{From: contact})
+	client.SimulateEvent(&events.ChatPresence{
+		MessageSource: types.MessageSource{Chat: contact, Sender: contact},
+		State:         types.ChatPresenceComposing,
+	})
+
+	p, err := storeDB.Presence.Get(ctx, contact.String())
+	require.NoError(t, err)
+	assert.True(t, p.Online)
+	assert.True(t, p.Composing)
+
+	client.SimulateEvent(&events.Presence{From: contact, Unavailable: true, LastSeen: lastSeen})
+
+	p, err = storeDB.Presence.Get(ctx, contact.String())
+	require.NoError(t, err)
+	assert.False(t, p.Online)
+	assert.False(t, p.Composing, "going offline clears typing")
+	require.NotNil(t, p.LastSeen)
+	assert.True(t, lastSeen.Equal(*p.LastSeen))
+}
+
+func TestBridge_ReactionEvents(t *testing.T) {
+	_, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	chat := types.NewJID("1234567890", types.DefaultUserServer)
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: chat.String()}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{ID: "MSG1", ChatJID: chat.String(), Sender: "me", IsFromMe: true, Timestamp: time.Now()}))
+
+	react := func(emoji string) {
+		client.SimulateEvent(&events.Message{
+			Info: types.MessageInfo{
+				MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+				ID:            "REACT1",
+				Timestamp:     time.Now(),
+			},
+			Message: &waE2E.Message{ReactionMessage: &waE2E.ReactionMessage{
+				Key:  &waCommon.MessageKey{ID: proto.String("MSG1")},
+				Text: proto.String(emoji),
+			}},
+		})
+	}
+
+	react("👍")
+	reactions, err := storeDB.Messages.GetReactions(ctx, chat.String(), "MSG1")
+	require.NoError(t, err)
+	require.Len(t, reactions, 1)
+	assert.Equal(t, chat.String(), reactions[0].Sender)
+	assert.Equal(t, "👍", reactions[0].Emoji)
+
+	_, err = storeDB.Messages.GetByID(ctx, chat.String(), "REACT1")
+	assert.ErrorIs(t, err, store.ErrNotFound, "reactions are not stored as messages")
+
+	react("")
+	reactions, err = storeDB.Messages.GetReactions(ctx, chat.String(), "MSG1")
+	require.NoError(t, err)
+	assert.Empty(t, reactions)
+}
+
+func TestBridge_IncomingMessageStoresPushName(t *testing.T) {
+	_, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	sender := types.NewJID("1234567890", types.DefaultUserServer)
+	require.NoError(t, storeDB.Contacts.Upsert(ctx, &store.Contact{JID: sender.String(), Name: "Alice Smith"}))
+
+	client.SimulateEvent(&events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: sender, Sender: sender},
+			ID:            "MSG1",
+			PushName:      "Ally",
+			Timestamp:     time.Now(),
+		},
+		Message: &waE2E.Message{Conversation: proto.String("hi")},
+	})
+
+	contact, err := storeDB.Contacts.GetByJID(ctx, sender.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Smith", contact.Name, "push name must not replace a saved name")
+	assert.Equal(t, "Ally", contact.PushName)
+}
+
+func TestBridge_IncomingMessageSender(t *testing.T) {
+	_, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	alice := types.NewJID("1234567890", types.DefaultUserServer)
+	aliceDevice := types.NewADJID("1234567890", 0, 5)
+	group := types.NewJID("120363000000000000", types.GroupServer)
+
+	tests := []struct {
+		name   string
+		id     string
+		source types.MessageSource
+		want   string
+	}{
+		{"direct chat", "DM1", types.MessageSource{Chat: alice, Sender: aliceDevice}, alice.String()},
+		{"group", "GRP1", types.MessageSource{Chat: group, Sender: aliceDevice, IsGroup: true}, alice.String()},
+		{"own message", "OWN1", types.MessageSource{Chat: group, Sender: types.NewADJID("1987654321", 0, 2), IsFromMe: true, IsGroup: true}, "me"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client.SimulateEvent(&events.Message{
+				Info:    types.MessageInfo{MessageSource: tt.source, ID: types.MessageID(tt.id), Timestamp: time.Now()},
+				Message: &waE2E.Message{Conversation: proto.String("hi")},
+			})
+
+			msg, err := storeDB.Messages.GetByID(ctx, tt.source.Chat.String(), tt.id)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, msg.Sender)
+			assert.Equal(t, tt.source.IsFromMe, msg.IsFromMe)
+		})
+	}
+}
+
+func TestBridge_IncomingMediaStoresThumbnail(t *testing.T) {
+	_, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	sender := types.NewJID("1234567890", types.DefaultUserServer)
+	thumb := []byte("\xff\xd8\xff fake jpeg")
+	client.SimulateEvent(&events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: sender, Sender: sender},
+			ID:            "VID1",
+			Timestamp:     time.Now(),
+		},
+		Message: &waE2E.Message{VideoMessage: &waE2E.VideoMessage{JPEGThumbnail: thumb}},
+	})
+
+	stored, err := storeDB.Messages.Thumbnail(ctx, sender.String(), "VID1")
+	require.NoError(t, err)
+	assert.Equal(t, thumb, stored)
+}
+
+func TestBridge_DownloadMedia(t *testing.T) {
+	bridge, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	sender := types.NewJID("1234567890", types.DefaultUserServer)
+	client.SimulateEvent(&events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: sender, Sender: sender},
+			ID:            "IMG1",
+			Timestamp:     time.Now(),
+		},
+		Message: &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+			DirectPath:    proto.String("/v/t62/abc"),
+			MediaKey:      []byte("key"),
+			FileSHA256:    []byte("sha"),
+			FileEncSHA256: []byte("enc"),
+			FileLength:    proto.Uint64(2048),
+		}},
+	})
+
+	path, err := bridge.DownloadMedia(ctx, sender.String(), "IMG1", "/tmp/img1.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/img1.jpg", path)
+	require.Len(t, client.downloads, 1)
+	assert.Equal(t, whatsapp.MediaFile{
+		MediaType:     "image",
+		DirectPath:    "/v/t62/abc",
+		MediaKey:      []byte("key"),
+		FileSHA256:    []byte("sha"),
+		FileEncSHA256: []byte("enc"),
+		FileLength:    2048,
+	}, client.downloads[0])
+
+	pending, err := storeDB.Messages.ListPendingMedia(ctx, sender.String(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending, "downloaded media is no longer pending")
+
+	_, err = bridge.DownloadMedia(ctx, sender.String(), "missing", "/tmp/x.jpg")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestBridge_GroupInfoStoresSystemMessage(t *testing.T) {
+	_, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	alice := types.NewJID("1234567890", types.DefaultUserServer)
+	bob := types.NewJID("1987654321", types.DefaultUserServer)
+	group := types.NewJID("120363000000000000", types.GroupServer)
+	require.NoError(t, storeDB.Contacts.Upsert(ctx, &store.Contact{JID: alice.String(), Name: "Alice"}))
+	require.NoError(t, storeDB.Contacts.UpsertPushName(ctx, bob.String(), "Bob"))
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: group.String(), IsGroup: true}))
+
+	client.SimulateEvent(&events.GroupInfo{
+		JID:       group,
+		Sender:    &alice,
+		Timestamp: time.Now(),
+		Join:      []types.JID{bob},
+	})
+
+	msgs, err := storeDB.Messages.List(ctx, group.String(), 10, "", "")
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "Alice added Bob", msgs[0].Content)
+	assert.Equal(t, store.MessageKindSystem, msgs[0].Kind)
+	assert.Equal(t, alice.String(), msgs[0].Sender)
+
+	chat, err := storeDB.Chats.GetByJID(ctx, group.String())
+	require.NoError(t, err)
+	assert.Zero(t, chat.UnreadCount, "system messages are not unread")
+}
+
+func TestBridge_SystemMessageDescriptions(t *testing.T) {
+	_, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	alice := types.NewJID("1234567890", types.DefaultUserServer)
+	group := types.NewJID("120363000000000000", types.GroupServer)
+	require.NoError(t, storeDB.Contacts.Upsert(ctx, &store.Contact{JID: alice.String(), Name: "Alice"}))
+
+	tests := []struct {
+		name string
+		evt  *events.GroupInfo
+		want string
+	}{
+		{"left", &events.GroupInfo{Sender: &alice, Leave: []types.JID{alice}}, "Alice left"},
+		{"removed several", &events.GroupInfo{Sender: &alice, Leave: []types.JID{
+			types.NewJID("111", types.DefaultUserServer),
+			types.NewJID("222", types.DefaultUserServer),
+			types.NewJID("333", types.DefaultUserServer),
+		}}, "Alice removed +111, +222 and +333"},
+		{"joined by link", &events.GroupInfo{JoinReason: "invite", Join: []types.JID{alice}}, "Alice joined using the group's invite link"},
+		{"renamed", &events.GroupInfo{Sender: &alice, Name: &types.GroupName{Name: "Trip"}}, `Alice changed the group name to "Trip"`},
+		{"disappearing", &events.GroupInfo{Sender: &alice, Ephemeral: &types.GroupEphemeral{IsEphemeral: true, DisappearingTimer: 7 * 24 * 3600}}, "Alice turned on disappearing messages (7 days)"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.evt.JID = group
+			tt.evt.Timestamp = time.Unix(int64(1700000000+i), 0)
+			client.SimulateEvent(tt.evt)
+
+			msgs, err := storeDB.Messages.List(ctx, group.String(), 1, "", "")
+			require.NoError(t, err)
+			require.Len(t, msgs, 1)
+			assert.Equal(t, tt.want, msgs[0].Content)
+		})
+	}
+}
+
+func TestBridge_HistorySyncStubIsSystemMessage(t *testing.T) {
+	_, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	group := "120363000000000000@g.us"
+	client.SimulateEvent(&events.HistorySync{Data: &waHistorySync.HistorySync{
+		Conversations: []*waHistorySync.Conversation{{
+			ID: proto.String(group),
+			Messages: []*waHistorySync.HistorySyncMsg{{
+				Message: &waWeb.WebMessageInfo{
+					Key:                   &waCommon.MessageKey{ID: proto.String("STUB1"), FromMe: proto.Bool(true)},
+					MessageTimestamp:      proto.Uint64(1700000000),
+					MessageStubType:       waWeb.WebMessageInfo_GROUP_PARTICIPANT_ADD.Enum(),
+					MessageStubParameters: []string{"1987654321@s.whatsapp.net"},
+				},
+			}},
+		}},
+	}})
+
+	msg, err := storeDB.Messages.GetByID(ctx, group, "STUB1")
+	require.NoError(t, err)
+	assert.Equal(t, "You added +1987654321", msg.Content)
+	assert.Equal(t, store.MessageKindSystem, msg.Kind)
+}
+
+func TestBridge_SyncProgressCountsHistorySync(t *testing.T) {
+	b, client, _ := setupReadyBridge(t)
+
+	p := b.SyncProgress()
+	assert.Equal(t, state.StateReady, p.State)
+	assert.False(t, p.InProgress)
+	assert.Zero(t, p.Batches)
+	assert.Nil(t, p.Percent)
+
+	msg := func(id string) *waHistorySync.HistorySyncMsg {
+		return &waHistorySync.HistorySyncMsg{Message: &waWeb.WebMessageInfo{
+			Key:              &waCommon.MessageKey{ID: proto.String(id), RemoteJID: proto.String("1234567890@s.whatsapp.net")},
+			MessageTimestamp: proto.Uint64(1700000000),
+			Message:          &waE2E.Message{Conversation: proto.String("hi")},
+		}}
+	}
+	client.SimulateEvent(&events.HistorySync{Data: &waHistorySync.HistorySync{
+		SyncType: waHistorySync.HistorySync_INITIAL_BOOTSTRAP.Enum(),
+		Progress: proto.Uint32(40),
+		Conversations: []*waHistorySync.Conversation{
+			{ID: proto.String("1234567890@s.whatsapp.net"), Messages: []*waHistorySync.HistorySyncMsg{msg("H1"), msg("H2")}},
+			{ID: proto.String("1987654321@s.whatsapp.net"), Messages: []*waHistorySync.HistorySyncMsg{msg("H3")}},
+		},
+	}})
+
+	p = b.SyncProgress()
+	assert.True(t, p.InProgress, "a sync below 100% that just sent a batch is running")
+	assert.Equal(t, 1, p.Batches)
+	assert.Equal(t, 2, p.Conversations)
+	assert.Equal(t, 3, p.Messages)
+	require.NotNil(t, p.Percent)
+	assert.Equal(t, 40, *p.Percent)
+	assert.Equal(t, "INITIAL_BOOTSTRAP", p.SyncType)
+
+	// A batch without an estimate keeps the last one; 100% ends the sync
+	client.SimulateEvent(&events.HistorySync{Data: &waHistorySync.HistorySync{
+		SyncType: waHistorySync.HistorySync_PUSH_NAME.Enum(),
+	}})
+	require.NotNil(t, b.SyncProgress().Percent)
+	assert.Equal(t, 40, *b.SyncProgress().Percent)
+
+	client.SimulateEvent(&events.HistorySync{Data: &waHistorySync.HistorySync{
+		SyncType:      waHistorySync.HistorySync_INITIAL_BOOTSTRAP.Enum(),
+		Progress:      proto.Uint32(100),
+		Conversations: []*waHistorySync.Conversation{{ID: proto.String("1234567890@s.whatsapp.net"), Messages: []*waHistorySync.HistorySyncMsg{msg("H4")}}},
+	}})
+	p = b.SyncProgress()
+	assert.False(t, p.InProgress)
+	assert.Equal(t, 3, p.Batches)
+	assert.Equal(t, 3, p.Conversations)
+	assert.Equal(t, 4, p.Messages)
+}
+
+func TestBridge_ProtocolMessageNotStored(t *testing.T) {
+	_, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	sender := types.NewJID("1234567890", types.DefaultUserServer)
+	client.SimulateEvent(&events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: sender, Sender: sender},
+			ID:            "PROTO1",
+			Timestamp:     time.Now(),
+		},
+		Message: &waE2E.Message{ProtocolMessage: &waE2E.ProtocolMessage{
+			Type: waE2E.ProtocolMessage_APP_STATE_SYNC_KEY_SHARE.Enum(),
+		}},
+	})
+
+	_, err := storeDB.Messages.GetByID(ctx, sender.String(), "PROTO1")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+// lockedMessages fails every message store, as a busy database would.
+type lockedMessages struct {
+	store.MessageRepository
+}
+
+func (lockedMessages) Store(ctx context.Context, msg *store.Message) error {
+	return errors.New("database is locked")
+}
+
+func TestBridge_FailedMessageStoreIsDeadLettered(t *testing.T) {
+	bridge, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+	messages := bridge.store.Messages
+	bridge.store.Messages = lockedMessages{messages}
+
+	sender := types.NewJID("1234567890", types.DefaultUserServer)
+	client.SimulateEvent(&events.Message{
+		Info:    types.MessageInfo{MessageSource: types.MessageSource{Chat: sender, Sender: sender}, ID: "LOST1", Timestamp: time.Now()},
+		Message: &waE2E.Message{Conversation: proto.String("don't drop me")},
+	})
+
+	failed, err := storeDB.DeadLetters.List(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, "LOST1", failed[0].Message.ID)
+	assert.Equal(t, "don't drop me", failed[0].Message.Content)
+	assert.Equal(t, "database is locked", failed[0].Error)
+
+	// Still failing: the entry stays and counts the attempt
+	stored, stillFailing, err := bridge.RetryFailedStores(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored)
+	assert.Equal(t, 1, stillFailing)
+	failed, err = storeDB.DeadLetters.List(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, 2, failed[0].Attempts)
+
+	bridge.store.Messages = messages
+	stored, stillFailing, err = bridge.RetryFailedStores(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored)
+	assert.Equal(t, 0, stillFailing)
+
+	msg, err := storeDB.Messages.GetByID(ctx, sender.String(), "LOST1")
+	require.NoError(t, err)
+	assert.Equal(t, "don't drop me", msg.Content)
+	n, err := storeDB.DeadLetters.Count(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestBridge_GetNewsletterMessages(t *testing.T) {
+	bridge, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	jid := "120363000000000001@newsletter"
+	posted := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	client.newsletterMessages = []*types.NewsletterMessage{
+		{MessageServerID: 101, MessageID: "AAA", Type: "text", Timestamp: posted, ViewsCount: 7,
+			Message: &waE2E.Message{Conversation: proto.String("v2 is out")}},
+		{MessageServerID: 102, Type: "media", Timestamp: posted.Add(time.Hour),
+			Message: &waE2E.Message{ImageMessage: &waE2E.ImageMessage{Caption: proto.String("screenshot")}}},
+	}
+
+	posts, err := bridge.GetNewsletterMessages(ctx, jid, 20)
+	require.NoError(t, err)
+	require.Len(t, posts, 2)
+	assert.Equal(t, "v2 is out", posts[0].Content)
+	assert.Equal(t, 7, posts[0].Views)
+	assert.Equal(t, "102", posts[1].ID, "posts without a message ID fall back to the server ID")
+
+	// Posts are stored under the channel as a chat, without counting as unread
+	chat, err := storeDB.Chats.GetByJID(ctx, jid)
+	require.NoError(t, err)
+	assert.Zero(t, chat.UnreadCount)
+	stored, err := storeDB.Messages.GetByID(ctx, jid, "102")
+	require.NoError(t, err)
+	assert.Equal(t, "image", stored.MediaType)
+	assert.Equal(t, jid, stored.Sender)
+
+	// WhatsApp refusing channel queries is reported as unsupported
+	client.newsletterErr = fmt.Errorf("failed to get newsletter messages: %w", whatsmeow.ErrIQForbidden)
+	_, err = bridge.GetNewsletterMessages(ctx, jid, 20)
+	assert.ErrorIs(t, err, ErrNewslettersUnsupported)
+}
+
+func TestBridge_ContactNameEvents(t *testing.T) {
+	_, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	jid := types.NewJID("1234567890", types.DefaultUserServer)
+	require.NoError(t, storeDB.Contacts.Upsert(ctx, &store.Contact{JID: jid.String(), PushName: "Ally", Blocked: true}))
+
+	client.SimulateEvent(&events.PushName{JID: jid, OldPushName: "Ally", NewPushName: "Alice"})
+	client.SimulateEvent(&events.BusinessName{JID: jid, NewBusinessName: "Alice's Bakery"})
+
+	contact, err := storeDB.Contacts.GetByJID(ctx, jid.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", contact.PushName)
+	assert.Equal(t, "Alice's Bakery", contact.BusinessName)
+	assert.True(t, contact.Blocked, "name events must not touch other fields")
+
+	found, err := storeDB.Contacts.Search(ctx, "Alice", 10)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, jid.String(), found[0].JID)
+
+	// Saving the contact on the phone sets its name
+	client.SimulateEvent(&events.Contact{JID: jid, Action: &waSyncAction.ContactAction{FullName: proto.String("Alice Smith")}})
+	contact, err = storeDB.Contacts.GetByJID(ctx, jid.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Smith", contact.Name)
+	assert.True(t, contact.IsSaved)
+}
+
+func TestBridge_UnreadCount(t *testing.T) {
+	bridge, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	chat := types.NewJID("1234567890", types.DefaultUserServer)
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: chat.String(), Name: "Alice", Pinned: true}))
+
+	message := func(id string, fromMe bool) *events.Message {
+		return &events.Message{
+			Info: types.MessageInfo{
+				MessageSource: types.MessageSource{Chat: chat, Sender: chat, IsFromMe: fromMe},
+				ID:            id,
+				Timestamp:     time.Now(),
+			},
+			Message: &waE2E.Message{Conversation: proto.String("hi")},
+		}
+	}
+	client.SimulateEvent(message("MSG1", false))
+	client.SimulateEvent(message("MSG2", false))
+	client.SimulateEvent(message("MSG3", true))
+
+	got, err := storeDB.Chats.GetByJID(ctx, chat.String())
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.UnreadCount)
+	assert.Equal(t, "Alice", got.Name, "new messages must not clear the chat name")
+	assert.True(t, got.Pinned)
+
+	require.NoError(t, bridge.MarkChatRead(ctx, chat.String()))
+	got, err = storeDB.Chats.GetByJID(ctx, chat.String())
+	require.NoError(t, err)
+	assert.Equal(t, 0, got.UnreadCount)
+}
+
+func TestBridge_PresenceMode(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{config.PresenceAlwaysOnline, "online"},
+		{config.PresenceAlwaysOffline, "offline"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			bridge, client, _ := setupTestBridge(t)
+			bridge.config.PresenceMode = tt.mode
+			bridge.config.KeepaliveInterval = 10 * time.Millisecond
+			client.SetLoggedIn(true)
+
+			require.NoError(t, bridge.Connect(context.Background()))
+			require.Eventually(t, func() bool {
+				calls := client.GetPresenceCalls()
+				return len(calls) > 0 && calls[0] == tt.want
+			}, time.Second, 5*time.Millisecond)
+
+			if tt.mode == config.PresenceAlwaysOnline {
+				require.Eventually(t, func() bool {
+					return len(client.GetPresenceCalls()) >= 3
+				}, time.Second, 5*time.Millisecond, "presence is renewed on the keepalive tick")
+			}
+			for _, call := range client.GetPresenceCalls() {
+				assert.Equal(t, tt.want, call)
+			}
+		})
+	}
+
+	t.Run(config.PresenceAuto, func(t *testing.T) {
+		bridge, client, _ := setupReadyBridge(t)
+		assert.Equal(t, config.PresenceAuto, bridge.config.PresenceMode)
+		time.Sleep(20 * time.Millisecond)
+		assert.Empty(t, client.GetPresenceCalls())
+	})
+}
+
+func TestBridge_SendBatch(t *testing.T) {
+	ctx := context.Background()
+	jids := []string{"111@s.whatsapp.net", "222@s.whatsapp.net", "333@s.whatsapp.net"}
+	texts := []string{"one", "two", "three"}
+
+	t.Run("all succeed", func(t *testing.T) {
+		bridge, client, _ := setupReadyBridge(t)
+
+		ids, errs, err := bridge.SendBatch(ctx, jids, texts, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"msg-111@s.whatsapp.net", "msg-222@s.whatsapp.net", "msg-333@s.whatsapp.net"}, ids)
+		assert.Equal(t, []error{nil, nil, nil}, errs)
+		assert.Len(t, client.GetSentMessages(), 3)
+	})
+
+	t.Run("continue on error", func(t *testing.T) {
+		bridge, client, _ := setupReadyBridge(t)
+		client.failJIDs = map[string]bool{"222@s.whatsapp.net": true}
+
+		ids, errs, err := bridge.SendBatch(ctx, jids, texts, true)
+		require.NoError(t, err)
+		assert.NoError(t, errs[0])
+		assert.Error(t, errs[1])
+		assert.NoError(t, errs[2])
+		assert.Equal(t, "msg-333@s.whatsapp.net", ids[2])
+		assert.Len(t, client.GetSentMessages(), 2)
+	})
+
+	t.Run("stop on error", func(t *testing.T) {
+		bridge, client, _ := setupReadyBridge(t)
+		client.failJIDs = map[string]bool{"222@s.whatsapp.net": true}
+
+		ids, errs, err := bridge.SendBatch(ctx, jids, texts, false)
+		require.NoError(t, err)
+		assert.NoError(t, errs[0])
+		assert.Error(t, errs[1])
+		assert.ErrorIs(t, errs[2], ErrBatchStopped)
+		assert.Empty(t, ids[2])
+		assert.Len(t, client.GetSentMessages(), 1)
+	})
+}
+
+func TestBridge_SendBroadcast_UsesSendPath(t *testing.T) {
+	bridge, client, _ := setupReadyBridge(t)
+	bridge.cooldown = newRecipientCooldown(time.Minute)
+	ctx := context.Background()
+
+	_, err := bridge.SendMessage(ctx, "111@s.whatsapp.net", "earlier", nil)
+	require.NoError(t, err)
+
+	// A dropped connection is retried, and the cooldown applies per recipient
+	client.transientFailures = 1
+	ids, errs, err := bridge.SendBroadcast(ctx, []string{"111@s.whatsapp.net", "222@s.whatsapp.net"}, "hello")
+	require.NoError(t, err)
+	var cooldown *CooldownError
+	require.ErrorAs(t, errs[0], &cooldown)
+	assert.Equal(t, "111@s.whatsapp.net", cooldown.JID)
+	assert.Empty(t, ids[0])
+	require.NoError(t, errs[1])
+	assert.Equal(t, "msg-222@s.whatsapp.net", ids[1])
+	assert.Len(t, client.GetSentMessages(), 2)
+}
+
+func TestBridge_SendMessage_IdempotencyKey(t *testing.T) {
+	bridge, client, _ := setupReadyBridge(t)
+	ctx := WithIdempotencyKey(context.Background(), "send_message", "order-42")
+
+	first, err := bridge.SendMessage(ctx, "111@s.whatsapp.net", "hello", nil)
+	require.NoError(t, err)
+	second, err := bridge.SendMessage(ctx, "111@s.whatsapp.net", "hello", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Len(t, client.GetSentMessages(), 1, "a repeated key must not send again")
+
+	// A different key, or no key, sends as usual
+	_, err = bridge.SendMessage(WithIdempotencyKey(context.Background(), "send_message", "order-43"), "111@s.whatsapp.net", "hello", nil)
+	require.NoError(t, err)
+	_, err = bridge.SendMessage(context.Background(), "111@s.whatsapp.net", "hello", nil)
+	require.NoError(t, err)
+	assert.Len(t, client.GetSentMessages(), 3)
+}
+
+func TestBridge_SendMessage_IdempotencyKeyScopedToRecipientAndTool(t *testing.T) {
+	bridge, client, _ := setupReadyBridge(t)
+	ctx := WithIdempotencyKey(context.Background(), "send_message", "order-42")
+
+	first, err := bridge.SendMessage(ctx, "111@s.whatsapp.net", "hello", nil)
+	require.NoError(t, err)
+
+	// The same key for another recipient is a different message
+	other, err := bridge.SendMessage(ctx, "222@s.whatsapp.net", "hello", nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, other.ID)
+	assert.Len(t, client.GetSentMessages(), 2)
+
+	// So is the same key from another tool
+	replyCtx := WithIdempotencyKey(context.Background(), "reply_to_message", "order-42")
+	_, err = bridge.SendMessage(replyCtx, "111@s.whatsapp.net", "hello", nil)
+	require.NoError(t, err)
+	assert.Len(t, client.GetSentMessages(), 3)
+}
+
+func TestBridge_PinChat_RejectsFourthPin(t *testing.T) {
+	bridge, _, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+
+	for _, jid := range []string{"111@s.whatsapp.net", "222@s.whatsapp.net", "333@s.whatsapp.net", "444@s.whatsapp.net"} {
+		require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: jid}))
+	}
+	for _, jid := range []string{"111@s.whatsapp.net", "222@s.whatsapp.net", "333@s.whatsapp.net"} {
+		require.NoError(t, bridge.PinChat(ctx, jid, true))
+	}
+
+	err := bridge.PinChat(ctx, "444@s.whatsapp.net", true)
+	require.ErrorIs(t, err, ErrTooManyPinnedChats)
+	assert.EqualError(t, err, "maximum 3 pinned chats")
+
+	// Pinning an already pinned chat is not a new pin
+	require.NoError(t, bridge.PinChat(ctx, "111@s.whatsapp.net", true))
+
+	// Unpinning frees a slot
+	require.NoError(t, bridge.PinChat(ctx, "222@s.whatsapp.net", false))
+	require.NoError(t, bridge.PinChat(ctx, "444@s.whatsapp.net", true))
+
+	pinned, err := storeDB.Chats.CountPinned(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, pinned)
+}
+
+func TestBridge_ArchiveChats_StopsWhenRateLimited(t *testing.T) {
+	bridge, client, _ := setupReadyBridge(t)
+	restore := bulkChatInterval
+	bulkChatInterval = time.Millisecond
+	t.Cleanup(func() { bulkChatInterval = restore })
+
+	client.archiveErrs = map[string]error{
+		"222@s.whatsapp.net": errors.New("chat not found"),
+		"333@s.whatsapp.net": fmt.Errorf("failed to archive: %w", whatsmeow.ErrIQRateOverLimit),
+	}
+	jids := []string{"111@s.whatsapp.net", "222@s.whatsapp.net", "333@s.whatsapp.net", "444@s.whatsapp.net"}
+
+	errs, err := bridge.ArchiveChats(context.Background(), jids, true)
+	require.NoError(t, err)
+	require.Len(t, errs, 4)
+	assert.NoError(t, errs[0])
+	assert.EqualError(t, errs[1], "chat not found")
+	assert.ErrorIs(t, errs[2], whatsmeow.ErrIQRateOverLimit)
+	assert.ErrorIs(t, errs[3], ErrBulkRateLimited)
+	assert.Equal(t, []string{"111@s.whatsapp.net"}, client.archived)
+}
+
+func TestBridge_GetInviteLink_Cache(t *testing.T) {
+	bridge, client, storeDB := setupReadyBridge(t)
+	ctx := context.Background()
+	groupJID := "120363000000000001@g.us"
+
+	link, err := bridge.GetInviteLink(ctx, groupJID, false)
+	require.NoError(t, err)
+	assert.Equal(t, "https://chat.whatsapp.com/link1", link.Link)
+	assert.False(t, link.Cached)
+
+	// A fresh link is served from the store
+	link, err = bridge.GetInviteLink(ctx, groupJID, false)
+	require.NoError(t, err)
+	assert.Equal(t, "https://chat.whatsapp.com/link1", link.Link)
+	assert.True(t, link.Cached)
+	assert.Equal(t, 1, client.InviteLinkFetches())
+
+	// refresh goes to WhatsApp regardless
+	link, err = bridge.GetInviteLink(ctx, groupJID, true)
+	require.NoError(t, err)
+	assert.Equal(t, "https://chat.whatsapp.com/link2", link.Link)
+	assert.False(t, link.Cached)
+
+	// So does a link older than the TTL
+	stale := time.Now().Add(-bridge.config.InviteLinkTTL - time.Minute)
+	require.NoError(t, storeDB.Groups.SetInviteLink(ctx, groupJID, "https://chat.whatsapp.com/stale", stale))
+	link, err = bridge.GetInviteLink(ctx, groupJID, false)
+	require.NoError(t, err)
+	assert.Equal(t, "https://chat.whatsapp.com/link3", link.Link)
+
+	// Revoking replaces the cached link
+	revoked, err := bridge.RevokeInviteLink(ctx, groupJID)
+	require.NoError(t, err)
+	link, err = bridge.GetInviteLink(ctx, groupJID, false)
+	require.NoError(t, err)
+	assert.True(t, link.Cached)
+	assert.Equal(t, revoked, link.Link)
+	assert.Equal(t, 3, client.InviteLinkFetches())
+}
+
+func TestBridge_SendMessage_RetriesTransientFailures(t *testing.T) {
+	bridge, client, _ := setupReadyBridge(t)
+	restore := sendRetryInterval
+	sendRetryInterval = time.Millisecond
+	t.Cleanup(func() { sendRetryInterval = restore })
+
+	client.transientFailures = 2
+	ctx := WithAttemptCounter(context.Background())
+	result, err := bridge.SendMessage(ctx, "111@s.whatsapp.net", "hello", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "msg-111@s.whatsapp.net", result.ID)
+	assert.Equal(t, 3, SendAttempts(ctx))
+	assert.Len(t, client.GetSentMessages(), 1)
+
+	// Gives up after sendMaxAttempts
+	client.transientFailures = sendMaxAttempts
+	_, err = bridge.SendMessage(ctx, "111@s.whatsapp.net", "hello", nil)
+	require.ErrorIs(t, err, whatsapp.ErrNotConnected)
+	assert.Equal(t, sendMaxAttempts, SendAttempts(ctx))
+
+	// Permanent errors are not retried
+	client.transientFailures = 0
+	client.failJIDs = map[string]bool{"222@s.whatsapp.net": true}
+	_, err = bridge.SendMessage(ctx, "222@s.whatsapp.net", "hello", nil)
+	require.Error(t, err)
+	assert.Equal(t, 1, SendAttempts(ctx))
+}
+
+func TestBridge_SendMessage_PerRecipientCooldown(t *testing.T) {
+	bridge, client, _ := setupReadyBridge(t)
+	bridge.cooldown = newRecipientCooldown(time.Minute)
+	ctx := context.Background()
+
+	// A second message to the same recipient right away is throttled
+	_, err := bridge.SendMessage(ctx, "111@s.whatsapp.net", "one", nil)
+	require.NoError(t, err)
+	_, err = bridge.SendMessage(ctx, "111@s.whatsapp.net", "two", nil)
+	var cooldown *CooldownError
+	require.ErrorAs(t, err, &cooldown)
+	assert.Equal(t, "111@s.whatsapp.net", cooldown.JID)
+	assert.Greater(t, cooldown.Remaining, 59*time.Second)
+
+	// Different recipients don't share a cooldown
+	_, err = bridge.SendMessage(ctx, "222@s.whatsapp.net", "hi", nil)
+	require.NoError(t, err)
+	_, err = bridge.SendMessage(ctx, "333@s.whatsapp.net", "hi", nil)
+	require.NoError(t, err)
+	assert.Len(t, client.GetSentMessages(), 3)
+
+	// A failed send doesn't start the cooldown
+	client.failJIDs = map[string]bool{"444@s.whatsapp.net": true}
+	_, err = bridge.SendMessage(ctx, "444@s.whatsapp.net", "hi", nil)
+	require.Error(t, err)
+	require.False(t, errors.As(err, &cooldown))
+	client.failJIDs = nil
+	_, err = bridge.SendMessage(ctx, "444@s.whatsapp.net", "hi", nil)
+	require.NoError(t, err)
+
+	// The cooldown ends after the interval
+	bridge.cooldown.now = func() time.Time { return time.Now().Add(time.Minute) }
+	_, err = bridge.SendMessage(ctx, "111@s.whatsapp.net", "three", nil)
+	require.NoError(t, err)
+
+	// A reloaded interval applies from the next send, and zero disables it
+	bridge.cooldown.now = time.Now
+	bridge.SetPerRecipientCooldown(2 * time.Minute)
+	_, err = bridge.SendMessage(ctx, "555@s.whatsapp.net", "one", nil)
+	require.NoError(t, err)
+	_, err = bridge.SendMessage(ctx, "555@s.whatsapp.net", "two", nil)
+	require.ErrorAs(t, err, &cooldown)
+	assert.Greater(t, cooldown.Remaining, 119*time.Second)
+	bridge.SetPerRecipientCooldown(0)
+	_, err = bridge.SendMessage(ctx, "555@s.whatsapp.net", "three", nil)
+	require.NoError(t, err)
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, isRetryable(whatsapp.ErrNotConnected))
+	assert.True(t, isRetryable(fmt.Errorf("failed to send message: %w", whatsmeow.ErrNotConnected)))
+	assert.False(t, isRetryable(fmt.Errorf("failed to send message: %w", whatsmeow.ErrMessageTimedOut)))
+	assert.True(t, isRetryable(whatsmeow.ErrIQServiceUnavailable))
+	assert.False(t, isRetryable(context.Canceled))
+	assert.False(t, isRetryable(context.DeadlineExceeded))
+	assert.False(t, isRetryable(errors.New("invalid JID: unexpected number of dots in JID")))
+	assert.False(t, isRetryable(whatsmeow.ErrIQForbidden))
+}
+
+func TestBridge_SendMessage_IdempotencyKeyRetriesFailures(t *testing.T) {
+	bridge, client, _ := setupReadyBridge(t)
+	ctx := WithIdempotencyKey(context.Background(), "send_message", "retry-me")
+
+	client.failJIDs = map[string]bool{"111@s.whatsapp.net": true}
+	_, err := bridge.SendMessage(ctx, "111@s.whatsapp.net", "hello", nil)
+	require.Error(t, err)
+
+	client.failJIDs = nil
+	_, err = bridge.SendMessage(ctx, "111@s.whatsapp.net", "hello", nil)
+	require.NoError(t, err)
+	assert.Len(t, client.GetSentMessages(), 1)
+}
@@ -0,0 +1,82 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// bulkChatInterval spaces out the app state patches of a bulk chat
+// operation so a long list doesn't trip WhatsApp's rate limit. A variable
+// so tests can shorten it.
+var bulkChatInterval = 250 * time.Millisecond
+
+// ErrBulkRateLimited marks bulk entries that were not attempted because
+// WhatsApp rate limited an earlier one.
+var ErrBulkRateLimited = errors.New("not attempted: rate limited by WhatsApp")
+
+// ArchiveChats archives or unarchives each chat in turn and returns an
+// error per JID.
+func (b *Bridge) ArchiveChats(ctx context.Context, jids []string, archive bool) ([]error, error) {
+	if !b.IsReady() {
+		return nil, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.forEachChat(ctx, jids, func(jid string) error {
+		return b.client.ArchiveChat(ctx, jid, archive)
+	}), nil
+}
+
+// MuteChats mutes or unmutes each chat in turn and returns an error per
+// JID. duration applies to every chat, as for MuteChat.
+func (b *Bridge) MuteChats(ctx context.Context, jids []string, mute bool, duration string) ([]error, error) {
+	if !b.IsReady() {
+		return nil, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.forEachChat(ctx, jids, func(jid string) error {
+		return b.client.MuteChat(ctx, jid, mute, duration)
+	}), nil
+}
+
+// MarkAllRead marks each chat as read in turn and returns an error per JID.
+// Like the other bulk operations it paces the calls and stops at the first
+// rate limit.
+func (b *Bridge) MarkAllRead(ctx context.Context, jids []string) ([]error, error) {
+	if !b.IsReady() {
+		return nil, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+	return b.forEachChat(ctx, jids, func(jid string) error {
+		return b.client.MarkChatRead(ctx, jid)
+	}), nil
+}
+
+// forEachChat runs op for each JID, pausing bulkChatInterval between
+// calls. Once WhatsApp answers with a rate limit error, or ctx is done, the
+// remaining JIDs are not attempted and get ErrBulkRateLimited or the
+// context's error.
+func (b *Bridge) forEachChat(ctx context.Context, jids []string, op func(jid string) error) []error {
+	errs := make([]error, len(jids))
+	var stop error
+	for i, jid := range jids {
+		if stop == nil && i > 0 {
+			select {
+			case <-ctx.Done():
+				stop = ctx.Err()
+			case <-time.After(bulkChatInterval):
+			}
+		}
+		if stop != nil {
+			errs[i] = stop
+			continue
+		}
+
+		errs[i] = op(jid)
+		if errors.Is(errs[i], whatsmeow.ErrIQRateOverLimit) {
+			b.log.Warn("rate limited during bulk chat update", "done", i, "total", len(jids))
+			stop = ErrBulkRateLimited
+		}
+	}
+	return errs
+}
@@ -2,39 +2,61 @@ package bridge
 
 import (
 	"context"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/whatsapp"
 )
 
 // WhatsAppClient defines the interface for WhatsApp operations.
 // This allows for easy mocking in tests.
 type WhatsAppClient interface {
 	Connect(ctx context.Context) error
+	NeedsPairing(ctx context.Context) (bool, error)
 	Disconnect()
 	IsConnected() bool
 	IsLoggedIn() bool
+	IsBusiness() bool
+	GetLinkedDevices(ctx context.Context) ([]whatsapp.DeviceInfo, error)
+	Ping(ctx context.Context) (time.Duration, error)
 
 	// Messaging
-	SendMessage(ctx context.Context, jid string, text string) (string, error)
-	ReplyToMessage(ctx context.Context, chatJID, messageID, text string) (string, error)
-	ForwardMessage(ctx context.Context, sourceChatJID, messageID, targetJID string) (string, error)
-	EditMessage(ctx context.Context, chatJID, messageID, newContent string) error
+	SendMessage(ctx context.Context, jid string, text string, mentions []string) (whatsmeow.SendResponse, error)
+	SendQuotedMessage(ctx context.Context, jid, text string, mentions []string, quotedChatJID, quotedID, quotedSender, quotedText string) (whatsmeow.SendResponse, error)
+	ReplyToMessage(ctx context.Context, chatJID, messageID, text string) (whatsmeow.SendResponse, error)
+	ForwardMessage(ctx context.Context, sourceChatJID, messageID, targetJID string) (whatsmeow.SendResponse, error)
+	EditMessage(ctx context.Context, chatJID, messageID, newContent, mediaType string) error
 	DeleteMessage(ctx context.Context, chatJID, messageID string, forEveryone bool) error
+	DeleteMessageForMe(ctx context.Context, chatJID, senderJID, messageID string, fromMe bool, timestamp time.Time) error
 	ReactToMessage(ctx context.Context, chatJID, messageID, emoji string) error
 
 	// Media
-	SendImage(ctx context.Context, jid, imagePath, caption string) (string, error)
-	SendVideo(ctx context.Context, jid, videoPath, caption string) (string, error)
-	SendAudio(ctx context.Context, jid, audioPath string, asVoice bool) (string, error)
-	SendDocument(ctx context.Context, jid, filePath, filename string) (string, error)
-	SendLocation(ctx context.Context, jid string, lat, lon float64, name, address string) (string, error)
-	SendContactCard(ctx context.Context, jid, contactJID string) (string, error)
-	DownloadMedia(ctx context.Context, chatJID, messageID, savePath string) (string, error)
+	SendImage(ctx context.Context, jid, imagePath, caption string, viewOnce bool) (whatsmeow.SendResponse, error)
+	SendVideo(ctx context.Context, jid, videoPath, caption string, viewOnce bool) (whatsmeow.SendResponse, error)
+	SendGIF(ctx context.Context, jid, gifPath, caption string) (whatsmeow.SendResponse, error)
+	SendAudio(ctx context.Context, jid, audioPath string, asVoice bool) (whatsmeow.SendResponse, error)
+	SendDocument(ctx context.Context, jid, filePath, filename, caption string) (whatsmeow.SendResponse, error)
+	SendLocation(ctx context.Context, jid string, lat, lon float64, name, address string) (whatsmeow.SendResponse, error)
+	SendLiveLocation(ctx context.Context, jid string, lat, lon float64, durationSec int) (whatsmeow.SendResponse, error)
+	SendContactCard(ctx context.Context, jid, contactJID string) (whatsmeow.SendResponse, error)
+	SendVCard(ctx context.Context, jid string, card whatsapp.ContactCard) (whatsmeow.SendResponse, error)
+	SendContactCards(ctx context.Context, jid string, contactJIDs []string) (whatsmeow.SendResponse, error)
+	DownloadMedia(ctx context.Context, media whatsapp.MediaFile, savePath string) (string, error)
 
 	// Chats
 	ArchiveChat(ctx context.Context, jid string, archive bool) error
 	PinChat(ctx context.Context, jid string, pin bool) error
 	MuteChat(ctx context.Context, jid string, mute bool, duration string) error
+	SetDisappearingTimer(ctx context.Context, jid string, duration time.Duration) error
 	MarkChatRead(ctx context.Context, jid string) error
+	MarkChatUnread(ctx context.Context, jid string) error
 	DeleteChat(ctx context.Context, jid string) error
+	GetChatSettings(ctx context.Context, jid string) (types.LocalChatSettings, error)
+	RequestHistorySync(ctx context.Context, chatJID, oldestID string, oldestFromMe bool, oldestTimestamp time.Time, count int) error
+	AddChatLabel(ctx context.Context, chatJID, labelID string) error
+	RemoveChatLabel(ctx context.Context, chatJID, labelID string) error
 
 	// Contacts
 	BlockContact(ctx context.Context, jid string, block bool) error
@@ -42,7 +64,7 @@ type WhatsAppClient interface {
 
 	// Groups
 	CreateGroup(ctx context.Context, name string, participants []string) (string, error)
-	GetGroupInfo(ctx context.Context, jid string) (interface{}, error)
+	GetGroupInfo(ctx context.Context, jid string) (*types.GroupInfo, error)
 	LeaveGroup(ctx context.Context, jid string) error
 	AddGroupMembers(ctx context.Context, groupJID string, participants []string) error
 	RemoveGroupMembers(ctx context.Context, groupJID string, participants []string) error
@@ -50,10 +72,18 @@ type WhatsAppClient interface {
 	DemoteAdmin(ctx context.Context, groupJID string, participants []string) error
 	SetGroupName(ctx context.Context, groupJID, name string) error
 	SetGroupTopic(ctx context.Context, groupJID, topic string) error
+	SetGroupAnnounce(ctx context.Context, groupJID string, announce bool) error
+	SetGroupLocked(ctx context.Context, groupJID string, locked bool) error
 	SetGroupPhoto(ctx context.Context, groupJID, imagePath string) error
+	RemoveGroupPhoto(ctx context.Context, groupJID string) error
 	GetInviteLink(ctx context.Context, groupJID string) (string, error)
 	RevokeInviteLink(ctx context.Context, groupJID string) (string, error)
 	JoinViaInvite(ctx context.Context, inviteLink string) (string, error)
+	GetGroupInfoFromLink(ctx context.Context, inviteLink string) (*types.GroupInfo, error)
+	GetCommonGroups(ctx context.Context, jid string) ([]string, error)
+	GetGroupJoinRequests(ctx context.Context, groupJID string) ([]types.GroupParticipantRequest, error)
+	ApproveGroupJoinRequest(ctx context.Context, groupJID string, participants []string) error
+	RejectGroupJoinRequest(ctx context.Context, groupJID string, participants []string) error
 
 	// Presence
 	SubscribePresence(ctx context.Context, jid string) error
@@ -66,6 +96,11 @@ type WhatsAppClient interface {
 	PostTextStatus(ctx context.Context, text, backgroundColor string) error
 	PostImageStatus(ctx context.Context, imagePath, caption string) error
 	DeleteStatus(ctx context.Context, statusID string) error
+	ReplyToStatus(ctx context.Context, statusID, senderJID, text string) (whatsmeow.SendResponse, error)
+
+	// Newsletters
+	GetSubscribedNewsletters(ctx context.Context) ([]*types.NewsletterMetadata, error)
+	GetNewsletterMessages(ctx context.Context, newsletterJID string, count int) ([]*types.NewsletterMessage, error)
 
 	GetQRChannel() <-chan string
 
@@ -73,8 +108,13 @@ type WhatsAppClient interface {
 	AddEventHandler(handler func(interface{}))
 }
 
-// SendMessageResult contains the result of sending a message.
+// SendMessageResult contains the result of sending a message: its ID and
+// the time the server acknowledged it.
 type SendMessageResult struct {
 	ID        string
-	Timestamp int64
+	Timestamp time.Time
+}
+
+func newSendMessageResult(resp whatsmeow.SendResponse) SendMessageResult {
+	return SendMessageResult{ID: resp.ID, Timestamp: resp.Timestamp.UTC()}
 }
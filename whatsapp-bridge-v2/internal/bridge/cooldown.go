@@ -0,0 +1,85 @@
+package bridge
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CooldownError is returned when a send is refused because the recipient
+// was messaged less than Config.PerRecipientCooldown ago.
+type CooldownError struct {
+	JID       string
+	Remaining time.Duration
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("recipient %s was messaged recently, wait %s before sending again", e.JID, e.Remaining.Round(time.Millisecond))
+}
+
+// recipientCooldown enforces a minimum interval between sends to the same
+// JID. Entries older than the interval are dropped as new sends come in,
+// so the map only holds recently messaged recipients.
+type recipientCooldown struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     map[string]time.Time
+	now      func() time.Time
+}
+
+func newRecipientCooldown(interval time.Duration) *recipientCooldown {
+	return &recipientCooldown{
+		interval: interval,
+		last:     make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// reserve claims a send to jid. If jid is still cooling down it returns a
+// *CooldownError; otherwise the send is recorded immediately, so concurrent
+// sends to one recipient can't both pass, and release undoes the record
+// for a send that then fails. A zero interval disables the cooldown.
+func (c *recipientCooldown) reserve(jid string) (release func(), err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.interval <= 0 {
+		return func() {}, nil
+	}
+
+	now := c.now()
+	for j, t := range c.last {
+		if now.Sub(t) >= c.interval {
+			delete(c.last, j)
+		}
+	}
+
+	prev, ok := c.last[jid]
+	if ok {
+		return nil, &CooldownError{JID: jid, Remaining: c.interval - now.Sub(prev)}
+	}
+
+	c.last[jid] = now
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.last[jid].Equal(now) {
+			delete(c.last, jid)
+		}
+	}, nil
+}
+
+// setInterval changes the cooldown. It applies from the next send,
+// including to recipients that are still cooling down.
+func (c *recipientCooldown) setInterval(interval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interval = interval
+}
+
+// SetPerRecipientCooldown changes the minimum time between two sends to the
+// same recipient while the bridge is running, e.g. on a config reload. Zero
+// disables the cooldown.
+func (b *Bridge) SetPerRecipientCooldown(interval time.Duration) {
+	b.cooldown.setInterval(interval)
+}
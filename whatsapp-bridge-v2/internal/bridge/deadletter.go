@@ -0,0 +1,67 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
+)
+
+// storeMessage stores a received message. When that fails the message goes
+// to the dead-letter table instead of being dropped, so RetryFailedStores
+// can store it once the database is healthy again.
+func (b *Bridge) storeMessage(ctx context.Context, msg *store.Message) error {
+	err := b.store.Messages.Store(ctx, msg)
+	if err == nil {
+		return nil
+	}
+	b.log.Warn("failed to store message", "error", err, "id", msg.ID, "chat", msg.ChatJID)
+	if dlErr := b.store.DeadLetters.Add(ctx, msg, err); dlErr != nil {
+		b.log.Error("failed to keep unstored message, it is lost", "error", dlErr, "id", msg.ID, "chat", msg.ChatJID)
+	}
+	return err
+}
+
+// RetryFailedStores tries again to store up to limit dead-lettered
+// messages, oldest first. Stored messages leave the dead-letter table; the
+// others stay with their latest error.
+func (b *Bridge) RetryFailedStores(ctx context.Context, limit int) (stored, failed int, err error) {
+	entries, err := b.store.DeadLetters.List(ctx, limit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, entry := range entries {
+		if err := b.restoreMessage(ctx, &entry.Message); err != nil {
+			failed++
+			if err := b.store.DeadLetters.RecordAttempt(ctx, entry.ID, err); err != nil {
+				return stored, failed, err
+			}
+			continue
+		}
+		stored++
+		if err := b.store.DeadLetters.Delete(ctx, entry.ID); err != nil {
+			return stored, failed, err
+		}
+	}
+	return stored, failed, nil
+}
+
+// restoreMessage stores a dead-lettered message, first creating its chat if
+// that is what was missing.
+func (b *Bridge) restoreMessage(ctx context.Context, msg *store.Message) error {
+	if _, err := b.store.Chats.GetByJID(ctx, msg.ChatJID); errors.Is(err, store.ErrNotFound) {
+		chat := &store.Chat{
+			JID:             msg.ChatJID,
+			IsGroup:         strings.HasSuffix(msg.ChatJID, "@g.us"),
+			LastMessageTime: msg.Timestamp,
+		}
+		if err := b.store.Chats.Upsert(ctx, chat); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	return b.store.Messages.Store(ctx, msg)
+}
@@ -18,6 +18,7 @@ const (
 	EventCallOffer
 	EventChatArchive
 	EventQRCode
+	EventStateChange
 )
 
 // String returns the string representation of the event type.
@@ -41,6 +42,8 @@ func (e EventType) String() string {
 		return "chat_archive"
 	case EventQRCode:
 		return "qr_code"
+	case EventStateChange:
+		return "state_change"
 	default:
 		return "unknown"
 	}
@@ -71,6 +74,10 @@ type MessagePayload struct {
 	IsFromMe  bool
 	MediaType string
 	Timestamp time.Time
+
+	// Persisted is set when the message was already written to the store
+	// by the WhatsApp event handler, so the event pipeline only notifies.
+	Persisted bool
 }
 
 // QRCodePayload contains data for QR code events.
@@ -83,3 +90,11 @@ type ConnectionPayload struct {
 	Connected bool
 	Reason    string
 }
+
+// StateChangePayload contains data for state machine transitions.
+type StateChangePayload struct {
+	From    string
+	To      string
+	Trigger string
+	Error   string
+}
@@ -0,0 +1,162 @@
+package bridge
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// Idempotency cache bounds: keys are remembered for idempotencyTTL, and at
+// most idempotencyMaxKeys are kept, oldest evicted first.
+const (
+	idempotencyTTL     = 10 * time.Minute
+	idempotencyMaxKeys = 1024
+)
+
+type idempotencyCtxKey struct{}
+
+// idempotencyScope is the idempotency key a caller attached to a context,
+// with the tool it came from.
+type idempotencyScope struct {
+	tool string
+	key  string
+}
+
+// WithIdempotencyKey returns a context that makes the bridge's send methods
+// idempotent under key: a repeat send by the same tool to the same
+// recipient with the same key within the TTL returns the original send
+// result instead of sending again. An empty key clears any earlier one.
+func WithIdempotencyKey(ctx context.Context, tool, key string) context.Context {
+	return context.WithValue(ctx, idempotencyCtxKey{}, idempotencyScope{tool: tool, key: key})
+}
+
+// idempotencyKeyFrom returns the cache key for a send to jid under the
+// idempotency key in ctx, or "" if there is none. A client reusing its key
+// for another recipient or tool gets a distinct send, not the earlier
+// message's result.
+func idempotencyKeyFrom(ctx context.Context, jid string) string {
+	scope, _ := ctx.Value(idempotencyCtxKey{}).(idempotencyScope)
+	if scope.key == "" {
+		return ""
+	}
+	return scope.tool + "\x00" + jid + "\x00" + scope.key
+}
+
+// sendEntry is a send that has finished, or is still in flight while done
+// is open.
+type sendEntry struct {
+	key     string
+	sent    SendMessageResult
+	expires time.Time
+	done    chan struct{}
+}
+
+// idempotencyCache remembers the send result produced for each recent key.
+type idempotencyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	entries map[string]*list.Element
+	order   *list.List // front is most recently used
+	now     func() time.Time
+}
+
+func newIdempotencyCache(ttl time.Duration, max int) *idempotencyCache {
+	return &idempotencyCache{
+		ttl:     ttl,
+		max:     max,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+// do runs send unless key already produced a message, in which case the
+// earlier result is returned. Concurrent calls with the same key wait
+// for the first one. Failed sends are forgotten so they can be retried.
+func (c *idempotencyCache) do(key string, send func() (SendMessageResult, error)) (SendMessageResult, error) {
+	for {
+		c.mu.Lock()
+		el, ok := c.entries[key]
+		if ok {
+			e := el.Value.(*sendEntry)
+			select {
+			case <-e.done:
+				if c.now().Before(e.expires) {
+					c.order.MoveToFront(el)
+					c.mu.Unlock()
+					return e.sent, nil
+				}
+				c.removeLocked(el)
+			default:
+				c.mu.Unlock()
+				<-e.done
+				continue
+			}
+		}
+
+		e := &sendEntry{key: key, done: make(chan struct{})}
+		c.entries[key] = c.order.PushFront(e)
+		c.evictLocked()
+		c.mu.Unlock()
+
+		sent, err := send()
+
+		c.mu.Lock()
+		if err != nil {
+			if el, ok := c.entries[key]; ok && el.Value == e {
+				c.removeLocked(el)
+			}
+		} else {
+			e.sent = sent
+			e.expires = c.now().Add(c.ttl)
+		}
+		close(e.done)
+		c.mu.Unlock()
+		return sent, err
+	}
+}
+
+// evictLocked drops completed entries from the back until the cache fits.
+// In-flight sends are never evicted.
+func (c *idempotencyCache) evictLocked() {
+	for el := c.order.Back(); el != nil && c.order.Len() > c.max; {
+		prev := el.Prev()
+		select {
+		case <-el.Value.(*sendEntry).done:
+			c.removeLocked(el)
+		default:
+		}
+		el = prev
+	}
+}
+
+func (c *idempotencyCache) removeLocked(el *list.Element) {
+	delete(c.entries, el.Value.(*sendEntry).key)
+	c.order.Remove(el)
+}
+
+// sendOnce runs send to jid, retrying transient failures, deduplicated by
+// the idempotency key in ctx if any. A send that isn't a replay of an
+// earlier key is subject to the per-recipient cooldown.
+func (b *Bridge) sendOnce(ctx context.Context, jid string, send func() (whatsmeow.SendResponse, error)) (SendMessageResult, error) {
+	retrying := func() (SendMessageResult, error) {
+		release, err := b.cooldown.reserve(jid)
+		if err != nil {
+			return SendMessageResult{}, err
+		}
+		sent, err := sendWithRetry(ctx, send)
+		if err != nil {
+			release()
+		}
+		return sent, err
+	}
+	key := idempotencyKeyFrom(ctx, jid)
+	if key == "" {
+		return retrying()
+	}
+	return b.sent.do(key, retrying)
+}
@@ -0,0 +1,74 @@
+package bridge
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyCache_Expiry(t *testing.T) {
+	c := newIdempotencyCache(time.Minute, 10)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	var sends int
+	send := func() (SendMessageResult, error) {
+		sends++
+		return SendMessageResult{ID: fmt.Sprintf("msg-%d", sends), Timestamp: now}, nil
+	}
+
+	sent, _ := c.do("k", send)
+	assert.Equal(t, "msg-1", sent.ID)
+	sent, _ = c.do("k", send)
+	assert.Equal(t, "msg-1", sent.ID)
+
+	now = now.Add(2 * time.Minute)
+	sent, _ = c.do("k", send)
+	assert.Equal(t, "msg-2", sent.ID, "an expired key sends again")
+}
+
+func TestIdempotencyCache_Eviction(t *testing.T) {
+	c := newIdempotencyCache(time.Minute, 2)
+	send := func() (SendMessageResult, error) { return SendMessageResult{ID: "id"}, nil }
+
+	c.do("a", send)
+	c.do("b", send)
+	c.do("c", send)
+
+	assert.Len(t, c.entries, 2)
+	assert.NotContains(t, c.entries, "a", "least recently used key is evicted first")
+}
+
+func TestIdempotencyCache_ConcurrentSameKey(t *testing.T) {
+	c := newIdempotencyCache(time.Minute, 10)
+	var sends atomic.Int32
+	release := make(chan struct{})
+	send := func() (SendMessageResult, error) {
+		sends.Add(1)
+		<-release
+		return SendMessageResult{ID: "only"}, nil
+	}
+
+	var wg sync.WaitGroup
+	ids := make([]string, 5)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sent, _ := c.do("k", send)
+			ids[i] = sent.ID
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), sends.Load())
+	for _, id := range ids {
+		assert.Equal(t, "only", id)
+	}
+}
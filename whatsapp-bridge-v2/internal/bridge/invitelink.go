@@ -0,0 +1,62 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// InviteLink is a group invite link and when it was fetched from WhatsApp.
+// Cached is true when it came from the store rather than the network.
+type InviteLink struct {
+	Link      string
+	FetchedAt time.Time
+	Cached    bool
+}
+
+// GetInviteLink returns a group's invite link. A link fetched less than
+// InviteLinkTTL ago is served from the store unless refresh is set, since
+// the link only changes when someone revokes it.
+func (b *Bridge) GetInviteLink(ctx context.Context, groupJID string, refresh bool) (InviteLink, error) {
+	if !b.IsReady() {
+		return InviteLink{}, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+
+	if !refresh && b.config.InviteLinkTTL > 0 {
+		link, fetchedAt, err := b.store.Groups.GetInviteLink(ctx, groupJID)
+		if err == nil && time.Since(fetchedAt) < b.config.InviteLinkTTL {
+			return InviteLink{Link: link, FetchedAt: fetchedAt, Cached: true}, nil
+		}
+	}
+
+	link, err := b.client.GetInviteLink(ctx, groupJID)
+	if err != nil {
+		return InviteLink{}, err
+	}
+	return b.cacheInviteLink(ctx, groupJID, link), nil
+}
+
+// RevokeInviteLink revokes a group's invite link and caches the new one in
+// its place.
+func (b *Bridge) RevokeInviteLink(ctx context.Context, groupJID string) (string, error) {
+	if !b.IsReady() {
+		return "", fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+
+	link, err := b.client.RevokeInviteLink(ctx, groupJID)
+	if err != nil {
+		return "", err
+	}
+	b.cacheInviteLink(ctx, groupJID, link)
+	return link, nil
+}
+
+// cacheInviteLink stores a freshly fetched link. A failed write only costs
+// a network call next time, so it is logged and otherwise ignored.
+func (b *Bridge) cacheInviteLink(ctx context.Context, groupJID, link string) InviteLink {
+	now := time.Now().UTC()
+	if err := b.store.Groups.SetInviteLink(ctx, groupJID, link, now); err != nil {
+		b.log.Debug("failed to cache invite link", "error", err, "group", groupJID)
+	}
+	return InviteLink{Link: link, FetchedAt: now}
+}
@@ -0,0 +1,130 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
+)
+
+// ErrNewslettersUnsupported is returned when WhatsApp refuses channel
+// queries, which happens for accounts or regions without channels.
+var ErrNewslettersUnsupported = errors.New("channels are not available for this account")
+
+// NewsletterPost is a message posted to a channel, as returned by
+// GetNewsletterMessages.
+type NewsletterPost struct {
+	ServerID  int            `json:"server_id"`
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	Content   string         `json:"content,omitempty"`
+	MediaType string         `json:"media_type,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+	Views     int            `json:"views"`
+	Reactions map[string]int `json:"reactions,omitempty"`
+}
+
+// GetSubscribedNewsletters lists the channels the account follows.
+func (b *Bridge) GetSubscribedNewsletters(ctx context.Context) ([]*types.NewsletterMetadata, error) {
+	if !b.IsReady() {
+		return nil, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+
+	newsletters, err := b.client.GetSubscribedNewsletters(ctx)
+	if err != nil {
+		return nil, newsletterError(err)
+	}
+	return newsletters, nil
+}
+
+// GetNewsletterMessages fetches the latest count posts of a channel and
+// stores them in the messages table with the channel as the chat, so they
+// can be listed and searched like any other message afterwards.
+func (b *Bridge) GetNewsletterMessages(ctx context.Context, newsletterJID string, count int) ([]NewsletterPost, error) {
+	if !b.IsReady() {
+		return nil, fmt.Errorf("bridge not ready, current state: %s", b.CurrentState())
+	}
+
+	messages, err := b.client.GetNewsletterMessages(ctx, newsletterJID, count)
+	if err != nil {
+		return nil, newsletterError(err)
+	}
+
+	posts := make([]NewsletterPost, 0, len(messages))
+	for _, m := range messages {
+		id := m.MessageID
+		if id == "" {
+			id = strconv.Itoa(int(m.MessageServerID))
+		}
+		posts = append(posts, NewsletterPost{
+			ServerID:  int(m.MessageServerID),
+			ID:        id,
+			Type:      m.Type,
+			Content:   extractMessageText(m.Message),
+			MediaType: extractMediaType(m.Message),
+			Timestamp: m.Timestamp,
+			Views:     m.ViewsCount,
+			Reactions: m.ReactionCounts,
+		})
+	}
+
+	b.persistNewsletterPosts(ctx, newsletterJID, posts)
+	return posts, nil
+}
+
+// persistNewsletterPosts stores channel posts, creating the chat first if
+// needed. Posts are fetched history, so they do not count as unread.
+func (b *Bridge) persistNewsletterPosts(ctx context.Context, newsletterJID string, posts []NewsletterPost) {
+	if len(posts) == 0 {
+		return
+	}
+
+	if _, err := b.store.Chats.GetByJID(ctx, newsletterJID); errors.Is(err, store.ErrNotFound) {
+		if err := b.store.Chats.Upsert(ctx, &store.Chat{JID: newsletterJID}); err != nil {
+			b.log.Error("failed to create newsletter chat", "error", err, "jid", newsletterJID)
+			return
+		}
+	}
+
+	var latest time.Time
+	for _, p := range posts {
+		msg := &store.Message{
+			ID:        p.ID,
+			ChatJID:   newsletterJID,
+			Sender:    newsletterJID,
+			Content:   p.Content,
+			MediaType: p.MediaType,
+			Timestamp: p.Timestamp,
+		}
+		if err := b.store.Messages.Store(ctx, msg); err != nil {
+			b.log.Debug("failed to store newsletter message", "error", err, "id", p.ID)
+			continue
+		}
+		if p.Timestamp.After(latest) {
+			latest = p.Timestamp
+		}
+	}
+
+	if !latest.IsZero() {
+		if err := b.store.Chats.UpdateLastMessage(ctx, newsletterJID, latest); err != nil {
+			b.log.Debug("failed to update last message time", "error", err, "jid", newsletterJID)
+		}
+	}
+}
+
+// newsletterError marks the errors WhatsApp uses to refuse channel access
+// with ErrNewslettersUnsupported, keeping the original for context.
+func newsletterError(err error) error {
+	var iqErr *whatsmeow.IQError
+	if errors.Is(err, whatsmeow.ErrIQForbidden) || errors.Is(err, whatsmeow.ErrIQNotAllowed) ||
+		(errors.As(err, &iqErr) && iqErr.Code == 501) {
+		return fmt.Errorf("%w: %v", ErrNewslettersUnsupported, err)
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package bridge
+
+import (
+	"context"
+	"time"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/config"
+)
+
+// applyPresenceMode sends the presence configured by PresenceMode after the
+// bridge becomes ready. In always_online mode the presence is renewed every
+// keepalive interval until the bridge leaves the ready state, since WhatsApp
+// drops an idle client back to unavailable. whatsmeow itself never announces
+// presence, so always_offline only has to say so once per connection.
+func (b *Bridge) applyPresenceMode() {
+	mode := b.config.PresenceMode
+	if mode != config.PresenceAlwaysOnline && mode != config.PresenceAlwaysOffline {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ctx.Err() != nil {
+		return
+	}
+	if b.presenceCancel != nil {
+		b.presenceCancel()
+	}
+	ctx, cancel := context.WithCancel(b.ctx)
+	b.presenceCancel = cancel
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		if mode == config.PresenceAlwaysOffline {
+			b.sendPresence(ctx, false)
+			return
+		}
+		b.keepOnline(ctx, b.config.KeepaliveInterval)
+	}()
+}
+
+// keepOnline marks the account available now and again on every tick while
+// the bridge stays ready.
+func (b *Bridge) keepOnline(ctx context.Context, interval time.Duration) {
+	b.sendPresence(ctx, true)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !b.IsReady() {
+				return
+			}
+			b.sendPresence(ctx, true)
+		}
+	}
+}
+
+func (b *Bridge) sendPresence(ctx context.Context, online bool) {
+	var err error
+	if online {
+		err = b.client.SetOnline(ctx)
+	} else {
+		err = b.client.SetOffline(ctx)
+	}
+	if err != nil && ctx.Err() == nil {
+		b.log.Warn("failed to apply presence mode", "mode", b.config.PresenceMode, "error", err)
+	}
+}
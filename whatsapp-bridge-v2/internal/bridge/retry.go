@@ -0,0 +1,101 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/socket"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/whatsapp"
+)
+
+// sendMaxAttempts bounds how many times a send is tried in total.
+const sendMaxAttempts = 3
+
+// sendRetryInterval is the wait before the first retry; later waits grow
+// exponentially up to sendRetryMaxInterval. Variables so tests can shorten them.
+var (
+	sendRetryInterval    = 250 * time.Millisecond
+	sendRetryMaxInterval = 2 * time.Second
+)
+
+type attemptsCtxKey struct{}
+
+// WithAttemptCounter returns a context under which the bridge's send
+// methods record how many times the send was tried; read it back with
+// SendAttempts.
+func WithAttemptCounter(ctx context.Context) context.Context {
+	return context.WithValue(ctx, attemptsCtxKey{}, new(int))
+}
+
+// SendAttempts returns the number of attempts recorded in a context from
+// WithAttemptCounter. It is zero when nothing was sent, e.g. because an
+// idempotency key matched an earlier send.
+func SendAttempts(ctx context.Context) int {
+	if n, ok := ctx.Value(attemptsCtxKey{}).(*int); ok {
+		return *n
+	}
+	return 0
+}
+
+// sendWithRetry runs send, retrying with a short backoff while it fails
+// with a retryable error. It gives up early when ctx is done.
+func sendWithRetry(ctx context.Context, send func() (whatsmeow.SendResponse, error)) (SendMessageResult, error) {
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = sendRetryInterval
+	bo.MaxInterval = sendRetryMaxInterval
+
+	var resp whatsmeow.SendResponse
+	attempts := 0
+	err := backoff.Retry(func() error {
+		attempts++
+		var err error
+		resp, err = send()
+		if err != nil && !isRetryable(err) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}, backoff.WithContext(backoff.WithMaxRetries(bo, sendMaxAttempts-1), ctx))
+
+	if n, ok := ctx.Value(attemptsCtxKey{}).(*int); ok {
+		*n = attempts
+	}
+	if err != nil {
+		return SendMessageResult{}, err
+	}
+	return newSendMessageResult(resp), nil
+}
+
+// isRetryable reports whether a failed send may succeed if tried again:
+// the websocket dropped or was reconnecting, or the server was briefly
+// unavailable. Bad input and refusals are permanent. A message that timed
+// out waiting for the server's ack may still have been delivered, and a
+// retry would send it again under a new ID, so that is final too.
+func isRetryable(err error) bool {
+	// The caller's own deadline or cancellation is final
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	switch {
+	case errors.Is(err, whatsapp.ErrNotConnected),
+		errors.Is(err, whatsmeow.ErrNotConnected),
+		errors.Is(err, whatsmeow.ErrIQTimedOut),
+		errors.Is(err, whatsmeow.ErrIQInternalServerError),
+		errors.Is(err, whatsmeow.ErrIQServiceUnavailable),
+		errors.Is(err, socket.ErrSocketClosed),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.EPIPE):
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
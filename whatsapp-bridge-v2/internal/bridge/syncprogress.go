@@ -0,0 +1,96 @@
+package bridge
+
+import (
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/state"
+)
+
+// historySyncQuiet is how long after the last history sync batch a sync
+// that never reported 100% is still considered running. The phone sends
+// batches seconds apart, so a longer gap means it has stopped.
+var historySyncQuiet = 2 * time.Minute
+
+// SyncProgress reports how far the bridge has got from connecting to a
+// fully synced store.
+type SyncProgress struct {
+	State state.State `json:"state"`
+	// InProgress is true while the bridge is still starting up, and while
+	// history sync batches keep arriving without WhatsApp reporting the
+	// sync as complete.
+	InProgress    bool `json:"in_progress"`
+	Batches       int  `json:"batches"`
+	Conversations int  `json:"conversations"`
+	Messages      int  `json:"messages"`
+	// Percent is WhatsApp's own estimate for the current sync, from the
+	// last batch that carried one; nil before any did.
+	Percent     *int       `json:"percent,omitempty"`
+	SyncType    string     `json:"sync_type,omitempty"`
+	LastBatchAt *time.Time `json:"last_batch_at,omitempty"`
+}
+
+// historySyncCounts accumulates what persistHistorySync stored.
+type historySyncCounts struct {
+	batches       int
+	conversations int
+	messages      int
+	percent       int
+	hasPercent    bool
+	syncType      waHistorySync.HistorySync_HistorySyncType
+	lastBatch     time.Time
+}
+
+// recordHistorySync adds one history sync batch to the counters behind
+// SyncProgress.
+func (b *Bridge) recordHistorySync(data *waHistorySync.HistorySync, conversations, messages int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h := &b.history
+	h.batches++
+	h.conversations += conversations
+	h.messages += messages
+	h.syncType = data.GetSyncType()
+	h.lastBatch = time.Now()
+	// Only the bootstrap and full syncs report progress; other batches
+	// leave the last estimate alone.
+	if data.Progress != nil {
+		h.percent = int(data.GetProgress())
+		h.hasPercent = true
+	}
+}
+
+// SyncProgress returns the bridge state and the history sync counters
+// since startup.
+func (b *Bridge) SyncProgress() SyncProgress {
+	current := b.CurrentState()
+
+	b.mu.RLock()
+	h := b.history
+	b.mu.RUnlock()
+
+	p := SyncProgress{
+		State:         current,
+		Batches:       h.batches,
+		Conversations: h.conversations,
+		Messages:      h.messages,
+	}
+	switch current {
+	case state.StateConnecting, state.StateQRPending, state.StateAuthenticating, state.StateSyncing:
+		p.InProgress = true
+	}
+	if h.batches > 0 {
+		p.SyncType = h.syncType.String()
+		last := h.lastBatch
+		p.LastBatchAt = &last
+		if time.Since(last) < historySyncQuiet && (!h.hasPercent || h.percent < 100) {
+			p.InProgress = true
+		}
+	}
+	if h.hasPercent {
+		percent := h.percent
+		p.Percent = &percent
+	}
+	return p
+}
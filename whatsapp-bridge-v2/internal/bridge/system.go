@@ -0,0 +1,299 @@
+package bridge
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
+)
+
+// systemStub is one system notice: what happened and its parameters, in
+// the form WhatsApp uses for stub messages in history syncs. Participant
+// parameters are JID strings.
+type systemStub struct {
+	typ    waWeb.WebMessageInfo_StubType
+	params []string
+}
+
+// groupInfoStubs translates a live group change notification into the stubs
+// a history sync would carry for it. A single notification can report
+// several changes.
+func groupInfoStubs(evt *events.GroupInfo) []systemStub {
+	var stubs []systemStub
+	add := func(typ waWeb.WebMessageInfo_StubType, params ...string) {
+		stubs = append(stubs, systemStub{typ: typ, params: params})
+	}
+	if evt.Name != nil {
+		add(waWeb.WebMessageInfo_GROUP_CHANGE_SUBJECT, evt.Name.Name)
+	}
+	if evt.Topic != nil {
+		add(waWeb.WebMessageInfo_GROUP_CHANGE_DESCRIPTION)
+	}
+	if evt.NewInviteLink != nil {
+		add(waWeb.WebMessageInfo_GROUP_CHANGE_INVITE_LINK)
+	}
+	if evt.Announce != nil {
+		add(waWeb.WebMessageInfo_GROUP_CHANGE_ANNOUNCE, onOff(evt.Announce.IsAnnounce))
+	}
+	if evt.Locked != nil {
+		add(waWeb.WebMessageInfo_GROUP_CHANGE_RESTRICT, onOff(evt.Locked.IsLocked))
+	}
+	if evt.Ephemeral != nil {
+		var timer uint32
+		if evt.Ephemeral.IsEphemeral {
+			timer = evt.Ephemeral.DisappearingTimer
+		}
+		add(waWeb.WebMessageInfo_CHANGE_EPHEMERAL_SETTING, strconv.FormatUint(uint64(timer), 10))
+	}
+	if len(evt.Join) > 0 {
+		typ := waWeb.WebMessageInfo_GROUP_PARTICIPANT_ADD
+		if evt.JoinReason == "invite" {
+			typ = waWeb.WebMessageInfo_GROUP_PARTICIPANT_INVITE
+		}
+		add(typ, jidStrings(evt.Join)...)
+	}
+	if len(evt.Leave) > 0 {
+		add(waWeb.WebMessageInfo_GROUP_PARTICIPANT_REMOVE, jidStrings(evt.Leave)...)
+	}
+	if len(evt.Promote) > 0 {
+		add(waWeb.WebMessageInfo_GROUP_PARTICIPANT_PROMOTE, jidStrings(evt.Promote)...)
+	}
+	if len(evt.Demote) > 0 {
+		add(waWeb.WebMessageInfo_GROUP_PARTICIPANT_DEMOTE, jidStrings(evt.Demote)...)
+	}
+	return stubs
+}
+
+// messageStub reports whether a message event is a system notice rather
+// than something a user sent: either a stub resent from the phone, or a
+// disappearing messages change.
+func messageStub(evt *events.Message) (systemStub, bool) {
+	if typ := evt.SourceWebMsg.GetMessageStubType(); typ != waWeb.WebMessageInfo_UNKNOWN {
+		return systemStub{typ: typ, params: evt.SourceWebMsg.GetMessageStubParameters()}, true
+	}
+	if proto := evt.Message.GetProtocolMessage(); proto.GetType() == waE2E.ProtocolMessage_EPHEMERAL_SETTING {
+		timer := strconv.FormatUint(uint64(proto.GetEphemeralExpiration()), 10)
+		return systemStub{typ: waWeb.WebMessageInfo_CHANGE_EPHEMERAL_SETTING, params: []string{timer}}, true
+	}
+	return systemStub{}, false
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+func jidStrings(jids []types.JID) []string {
+	out := make([]string, len(jids))
+	for i, jid := range jids {
+		out[i] = jid.ToNonAD().String()
+	}
+	return out
+}
+
+// persistGroupInfo stores each change in a group notification as a system
+// message in the group's transcript.
+func (b *Bridge) persistGroupInfo(ctx context.Context, evt *events.GroupInfo) {
+	actor := ""
+	switch {
+	case evt.SenderPN != nil && !evt.SenderPN.IsEmpty():
+		actor = evt.SenderPN.ToNonAD().String()
+	case evt.Sender != nil && !evt.Sender.IsEmpty():
+		actor = evt.Sender.ToNonAD().String()
+	}
+	chatJID := evt.JID.String()
+	for _, stub := range groupInfoStubs(evt) {
+		b.persistSystemMessage(ctx, chatJID, actor, evt.Timestamp, b.describeStub(ctx, actor, stub))
+	}
+}
+
+// persistIdentityChange notes a contact's new security code in their chat.
+// Implicit changes come from decryption failures rather than a notification
+// and are not recorded, nor are changes for contacts we have never chatted
+// with.
+func (b *Bridge) persistIdentityChange(ctx context.Context, evt *events.IdentityChange) {
+	if evt.Implicit {
+		return
+	}
+	chatJID := evt.JID.ToNonAD().String()
+	if _, err := b.store.Chats.GetByJID(ctx, chatJID); err != nil {
+		return
+	}
+	stub := systemStub{typ: waWeb.WebMessageInfo_E2E_IDENTITY_CHANGED, params: []string{chatJID}}
+	b.persistSystemMessage(ctx, chatJID, chatJID, evt.Timestamp, b.describeStub(ctx, chatJID, stub))
+}
+
+// persistSystemMessage stores a system notice. Notices have no WhatsApp
+// message ID, so the ID is derived from the chat, time and text; a repeated
+// notification overwrites the earlier row. Notices don't count as unread.
+func (b *Bridge) persistSystemMessage(ctx context.Context, chatJID, sender string, ts time.Time, description string) {
+	if description == "" {
+		return
+	}
+	if _, err := b.store.Chats.GetByJID(ctx, chatJID); errors.Is(err, store.ErrNotFound) {
+		chat := &store.Chat{
+			JID:             chatJID,
+			IsGroup:         strings.HasSuffix(chatJID, "@"+types.GroupServer),
+			LastMessageTime: ts,
+		}
+		if err := b.store.Chats.Upsert(ctx, chat); err != nil {
+			b.log.Error("failed to upsert chat on system message", "error", err, "jid", chatJID)
+		}
+	}
+
+	sum := sha1.Sum([]byte(chatJID + "|" + ts.UTC().Format(time.RFC3339Nano) + "|" + description))
+	b.storeMessage(ctx, &store.Message{
+		ID:        "sys-" + hex.EncodeToString(sum[:8]),
+		ChatJID:   chatJID,
+		Sender:    sender,
+		Content:   description,
+		Kind:      store.MessageKindSystem,
+		Timestamp: ts,
+	})
+}
+
+// describeStub renders a system notice as a sentence such as "Alice added
+// Bob". actor is the stored sender of the notice ("me", a JID, or "" when
+// unknown). Stub types without a specific description fall back to the
+// stub's name.
+func (b *Bridge) describeStub(ctx context.Context, actor string, stub systemStub) string {
+	who := "Someone"
+	if actor != "" {
+		who = b.displayName(ctx, actor)
+	}
+	param := func(i int) string {
+		if i < len(stub.params) {
+			return stub.params[i]
+		}
+		return ""
+	}
+	names := func() string {
+		list := make([]string, len(stub.params))
+		for i, p := range stub.params {
+			list[i] = b.displayName(ctx, p)
+		}
+		return joinNames(list)
+	}
+	// A participant removing themselves is leaving; adding themselves is
+	// joining.
+	self := len(stub.params) == 1 && actor != "" && stub.params[0] == actor
+
+	switch stub.typ {
+	case waWeb.WebMessageInfo_GROUP_CREATE:
+		return fmt.Sprintf("%s created group %q", who, param(0))
+	case waWeb.WebMessageInfo_GROUP_CHANGE_SUBJECT:
+		return fmt.Sprintf("%s changed the group name to %q", who, param(0))
+	case waWeb.WebMessageInfo_GROUP_CHANGE_DESCRIPTION:
+		return who + " changed the group description"
+	case waWeb.WebMessageInfo_GROUP_CHANGE_ICON:
+		return who + " changed the group icon"
+	case waWeb.WebMessageInfo_GROUP_CHANGE_INVITE_LINK:
+		return who + " reset the group invite link"
+	case waWeb.WebMessageInfo_GROUP_CHANGE_ANNOUNCE:
+		if param(0) == "on" {
+			return who + " changed the group so only admins can send messages"
+		}
+		return who + " changed the group so all participants can send messages"
+	case waWeb.WebMessageInfo_GROUP_CHANGE_RESTRICT:
+		if param(0) == "on" {
+			return who + " changed the group so only admins can edit group info"
+		}
+		return who + " changed the group so all participants can edit group info"
+	case waWeb.WebMessageInfo_GROUP_PARTICIPANT_ADD:
+		if self || actor == "" {
+			return names() + " joined"
+		}
+		return who + " added " + names()
+	case waWeb.WebMessageInfo_GROUP_PARTICIPANT_INVITE:
+		return names() + " joined using the group's invite link"
+	case waWeb.WebMessageInfo_GROUP_PARTICIPANT_REMOVE:
+		if self || actor == "" {
+			return names() + " left"
+		}
+		return who + " removed " + names()
+	case waWeb.WebMessageInfo_GROUP_PARTICIPANT_LEAVE:
+		return names() + " left"
+	case waWeb.WebMessageInfo_GROUP_PARTICIPANT_PROMOTE:
+		return who + " made " + names() + " an admin"
+	case waWeb.WebMessageInfo_GROUP_PARTICIPANT_DEMOTE:
+		return who + " dismissed " + names() + " as admin"
+	case waWeb.WebMessageInfo_GROUP_PARTICIPANT_CHANGE_NUMBER:
+		return who + " changed their phone number"
+	case waWeb.WebMessageInfo_E2E_IDENTITY_CHANGED:
+		subject := actor
+		if len(stub.params) > 0 {
+			subject = param(0)
+		}
+		return fmt.Sprintf("Your security code with %s changed", b.displayName(ctx, subject))
+	case waWeb.WebMessageInfo_CHANGE_EPHEMERAL_SETTING:
+		secs, _ := strconv.ParseUint(param(0), 10, 32)
+		if secs == 0 {
+			return who + " turned off disappearing messages"
+		}
+		return fmt.Sprintf("%s turned on disappearing messages (%s)", who, ephemeralLabel(time.Duration(secs)*time.Second))
+	}
+	return "[" + strings.ToLower(strings.ReplaceAll(stub.typ.String(), "_", " ")) + "]"
+}
+
+// displayName names a participant the way the chat list would: saved name,
+// then push name, then business name, then the phone number. Our own
+// messages are "You".
+func (b *Bridge) displayName(ctx context.Context, jid string) string {
+	if jid == "me" {
+		return "You"
+	}
+	if contact, err := b.store.Contacts.GetByJID(ctx, jid); err == nil {
+		for _, name := range []string{contact.Name, contact.PushName, contact.BusinessName} {
+			if name != "" {
+				return name
+			}
+		}
+	}
+	parsed, err := types.ParseJID(jid)
+	if err != nil || parsed.User == "" {
+		return jid
+	}
+	if parsed.Server == types.DefaultUserServer {
+		return "+" + parsed.User
+	}
+	return parsed.User
+}
+
+// joinNames lists names as "A", "A and B" or "A, B and C".
+func joinNames(names []string) string {
+	switch len(names) {
+	case 0:
+		return "someone"
+	case 1:
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// ephemeralLabel renders a disappearing message timer in days or hours.
+func ephemeralLabel(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		days := int(d / (24 * time.Hour))
+		if days == 1 {
+			return "1 day"
+		}
+		return fmt.Sprintf("%d days", days)
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%d hours", int(d/time.Hour))
+	}
+	return d.String()
+}
@@ -2,12 +2,17 @@ package bridge
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"time"
 
+	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/state"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
 )
 
@@ -18,7 +23,8 @@ func (b *Bridge) registerWhatsAppEventHandler() {
 	b.client.AddEventHandler(b.handleWhatsAppEvent)
 }
 
-// handleWhatsAppEvent processes raw whatsmeow events and persists relevant data to the store.
+// handleWhatsAppEvent processes raw whatsmeow events, persisting relevant data to the store
+// and driving the state machine from connection lifecycle events.
 func (b *Bridge) handleWhatsAppEvent(rawEvt interface{}) {
 	ctx := context.Background()
 	switch evt := rawEvt.(type) {
@@ -26,6 +32,110 @@ func (b *Bridge) handleWhatsAppEvent(rawEvt interface{}) {
 		b.persistMessage(ctx, evt)
 	case *events.HistorySync:
 		b.persistHistorySync(ctx, evt)
+	case *events.GroupInfo:
+		b.persistGroupInfo(ctx, evt)
+	case *events.IdentityChange:
+		b.persistIdentityChange(ctx, evt)
+	case *events.DeleteForMe:
+		if err := b.store.Messages.Delete(ctx, evt.ChatJID.String(), evt.MessageID); err != nil {
+			b.log.Error("failed to delete message", "error", err, "id", evt.MessageID)
+		}
+	case *events.LabelEdit:
+		b.persistLabelEdit(ctx, evt)
+	case *events.LabelAssociationChat:
+		if err := b.store.Labels.SetChatLabel(ctx, evt.JID.String(), evt.LabelID, evt.Action.GetLabeled()); err != nil {
+			b.log.Error("failed to store chat label", "error", err, "jid", evt.JID, "label", evt.LabelID)
+		}
+	case *events.PushName:
+		jid := evt.JID.ToNonAD().String()
+		if err := b.store.Contacts.UpsertPushName(ctx, jid, evt.NewPushName); err != nil {
+			b.log.Error("failed to store push name", "error", err, "jid", jid)
+		}
+	case *events.BusinessName:
+		jid := evt.JID.ToNonAD().String()
+		if err := b.store.Contacts.UpsertBusinessName(ctx, jid, evt.NewBusinessName); err != nil {
+			b.log.Error("failed to store business name", "error", err, "jid", jid)
+		}
+	case *events.Contact:
+		jid := evt.JID.ToNonAD().String()
+		if err := b.store.Contacts.UpsertSavedName(ctx, jid, evt.Action.GetFullName()); err != nil {
+			b.log.Error("failed to store contact name", "error", err, "jid", jid)
+		}
+	case *events.Presence:
+		jid := evt.From.ToNonAD().String()
+		if err := b.store.Presence.SetOnline(ctx, jid, !evt.Unavailable, evt.LastSeen); err != nil {
+			b.log.Error("failed to store presence", "error", err, "jid", jid)
+		}
+	case *events.ChatPresence:
+		jid := evt.Sender.ToNonAD().String()
+		if err := b.store.Presence.SetComposing(ctx, jid, evt.State == types.ChatPresenceComposing); err != nil {
+			b.log.Error("failed to store chat presence", "error", err, "jid", jid)
+		}
+	case *events.Disconnected:
+		b.fireFromEvent(ctx, state.TriggerConnectionLost, nil)
+	case *events.Connected:
+		b.clearBan()
+		if b.CurrentState() == state.StateReconnecting {
+			b.fireFromEvent(ctx, state.TriggerReconnected, nil)
+		}
+	case *events.LoggedOut:
+		// A stream error while connected means the device was unlinked; a
+		// failure on connect means the stored session was rejected.
+		if evt.OnConnect {
+			b.fireFromEvent(ctx, state.TriggerSessionInvalid, errors.New(evt.Reason.String()))
+		} else {
+			b.fireFromEvent(ctx, state.TriggerLogout, errors.New("device was logged out"))
+		}
+	case *events.TemporaryBan:
+		b.recordBan(evt)
+		b.fireFromEvent(ctx, state.TriggerBanDetected, errors.New(evt.String()))
+	}
+}
+
+// fireFromEvent fires a trigger in response to a connection event. Events
+// that don't apply to the current state (e.g. Disconnected after a manual
+// disconnect) are ignored.
+func (b *Bridge) fireFromEvent(ctx context.Context, trigger state.Trigger, cause error) {
+	if ok, _ := b.stateMachine.CanFire(ctx, trigger); !ok {
+		b.log.Debug("ignoring connection event", "trigger", trigger, "state", b.CurrentState())
+		return
+	}
+	if err := b.stateMachine.FireWithError(ctx, trigger, cause); err != nil {
+		b.log.Error("state transition failed", "trigger", trigger, "error", err)
+	}
+}
+
+// persistLabelEdit stores a business label created, renamed or deleted on any device.
+func (b *Bridge) persistLabelEdit(ctx context.Context, evt *events.LabelEdit) {
+	if evt.Action.GetDeleted() {
+		if err := b.store.Labels.Delete(ctx, evt.LabelID); err != nil {
+			b.log.Error("failed to delete label", "error", err, "label", evt.LabelID)
+		}
+		return
+	}
+
+	label := &store.Label{
+		ID:    evt.LabelID,
+		Name:  evt.Action.GetName(),
+		Color: evt.Action.GetColor(),
+	}
+	if err := b.store.Labels.Upsert(ctx, label); err != nil {
+		b.log.Error("failed to store label", "error", err, "label", evt.LabelID)
+	}
+}
+
+// messageSender returns the Sender stored for a message: "me" for our own
+// messages, the participant in groups and broadcasts, and the chat in
+// direct chats, where the sender can only be the other party. Device
+// suffixes are dropped so every message from a user has the same sender.
+func messageSender(chat, participant types.JID, fromMe bool) string {
+	switch {
+	case fromMe:
+		return "me"
+	case (chat.Server == types.GroupServer || chat.Server == types.BroadcastServer) && !participant.IsEmpty():
+		return participant.ToNonAD().String()
+	default:
+		return chat.ToNonAD().String()
 	}
 }
 
@@ -33,19 +143,46 @@ func (b *Bridge) handleWhatsAppEvent(rawEvt interface{}) {
 func (b *Bridge) persistMessage(ctx context.Context, evt *events.Message) {
 	chatJID := evt.Info.Chat.String()
 	content := extractMessageText(evt.Message)
-	sender := evt.Info.Sender.String()
-	if evt.Info.IsFromMe {
-		sender = "me"
+	sender := messageSender(evt.Info.Chat, evt.Info.Sender, evt.Info.IsFromMe)
+
+	// Reactions annotate the message they target rather than being stored
+	// as messages of their own.
+	if reaction := evt.Message.GetReactionMessage(); reaction != nil {
+		b.persistReaction(ctx, chatJID, sender, reaction, evt.Info.Timestamp)
+		return
 	}
 
-	// Upsert the chat so it appears in list_chats
-	chat := &store.Chat{
-		JID:             chatJID,
-		IsGroup:         evt.Info.IsGroup,
-		LastMessageTime: evt.Info.Timestamp,
+	// System notices carry no text of their own; store a description of
+	// them instead. Other protocol messages (revokes, edits, key shares)
+	// are bookkeeping and don't belong in the transcript.
+	if stub, ok := messageStub(evt); ok {
+		b.persistSystemMessage(ctx, chatJID, sender, evt.Info.Timestamp, b.describeStub(ctx, sender, stub))
+		return
 	}
-	if err := b.store.Chats.Upsert(ctx, chat); err != nil {
-		b.log.Error("failed to upsert chat on message", "error", err, "jid", chatJID)
+	if proto := evt.Message.GetProtocolMessage(); proto != nil {
+		b.log.Debug("skipping protocol message", "type", proto.GetType().String(), "chat", chatJID, "id", evt.Info.ID)
+		return
+	}
+
+	// Remember the sender's push name so messages can show who sent them
+	if !evt.Info.IsFromMe && evt.Info.PushName != "" {
+		senderJID := evt.Info.Sender.ToNonAD().String()
+		if err := b.store.Contacts.UpsertPushName(ctx, senderJID, evt.Info.PushName); err != nil {
+			b.log.Debug("failed to store push name", "error", err, "jid", senderJID)
+		}
+	}
+
+	// Create the chat if it is new so it appears in list_chats. An existing
+	// chat keeps its name, settings and unread count.
+	if _, err := b.store.Chats.GetByJID(ctx, chatJID); errors.Is(err, store.ErrNotFound) {
+		chat := &store.Chat{
+			JID:             chatJID,
+			IsGroup:         evt.Info.IsGroup,
+			LastMessageTime: evt.Info.Timestamp,
+		}
+		if err := b.store.Chats.Upsert(ctx, chat); err != nil {
+			b.log.Error("failed to upsert chat on message", "error", err, "jid", chatJID)
+		}
 	}
 	if err := b.store.Chats.UpdateLastMessage(ctx, chatJID, evt.Info.Timestamp); err != nil {
 		b.log.Debug("failed to update last message time", "error", err, "jid", chatJID)
@@ -53,15 +190,56 @@ func (b *Bridge) persistMessage(ctx context.Context, evt *events.Message) {
 
 	// Store the message
 	msg := &store.Message{
-		ID:        evt.Info.ID,
-		ChatJID:   chatJID,
-		Sender:    sender,
-		Content:   content,
-		Timestamp: evt.Info.Timestamp,
-		IsFromMe:  evt.Info.IsFromMe,
+		ID:         evt.Info.ID,
+		ChatJID:    chatJID,
+		Sender:     sender,
+		Content:    content,
+		Timestamp:  evt.Info.Timestamp,
+		IsFromMe:   evt.Info.IsFromMe,
+		MediaType:  extractMediaType(evt.Message),
+		FileLength: extractFileLength(evt.Message),
+		Thumbnail:  extractThumbnail(evt.Message),
+	}
+	setMediaKeys(msg, evt.Message)
+	if err := b.storeMessage(ctx, msg); err != nil {
+		return
 	}
-	if err := b.store.Messages.Store(ctx, msg); err != nil {
-		b.log.Debug("failed to store message", "error", err, "id", evt.Info.ID)
+	if !evt.Info.IsFromMe {
+		if err := b.store.Chats.IncrementUnread(ctx, chatJID); err != nil {
+			b.log.Debug("failed to increment unread count", "error", err, "jid", chatJID)
+		}
+	}
+
+	b.EmitEvent(NewEvent(EventMessage, MessagePayload{
+		ID:        msg.ID,
+		ChatJID:   msg.ChatJID,
+		Sender:    msg.Sender,
+		Content:   msg.Content,
+		IsFromMe:  msg.IsFromMe,
+		MediaType: msg.MediaType,
+		Timestamp: msg.Timestamp,
+		Persisted: true,
+	}))
+}
+
+// persistReaction records a reaction on the stored message it targets. An
+// empty reaction text means the sender removed their reaction.
+func (b *Bridge) persistReaction(ctx context.Context, chatJID, sender string, reaction *waE2E.ReactionMessage, ts time.Time) {
+	targetID := reaction.GetKey().GetID()
+	if targetID == "" {
+		return
+	}
+	if ms := reaction.GetSenderTimestampMS(); ms > 0 {
+		ts = time.UnixMilli(ms)
+	}
+
+	err := b.store.Messages.SetReaction(ctx, chatJID, targetID, store.Reaction{
+		Sender:    sender,
+		Emoji:     reaction.GetText(),
+		ReactedAt: ts,
+	})
+	if err != nil {
+		b.log.Debug("failed to store reaction", "error", err, "chat", chatJID, "id", targetID)
 	}
 }
 
@@ -70,6 +248,9 @@ func (b *Bridge) persistHistorySync(ctx context.Context, evt *events.HistorySync
 	convs := evt.Data.GetConversations()
 	b.log.Info("processing history sync", "type", evt.Data.GetSyncType().String(), "conversations", len(convs))
 
+	var storedConvs, storedMsgs int
+	defer func() { b.recordHistorySync(evt.Data, storedConvs, storedMsgs) }()
+
 	for _, conv := range convs {
 		jid := conv.GetID()
 		if jid == "" {
@@ -112,8 +293,10 @@ func (b *Bridge) persistHistorySync(ctx context.Context, evt *events.HistorySync
 			b.log.Error("failed to upsert chat from history", "error", err, "jid", jid)
 			continue
 		}
+		storedConvs++
 
 		// Store messages from history
+		chatJID, _ := types.ParseJID(jid)
 		for _, histMsg := range conv.GetMessages() {
 			webMsg := histMsg.GetMessage()
 			if webMsg == nil {
@@ -130,30 +313,123 @@ func (b *Bridge) persistHistorySync(ctx context.Context, evt *events.HistorySync
 
 			ts := time.Unix(int64(webMsg.GetMessageTimestamp()), 0)
 			fromMe := key.GetFromMe()
-			participant := key.GetParticipant()
-			sender := participant
-			if fromMe {
-				sender = "me"
+			participant, _ := types.ParseJID(key.GetParticipant())
+			sender := messageSender(chatJID, participant, fromMe)
+
+			if stubType := webMsg.GetMessageStubType(); stubType != waWeb.WebMessageInfo_UNKNOWN {
+				stub := systemStub{typ: stubType, params: webMsg.GetMessageStubParameters()}
+				err := b.storeMessage(ctx, &store.Message{
+					ID:        msgID,
+					ChatJID:   jid,
+					Sender:    sender,
+					Content:   b.describeStub(ctx, sender, stub),
+					Kind:      store.MessageKindSystem,
+					Timestamp: ts,
+					IsFromMe:  fromMe,
+				})
+				if err == nil {
+					storedMsgs++
+				}
+				continue
 			}
 
 			content := extractMessageText(webMsg.GetMessage())
 
 			msg := &store.Message{
-				ID:        msgID,
-				ChatJID:   jid,
-				Sender:    sender,
-				Content:   content,
-				Timestamp: ts,
-				IsFromMe:  fromMe,
+				ID:         msgID,
+				ChatJID:    jid,
+				Sender:     sender,
+				Content:    content,
+				Timestamp:  ts,
+				IsFromMe:   fromMe,
+				MediaType:  extractMediaType(webMsg.GetMessage()),
+				FileLength: extractFileLength(webMsg.GetMessage()),
+				Thumbnail:  extractThumbnail(webMsg.GetMessage()),
 			}
-			if err := b.store.Messages.Store(ctx, msg); err != nil {
-				// Duplicate key errors are expected; log at debug only
-				b.log.Debug("failed to store history message", "error", err, "id", msgID)
+			setMediaKeys(msg, webMsg.GetMessage())
+			if err := b.storeMessage(ctx, msg); err == nil {
+				storedMsgs++
 			}
 		}
 	}
 }
 
+// extractMediaType reports the kind of media a message carries, or "" for
+// text and other non-media messages.
+func extractMediaType(msg *waE2E.Message) string {
+	switch {
+	case msg == nil:
+		return ""
+	case msg.GetImageMessage() != nil:
+		return "image"
+	case msg.GetVideoMessage() != nil:
+		return "video"
+	case msg.GetDocumentMessage() != nil:
+		return "document"
+	case msg.GetAudioMessage() != nil:
+		return "audio"
+	case msg.GetStickerMessage() != nil:
+		return "sticker"
+	}
+	return ""
+}
+
+// extractThumbnail returns the JPEG preview embedded in an image, video or
+// document message, or nil.
+func extractThumbnail(msg *waE2E.Message) []byte {
+	switch {
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetJPEGThumbnail()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetJPEGThumbnail()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetJPEGThumbnail()
+	}
+	return nil
+}
+
+// extractFileLength returns the size in bytes of the file a media message
+// carries, or 0.
+func extractFileLength(msg *waE2E.Message) uint64 {
+	switch {
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetFileLength()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetFileLength()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetFileLength()
+	case msg.GetAudioMessage() != nil:
+		return msg.GetAudioMessage().GetFileLength()
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage().GetFileLength()
+	}
+	return 0
+}
+
+// setMediaKeys copies the path and keys needed to download a media
+// message's file later from src into msg.
+func setMediaKeys(msg *store.Message, src *waE2E.Message) {
+	var media whatsmeow.DownloadableMessage
+	switch {
+	case src.GetImageMessage() != nil:
+		media = src.GetImageMessage()
+	case src.GetVideoMessage() != nil:
+		media = src.GetVideoMessage()
+	case src.GetDocumentMessage() != nil:
+		media = src.GetDocumentMessage()
+	case src.GetAudioMessage() != nil:
+		media = src.GetAudioMessage()
+	case src.GetStickerMessage() != nil:
+		media = src.GetStickerMessage()
+	default:
+		return
+	}
+	msg.DirectPath = media.GetDirectPath()
+	msg.MediaKey = media.GetMediaKey()
+	msg.FileSHA256 = media.GetFileSHA256()
+	msg.FileEncSHA256 = media.GetFileEncSHA256()
+}
+
 // extractMessageText pulls the plain-text content out of a WhatsApp message.
 func extractMessageText(msg *waE2E.Message) string {
 	if msg == nil {
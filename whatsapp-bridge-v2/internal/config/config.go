@@ -4,8 +4,10 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"time"
 
@@ -22,31 +24,122 @@ func defaultDataDir() string {
 	return filepath.Join(home, ".whatsapp-mcp")
 }
 
+// Presence modes for Config.PresenceMode.
+const (
+	PresenceAuto          = "auto"
+	PresenceAlwaysOnline  = "always_online"
+	PresenceAlwaysOffline = "always_offline"
+)
+
 // Config holds all configuration for the WhatsApp bridge.
 type Config struct {
 	// Paths
-	SessionPath string        `mapstructure:"session_path"`
-	StorePath   string        `mapstructure:"store_path"`
+	SessionPath string `mapstructure:"session_path"`
+	StorePath   string `mapstructure:"store_path"`
+
+	// AccountID, when set, gives each WhatsApp account its own session and
+	// store by moving both databases into an <account> subdirectory. See
+	// ApplyAccount.
+	AccountID string `mapstructure:"account_id"`
+
+	// StoreBusyTimeout is how long a store write waits for a lock held by
+	// another connection before failing with "database is locked".
+	StoreBusyTimeout time.Duration `mapstructure:"store_busy_timeout"`
+
+	// Media
+	// MediaAllowedDirs restricts media reads/writes to these directories.
+	// When empty, a built-in denylist of system directories is used instead.
+	MediaAllowedDirs []string `mapstructure:"media_allowed_dirs"`
+	// MaxMediaBytes caps the size of any file sent as media. Each media
+	// type also has its own WhatsApp limit; see MediaLimit.
+	MaxMediaBytes int64 `mapstructure:"max_media_bytes"`
 
 	// Connection
+	// ConnectTimeout bounds connecting with an existing session.
+	// PairingTimeout bounds a first connection, which waits for the QR code
+	// to be scanned and so needs much longer.
 	ConnectTimeout time.Duration `mapstructure:"connect_timeout"`
+	PairingTimeout time.Duration `mapstructure:"pairing_timeout"`
+
+	// QR pairing
+	// QROutput selects where pairing QR codes go: stderr, file, both, or none.
+	// QRFilePath overrides the PNG location (default: qrcode.png next to the store).
+	QROutput   string `mapstructure:"qr_output"`
+	QRFilePath string `mapstructure:"qr_file_path"`
+
+	// Presence
+	// PresenceMode controls the account's own presence once the bridge is
+	// ready: auto leaves it to the set_online/set_offline tools,
+	// always_online announces it and renews it every KeepaliveInterval, and
+	// always_offline announces the account as unavailable.
+	PresenceMode string `mapstructure:"presence_mode"`
+	// SendReadReceipts lets mark_chat_read and mark_all_read tell senders
+	// their messages were read. whatsmeow only sends delivery receipts on
+	// its own, so with this off the bridge reads without blue ticks unless
+	// a tool call passes force.
+	SendReadReceipts bool `mapstructure:"send_read_receipts"`
 
 	// Health & Reconnection
 	KeepaliveInterval   time.Duration `mapstructure:"keepalive_interval"`
 	ReconnectMaxRetries int           `mapstructure:"reconnect_max_retries"`
 	ReconnectBaseDelay  time.Duration `mapstructure:"reconnect_base_delay"`
 	ReconnectMaxDelay   time.Duration `mapstructure:"reconnect_max_delay"`
+	// ReconnectMultiplier grows the delay between attempts and
+	// ReconnectJitter randomizes each delay by up to that fraction, so many
+	// bridges don't reconnect in lockstep.
+	ReconnectMultiplier float64 `mapstructure:"reconnect_multiplier"`
+	ReconnectJitter     float64 `mapstructure:"reconnect_jitter"`
+
+	// Events
+	// EventQueueSize is how many bridge events may wait for the webhook,
+	// subscriptions and other listeners. When the queue is full, message
+	// events wait up to EventQueueTimeout for room; other events, and
+	// message events that time out, are dropped and counted.
+	EventQueueSize    int           `mapstructure:"event_queue_size"`
+	EventQueueTimeout time.Duration `mapstructure:"event_queue_timeout"`
 
 	// Logging
-	LogLevel  string `mapstructure:"log_level"`
-	LogFormat string `mapstructure:"log_format"`
+	// WhatsmeowLogLevel filters whatsmeow's own logs separately from
+	// LogLevel. LogFile, when set, receives logs instead of stderr.
+	LogLevel          string `mapstructure:"log_level"`
+	LogFormat         string `mapstructure:"log_format"`
+	WhatsmeowLogLevel string `mapstructure:"whatsmeow_log_level"`
+	LogFile           string `mapstructure:"log_file"`
+	// RedactLogs hashes message text, captions and tool arguments in logs,
+	// including the raw JSON-RPC lines logged at debug level.
+	RedactLogs bool `mapstructure:"redact_logs"`
 
 	// Metrics
 	MetricsEnabled bool `mapstructure:"metrics_enabled"`
 	MetricsPort    int  `mapstructure:"metrics_port"`
 
+	// Webhook
+	// WebhookURL receives a JSON POST for each incoming message and state
+	// change. WebhookSecret, when set, signs the body with HMAC-SHA256.
+	WebhookURL        string        `mapstructure:"webhook_url"`
+	WebhookSecret     string        `mapstructure:"webhook_secret"`
+	WebhookTimeout    time.Duration `mapstructure:"webhook_timeout"`
+	WebhookMaxRetries int           `mapstructure:"webhook_max_retries"`
+
 	// MCP
 	MCPEnabled bool `mapstructure:"mcp_enabled"`
+
+	// ToolCallTimeout bounds a single tool call; zero disables the limit.
+	ToolCallTimeout time.Duration `mapstructure:"tool_call_timeout"`
+
+	// ShutdownGracePeriod is how long shutdown waits for in-flight tool
+	// calls, such as a send mid-upload, before disconnecting anyway.
+	ShutdownGracePeriod time.Duration `mapstructure:"shutdown_grace_period"`
+
+	// PerRecipientCooldown is the minimum time between two sends to the
+	// same JID, so rapid repeats don't get the account flagged for spam.
+	// Zero disables it.
+	PerRecipientCooldown time.Duration `mapstructure:"per_recipient_cooldown"`
+
+	// InviteLinkTTL is how long a fetched group invite link is served from
+	// the store before get_invite_link asks WhatsApp again. Zero disables
+	// the cache.
+	InviteLinkTTL time.Duration `mapstructure:"invite_link_ttl"`
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -55,16 +148,33 @@ func DefaultConfig() *Config {
 	return &Config{
 		SessionPath:         filepath.Join(dataDir, "whatsapp.db"),
 		StorePath:           filepath.Join(dataDir, "messages.db"),
+		StoreBusyTimeout:    5 * time.Second,
+		MaxMediaBytes:       64 << 20,
 		ConnectTimeout:      30 * time.Second,
+		PairingTimeout:      5 * time.Minute,
+		QROutput:            "both",
+		PresenceMode:        PresenceAuto,
+		SendReadReceipts:    true,
 		KeepaliveInterval:   30 * time.Second,
 		ReconnectMaxRetries: 10,
 		ReconnectBaseDelay:  1 * time.Second,
 		ReconnectMaxDelay:   5 * time.Minute,
+		ReconnectMultiplier: 1.5,
+		ReconnectJitter:     0.5,
+		EventQueueSize:      100,
+		EventQueueTimeout:   2 * time.Second,
 		LogLevel:            "info",
 		LogFormat:           "json",
+		WhatsmeowLogLevel:   "warn",
+		RedactLogs:          true,
 		MetricsEnabled:      true,
 		MetricsPort:         9090,
+		WebhookTimeout:      5 * time.Second,
+		WebhookMaxRetries:   3,
 		MCPEnabled:          true,
+		ToolCallTimeout:     2 * time.Minute,
+		ShutdownGracePeriod: 10 * time.Second,
+		InviteLinkTTL:       24 * time.Hour,
 	}
 }
 
@@ -77,16 +187,40 @@ func LoadConfig(configPath string) (*Config, error) {
 	defaults := DefaultConfig()
 	v.SetDefault("session_path", defaults.SessionPath)
 	v.SetDefault("store_path", defaults.StorePath)
+	v.SetDefault("account_id", defaults.AccountID)
+	v.SetDefault("store_busy_timeout", defaults.StoreBusyTimeout)
+	v.SetDefault("media_allowed_dirs", defaults.MediaAllowedDirs)
+	v.SetDefault("max_media_bytes", defaults.MaxMediaBytes)
 	v.SetDefault("connect_timeout", defaults.ConnectTimeout)
+	v.SetDefault("pairing_timeout", defaults.PairingTimeout)
+	v.SetDefault("qr_output", defaults.QROutput)
+	v.SetDefault("qr_file_path", defaults.QRFilePath)
+	v.SetDefault("presence_mode", defaults.PresenceMode)
+	v.SetDefault("send_read_receipts", defaults.SendReadReceipts)
 	v.SetDefault("keepalive_interval", defaults.KeepaliveInterval)
 	v.SetDefault("reconnect_max_retries", defaults.ReconnectMaxRetries)
 	v.SetDefault("reconnect_base_delay", defaults.ReconnectBaseDelay)
 	v.SetDefault("reconnect_max_delay", defaults.ReconnectMaxDelay)
+	v.SetDefault("reconnect_multiplier", defaults.ReconnectMultiplier)
+	v.SetDefault("reconnect_jitter", defaults.ReconnectJitter)
+	v.SetDefault("event_queue_size", defaults.EventQueueSize)
+	v.SetDefault("event_queue_timeout", defaults.EventQueueTimeout)
 	v.SetDefault("log_level", defaults.LogLevel)
 	v.SetDefault("log_format", defaults.LogFormat)
+	v.SetDefault("whatsmeow_log_level", defaults.WhatsmeowLogLevel)
+	v.SetDefault("log_file", defaults.LogFile)
+	v.SetDefault("redact_logs", defaults.RedactLogs)
 	v.SetDefault("metrics_enabled", defaults.MetricsEnabled)
 	v.SetDefault("metrics_port", defaults.MetricsPort)
+	v.SetDefault("webhook_url", defaults.WebhookURL)
+	v.SetDefault("webhook_secret", defaults.WebhookSecret)
+	v.SetDefault("webhook_timeout", defaults.WebhookTimeout)
+	v.SetDefault("webhook_max_retries", defaults.WebhookMaxRetries)
 	v.SetDefault("mcp_enabled", defaults.MCPEnabled)
+	v.SetDefault("tool_call_timeout", defaults.ToolCallTimeout)
+	v.SetDefault("shutdown_grace_period", defaults.ShutdownGracePeriod)
+	v.SetDefault("per_recipient_cooldown", defaults.PerRecipientCooldown)
+	v.SetDefault("invite_link_ttl", defaults.InviteLinkTTL)
 
 	// Environment variables with WABRIDGE_ prefix
 	v.SetEnvPrefix("WABRIDGE")
@@ -114,6 +248,65 @@ func LoadConfig(configPath string) (*Config, error) {
 	return cfg, nil
 }
 
+// ApplyAccount namespaces SessionPath and StorePath by AccountID, so
+// ~/.whatsapp-mcp/whatsapp.db becomes ~/.whatsapp-mcp/<account>/whatsapp.db.
+// It is a no-op without an account and must be called once, after flags
+// have been merged.
+func (c *Config) ApplyAccount() {
+	if c.AccountID == "" {
+		return
+	}
+	c.SessionPath = accountPath(c.SessionPath, c.AccountID)
+	c.StorePath = accountPath(c.StorePath, c.AccountID)
+}
+
+func accountPath(path, account string) string {
+	return filepath.Join(filepath.Dir(path), account, filepath.Base(path))
+}
+
+// mediaTypeLimits are the largest files WhatsApp accepts per media type.
+var mediaTypeLimits = map[string]int64{
+	"image":    16 << 20,
+	"audio":    16 << 20,
+	"video":    64 << 20,
+	"document": 2 << 30,
+}
+
+// MediaLimit returns the largest file, in bytes, that may be sent as the
+// given media type (image, video, audio or document): the WhatsApp limit for
+// that type, lowered to MaxMediaBytes. Unknown types get MaxMediaBytes.
+func (c *Config) MediaLimit(mediaType string) int64 {
+	if limit, ok := mediaTypeLimits[mediaType]; ok && limit < c.MaxMediaBytes {
+		return limit
+	}
+	return c.MaxMediaBytes
+}
+
+// reloadable lists the settings, by config key, that the running bridge can
+// pick up on SIGHUP.
+var reloadable = map[string]bool{
+	"log_level":              true,
+	"webhook_url":            true,
+	"per_recipient_cooldown": true,
+}
+
+// RestartRequired returns the keys of settings that differ between c and
+// next but are only read at startup, so a reload cannot apply them.
+func (c *Config) RestartRequired(next *Config) []string {
+	var keys []string
+	cur, nxt := reflect.ValueOf(c).Elem(), reflect.ValueOf(next).Elem()
+	for i := 0; i < cur.NumField(); i++ {
+		key := cur.Type().Field(i).Tag.Get("mapstructure")
+		if reloadable[key] {
+			continue
+		}
+		if !reflect.DeepEqual(cur.Field(i).Interface(), nxt.Field(i).Interface()) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
 // Validate checks if the configuration is valid.
 func (c *Config) Validate() error {
 	// Validate log level
@@ -126,6 +319,37 @@ func (c *Config) Validate() error {
 	if !validLogLevels[c.LogLevel] {
 		return fmt.Errorf("invalid log level: %s (must be debug, info, warn, or error)", c.LogLevel)
 	}
+	if !validLogLevels[c.WhatsmeowLogLevel] {
+		return fmt.Errorf("invalid whatsmeow log level: %s (must be debug, info, warn, or error)", c.WhatsmeowLogLevel)
+	}
+
+	// The account ID becomes a directory name, so it must be a single safe
+	// path component.
+	if c.AccountID != "" && !validAccountID(c.AccountID) {
+		return fmt.Errorf("invalid account id: %q (use letters, digits, '.', '_' or '-')", c.AccountID)
+	}
+
+	// Validate QR output
+	validQROutputs := map[string]bool{
+		"stderr": true,
+		"file":   true,
+		"both":   true,
+		"none":   true,
+	}
+	if !validQROutputs[c.QROutput] {
+		return fmt.Errorf("invalid qr output: %s (must be stderr, file, both, or none)", c.QROutput)
+	}
+
+	// Validate presence mode
+	switch c.PresenceMode {
+	case PresenceAuto, PresenceAlwaysOnline, PresenceAlwaysOffline:
+	default:
+		return fmt.Errorf("invalid presence mode: %s (must be auto, always_online, or always_offline)", c.PresenceMode)
+	}
+
+	if c.MaxMediaBytes <= 0 {
+		return fmt.Errorf("max media bytes must be positive")
+	}
 
 	// Validate metrics port
 	if c.MetricsPort < 0 || c.MetricsPort > 65535 {
@@ -133,6 +357,14 @@ func (c *Config) Validate() error {
 	}
 
 	// Validate keepalive interval
+	if c.ConnectTimeout <= 0 {
+		return fmt.Errorf("connect timeout must be positive")
+	}
+
+	if c.PairingTimeout <= 0 {
+		return fmt.Errorf("pairing timeout must be positive")
+	}
+
 	if c.KeepaliveInterval <= 0 {
 		return fmt.Errorf("keepalive interval must be positive")
 	}
@@ -150,9 +382,80 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("reconnect max delay must be positive")
 	}
 
+	if c.ReconnectMultiplier < 1 {
+		return fmt.Errorf("reconnect multiplier must be at least 1")
+	}
+
+	if c.ReconnectJitter < 0 || c.ReconnectJitter > 1 {
+		return fmt.Errorf("reconnect jitter must be between 0 and 1")
+	}
+
 	if c.ReconnectBaseDelay > c.ReconnectMaxDelay {
 		return fmt.Errorf("reconnect base delay must be less than or equal to max delay")
 	}
 
+	if c.EventQueueSize <= 0 {
+		return fmt.Errorf("event queue size must be positive")
+	}
+	if c.EventQueueTimeout < 0 {
+		return fmt.Errorf("event queue timeout must be non-negative")
+	}
+
+	if c.StoreBusyTimeout < 0 {
+		return fmt.Errorf("store busy timeout must be non-negative")
+	}
+
+	// Validate webhook settings
+	if c.WebhookURL != "" {
+		u, err := url.Parse(c.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook url: %q (must be an http or https URL)", c.WebhookURL)
+		}
+		if c.WebhookTimeout <= 0 {
+			return fmt.Errorf("webhook timeout must be positive")
+		}
+		if c.WebhookMaxRetries < 0 {
+			return fmt.Errorf("webhook max retries must be non-negative")
+		}
+	}
+
+	if c.ToolCallTimeout < 0 {
+		return fmt.Errorf("tool call timeout must be non-negative")
+	}
+
+	if c.ShutdownGracePeriod < 0 {
+		return fmt.Errorf("shutdown grace period must be non-negative")
+	}
+
+	if c.PerRecipientCooldown < 0 {
+		return fmt.Errorf("per recipient cooldown must be non-negative")
+	}
+
+	if c.InviteLinkTTL < 0 {
+		return fmt.Errorf("invite link ttl must be non-negative")
+	}
+
+	// Validate media allowed dirs
+	for _, dir := range c.MediaAllowedDirs {
+		if !filepath.IsAbs(dir) {
+			return fmt.Errorf("media allowed dir must be an absolute path: %q", dir)
+		}
+	}
+
 	return nil
 }
+
+func validAccountID(id string) bool {
+	if id == "." || id == ".." {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '.', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
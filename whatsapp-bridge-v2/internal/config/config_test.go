@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,6 +18,7 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal(t, filepath.Join(home, ".whatsapp-mcp", "whatsapp.db"), cfg.SessionPath)
 	assert.Equal(t, filepath.Join(home, ".whatsapp-mcp", "messages.db"), cfg.StorePath)
 	assert.Equal(t, 30*time.Second, cfg.ConnectTimeout)
+	assert.Equal(t, 5*time.Minute, cfg.PairingTimeout)
 	assert.Equal(t, 30*time.Second, cfg.KeepaliveInterval)
 	assert.Equal(t, 10, cfg.ReconnectMaxRetries)
 	assert.Equal(t, 1*time.Second, cfg.ReconnectBaseDelay)
@@ -26,6 +28,7 @@ func TestDefaultConfig(t *testing.T) {
 	assert.True(t, cfg.MetricsEnabled)
 	assert.Equal(t, 9090, cfg.MetricsPort)
 	assert.True(t, cfg.MCPEnabled)
+	assert.True(t, cfg.SendReadReceipts)
 }
 
 func TestLoadConfig_FromFile(t *testing.T) {
@@ -46,6 +49,7 @@ log_format: text
 metrics_enabled: false
 metrics_port: 8080
 mcp_enabled: false
+send_read_receipts: false
 `
 	err := os.WriteFile(configPath, []byte(configContent), 0644)
 	require.NoError(t, err)
@@ -65,6 +69,7 @@ mcp_enabled: false
 	assert.False(t, cfg.MetricsEnabled)
 	assert.Equal(t, 8080, cfg.MetricsPort)
 	assert.False(t, cfg.MCPEnabled)
+	assert.False(t, cfg.SendReadReceipts)
 }
 
 func TestLoadConfig_EnvOverrides(t *testing.T) {
@@ -122,6 +127,27 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "zero connect timeout",
+			modify: func(c *Config) {
+				c.ConnectTimeout = 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative pairing timeout",
+			modify: func(c *Config) {
+				c.PairingTimeout = -time.Minute
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid whatsmeow log level",
+			modify: func(c *Config) {
+				c.WhatsmeowLogLevel = "trace"
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid metrics port",
 			modify: func(c *Config) {
@@ -143,6 +169,97 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "zero event queue size",
+			modify: func(c *Config) {
+				c.EventQueueSize = 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative shutdown grace period",
+			modify: func(c *Config) {
+				c.ShutdownGracePeriod = -time.Second
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid qr output",
+			modify: func(c *Config) {
+				c.QROutput = "stdout"
+			},
+			wantErr: true,
+		},
+		{
+			name: "reconnect multiplier below one",
+			modify: func(c *Config) {
+				c.ReconnectMultiplier = 0.5
+			},
+			wantErr: true,
+		},
+		{
+			name: "reconnect jitter above one",
+			modify: func(c *Config) {
+				c.ReconnectJitter = 1.5
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid presence mode",
+			modify: func(c *Config) {
+				c.PresenceMode = "invisible"
+			},
+			wantErr: true,
+		},
+		{
+			name: "always online presence mode",
+			modify: func(c *Config) {
+				c.PresenceMode = PresenceAlwaysOnline
+			},
+			wantErr: false,
+		},
+		{
+			name: "relative media allowed dir",
+			modify: func(c *Config) {
+				c.MediaAllowedDirs = []string{"media"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "absolute media allowed dir",
+			modify: func(c *Config) {
+				c.MediaAllowedDirs = []string{"/tmp/media"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid webhook url",
+			modify: func(c *Config) {
+				c.WebhookURL = "ftp://example.com/hook"
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid webhook url",
+			modify: func(c *Config) {
+				c.WebhookURL = "https://example.com/hook"
+			},
+			wantErr: false,
+		},
+		{
+			name: "account id with path separator",
+			modify: func(c *Config) {
+				c.AccountID = "../other"
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid account id",
+			modify: func(c *Config) {
+				c.AccountID = "work-2"
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -159,3 +276,56 @@ func TestConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_ApplyAccount(t *testing.T) {
+	work := DefaultConfig()
+	work.AccountID = "work"
+	work.ApplyAccount()
+
+	personal := DefaultConfig()
+	personal.AccountID = "personal"
+	personal.ApplyAccount()
+
+	home, _ := os.UserHomeDir()
+	assert.Equal(t, filepath.Join(home, ".whatsapp-mcp", "work", "whatsapp.db"), work.SessionPath)
+	assert.Equal(t, filepath.Join(home, ".whatsapp-mcp", "work", "messages.db"), work.StorePath)
+
+	// Each account's directory must not contain the other's
+	workDir, personalDir := filepath.Dir(work.StorePath), filepath.Dir(personal.StorePath)
+	assert.NotEqual(t, workDir, personalDir)
+	assert.False(t, strings.HasPrefix(workDir+string(filepath.Separator), personalDir+string(filepath.Separator)))
+	assert.False(t, strings.HasPrefix(personalDir+string(filepath.Separator), workDir+string(filepath.Separator)))
+	assert.NotEqual(t, work.SessionPath, personal.SessionPath)
+
+	// Without an account the paths are left alone
+	none := DefaultConfig()
+	none.ApplyAccount()
+	assert.Equal(t, DefaultConfig().StorePath, none.StorePath)
+}
+
+func TestConfig_MediaLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.Equal(t, int64(16<<20), cfg.MediaLimit("image"))
+	assert.Equal(t, int64(64<<20), cfg.MediaLimit("video"))
+	assert.Equal(t, int64(64<<20), cfg.MediaLimit("document"), "MaxMediaBytes lowers the WhatsApp limit")
+	assert.Equal(t, int64(64<<20), cfg.MediaLimit("sticker"))
+
+	cfg.MaxMediaBytes = 1 << 20
+	assert.Equal(t, int64(1<<20), cfg.MediaLimit("image"))
+}
+
+func TestConfig_RestartRequired(t *testing.T) {
+	cur := DefaultConfig()
+	next := DefaultConfig()
+	assert.Empty(t, cur.RestartRequired(next))
+
+	// Reloadable settings are not reported
+	next.LogLevel = "debug"
+	next.WebhookURL = "https://example.com/hook"
+	next.PerRecipientCooldown = time.Minute
+	assert.Empty(t, cur.RestartRequired(next))
+
+	next.StorePath = "/tmp/other.db"
+	next.MediaAllowedDirs = []string{"/tmp"}
+	assert.Equal(t, []string{"store_path", "media_allowed_dirs"}, cur.RestartRequired(next))
+}
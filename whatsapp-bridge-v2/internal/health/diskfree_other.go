@@ -0,0 +1,9 @@
+//go:build !unix
+
+package health
+
+import "errors"
+
+func diskFree(dir string) (uint64, error) {
+	return 0, errors.New("disk space check is not supported on this platform")
+}
@@ -4,6 +4,7 @@ package health
 import (
 	"context"
 	"log/slog"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,13 +17,29 @@ import (
 
 // Status represents the health status of the bridge.
 type Status struct {
-	State            string    `json:"state"`
-	Connected        bool      `json:"connected"`
-	UptimeSeconds    int64     `json:"uptime_seconds"`
-	LastMessage      time.Time `json:"last_message"`
-	ReconnectCount   int       `json:"reconnect_count"`
-	MessagesReceived int64     `json:"messages_received"`
-	MessagesSent     int64     `json:"messages_sent"`
+	State            string           `json:"state"`
+	Connected        bool             `json:"connected"`
+	UptimeSeconds    int64            `json:"uptime_seconds"`
+	LastMessage      time.Time        `json:"last_message"`
+	ReconnectCount   int              `json:"reconnect_count"`
+	MessagesReceived int64            `json:"messages_received"`
+	MessagesSent     int64            `json:"messages_sent"`
+	ToolCalls        map[string]int64 `json:"tool_calls"`
+
+	// EventsDropped counts bridge events lost to a full event queue. The
+	// monitor doesn't see the queue, so the API handler fills it in.
+	EventsDropped int64 `json:"events_dropped"`
+
+	// Reconnection progress. BackoffActive is true between a failed
+	// connection and the next successful one.
+	BackoffActive      bool       `json:"backoff_active"`
+	ReconnectAttempt   int        `json:"reconnect_attempt"`
+	NextReconnectDelay float64    `json:"next_reconnect_delay_seconds,omitempty"`
+	NextReconnectAt    *time.Time `json:"next_reconnect_at,omitempty"`
+
+	// Result of the last ping_whatsapp probe, absent until one succeeds.
+	LatencyMs       *float64   `json:"latency_ms,omitempty"`
+	LatencyMeasured *time.Time `json:"latency_measured_at,omitempty"`
 }
 
 // Monitor tracks bridge health and manages reconnection.
@@ -35,12 +52,18 @@ type Monitor struct {
 	reconnectBackoff  *backoff.ExponentialBackOff
 	maxRetries        int
 	retryCount        int
+	nextDelay         time.Duration
+	nextReconnectAt   time.Time
 
 	startTime        time.Time
 	lastMessage      time.Time
 	reconnectCount   int
 	messagesReceived atomic.Int64
 	messagesSent     atomic.Int64
+	toolCalls        map[string]int64
+	toolDurations    map[string]toolDuration
+	latency          time.Duration
+	latencyMeasured  time.Time
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -55,6 +78,8 @@ func NewMonitor(cfg *config.Config, sm *state.Machine) *Monitor {
 	bo := backoff.NewExponentialBackOff()
 	bo.InitialInterval = cfg.ReconnectBaseDelay
 	bo.MaxInterval = cfg.ReconnectMaxDelay
+	bo.Multiplier = cfg.ReconnectMultiplier
+	bo.RandomizationFactor = cfg.ReconnectJitter
 	bo.MaxElapsedTime = 0 // Never stop based on elapsed time
 	bo.Reset()
 
@@ -66,6 +91,8 @@ func NewMonitor(cfg *config.Config, sm *state.Machine) *Monitor {
 		reconnectBackoff:  bo,
 		maxRetries:        cfg.ReconnectMaxRetries,
 		startTime:         time.Now(),
+		toolCalls:         make(map[string]int64),
+		toolDurations:     make(map[string]toolDuration),
 		ctx:               ctx,
 		cancel:            cancel,
 	}
@@ -92,7 +119,7 @@ func (m *Monitor) GetStatus() Status {
 	currentState, _ := m.stateMachine.State(context.Background())
 	connected := currentState == state.StateReady
 
-	return Status{
+	status := Status{
 		State:            string(currentState),
 		Connected:        connected,
 		UptimeSeconds:    int64(time.Since(m.startTime).Seconds()),
@@ -100,7 +127,22 @@ func (m *Monitor) GetStatus() Status {
 		ReconnectCount:   m.reconnectCount,
 		MessagesReceived: m.messagesReceived.Load(),
 		MessagesSent:     m.messagesSent.Load(),
+		ToolCalls:        m.toolCallsLocked(),
+		BackoffActive:    m.retryCount > 0,
+		ReconnectAttempt: m.retryCount,
 	}
+	if status.BackoffActive {
+		next := m.nextReconnectAt
+		status.NextReconnectDelay = m.nextDelay.Seconds()
+		status.NextReconnectAt = &next
+	}
+	if !m.latencyMeasured.IsZero() {
+		ms := float64(m.latency.Microseconds()) / 1000
+		measured := m.latencyMeasured
+		status.LatencyMs = &ms
+		status.LatencyMeasured = &measured
+	}
+	return status
 }
 
 // RecordMessageReceived records an incoming message.
@@ -116,6 +158,84 @@ func (m *Monitor) RecordMessageSent() {
 	m.messagesSent.Add(1)
 }
 
+// RecordLatency records a measured round-trip time to the server.
+func (m *Monitor) RecordLatency(latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latency = latency
+	m.latencyMeasured = time.Now()
+}
+
+// RecordToolCall records an invocation of the named MCP tool.
+func (m *Monitor) RecordToolCall(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.toolCalls[name]++
+}
+
+// GetToolCallCounts returns a snapshot of tool invocations by name.
+func (m *Monitor) GetToolCallCounts() map[string]int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.toolCallsLocked()
+}
+
+// toolDuration accumulates the time spent in completed calls of one tool.
+type toolDuration struct {
+	completed int64
+	total     time.Duration
+}
+
+// ToolUsage summarises the calls made to one MCP tool since startup.
+type ToolUsage struct {
+	Tool         string  `json:"tool"`
+	Calls        int64   `json:"calls"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+}
+
+// RecordToolDuration records how long a completed call of the named tool
+// took. Calls are counted separately by RecordToolCall, so one still in
+// flight is counted but not yet part of the average.
+func (m *Monitor) RecordToolDuration(name string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	td := m.toolDurations[name]
+	td.completed++
+	td.total += d
+	m.toolDurations[name] = td
+}
+
+// GetToolUsage returns call counts and average latency per tool, busiest
+// tool first.
+func (m *Monitor) GetToolUsage() []ToolUsage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	usage := make([]ToolUsage, 0, len(m.toolCalls))
+	for name, n := range m.toolCalls {
+		u := ToolUsage{Tool: name, Calls: n}
+		if td := m.toolDurations[name]; td.completed > 0 {
+			u.AvgLatencyMs = float64(td.total.Microseconds()) / 1000 / float64(td.completed)
+		}
+		usage = append(usage, u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Calls != usage[j].Calls {
+			return usage[i].Calls > usage[j].Calls
+		}
+		return usage[i].Tool < usage[j].Tool
+	})
+	return usage
+}
+
+func (m *Monitor) toolCallsLocked() map[string]int64 {
+	counts := make(map[string]int64, len(m.toolCalls))
+	for name, n := range m.toolCalls {
+		counts[name] = n
+	}
+	return counts
+}
+
 // GetLastMessageTime returns the time of the last message.
 func (m *Monitor) GetLastMessageTime() time.Time {
 	m.mu.RLock()
@@ -129,7 +249,9 @@ func (m *Monitor) GetNextReconnectDelay() time.Duration {
 	defer m.mu.Unlock()
 
 	m.retryCount++
-	return m.reconnectBackoff.NextBackOff()
+	m.nextDelay = m.reconnectBackoff.NextBackOff()
+	m.nextReconnectAt = time.Now().Add(m.nextDelay)
+	return m.nextDelay
 }
 
 // ResetReconnectBackoff resets the backoff to initial values.
@@ -139,6 +261,8 @@ func (m *Monitor) ResetReconnectBackoff() {
 
 	m.reconnectBackoff.Reset()
 	m.retryCount = 0
+	m.nextDelay = 0
+	m.nextReconnectAt = time.Time{}
 }
 
 // IsMaxRetriesExceeded returns true if max reconnection retries have been exceeded.
@@ -75,6 +75,37 @@ func TestMonitor_ReconnectBackoff(t *testing.T) {
 	assert.LessOrEqual(t, delayAfterReset, cfg.ReconnectMaxDelay)
 }
 
+func TestMonitor_GetStatus_ReportsBackoff(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ReconnectBaseDelay = time.Second
+	cfg.ReconnectMaxDelay = time.Minute
+	cfg.ReconnectMultiplier = 2
+	cfg.ReconnectJitter = 0
+
+	m := NewMonitor(cfg, state.NewMachine())
+
+	status := m.GetStatus()
+	assert.False(t, status.BackoffActive)
+	assert.Zero(t, status.ReconnectAttempt)
+	assert.Nil(t, status.NextReconnectAt)
+
+	assert.Equal(t, time.Second, m.GetNextReconnectDelay())
+	assert.Equal(t, 2*time.Second, m.GetNextReconnectDelay(), "multiplier applies without jitter")
+
+	status = m.GetStatus()
+	assert.True(t, status.BackoffActive)
+	assert.Equal(t, 2, status.ReconnectAttempt)
+	assert.Equal(t, 2.0, status.NextReconnectDelay)
+	require.NotNil(t, status.NextReconnectAt)
+	assert.WithinDuration(t, time.Now().Add(2*time.Second), *status.NextReconnectAt, time.Second)
+
+	m.OnConnectionRestored()
+	status = m.GetStatus()
+	assert.False(t, status.BackoffActive)
+	assert.Zero(t, status.NextReconnectDelay)
+	assert.Nil(t, status.NextReconnectAt)
+}
+
 func TestMonitor_MaxRetriesExceeded(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.ReconnectBaseDelay = 1 * time.Millisecond
@@ -141,3 +172,44 @@ func TestMonitor_IncrementReconnectCount(t *testing.T) {
 
 	assert.Equal(t, 2, m.GetReconnectCount())
 }
+
+func TestMonitor_RecordToolCall(t *testing.T) {
+	cfg := config.DefaultConfig()
+	sm := state.NewMachine()
+
+	m := NewMonitor(cfg, sm)
+
+	m.RecordToolCall("send_message")
+	m.RecordToolCall("send_message")
+	m.RecordToolCall("list_chats")
+
+	counts := m.GetToolCallCounts()
+	assert.Equal(t, int64(2), counts["send_message"])
+	assert.Equal(t, int64(1), counts["list_chats"])
+
+	// Returned map is a snapshot
+	counts["send_message"] = 100
+	assert.Equal(t, int64(2), m.GetStatus().ToolCalls["send_message"])
+}
+
+func TestMonitor_GetToolUsage(t *testing.T) {
+	cfg := config.DefaultConfig()
+	sm := state.NewMachine()
+
+	m := NewMonitor(cfg, sm)
+
+	m.RecordToolCall("list_chats")
+	m.RecordToolDuration("list_chats", 4*time.Millisecond)
+	m.RecordToolCall("send_message")
+	m.RecordToolDuration("send_message", 10*time.Millisecond)
+	m.RecordToolCall("send_message")
+	m.RecordToolDuration("send_message", 20*time.Millisecond)
+	m.RecordToolCall("get_chat") // still in flight
+
+	usage := m.GetToolUsage()
+	assert.Equal(t, []ToolUsage{
+		{Tool: "send_message", Calls: 2, AvgLatencyMs: 15},
+		{Tool: "get_chat", Calls: 1},
+		{Tool: "list_chats", Calls: 1, AvgLatencyMs: 4},
+	}, usage)
+}
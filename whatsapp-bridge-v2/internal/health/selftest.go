@@ -0,0 +1,124 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/state"
+)
+
+// minFreeDisk is the free space below which the disk check fails.
+const minFreeDisk = 100 << 20 // 100 MiB
+
+// Self-test check names.
+const (
+	CheckDatabase    = "database"
+	CheckConnection  = "whatsapp_connection"
+	CheckState       = "state_machine"
+	CheckDiskSpace   = "disk_space"
+	CheckLastMessage = "last_message"
+)
+
+// CheckResult is the outcome of a single self-test check.
+type CheckResult struct {
+	Name   string `json:"name"`
+	Pass   bool   `json:"pass"`
+	Detail string `json:"detail"`
+}
+
+// SelfTestReport is the result of a self-test. Status is "pass" only when
+// every check passed.
+type SelfTestReport struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Pinger is a database that can be probed with a trivial query.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// SelfTestProbes are the parts of the bridge a self-test inspects.
+// LastReceived returns the zero time when nothing has been received yet.
+type SelfTestProbes struct {
+	DB           Pinger
+	Connected    func() bool
+	StoreDir     string
+	LastReceived func(ctx context.Context) (time.Time, error)
+}
+
+// unhealthyStates are states the bridge cannot leave without intervention.
+var unhealthyStates = map[state.State]bool{
+	state.StateLoggedOut:      true,
+	state.StateSessionExpired: true,
+	state.StateTemporaryBan:   true,
+	state.StateShuttingDown:   true,
+	state.StateFatalError:     true,
+}
+
+// SelfTest runs a quick diagnostic of the database, the WhatsApp
+// connection, the state machine, free disk space and message flow.
+func (m *Monitor) SelfTest(ctx context.Context, p SelfTestProbes) SelfTestReport {
+	checks := []CheckResult{
+		checkDatabase(ctx, p.DB),
+		checkConnection(p.Connected),
+		m.checkState(ctx),
+		checkDiskSpace(p.StoreDir),
+		checkLastMessage(ctx, p.LastReceived),
+	}
+
+	report := SelfTestReport{Status: "pass", Checks: checks}
+	for _, c := range checks {
+		if !c.Pass {
+			report.Status = "fail"
+			break
+		}
+	}
+	return report
+}
+
+func checkDatabase(ctx context.Context, db Pinger) CheckResult {
+	if err := db.Ping(ctx); err != nil {
+		return CheckResult{Name: CheckDatabase, Detail: err.Error()}
+	}
+	return CheckResult{Name: CheckDatabase, Pass: true, Detail: "reachable"}
+}
+
+func checkConnection(connected func() bool) CheckResult {
+	if !connected() {
+		return CheckResult{Name: CheckConnection, Detail: "not connected to WhatsApp"}
+	}
+	return CheckResult{Name: CheckConnection, Pass: true, Detail: "connected"}
+}
+
+func (m *Monitor) checkState(ctx context.Context) CheckResult {
+	current, err := m.stateMachine.State(ctx)
+	if err != nil {
+		return CheckResult{Name: CheckState, Detail: err.Error()}
+	}
+	return CheckResult{Name: CheckState, Pass: !unhealthyStates[current], Detail: string(current)}
+}
+
+func checkDiskSpace(dir string) CheckResult {
+	free, err := diskFree(dir)
+	if err != nil {
+		return CheckResult{Name: CheckDiskSpace, Detail: err.Error()}
+	}
+	detail := fmt.Sprintf("%d MiB free in %s", free>>20, dir)
+	return CheckResult{Name: CheckDiskSpace, Pass: free >= minFreeDisk, Detail: detail}
+}
+
+// checkLastMessage reports how long ago a message arrived. A quiet account
+// is not a failure; only an unreadable store is.
+func checkLastMessage(ctx context.Context, lastReceived func(context.Context) (time.Time, error)) CheckResult {
+	last, err := lastReceived(ctx)
+	if err != nil {
+		return CheckResult{Name: CheckLastMessage, Detail: err.Error()}
+	}
+	if last.IsZero() {
+		return CheckResult{Name: CheckLastMessage, Pass: true, Detail: "no messages received yet"}
+	}
+	ago := time.Since(last).Round(time.Second)
+	return CheckResult{Name: CheckLastMessage, Pass: true, Detail: fmt.Sprintf("last message received %s ago", ago)}
+}
@@ -0,0 +1,62 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/config"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/state"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
+)
+
+func TestMonitor_SelfTest(t *testing.T) {
+	db, err := store.NewSQLiteStore(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	m := NewMonitor(config.DefaultConfig(), state.NewMachine())
+	report := m.SelfTest(context.Background(), SelfTestProbes{
+		DB:        db,
+		Connected: func() bool { return false },
+		StoreDir:  t.TempDir(),
+		LastReceived: func(ctx context.Context) (time.Time, error) {
+			return time.Time{}, nil
+		},
+	})
+
+	checks := make(map[string]CheckResult, len(report.Checks))
+	for _, c := range report.Checks {
+		checks[c.Name] = c
+	}
+	require.Len(t, checks, 5)
+
+	assert.True(t, checks[CheckDatabase].Pass, checks[CheckDatabase].Detail)
+	assert.False(t, checks[CheckConnection].Pass)
+	assert.True(t, checks[CheckState].Pass, "a fresh machine is disconnected, not broken")
+	assert.True(t, checks[CheckLastMessage].Pass)
+	assert.Equal(t, "fail", report.Status)
+}
+
+func TestMonitor_SelfTest_ClosedDB(t *testing.T) {
+	db, err := store.NewSQLiteStore(":memory:")
+	require.NoError(t, err)
+	db.Close()
+
+	m := NewMonitor(config.DefaultConfig(), state.NewMachine())
+	report := m.SelfTest(context.Background(), SelfTestProbes{
+		DB:        db,
+		Connected: func() bool { return true },
+		StoreDir:  t.TempDir(),
+		LastReceived: func(ctx context.Context) (time.Time, error) {
+			return time.Now().Add(-time.Minute), nil
+		},
+	})
+
+	assert.Equal(t, CheckDatabase, report.Checks[0].Name)
+	assert.False(t, report.Checks[0].Pass)
+	assert.Equal(t, "fail", report.Status)
+}
@@ -0,0 +1,111 @@
+// Package logging builds the bridge's slog logger and adapts it for whatsmeow.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	waLog "go.mau.fi/whatsmeow/util/log"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/config"
+)
+
+// ParseLevel converts a config log level name to a slog level. Unknown names
+// fall back to info.
+func ParseLevel(name string) slog.Level {
+	switch name {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// level is the minimum level of the application logger. There is one
+// application logger per process, so New and SetLevel share it.
+var level = new(slog.LevelVar)
+
+// SetLevel changes the minimum level of loggers created by New, taking
+// effect immediately. It is how a config reload applies log_level.
+func SetLevel(name string) {
+	level.Set(ParseLevel(name))
+}
+
+// New creates the application logger. Logs go to cfg.LogFile when set and to
+// stderr otherwise, never stdout, which carries the MCP protocol. With
+// cfg.RedactLogs, message content is redacted. The returned function closes
+// the log file.
+func New(cfg *config.Config) (*slog.Logger, func() error, error) {
+	var out io.Writer = os.Stderr
+	closeFn := func() error { return nil }
+
+	if cfg.LogFile != "" {
+		f, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		out = f
+		closeFn = f.Close
+	}
+
+	level.Set(ParseLevel(cfg.LogLevel))
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if cfg.LogFormat == "text" {
+		handler = slog.NewTextHandler(out, opts)
+	} else {
+		handler = slog.NewJSONHandler(out, opts)
+	}
+	if cfg.RedactLogs {
+		handler = NewRedactingHandler(handler)
+	}
+	return slog.New(handler), closeFn, nil
+}
+
+// whatsmeowLogger adapts slog.Logger to whatsmeow's log interface, dropping
+// messages below its own minimum level. The application log level still
+// applies on top, so whatsmeow can be made quieter but not more verbose.
+type whatsmeowLogger struct {
+	log *slog.Logger
+	min slog.Level
+}
+
+// NewWhatsmeowLogger returns a whatsmeow logger that writes to log at or
+// above the named level.
+func NewWhatsmeowLogger(log *slog.Logger, level string) waLog.Logger {
+	return &whatsmeowLogger{log: log, min: ParseLevel(level)}
+}
+
+func (w *whatsmeowLogger) logf(level slog.Level, msg string, args []interface{}) {
+	if level < w.min {
+		return
+	}
+	w.log.Log(context.Background(), level, fmt.Sprintf(msg, args...))
+}
+
+func (w *whatsmeowLogger) Debugf(msg string, args ...interface{}) {
+	w.logf(slog.LevelDebug, msg, args)
+}
+
+func (w *whatsmeowLogger) Infof(msg string, args ...interface{}) {
+	w.logf(slog.LevelInfo, msg, args)
+}
+
+func (w *whatsmeowLogger) Warnf(msg string, args ...interface{}) {
+	w.logf(slog.LevelWarn, msg, args)
+}
+
+func (w *whatsmeowLogger) Errorf(msg string, args ...interface{}) {
+	w.logf(slog.LevelError, msg, args)
+}
+
+func (w *whatsmeowLogger) Sub(module string) waLog.Logger {
+	return &whatsmeowLogger{log: w.log.With("module", module), min: w.min}
+}
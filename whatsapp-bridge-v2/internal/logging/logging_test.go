@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/config"
+)
+
+func TestWhatsmeowLogger_FiltersBelowLevel(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	log := NewWhatsmeowLogger(base, "warn")
+	log.Debugf("debug %d", 1)
+	log.Infof("info %d", 2)
+	log.Warnf("warn %d", 3)
+	log.Sub("Socket").Infof("sub info")
+	log.Sub("Socket").Errorf("sub error")
+
+	out := buf.String()
+	assert.NotContains(t, out, "debug 1")
+	assert.NotContains(t, out, "info 2")
+	assert.NotContains(t, out, "sub info")
+	assert.Contains(t, out, "warn 3")
+	assert.Contains(t, out, "sub error")
+	assert.Contains(t, out, "module=Socket")
+}
+
+func TestNew_LogFile(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.LogFile = filepath.Join(t.TempDir(), "bridge.log")
+	cfg.LogFormat = "text"
+
+	logger, closeLog, err := New(cfg)
+	require.NoError(t, err)
+	logger.Info("hello file")
+	logger.Debug("hidden at info")
+	require.NoError(t, closeLog())
+
+	data, err := os.ReadFile(cfg.LogFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "hello file")
+	assert.NotContains(t, string(data), "hidden at info")
+}
+
+func TestSetLevel_AppliesReloadedConfig(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.LogFile = filepath.Join(t.TempDir(), "bridge.log")
+	cfg.LogFormat = "text"
+
+	logger, closeLog, err := New(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { SetLevel("info") })
+
+	logger.Debug("before reload")
+
+	reloaded := config.DefaultConfig()
+	reloaded.LogLevel = "debug"
+	SetLevel(reloaded.LogLevel)
+	logger.Debug("after reload")
+	require.NoError(t, closeLog())
+
+	data, err := os.ReadFile(cfg.LogFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "before reload")
+	assert.Contains(t, string(data), "after reload")
+}
+
+func TestNew_RedactLogs(t *testing.T) {
+	const raw = `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"send_message","arguments":{"recipient":"123@s.whatsapp.net","message":"meet me at the secret spot","name":"not a tool"}}}`
+
+	write := func(redact bool) string {
+		cfg := config.DefaultConfig()
+		cfg.LogFile = filepath.Join(t.TempDir(), "bridge.log")
+		cfg.LogLevel = "debug"
+		cfg.RedactLogs = redact
+
+		logger, closeLog, err := New(cfg)
+		require.NoError(t, err)
+		t.Cleanup(func() { SetLevel("info") })
+		logger.Debug("received message", "raw", raw)
+		logger.With("caption", "holiday photo of my secret spot").Info("sending image", "tool", "send_image")
+		require.NoError(t, closeLog())
+
+		data, err := os.ReadFile(cfg.LogFile)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	out := write(true)
+	assert.NotContains(t, out, "secret spot")
+	assert.NotContains(t, out, "not a tool", "argument values are redacted even under kept keys")
+	assert.Contains(t, out, "tools/call")
+	assert.Contains(t, out, "send_message")
+	assert.Contains(t, out, "send_image")
+	assert.Contains(t, out, "[redacted ")
+
+	// Redaction can be turned off for local debugging
+	assert.Contains(t, write(false), "secret spot")
+}
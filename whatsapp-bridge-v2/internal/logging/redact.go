@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+// redactedKeys are the log attributes that can carry message text, captions
+// or file paths.
+var redactedKeys = map[string]bool{
+	"raw":       true, // JSON-RPC lines logged by the MCP transport
+	"args":      true,
+	"arguments": true,
+	"content":   true,
+	"text":      true,
+	"caption":   true,
+}
+
+// keptJSONKeys are the fields of a JSON-RPC message left readable in a
+// redacted "raw" attribute: they name what was called, not what was said.
+var keptJSONKeys = map[string]bool{
+	"jsonrpc":         true,
+	"method":          true,
+	"name":            true,
+	"type":            true,
+	"code":            true,
+	"uri":             true,
+	"protocolVersion": true,
+}
+
+// redactingHandler replaces sensitive attribute values with a length and a
+// short hash before passing records on, so logs still show that two calls
+// used the same value without revealing it.
+type redactingHandler struct {
+	next slog.Handler
+}
+
+// NewRedactingHandler wraps next so that message content is never written
+// to the log. JSON-RPC messages keep their method and tool names.
+func NewRedactingHandler(next slog.Handler) slog.Handler {
+	return &redactingHandler{next: next}
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &redactingHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			redacted[i] = redactAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	}
+	if !redactedKeys[a.Key] {
+		return a
+	}
+
+	s := a.Value.String()
+	if a.Key == "raw" {
+		return slog.String(a.Key, redactJSON(s))
+	}
+	return slog.String(a.Key, redactString(s))
+}
+
+// redactString replaces s with its length and the start of its SHA-256.
+func redactString(s string) string {
+	if s == "" {
+		return s
+	}
+	sum := sha256.Sum256([]byte(s))
+	return fmt.Sprintf("[redacted %dB %s]", len(s), hex.EncodeToString(sum[:4]))
+}
+
+// redactJSON redacts every string in a JSON document except the values of
+// keptJSONKeys outside tool arguments. Input that isn't JSON is redacted
+// as a whole.
+func redactJSON(s string) string {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		return redactString(s)
+	}
+	out, err := json.Marshal(redactValue(doc, "", false))
+	if err != nil {
+		return redactString(s)
+	}
+	return string(out)
+}
+
+func redactValue(v interface{}, key string, inArgs bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = redactValue(child, k, inArgs || k == "arguments")
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactValue(child, key, inArgs)
+		}
+		return v
+	case string:
+		if keptJSONKeys[key] && !inArgs {
+			return v
+		}
+		return redactString(v)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,100 @@
+// Package qr renders WhatsApp pairing QR codes to a PNG file and/or the terminal.
+package qr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/mdp/qrterminal/v3"
+	"github.com/skip2/go-qrcode"
+)
+
+// Output modes for QR codes.
+const (
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+	OutputBoth   = "both"
+	OutputNone   = "none"
+)
+
+// Renderer writes QR codes according to the configured output mode.
+type Renderer struct {
+	mode     string
+	filePath string
+	term     io.Writer
+	log      *slog.Logger
+}
+
+// NewRenderer creates a renderer. term receives terminal output (normally
+// os.Stderr) and filePath is where the PNG is written in file/both modes.
+func NewRenderer(mode, filePath string, term io.Writer, log *slog.Logger) *Renderer {
+	return &Renderer{
+		mode:     mode,
+		filePath: filePath,
+		term:     term,
+		log:      log,
+	}
+}
+
+// Run renders every code received on codes until the channel closes or ctx is
+// done. A closed channel means pairing finished, so the QR file is removed.
+func (r *Renderer) Run(ctx context.Context, codes <-chan string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case code, ok := <-codes:
+			if !ok {
+				r.removeFile()
+				return
+			}
+			r.Render(code)
+		}
+	}
+}
+
+// removeFile deletes the QR PNG so a stale code is not left behind.
+func (r *Renderer) removeFile() {
+	if r.mode != OutputFile && r.mode != OutputBoth {
+		return
+	}
+	if err := os.Remove(r.filePath); err != nil && !os.IsNotExist(err) {
+		r.log.Warn("Failed to remove QR code file", "path", r.filePath, "error", err)
+	}
+}
+
+// Render outputs a single QR code.
+func (r *Renderer) Render(code string) {
+	writeFile := r.mode == OutputFile || r.mode == OutputBoth
+	writeTerm := r.mode == OutputStderr || r.mode == OutputBoth
+
+	if !writeFile && !writeTerm {
+		r.log.Info("QR code received but QR output is disabled")
+		return
+	}
+
+	if writeFile {
+		if err := qrcode.WriteFile(code, qrcode.Medium, 256, r.filePath); err != nil {
+			r.log.Error("Failed to save QR code to file", "error", err)
+		} else {
+			r.log.Info("QR code saved to file - open this file to scan", "path", r.filePath)
+			if writeTerm {
+				fmt.Fprintf(r.term, "\n╔══════════════════════════════════════════════════════╗\n")
+				fmt.Fprintf(r.term, "║  QR CODE SAVED - Open this file to scan with phone:  ║\n")
+				fmt.Fprintf(r.term, "║  %s\n", r.filePath)
+				fmt.Fprintf(r.term, "╚══════════════════════════════════════════════════════╝\n\n")
+			}
+		}
+	}
+
+	if writeTerm {
+		fmt.Fprintln(r.term, "╔══════════════════════════════════════════╗")
+		fmt.Fprintln(r.term, "║  Scan this QR code with WhatsApp Mobile  ║")
+		fmt.Fprintln(r.term, "╚══════════════════════════════════════════╝")
+		qrterminal.GenerateHalfBlock(code, qrterminal.L, r.term)
+		fmt.Fprintln(r.term, "")
+	}
+}
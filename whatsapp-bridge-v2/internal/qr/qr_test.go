@@ -0,0 +1,87 @@
+package qr
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestRenderer_FileMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "qrcode.png")
+	var term bytes.Buffer
+
+	NewRenderer(OutputFile, path, &term, testLogger()).Render("2@test-qr-code")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data, []byte("\x89PNG")), "expected a PNG file")
+	assert.Empty(t, term.String(), "file mode must not write to the terminal")
+}
+
+func TestRenderer_StderrMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "qrcode.png")
+	var term bytes.Buffer
+
+	NewRenderer(OutputStderr, path, &term, testLogger()).Render("2@test-qr-code")
+
+	assert.NotEmpty(t, term.String())
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "stderr mode must not write the PNG")
+}
+
+func TestRenderer_BothMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "qrcode.png")
+	var term bytes.Buffer
+
+	NewRenderer(OutputBoth, path, &term, testLogger()).Render("2@test-qr-code")
+
+	assert.Contains(t, term.String(), path)
+	_, err := os.Stat(path)
+	assert.NoError(t, err)
+}
+
+func TestRenderer_NoneMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "qrcode.png")
+	var term bytes.Buffer
+
+	NewRenderer(OutputNone, path, &term, testLogger()).Render("2@test-qr-code")
+
+	assert.Empty(t, term.String())
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRenderer_RunRemovesFileWhenChannelCloses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "qrcode.png")
+	codes := make(chan string, 2)
+	codes <- "2@first-code"
+	codes <- "2@second-code"
+	close(codes)
+
+	done := make(chan struct{})
+	go func() {
+		NewRenderer(OutputFile, path, io.Discard, testLogger()).Run(context.Background(), codes)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the channel closed")
+	}
+
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "QR file should be removed after pairing")
+}
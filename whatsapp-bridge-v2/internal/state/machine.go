@@ -139,6 +139,28 @@ func (m *Machine) Fire(ctx context.Context, trigger Trigger, args ...any) error
 	return m.sm.FireCtx(ctx, trigger, args...)
 }
 
+// FireWithError triggers a state transition caused by err. The error is made
+// available to OnTransition callbacks through TransitionError.
+func (m *Machine) FireWithError(ctx context.Context, trigger Trigger, cause error, args ...any) error {
+	return m.Fire(WithTransitionError(ctx, cause), trigger, args...)
+}
+
+type transitionErrorKey struct{}
+
+// WithTransitionError returns a context carrying the cause of a transition.
+func WithTransitionError(ctx context.Context, err error) context.Context {
+	if err == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, transitionErrorKey{}, err)
+}
+
+// TransitionError returns the cause attached with WithTransitionError, if any.
+func TransitionError(ctx context.Context) error {
+	err, _ := ctx.Value(transitionErrorKey{}).(error)
+	return err
+}
+
 // CanFire returns true if the trigger can be fired from the current state.
 func (m *Machine) CanFire(ctx context.Context, trigger Trigger, args ...any) (bool, error) {
 	return m.sm.CanFireCtx(ctx, trigger, args...)
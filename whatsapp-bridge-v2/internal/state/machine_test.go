@@ -2,6 +2,7 @@ package state
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -358,3 +359,20 @@ func TestMachine_OnTransitionCallback(t *testing.T) {
 	assert.Equal(t, StateConnecting, transitions[0].to)
 	assert.Equal(t, TriggerConnect, transitions[0].trigger)
 }
+
+func TestMachine_FireWithError(t *testing.T) {
+	ctx := context.Background()
+	m := NewMachine()
+
+	var got error
+	m.OnTransition(func(ctx context.Context, from, to State, trigger Trigger) {
+		got = TransitionError(ctx)
+	})
+
+	require.NoError(t, m.Fire(ctx, TriggerConnect))
+	assert.NoError(t, got)
+
+	cause := errors.New("boom")
+	require.NoError(t, m.FireWithError(ctx, TriggerFatalError, cause))
+	assert.Equal(t, cause, got)
+}
@@ -0,0 +1,274 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migration is one numbered schema change. Migrations are applied in
+// version order, each in its own transaction, and recorded in
+// schema_migrations so that every step runs exactly once per database.
+// Append new steps to the end of migrations; never edit or renumber a step
+// that has shipped.
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+var migrations = []migration{
+	{1, "initial schema", schemaV1},
+	{2, "audit log", schemaV2AuditLog},
+	{3, "message thumbnails", "ALTER TABLE messages ADD COLUMN thumbnail BLOB"},
+	{4, "failed message stores", schemaV4FailedStores},
+	{5, "invite link fetch time", "ALTER TABLE groups ADD COLUMN invite_link_at TIMESTAMP"},
+	{6, "message kind", "ALTER TABLE messages ADD COLUMN kind TEXT NOT NULL DEFAULT 'user'"},
+	{7, "downloaded media path", "ALTER TABLE messages ADD COLUMN local_path TEXT NOT NULL DEFAULT ''"},
+	{8, "pending media index", "CREATE INDEX IF NOT EXISTS idx_messages_pending_media ON messages(timestamp DESC) WHERE media_type != '' AND local_path = ''"},
+	{9, "media download keys", "ALTER TABLE messages ADD COLUMN direct_path TEXT NOT NULL DEFAULT ''; ALTER TABLE messages ADD COLUMN file_enc_sha256 BLOB"},
+}
+
+func runMigrations(db *sql.DB) error {
+	return applyMigrations(db, migrations)
+}
+
+// applyMigrations brings db up to the last version in steps. Databases
+// created before schema_migrations existed already have the version 1
+// tables; its statements are all IF NOT EXISTS, so they simply get
+// recorded as version 1.
+func applyMigrations(db *sql.DB, steps []migration) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL DEFAULT '',
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	prev := 0
+	for _, m := range steps {
+		if m.version <= prev {
+			return fmt.Errorf("migration %d (%s) is out of order", m.version, m.name)
+		}
+		prev = m.version
+	}
+
+	var current int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for _, m := range steps {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.sql); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.version, m.name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+const schemaV1 = `
+-- Chats table
+CREATE TABLE IF NOT EXISTS chats (
+	jid TEXT PRIMARY KEY,
+	name TEXT NOT NULL DEFAULT '',
+	is_group BOOLEAN NOT NULL DEFAULT FALSE,
+	last_message_time TIMESTAMP,
+	unread_count INTEGER NOT NULL DEFAULT 0,
+	archived BOOLEAN NOT NULL DEFAULT FALSE,
+	pinned BOOLEAN NOT NULL DEFAULT FALSE,
+	muted BOOLEAN NOT NULL DEFAULT FALSE,
+	muted_until TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_chats_last_message ON chats(last_message_time DESC);
+
+-- Messages table
+CREATE TABLE IF NOT EXISTS messages (
+	id TEXT NOT NULL,
+	chat_jid TEXT NOT NULL,
+	sender TEXT NOT NULL,
+	content TEXT NOT NULL DEFAULT '',
+	timestamp TIMESTAMP NOT NULL,
+	is_from_me BOOLEAN NOT NULL DEFAULT FALSE,
+	media_type TEXT NOT NULL DEFAULT '',
+	filename TEXT NOT NULL DEFAULT '',
+	media_url TEXT NOT NULL DEFAULT '',
+	media_key BLOB,
+	file_sha256 BLOB,
+	file_length INTEGER NOT NULL DEFAULT 0,
+	quoted_id TEXT NOT NULL DEFAULT '',
+	quoted_sender TEXT NOT NULL DEFAULT '',
+	is_starred BOOLEAN NOT NULL DEFAULT FALSE,
+	is_deleted BOOLEAN NOT NULL DEFAULT FALSE,
+	reactions TEXT NOT NULL DEFAULT '[]',
+	PRIMARY KEY (id, chat_jid),
+	FOREIGN KEY (chat_jid) REFERENCES chats(jid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_messages_chat_timestamp ON messages(chat_jid, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_messages_starred ON messages(is_starred) WHERE is_starred = TRUE;
+
+-- Contacts table
+CREATE TABLE IF NOT EXISTS contacts (
+	jid TEXT PRIMARY KEY,
+	name TEXT NOT NULL DEFAULT '',
+	push_name TEXT NOT NULL DEFAULT '',
+	phone TEXT NOT NULL DEFAULT '',
+	business_name TEXT NOT NULL DEFAULT '',
+	blocked BOOLEAN NOT NULL DEFAULT FALSE,
+	is_saved BOOLEAN NOT NULL DEFAULT FALSE,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_contacts_blocked ON contacts(blocked) WHERE blocked = TRUE;
+
+-- Groups table
+CREATE TABLE IF NOT EXISTS groups (
+	jid TEXT PRIMARY KEY,
+	name TEXT NOT NULL DEFAULT '',
+	topic TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP,
+	created_by TEXT NOT NULL DEFAULT '',
+	invite_link TEXT NOT NULL DEFAULT '',
+	is_announce BOOLEAN NOT NULL DEFAULT FALSE,
+	is_locked BOOLEAN NOT NULL DEFAULT FALSE,
+	participant_count INTEGER NOT NULL DEFAULT 0,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Group participants table
+CREATE TABLE IF NOT EXISTS group_participants (
+	group_jid TEXT NOT NULL,
+	user_jid TEXT NOT NULL,
+	role TEXT NOT NULL DEFAULT 'member',
+	joined_at TIMESTAMP,
+	PRIMARY KEY (group_jid, user_jid),
+	FOREIGN KEY (group_jid) REFERENCES groups(jid) ON DELETE CASCADE
+);
+
+-- Status updates table
+CREATE TABLE IF NOT EXISTS status_updates (
+	id TEXT PRIMARY KEY,
+	sender_jid TEXT NOT NULL,
+	media_type TEXT NOT NULL DEFAULT '',
+	content TEXT NOT NULL DEFAULT '',
+	posted_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	viewed BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_sender ON status_updates(sender_jid);
+CREATE INDEX IF NOT EXISTS idx_status_expires ON status_updates(expires_at);
+
+-- Business labels table
+CREATE TABLE IF NOT EXISTS labels (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL DEFAULT '',
+	color INTEGER NOT NULL DEFAULT 0,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Label associations may sync before the label itself, so no foreign key
+CREATE TABLE IF NOT EXISTS chat_labels (
+	chat_jid TEXT NOT NULL,
+	label_id TEXT NOT NULL,
+	PRIMARY KEY (chat_jid, label_id)
+);
+
+-- Presence table, only filled for contacts we subscribed to
+CREATE TABLE IF NOT EXISTS presence (
+	jid TEXT PRIMARY KEY,
+	online BOOLEAN NOT NULL DEFAULT FALSE,
+	last_seen TIMESTAMP,
+	composing BOOLEAN NOT NULL DEFAULT FALSE,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- State table
+CREATE TABLE IF NOT EXISTS bridge_state (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	state TEXT NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+
+INSERT OR IGNORE INTO bridge_state (id, state, updated_at)
+VALUES (1, 'disconnected', CURRENT_TIMESTAMP);
+
+-- Transitions history table
+CREATE TABLE IF NOT EXISTS transitions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	from_state TEXT NOT NULL,
+	to_state TEXT NOT NULL,
+	trigger TEXT NOT NULL,
+	timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	error TEXT NOT NULL DEFAULT ''
+);
+`
+
+// schemaV2AuditLog adds the audit log of mutating tool calls. Triggers keep
+// it append-only.
+const schemaV2AuditLog = `
+CREATE TABLE IF NOT EXISTS audit_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	tool TEXT NOT NULL,
+	target_jid TEXT NOT NULL DEFAULT '',
+	timestamp TIMESTAMP NOT NULL,
+	success BOOLEAN NOT NULL,
+	error_code TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_timestamp ON audit_log(timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_log_tool ON audit_log(tool);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+	SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+	SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+`
+
+// schemaV4FailedStores adds the dead-letter table for received messages that
+// could not be stored. It has no foreign key on chats, since a missing chat
+// is one of the failures it has to hold.
+const schemaV4FailedStores = `
+CREATE TABLE IF NOT EXISTS failed_stores (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	message_id TEXT NOT NULL,
+	chat_jid TEXT NOT NULL,
+	sender TEXT NOT NULL DEFAULT '',
+	content TEXT NOT NULL DEFAULT '',
+	timestamp TIMESTAMP NOT NULL,
+	is_from_me BOOLEAN NOT NULL DEFAULT 0,
+	media_type TEXT NOT NULL DEFAULT '',
+	thumbnail BLOB,
+	error TEXT NOT NULL DEFAULT '',
+	attempts INTEGER NOT NULL DEFAULT 1,
+	failed_at TIMESTAMP NOT NULL
+);
+`
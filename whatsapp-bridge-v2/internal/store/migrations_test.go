@@ -0,0 +1,93 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openRawDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func schemaVersion(t *testing.T, db *sql.DB) (version, rows int) {
+	require.NoError(t, db.QueryRow("SELECT COALESCE(MAX(version), 0), COUNT(*) FROM schema_migrations").Scan(&version, &rows))
+	return version, rows
+}
+
+func TestMigrations_RerunIsNoOp(t *testing.T) {
+	db := openRawDB(t)
+
+	require.NoError(t, runMigrations(db))
+	version, rows := schemaVersion(t, db)
+	assert.Equal(t, migrations[len(migrations)-1].version, version)
+	assert.Equal(t, len(migrations), rows)
+
+	require.NoError(t, runMigrations(db))
+	version2, rows2 := schemaVersion(t, db)
+	assert.Equal(t, version, version2)
+	assert.Equal(t, rows, rows2)
+}
+
+func TestMigrations_NewStepAppliedOnce(t *testing.T) {
+	db := openRawDB(t)
+	require.NoError(t, runMigrations(db))
+
+	// ADD COLUMN fails if it runs twice, so a second application would error
+	next := migrations[len(migrations)-1].version + 1
+	steps := append(append([]migration{}, migrations...), migration{
+		version: next,
+		name:    "add chats.test_note",
+		sql:     "ALTER TABLE chats ADD COLUMN test_note TEXT NOT NULL DEFAULT ''",
+	})
+
+	require.NoError(t, applyMigrations(db, steps))
+	require.NoError(t, applyMigrations(db, steps))
+
+	version, rows := schemaVersion(t, db)
+	assert.Equal(t, next, version)
+	assert.Equal(t, len(steps), rows)
+
+	_, err := db.Exec("UPDATE chats SET test_note = 'ok'")
+	assert.NoError(t, err)
+}
+
+func TestMigrations_FailedStepRollsBack(t *testing.T) {
+	db := openRawDB(t)
+	require.NoError(t, runMigrations(db))
+
+	next := migrations[len(migrations)-1].version + 1
+	steps := append(append([]migration{}, migrations...), migration{
+		version: next,
+		name:    "broken",
+		sql:     "CREATE TABLE half_done (id INTEGER); SELECT * FROM no_such_table",
+	})
+
+	err := applyMigrations(db, steps)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broken")
+
+	version, _ := schemaVersion(t, db)
+	assert.Equal(t, next-1, version)
+	_, err = db.Exec("SELECT * FROM half_done")
+	assert.Error(t, err, "the failed step's table must be rolled back")
+}
+
+func TestMigrations_OutOfOrder(t *testing.T) {
+	db := openRawDB(t)
+	err := applyMigrations(db, []migration{
+		{2, "second", "SELECT 1"},
+		{1, "first", "SELECT 1"},
+	})
+	assert.ErrorContains(t, err, "out of order")
+
+	version, rows := schemaVersion(t, db)
+	assert.Zero(t, version)
+	assert.Zero(t, rows, "nothing is applied when the list is out of order")
+}
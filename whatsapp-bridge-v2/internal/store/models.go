@@ -7,25 +7,47 @@ import (
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/state"
 )
 
-// Message represents a WhatsApp message.
+// Message represents a WhatsApp message. Sender is "me" for our own
+// messages, the participant in group chats and the chat itself in direct
+// chats, always as a JID without a device suffix.
 type Message struct {
-	ID           string    `json:"id"`
-	ChatJID      string    `json:"chat_jid"`
-	Sender       string    `json:"sender"`
-	Content      string    `json:"content"`
-	Timestamp    time.Time `json:"timestamp"`
-	IsFromMe     bool      `json:"is_from_me"`
-	MediaType    string    `json:"media_type,omitempty"`
-	Filename     string    `json:"filename,omitempty"`
-	MediaURL     string    `json:"media_url,omitempty"`
-	MediaKey     []byte    `json:"-"`
-	FileSHA256   []byte    `json:"-"`
-	FileLength   uint64    `json:"file_length,omitempty"`
-	QuotedID     string    `json:"quoted_id,omitempty"`
-	QuotedSender string    `json:"quoted_sender,omitempty"`
-	IsStarred    bool      `json:"is_starred"`
-	IsDeleted    bool      `json:"is_deleted"`
-	Reactions    []string  `json:"reactions,omitempty"`
+	ID            string    `json:"id"`
+	ChatJID       string    `json:"chat_jid"`
+	Sender        string    `json:"sender"`
+	SenderName    string    `json:"sender_name,omitempty"` // from contacts; set by List and Search only
+	Content       string    `json:"content"`
+	Kind          string    `json:"kind"` // MessageKindUser or MessageKindSystem
+	Timestamp     time.Time `json:"timestamp"`
+	IsFromMe      bool      `json:"is_from_me"`
+	MediaType     string    `json:"media_type,omitempty"`
+	Filename      string    `json:"filename,omitempty"`
+	MediaURL      string    `json:"media_url,omitempty"`
+	DirectPath    string    `json:"-"`
+	MediaKey      []byte    `json:"-"`
+	FileSHA256    []byte    `json:"-"`
+	FileEncSHA256 []byte    `json:"-"`
+	FileLength    uint64    `json:"file_length,omitempty"`
+	Thumbnail     []byte    `json:"-"` // JPEG preview sent with media; read it with Thumbnail
+	QuotedID      string    `json:"quoted_id,omitempty"`
+	QuotedSender  string    `json:"quoted_sender,omitempty"`
+	IsStarred     bool      `json:"is_starred"`
+	IsDeleted     bool      `json:"is_deleted"`
+	Reactions     []string  `json:"reactions,omitempty"`
+}
+
+// Message kinds. System messages are notices such as group membership
+// changes; their Content is a description like "Alice added Bob".
+const (
+	MessageKindUser   = "user"
+	MessageKindSystem = "system"
+)
+
+// Reaction is a single emoji reaction on a message. Each sender holds at most
+// one reaction per message; reacting again replaces it.
+type Reaction struct {
+	Sender    string    `json:"sender"`
+	Emoji     string    `json:"emoji"`
+	ReactedAt time.Time `json:"reacted_at"`
 }
 
 // Chat represents a WhatsApp chat.
@@ -42,6 +64,10 @@ type Chat struct {
 	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
+// UnreadMarked is the UnreadCount of a chat that was marked as unread by
+// hand rather than having unread messages, as WhatsApp itself records it.
+const UnreadMarked = -1
+
 // Contact represents a WhatsApp contact.
 type Contact struct {
 	JID          string    `json:"jid"`
@@ -54,6 +80,48 @@ type Contact struct {
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// ContactWithChat is a contact together with its direct chat, if any.
+type ContactWithChat struct {
+	Contact
+	HasChat         bool       `json:"has_chat"`
+	LastMessageTime *time.Time `json:"last_message_time,omitempty"`
+}
+
+// MessageStatus describes a stored message without its content.
+type MessageStatus struct {
+	IsFromMe  bool `json:"is_from_me"`
+	HasMedia  bool `json:"has_media"`
+	IsDeleted bool `json:"is_deleted"`
+}
+
+// PendingMedia is a media message whose file has not been downloaded.
+type PendingMedia struct {
+	ChatJID    string    `json:"chat_jid"`
+	MessageID  string    `json:"message_id"`
+	Sender     string    `json:"sender"`
+	MediaType  string    `json:"media_type"`
+	Filename   string    `json:"filename,omitempty"`
+	FileLength uint64    `json:"file_length"` // bytes, 0 when unknown
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// ChatStats summarizes the stored messages of a chat.
+type ChatStats struct {
+	ChatJID      string         `json:"chat_jid"`
+	MessageCount int            `json:"message_count"`
+	ByMediaType  map[string]int `json:"by_media_type"` // "text" for messages without media
+	BySender     []SenderCount  `json:"by_sender"`     // most active first
+	FirstMessage *time.Time     `json:"first_message,omitempty"`
+	LastMessage  *time.Time     `json:"last_message,omitempty"`
+}
+
+// SenderCount is the number of messages one sender has in a chat.
+type SenderCount struct {
+	Sender string `json:"sender"`
+	Name   string `json:"name,omitempty"`
+	Count  int    `json:"count"`
+}
+
 // Group represents a WhatsApp group.
 type Group struct {
 	JID               string    `json:"jid"`
@@ -87,6 +155,24 @@ type StatusUpdate struct {
 	Viewed    bool      `json:"viewed"`
 }
 
+// Label represents a WhatsApp Business chat label.
+type Label struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Color     int32     `json:"color"`
+	ChatJIDs  []string  `json:"chat_jids,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Presence is the last known online and typing state of a contact.
+type Presence struct {
+	JID       string     `json:"jid"`
+	Online    bool       `json:"online"`
+	LastSeen  *time.Time `json:"last_seen,omitempty"`
+	Composing bool       `json:"composing"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
 // Transition represents a state machine transition record.
 type Transition struct {
 	ID        int64       `json:"id"`
@@ -97,6 +183,27 @@ type Transition struct {
 	Error     string      `json:"error,omitempty"`
 }
 
+// AuditEntry records one mutating tool call. It never holds message
+// content; ErrorCode is the MCP error code of a failed call.
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	Tool      string    `json:"tool"`
+	TargetJID string    `json:"target_jid,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Success   bool      `json:"success"`
+	ErrorCode string    `json:"error_code,omitempty"`
+}
+
+// FailedStore is a received message that could not be stored, kept with the
+// error so it can be stored again later.
+type FailedStore struct {
+	ID       int64     `json:"id"`
+	Message  Message   `json:"message"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
 // Session represents the bridge session state.
 type Session struct {
 	ID        int64
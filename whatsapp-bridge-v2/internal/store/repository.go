@@ -18,26 +18,92 @@ type QueryOpts struct {
 	Before string // For cursor-based pagination
 }
 
+// Message directions accepted by MessageRepository.List.
+const (
+	DirectionAll      = "all"
+	DirectionIncoming = "incoming"
+	DirectionOutgoing = "outgoing"
+)
+
+// TransitionFilter narrows a transition history query.
+type TransitionFilter struct {
+	Limit int
+	Since time.Time   // Zero means no lower bound
+	State state.State // Matches the state entered (to_state); empty means any
+}
+
+// AuditFilter narrows an audit log query.
+type AuditFilter struct {
+	Limit int
+	Since time.Time // Zero means no lower bound
+	Until time.Time // Zero means no upper bound
+	Tool  string    // Empty means any tool
+}
+
+// Store is a storage backend as the bridge and the API handler see it: its
+// repositories behind their interfaces, plus the backend's housekeeping.
+// SQLiteStore provides one with Store.
+type Store struct {
+	Messages    MessageRepository
+	Chats       ChatRepository
+	Contacts    ContactRepository
+	Groups      GroupRepository
+	Status      StatusRepository
+	Labels      LabelRepository
+	Presence    PresenceRepository
+	State       StateRepository
+	Audit       AuditRepository
+	DeadLetters DeadLetterRepository
+
+	Backend
+}
+
+// Backend is the housekeeping a storage backend provides besides its
+// repositories.
+type Backend interface {
+	// Ping confirms the backend is reachable.
+	Ping(ctx context.Context) error
+	// Checkpoint flushes pending writes to durable storage before shutdown.
+	Checkpoint(ctx context.Context) error
+	Close() error
+}
+
 // MessageRepository defines operations for message persistence.
 type MessageRepository interface {
 	Store(ctx context.Context, msg *Message) error
-	List(ctx context.Context, chatJID string, limit int, before string) ([]Message, error)
+	List(ctx context.Context, chatJID string, limit int, before, direction string) ([]Message, error)
 	GetByID(ctx context.Context, chatJID, msgID string) (*Message, error)
+	Thumbnail(ctx context.Context, chatJID, msgID string) ([]byte, error)
+	Status(ctx context.Context, chatJID, msgID string) (*MessageStatus, error)
+	Media(ctx context.Context, chatJID, msgID string) (*Message, error)
+	SetLocalPath(ctx context.Context, chatJID, msgID, path string) error
+	ListPendingMedia(ctx context.Context, chatJID string, limit int) ([]PendingMedia, error)
+	Oldest(ctx context.Context, chatJID string) (*Message, error)
+	LatestReceived(ctx context.Context) (time.Time, error)
 	Search(ctx context.Context, query string, limit int) ([]Message, error)
+	SearchInChat(ctx context.Context, chatJID, query string, limit int) ([]Message, error)
 	SetStarred(ctx context.Context, chatJID, msgID string, starred bool) error
+	SetReaction(ctx context.Context, chatJID, msgID string, reaction Reaction) error
+	GetReactions(ctx context.Context, chatJID, msgID string) ([]Reaction, error)
 	Delete(ctx context.Context, chatJID, msgID string) error
 	Count(ctx context.Context, chatJID string) (int, error)
+	Stats(ctx context.Context, chatJID string) (*ChatStats, error)
 }
 
 // ChatRepository defines operations for chat persistence.
 type ChatRepository interface {
 	Upsert(ctx context.Context, chat *Chat) error
 	List(ctx context.Context, limit int) ([]Chat, error)
+	ListUnread(ctx context.Context, limit int) ([]Chat, error)
 	GetByJID(ctx context.Context, jid string) (*Chat, error)
 	UpdateLastMessage(ctx context.Context, jid string, t time.Time) error
 	Archive(ctx context.Context, jid string, archived bool) error
 	Pin(ctx context.Context, jid string, pinned bool) error
+	CountPinned(ctx context.Context) (int, error)
 	Mute(ctx context.Context, jid string, muted bool, until *time.Time) error
+	IncrementUnread(ctx context.Context, jid string) error
+	ResetUnread(ctx context.Context, jid string) error
+	MarkUnread(ctx context.Context, jid string) error
 	Delete(ctx context.Context, jid string) error
 	Count(ctx context.Context) (int, error)
 }
@@ -45,8 +111,13 @@ type ChatRepository interface {
 // ContactRepository defines operations for contact persistence.
 type ContactRepository interface {
 	Upsert(ctx context.Context, contact *Contact) error
+	UpsertPushName(ctx context.Context, jid, pushName string) error
+	UpsertBusinessName(ctx context.Context, jid, businessName string) error
+	UpsertSavedName(ctx context.Context, jid, name string) error
 	Search(ctx context.Context, query string, limit int) ([]Contact, error)
 	GetByJID(ctx context.Context, jid string) (*Contact, error)
+	List(ctx context.Context, limit, offset int) ([]Contact, error)
+	ListWithChats(ctx context.Context, limit, offset int) ([]ContactWithChat, error)
 	Block(ctx context.Context, jid string, blocked bool) error
 	GetBlocked(ctx context.Context) ([]Contact, error)
 	Delete(ctx context.Context, jid string) error
@@ -57,8 +128,13 @@ type ContactRepository interface {
 type GroupRepository interface {
 	Upsert(ctx context.Context, group *Group) error
 	GetByJID(ctx context.Context, jid string) (*Group, error)
+	SetAnnounce(ctx context.Context, jid string, announce bool) error
+	SetLocked(ctx context.Context, jid string, locked bool) error
+	SetInviteLink(ctx context.Context, jid, link string, fetchedAt time.Time) error
+	GetInviteLink(ctx context.Context, jid string) (string, time.Time, error)
 	UpdateParticipants(ctx context.Context, groupJID string, participants []GroupParticipant) error
 	GetParticipants(ctx context.Context, groupJID string) ([]GroupParticipant, error)
+	ListCommon(ctx context.Context, userJID string) ([]Group, error)
 	Delete(ctx context.Context, jid string) error
 }
 
@@ -67,14 +143,48 @@ type StatusRepository interface {
 	Store(ctx context.Context, status *StatusUpdate) error
 	GetAll(ctx context.Context) ([]StatusUpdate, error)
 	GetByContact(ctx context.Context, contactJID string) ([]StatusUpdate, error)
+	GetByID(ctx context.Context, statusID string) (*StatusUpdate, error)
 	Delete(ctx context.Context, statusID string) error
 	DeleteExpired(ctx context.Context) error
 }
 
+// LabelRepository defines operations for business label persistence.
+type LabelRepository interface {
+	Upsert(ctx context.Context, label *Label) error
+	List(ctx context.Context) ([]Label, error)
+	Delete(ctx context.Context, id string) error
+	SetChatLabel(ctx context.Context, chatJID, labelID string, labeled bool) error
+}
+
+// PresenceRepository defines operations for contact presence persistence.
+type PresenceRepository interface {
+	SetOnline(ctx context.Context, jid string, online bool, lastSeen time.Time) error
+	SetComposing(ctx context.Context, jid string, composing bool) error
+	Get(ctx context.Context, jid string) (*Presence, error)
+}
+
 // StateRepository defines operations for state persistence.
 type StateRepository interface {
 	GetState(ctx context.Context) (state.State, error)
 	SaveState(ctx context.Context, s state.State) error
 	LogTransition(ctx context.Context, from, to state.State, trigger string) error
+	LogTransitionWithError(ctx context.Context, from, to state.State, trigger, errText string) error
 	GetTransitionHistory(ctx context.Context, limit int) ([]Transition, error)
+	ListTransitions(ctx context.Context, filter TransitionFilter) ([]Transition, error)
+}
+
+// AuditRepository defines operations for the append-only audit log.
+type AuditRepository interface {
+	Append(ctx context.Context, entry *AuditEntry) error
+	List(ctx context.Context, filter AuditFilter) ([]AuditEntry, error)
+}
+
+// DeadLetterRepository holds received messages whose store failed, so they
+// are not lost when the database is briefly unavailable.
+type DeadLetterRepository interface {
+	Add(ctx context.Context, msg *Message, storeErr error) error
+	List(ctx context.Context, limit int) ([]FailedStore, error)
+	RecordAttempt(ctx context.Context, id int64, storeErr error) error
+	Delete(ctx context.Context, id int64) error
+	Count(ctx context.Context) (int, error)
 }
@@ -3,7 +3,9 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -12,211 +14,254 @@ import (
 )
 
 // SQLiteStore implements all repositories using SQLite.
+// The message, chat and contact repositories run their queries on the
+// read-only pool ro and their writes on the single writer connection db.
 type SQLiteStore struct {
-	db       *sql.DB
-	Messages *SQLiteMessageRepo
-	Chats    *SQLiteChatRepo
-	Contacts *SQLiteContactRepo
-	Groups   *SQLiteGroupRepo
-	Status   *SQLiteStatusRepo
-	State    *SQLiteStateRepo
+	db          *sql.DB
+	ro          *sql.DB
+	Messages    *SQLiteMessageRepo
+	Chats       *SQLiteChatRepo
+	Contacts    *SQLiteContactRepo
+	Groups      *SQLiteGroupRepo
+	Status      *SQLiteStatusRepo
+	Labels      *SQLiteLabelRepo
+	Presence    *SQLitePresenceRepo
+	State       *SQLiteStateRepo
+	Audit       *SQLiteAuditRepo
+	DeadLetters *SQLiteDeadLetterRepo
 }
 
-// NewSQLiteStore creates a new SQLite-backed store.
+// The SQLite repositories implement the repository interfaces.
+var (
+	_ MessageRepository    = (*SQLiteMessageRepo)(nil)
+	_ ChatRepository       = (*SQLiteChatRepo)(nil)
+	_ ContactRepository    = (*SQLiteContactRepo)(nil)
+	_ GroupRepository      = (*SQLiteGroupRepo)(nil)
+	_ StatusRepository     = (*SQLiteStatusRepo)(nil)
+	_ LabelRepository      = (*SQLiteLabelRepo)(nil)
+	_ PresenceRepository   = (*SQLitePresenceRepo)(nil)
+	_ StateRepository      = (*SQLiteStateRepo)(nil)
+	_ AuditRepository      = (*SQLiteAuditRepo)(nil)
+	_ DeadLetterRepository = (*SQLiteDeadLetterRepo)(nil)
+	_ Backend              = (*SQLiteStore)(nil)
+)
+
+// Connection defaults.
+const (
+	// DefaultBusyTimeout is how long a write waits on a locked database.
+	DefaultBusyTimeout = 5 * time.Second
+	// DefaultReadConns is the size of the read-only connection pool.
+	DefaultReadConns = 4
+)
+
+// Options tunes the SQLite connections.
+type Options struct {
+	// BusyTimeout is how long SQLite retries a locked database before
+	// returning "database is locked".
+	BusyTimeout time.Duration
+	// ReadConns is the number of read-only connections used by queries.
+	ReadConns int
+}
+
+// NewSQLiteStore creates a new SQLite-backed store with default options.
 func NewSQLiteStore(dsn string) (*SQLiteStore, error) {
-	db, err := sql.Open("sqlite3", dsn+"?_foreign_keys=on&_journal_mode=WAL")
+	return NewSQLiteStoreWithOptions(dsn, Options{BusyTimeout: DefaultBusyTimeout, ReadConns: DefaultReadConns})
+}
+
+// NewSQLiteStoreWithOptions creates a new SQLite-backed store.
+func NewSQLiteStoreWithOptions(dsn string, opts Options) (*SQLiteStore, error) {
+	// _loc=UTC makes timestamps read back in UTC whatever offset they were
+	// written with; the repositories also write them in UTC
+	params := fmt.Sprintf("?_foreign_keys=on&_journal_mode=WAL&_loc=UTC&_busy_timeout=%d", opts.BusyTimeout.Milliseconds())
+	db, err := sql.Open("sqlite3", dsn+params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	// SQLite allows a single writer, so funnel everything through one
+	// connection instead of letting pooled connections fight over the lock.
+	// This also keeps ":memory:" databases on the connection that migrated them.
+	db.SetMaxOpenConns(1)
+
 	// Run migrations
 	if err := runMigrations(db); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	ro, err := openReader(dsn, db, opts)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open read-only database: %w", err)
+	}
+
 	store := &SQLiteStore{
-		db:       db,
-		Messages: &SQLiteMessageRepo{db: db},
-		Chats:    &SQLiteChatRepo{db: db},
-		Contacts: &SQLiteContactRepo{db: db},
-		Groups:   &SQLiteGroupRepo{db: db},
-		Status:   &SQLiteStatusRepo{db: db},
-		State:    &SQLiteStateRepo{db: db},
+		db:          db,
+		ro:          ro,
+		Messages:    &SQLiteMessageRepo{db: db, ro: ro},
+		Chats:       &SQLiteChatRepo{db: db, ro: ro},
+		Contacts:    &SQLiteContactRepo{db: db, ro: ro},
+		Groups:      &SQLiteGroupRepo{db: db},
+		Status:      &SQLiteStatusRepo{db: db},
+		Labels:      &SQLiteLabelRepo{db: db},
+		Presence:    &SQLitePresenceRepo{db: db},
+		State:       &SQLiteStateRepo{db: db},
+		Audit:       &SQLiteAuditRepo{db: db},
+		DeadLetters: &SQLiteDeadLetterRepo{db: db},
 	}
 
 	return store, nil
 }
 
-// Close closes the database connection.
-func (s *SQLiteStore) Close() error {
-	return s.db.Close()
+// Store returns the store's repositories behind their interfaces, for the
+// bridge and the API handler.
+func (s *SQLiteStore) Store() *Store {
+	return &Store{
+		Messages:    s.Messages,
+		Chats:       s.Chats,
+		Contacts:    s.Contacts,
+		Groups:      s.Groups,
+		Status:      s.Status,
+		Labels:      s.Labels,
+		Presence:    s.Presence,
+		State:       s.State,
+		Audit:       s.Audit,
+		DeadLetters: s.DeadLetters,
+		Backend:     s,
+	}
 }
 
-func runMigrations(db *sql.DB) error {
-	migration := `
-	-- Chats table
-	CREATE TABLE IF NOT EXISTS chats (
-		jid TEXT PRIMARY KEY,
-		name TEXT NOT NULL DEFAULT '',
-		is_group BOOLEAN NOT NULL DEFAULT FALSE,
-		last_message_time TIMESTAMP,
-		unread_count INTEGER NOT NULL DEFAULT 0,
-		archived BOOLEAN NOT NULL DEFAULT FALSE,
-		pinned BOOLEAN NOT NULL DEFAULT FALSE,
-		muted BOOLEAN NOT NULL DEFAULT FALSE,
-		muted_until TIMESTAMP,
-		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_chats_last_message ON chats(last_message_time DESC);
-
-	-- Messages table
-	CREATE TABLE IF NOT EXISTS messages (
-		id TEXT NOT NULL,
-		chat_jid TEXT NOT NULL,
-		sender TEXT NOT NULL,
-		content TEXT NOT NULL DEFAULT '',
-		timestamp TIMESTAMP NOT NULL,
-		is_from_me BOOLEAN NOT NULL DEFAULT FALSE,
-		media_type TEXT NOT NULL DEFAULT '',
-		filename TEXT NOT NULL DEFAULT '',
-		media_url TEXT NOT NULL DEFAULT '',
-		media_key BLOB,
-		file_sha256 BLOB,
-		file_length INTEGER NOT NULL DEFAULT 0,
-		quoted_id TEXT NOT NULL DEFAULT '',
-		quoted_sender TEXT NOT NULL DEFAULT '',
-		is_starred BOOLEAN NOT NULL DEFAULT FALSE,
-		is_deleted BOOLEAN NOT NULL DEFAULT FALSE,
-		reactions TEXT NOT NULL DEFAULT '[]',
-		PRIMARY KEY (id, chat_jid),
-		FOREIGN KEY (chat_jid) REFERENCES chats(jid) ON DELETE CASCADE
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_messages_chat_timestamp ON messages(chat_jid, timestamp DESC);
-	CREATE INDEX IF NOT EXISTS idx_messages_starred ON messages(is_starred) WHERE is_starred = TRUE;
-
-	-- Contacts table
-	CREATE TABLE IF NOT EXISTS contacts (
-		jid TEXT PRIMARY KEY,
-		name TEXT NOT NULL DEFAULT '',
-		push_name TEXT NOT NULL DEFAULT '',
-		phone TEXT NOT NULL DEFAULT '',
-		business_name TEXT NOT NULL DEFAULT '',
-		blocked BOOLEAN NOT NULL DEFAULT FALSE,
-		is_saved BOOLEAN NOT NULL DEFAULT FALSE,
-		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_contacts_blocked ON contacts(blocked) WHERE blocked = TRUE;
-
-	-- Groups table
-	CREATE TABLE IF NOT EXISTS groups (
-		jid TEXT PRIMARY KEY,
-		name TEXT NOT NULL DEFAULT '',
-		topic TEXT NOT NULL DEFAULT '',
-		created_at TIMESTAMP,
-		created_by TEXT NOT NULL DEFAULT '',
-		invite_link TEXT NOT NULL DEFAULT '',
-		is_announce BOOLEAN NOT NULL DEFAULT FALSE,
-		is_locked BOOLEAN NOT NULL DEFAULT FALSE,
-		participant_count INTEGER NOT NULL DEFAULT 0,
-		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-	);
-
-	-- Group participants table
-	CREATE TABLE IF NOT EXISTS group_participants (
-		group_jid TEXT NOT NULL,
-		user_jid TEXT NOT NULL,
-		role TEXT NOT NULL DEFAULT 'member',
-		joined_at TIMESTAMP,
-		PRIMARY KEY (group_jid, user_jid),
-		FOREIGN KEY (group_jid) REFERENCES groups(jid) ON DELETE CASCADE
-	);
-
-	-- Status updates table
-	CREATE TABLE IF NOT EXISTS status_updates (
-		id TEXT PRIMARY KEY,
-		sender_jid TEXT NOT NULL,
-		media_type TEXT NOT NULL DEFAULT '',
-		content TEXT NOT NULL DEFAULT '',
-		posted_at TIMESTAMP NOT NULL,
-		expires_at TIMESTAMP NOT NULL,
-		viewed BOOLEAN NOT NULL DEFAULT FALSE
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_status_sender ON status_updates(sender_jid);
-	CREATE INDEX IF NOT EXISTS idx_status_expires ON status_updates(expires_at);
-
-	-- State table
-	CREATE TABLE IF NOT EXISTS bridge_state (
-		id INTEGER PRIMARY KEY CHECK (id = 1),
-		state TEXT NOT NULL,
-		updated_at TIMESTAMP NOT NULL
-	);
-
-	INSERT OR IGNORE INTO bridge_state (id, state, updated_at)
-	VALUES (1, 'disconnected', CURRENT_TIMESTAMP);
-
-	-- Transitions history table
-	CREATE TABLE IF NOT EXISTS transitions (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		from_state TEXT NOT NULL,
-		to_state TEXT NOT NULL,
-		trigger TEXT NOT NULL,
-		timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		error TEXT NOT NULL DEFAULT ''
-	);
-	`
-	_, err := db.Exec(migration)
+// openReader opens a read-only connection pool on the same database so that
+// queries are not queued behind the single writer connection. In WAL mode
+// readers never block the writer or each other. An in-memory database only
+// exists on the writer connection, so it is shared instead.
+func openReader(dsn string, writer *sql.DB, opts Options) (*sql.DB, error) {
+	if dsn == ":memory:" || strings.Contains(dsn, "mode=memory") {
+		return writer, nil
+	}
+
+	if !strings.HasPrefix(dsn, "file:") {
+		dsn = "file:" + dsn
+	}
+	ro, err := sql.Open("sqlite3", fmt.Sprintf("%s?mode=ro&_loc=UTC&_busy_timeout=%d", dsn, opts.BusyTimeout.Milliseconds()))
+	if err != nil {
+		return nil, err
+	}
+
+	conns := opts.ReadConns
+	if conns <= 0 {
+		conns = DefaultReadConns
+	}
+	ro.SetMaxOpenConns(conns)
+	ro.SetMaxIdleConns(conns)
+
+	if err := ro.Ping(); err != nil {
+		ro.Close()
+		return nil, err
+	}
+	return ro, nil
+}
+
+// Ping runs a trivial query on the writer and the reader pool to confirm
+// the database is reachable.
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	var one int
+	if err := s.db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return err
+	}
+	return s.ro.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
+// Checkpoint flushes the WAL into the main database file so that a
+// subsequent open (or a copy of the file) sees all committed data.
+func (s *SQLiteStore) Checkpoint(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)")
 	return err
 }
 
+// Close closes the database connections.
+func (s *SQLiteStore) Close() error {
+	if s.ro != s.db {
+		s.ro.Close()
+	}
+	return s.db.Close()
+}
+
 // SQLiteMessageRepo implements MessageRepository.
 type SQLiteMessageRepo struct {
 	db *sql.DB
+	ro *sql.DB
 }
 
+// Store saves a message, updating it if it is already stored. Updates keep
+// what was recorded about the message since it arrived: its reactions, star
+// and downloaded media path.
 func (r *SQLiteMessageRepo) Store(ctx context.Context, msg *Message) error {
 	query := `
-		INSERT OR REPLACE INTO messages
-		(id, chat_jid, sender, content, timestamp, is_from_me, media_type, filename, media_url, media_key, file_sha256, file_length, quoted_id, quoted_sender, is_starred, is_deleted)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO messages
+		(id, chat_jid, sender, content, kind, timestamp, is_from_me, media_type, filename, media_url, direct_path, media_key, file_sha256, file_enc_sha256, file_length, thumbnail, quoted_id, quoted_sender, is_starred, is_deleted)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id, chat_jid) DO UPDATE SET
+			sender = excluded.sender,
+			content = excluded.content,
+			kind = excluded.kind,
+			timestamp = excluded.timestamp,
+			is_from_me = excluded.is_from_me,
+			media_type = excluded.media_type,
+			filename = excluded.filename,
+			media_url = excluded.media_url,
+			direct_path = excluded.direct_path,
+			media_key = excluded.media_key,
+			file_sha256 = excluded.file_sha256,
+			file_enc_sha256 = excluded.file_enc_sha256,
+			file_length = excluded.file_length,
+			thumbnail = excluded.thumbnail,
+			quoted_id = excluded.quoted_id,
+			quoted_sender = excluded.quoted_sender,
+			is_starred = messages.is_starred OR excluded.is_starred,
+			is_deleted = excluded.is_deleted
 	`
+	kind := msg.Kind
+	if kind == "" {
+		kind = MessageKindUser
+	}
 	_, err := r.db.ExecContext(ctx, query,
-		msg.ID, msg.ChatJID, msg.Sender, msg.Content, msg.Timestamp, msg.IsFromMe,
-		msg.MediaType, msg.Filename, msg.MediaURL, msg.MediaKey, msg.FileSHA256, msg.FileLength,
+		msg.ID, msg.ChatJID, msg.Sender, msg.Content, kind, msg.Timestamp.UTC(), msg.IsFromMe,
+		msg.MediaType, msg.Filename, msg.MediaURL, msg.DirectPath, msg.MediaKey, msg.FileSHA256, msg.FileEncSHA256, msg.FileLength, msg.Thumbnail,
 		msg.QuotedID, msg.QuotedSender, msg.IsStarred, msg.IsDeleted,
 	)
 	return err
 }
 
-func (r *SQLiteMessageRepo) List(ctx context.Context, chatJID string, limit int, before string) ([]Message, error) {
-	var query string
-	var args []interface{}
+// List returns a chat's messages, newest first. direction is one of the
+// Direction constants; an empty string means DirectionAll.
+func (r *SQLiteMessageRepo) List(ctx context.Context, chatJID string, limit int, before, direction string) ([]Message, error) {
+	query := `
+		SELECT ` + messageListColumns + `
+		FROM messages m
+		LEFT JOIN contacts c ON c.jid = m.sender
+		WHERE m.chat_jid = ?`
+	args := []interface{}{chatJID}
 
 	if before != "" {
-		query = `
-			SELECT id, chat_jid, sender, content, timestamp, is_from_me, media_type, filename, media_url, quoted_id, quoted_sender, is_starred, is_deleted
-			FROM messages
-			WHERE chat_jid = ? AND timestamp < (SELECT timestamp FROM messages WHERE id = ? AND chat_jid = ?)
-			ORDER BY timestamp DESC
-			LIMIT ?
-		`
-		args = []interface{}{chatJID, before, chatJID, limit}
-	} else {
-		query = `
-			SELECT id, chat_jid, sender, content, timestamp, is_from_me, media_type, filename, media_url, quoted_id, quoted_sender, is_starred, is_deleted
-			FROM messages
-			WHERE chat_jid = ?
-			ORDER BY timestamp DESC
-			LIMIT ?
-		`
-		args = []interface{}{chatJID, limit}
+		query += " AND m.timestamp < (SELECT timestamp FROM messages WHERE id = ? AND chat_jid = ?)"
+		args = append(args, before, chatJID)
 	}
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	switch direction {
+	case "", DirectionAll:
+	case DirectionIncoming:
+		query += " AND m.is_from_me = FALSE"
+	case DirectionOutgoing:
+		query += " AND m.is_from_me = TRUE"
+	default:
+		return nil, fmt.Errorf("invalid message direction: %q", direction)
+	}
+
+	query += " ORDER BY m.timestamp DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.ro.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -227,15 +272,15 @@ func (r *SQLiteMessageRepo) List(ctx context.Context, chatJID string, limit int,
 
 func (r *SQLiteMessageRepo) GetByID(ctx context.Context, chatJID, msgID string) (*Message, error) {
 	query := `
-		SELECT id, chat_jid, sender, content, timestamp, is_from_me, media_type, filename, media_url, quoted_id, quoted_sender, is_starred, is_deleted
+		SELECT id, chat_jid, sender, content, kind, timestamp, is_from_me, media_type, filename, media_url, quoted_id, quoted_sender, is_starred, is_deleted
 		FROM messages
 		WHERE chat_jid = ? AND id = ?
 	`
-	row := r.db.QueryRowContext(ctx, query, chatJID, msgID)
+	row := r.ro.QueryRowContext(ctx, query, chatJID, msgID)
 
 	var msg Message
 	err := row.Scan(
-		&msg.ID, &msg.ChatJID, &msg.Sender, &msg.Content, &msg.Timestamp, &msg.IsFromMe,
+		&msg.ID, &msg.ChatJID, &msg.Sender, &msg.Content, &msg.Kind, &msg.Timestamp, &msg.IsFromMe,
 		&msg.MediaType, &msg.Filename, &msg.MediaURL, &msg.QuotedID, &msg.QuotedSender, &msg.IsStarred, &msg.IsDeleted,
 	)
 	if err == sql.ErrNoRows {
@@ -247,15 +292,165 @@ func (r *SQLiteMessageRepo) GetByID(ctx context.Context, chatJID, msgID string)
 	return &msg, nil
 }
 
+// Thumbnail returns the stored JPEG preview of a media message, or nil if
+// it came without one. It returns ErrNotFound for an unknown message.
+func (r *SQLiteMessageRepo) Thumbnail(ctx context.Context, chatJID, msgID string) ([]byte, error) {
+	var thumb []byte
+	err := r.ro.QueryRowContext(ctx, "SELECT thumbnail FROM messages WHERE chat_jid = ? AND id = ?", chatJID, msgID).Scan(&thumb)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return thumb, err
+}
+
+// Status reports whether a message is stored and a few of its flags,
+// without reading its content or thumbnail.
+func (r *SQLiteMessageRepo) Status(ctx context.Context, chatJID, msgID string) (*MessageStatus, error) {
+	var status MessageStatus
+	err := r.ro.QueryRowContext(ctx,
+		"SELECT is_from_me, media_type != '', is_deleted FROM messages WHERE chat_jid = ? AND id = ?",
+		chatJID, msgID,
+	).Scan(&status.IsFromMe, &status.HasMedia, &status.IsDeleted)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Media returns what is needed to download a message's media: its type,
+// file name, size and the keys from the original message. It returns
+// ErrNotFound for an unknown message.
+func (r *SQLiteMessageRepo) Media(ctx context.Context, chatJID, msgID string) (*Message, error) {
+	msg := Message{ID: msgID, ChatJID: chatJID}
+	err := r.ro.QueryRowContext(ctx,
+		"SELECT media_type, filename, media_url, direct_path, media_key, file_sha256, file_enc_sha256, file_length FROM messages WHERE chat_jid = ? AND id = ?",
+		chatJID, msgID,
+	).Scan(&msg.MediaType, &msg.Filename, &msg.MediaURL, &msg.DirectPath, &msg.MediaKey, &msg.FileSHA256, &msg.FileEncSHA256, &msg.FileLength)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// SetLocalPath records where a message's media was downloaded to. It
+// returns ErrNotFound if the message is not stored.
+func (r *SQLiteMessageRepo) SetLocalPath(ctx context.Context, chatJID, msgID, path string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE messages SET local_path = ? WHERE chat_jid = ? AND id = ?", path, chatJID, msgID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListPendingMedia returns media messages with no downloaded file, newest
+// first, in one chat or, with an empty chatJID, in all chats. Deleted
+// messages are left out since their media can no longer be fetched.
+func (r *SQLiteMessageRepo) ListPendingMedia(ctx context.Context, chatJID string, limit int) ([]PendingMedia, error) {
+	query := `
+		SELECT chat_jid, id, sender, media_type, filename, file_length, timestamp
+		FROM messages
+		WHERE media_type != '' AND local_path = '' AND is_deleted = FALSE`
+	var args []interface{}
+	if chatJID != "" {
+		query += " AND chat_jid = ?"
+		args = append(args, chatJID)
+	}
+	query += " ORDER BY timestamp DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.ro.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pending := []PendingMedia{}
+	for rows.Next() {
+		var p PendingMedia
+		if err := rows.Scan(&p.ChatJID, &p.MessageID, &p.Sender, &p.MediaType, &p.Filename, &p.FileLength, &p.Timestamp); err != nil {
+			return nil, err
+		}
+		pending = append(pending, p)
+	}
+	return pending, rows.Err()
+}
+
+// Oldest returns the earliest stored message in a chat.
+func (r *SQLiteMessageRepo) Oldest(ctx context.Context, chatJID string) (*Message, error) {
+	query := `
+		SELECT id, chat_jid, sender, content, kind, timestamp, is_from_me, media_type, filename, media_url, quoted_id, quoted_sender, is_starred, is_deleted
+		FROM messages
+		WHERE chat_jid = ?
+		ORDER BY timestamp ASC
+		LIMIT 1
+	`
+	row := r.ro.QueryRowContext(ctx, query, chatJID)
+
+	var msg Message
+	err := row.Scan(
+		&msg.ID, &msg.ChatJID, &msg.Sender, &msg.Content, &msg.Kind, &msg.Timestamp, &msg.IsFromMe,
+		&msg.MediaType, &msg.Filename, &msg.MediaURL, &msg.QuotedID, &msg.QuotedSender, &msg.IsStarred, &msg.IsDeleted,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// LatestReceived returns the timestamp of the newest message not sent by us,
+// across all chats. It returns ErrNotFound if nothing has been received.
+func (r *SQLiteMessageRepo) LatestReceived(ctx context.Context) (time.Time, error) {
+	var ts time.Time
+	err := r.ro.QueryRowContext(ctx, "SELECT timestamp FROM messages WHERE is_from_me = FALSE ORDER BY timestamp DESC LIMIT 1").Scan(&ts)
+	if err == sql.ErrNoRows {
+		return time.Time{}, ErrNotFound
+	}
+	return ts, err
+}
+
 func (r *SQLiteMessageRepo) Search(ctx context.Context, query string, limit int) ([]Message, error) {
 	sqlQuery := `
-		SELECT id, chat_jid, sender, content, timestamp, is_from_me, media_type, filename, media_url, quoted_id, quoted_sender, is_starred, is_deleted
-		FROM messages
-		WHERE content LIKE ?
-		ORDER BY timestamp DESC
+		SELECT ` + messageListColumns + `
+		FROM messages m
+		LEFT JOIN contacts c ON c.jid = m.sender
+		WHERE m.content LIKE ?
+		ORDER BY m.timestamp DESC
+		LIMIT ?
+	`
+	rows, err := r.ro.QueryContext(ctx, sqlQuery, "%"+query+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
+// SearchInChat is Search limited to one chat. It walks the chat's messages
+// newest first through idx_messages_chat_timestamp, so it stops after limit
+// matches instead of scanning and sorting every message in the store.
+func (r *SQLiteMessageRepo) SearchInChat(ctx context.Context, chatJID, query string, limit int) ([]Message, error) {
+	sqlQuery := `
+		SELECT ` + messageListColumns + `
+		FROM messages m INDEXED BY idx_messages_chat_timestamp
+		LEFT JOIN contacts c ON c.jid = m.sender
+		WHERE m.chat_jid = ? AND m.content LIKE ?
+		ORDER BY m.timestamp DESC
 		LIMIT ?
 	`
-	rows, err := r.db.QueryContext(ctx, sqlQuery, "%"+query+"%", limit)
+	rows, err := r.ro.QueryContext(ctx, sqlQuery, chatJID, "%"+query+"%", limit)
 	if err != nil {
 		return nil, err
 	}
@@ -264,9 +459,93 @@ func (r *SQLiteMessageRepo) Search(ctx context.Context, query string, limit int)
 	return scanMessages(rows)
 }
 
+// SetStarred stars or unstars a message. It returns ErrNotFound if the
+// message is not stored.
 func (r *SQLiteMessageRepo) SetStarred(ctx context.Context, chatJID, msgID string, starred bool) error {
-	_, err := r.db.ExecContext(ctx, "UPDATE messages SET is_starred = ? WHERE chat_jid = ? AND id = ?", starred, chatJID, msgID)
-	return err
+	res, err := r.db.ExecContext(ctx, "UPDATE messages SET is_starred = ? WHERE chat_jid = ? AND id = ?", starred, chatJID, msgID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetReaction records a sender's reaction on a message, replacing any earlier
+// reaction from the same sender. An empty emoji removes the sender's reaction.
+// It returns ErrNotFound if the message is not stored.
+func (r *SQLiteMessageRepo) SetReaction(ctx context.Context, chatJID, msgID string, reaction Reaction) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var raw string
+	err = tx.QueryRowContext(ctx, "SELECT reactions FROM messages WHERE chat_jid = ? AND id = ?", chatJID, msgID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	reactions, err := decodeReactions(raw)
+	if err != nil {
+		return err
+	}
+	kept := reactions[:0]
+	for _, existing := range reactions {
+		if existing.Sender != reaction.Sender {
+			kept = append(kept, existing)
+		}
+	}
+	if reaction.Emoji != "" {
+		reaction.ReactedAt = reaction.ReactedAt.UTC()
+		kept = append(kept, reaction)
+	}
+
+	encoded, err := json.Marshal(kept)
+	if err != nil {
+		return fmt.Errorf("failed to encode reactions: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE messages SET reactions = ? WHERE chat_jid = ? AND id = ?", string(encoded), chatJID, msgID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetReactions returns the reactions on a message in the order they were
+// made. It returns an empty slice when there are none and ErrNotFound if the
+// message is not stored.
+func (r *SQLiteMessageRepo) GetReactions(ctx context.Context, chatJID, msgID string) ([]Reaction, error) {
+	var raw string
+	err := r.ro.QueryRowContext(ctx, "SELECT reactions FROM messages WHERE chat_jid = ? AND id = ?", chatJID, msgID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeReactions(raw)
+}
+
+// decodeReactions parses the reactions column. Empty values decode to an
+// empty, non-nil slice.
+func decodeReactions(raw string) ([]Reaction, error) {
+	reactions := []Reaction{}
+	if raw == "" {
+		return reactions, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &reactions); err != nil {
+		return nil, fmt.Errorf("failed to decode reactions: %w", err)
+	}
+	// Reactions stored before timestamps were normalized keep their offset
+	for i := range reactions {
+		reactions[i].ReactedAt = reactions[i].ReactedAt.UTC()
+	}
+	return reactions, nil
 }
 
 func (r *SQLiteMessageRepo) Delete(ctx context.Context, chatJID, msgID string) error {
@@ -276,17 +555,101 @@ func (r *SQLiteMessageRepo) Delete(ctx context.Context, chatJID, msgID string) e
 
 func (r *SQLiteMessageRepo) Count(ctx context.Context, chatJID string) (int, error) {
 	var count int
-	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM messages WHERE chat_jid = ?", chatJID).Scan(&count)
+	err := r.ro.QueryRowContext(ctx, "SELECT COUNT(*) FROM messages WHERE chat_jid = ?", chatJID).Scan(&count)
 	return count, err
 }
 
+// Stats aggregates a chat's stored messages by media type and sender. A
+// chat with no messages gets zero counts and no timestamps.
+func (r *SQLiteMessageRepo) Stats(ctx context.Context, chatJID string) (*ChatStats, error) {
+	count, err := r.Count(ctx, chatJID)
+	if err != nil {
+		return nil, err
+	}
+	stats := &ChatStats{
+		ChatJID:      chatJID,
+		MessageCount: count,
+		ByMediaType:  map[string]int{},
+		BySender:     []SenderCount{},
+	}
+	if count == 0 {
+		return stats, nil
+	}
+
+	rows, err := r.ro.QueryContext(ctx, `
+		SELECT COALESCE(NULLIF(media_type, ''), 'text'), COUNT(*)
+		FROM messages WHERE chat_jid = ?
+		GROUP BY 1
+	`, chatJID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var mediaType string
+		var n int
+		if err := rows.Scan(&mediaType, &n); err != nil {
+			return nil, err
+		}
+		stats.ByMediaType[mediaType] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	senders, err := r.ro.QueryContext(ctx, `
+		SELECT m.sender, COALESCE(NULLIF(c.name, ''), c.push_name, ''), COUNT(*)
+		FROM messages m
+		LEFT JOIN contacts c ON c.jid = m.sender
+		WHERE m.chat_jid = ?
+		GROUP BY m.sender
+		ORDER BY COUNT(*) DESC, m.sender
+	`, chatJID)
+	if err != nil {
+		return nil, err
+	}
+	defer senders.Close()
+	for senders.Next() {
+		var sc SenderCount
+		if err := senders.Scan(&sc.Sender, &sc.Name, &sc.Count); err != nil {
+			return nil, err
+		}
+		stats.BySender = append(stats.BySender, sc)
+	}
+	if err := senders.Err(); err != nil {
+		return nil, err
+	}
+
+	// MIN and MAX would return the timestamps as text, so read the
+	// first and last rows instead.
+	var first, last time.Time
+	const bound = "SELECT timestamp FROM messages WHERE chat_jid = ? ORDER BY timestamp %s LIMIT 1"
+	if err := r.ro.QueryRowContext(ctx, fmt.Sprintf(bound, "ASC"), chatJID).Scan(&first); err != nil {
+		return nil, err
+	}
+	if err := r.ro.QueryRowContext(ctx, fmt.Sprintf(bound, "DESC"), chatJID).Scan(&last); err != nil {
+		return nil, err
+	}
+	stats.FirstMessage, stats.LastMessage = &first, &last
+
+	return stats, nil
+}
+
+// messageListColumns selects a message (aliased m) along with the sender's
+// display name from the joined contact (aliased c). A saved contact name wins
+// over the sender's push name.
+const messageListColumns = `m.id, m.chat_jid, m.sender, m.content, m.kind, m.timestamp, m.is_from_me, m.media_type, m.filename, m.media_url, m.quoted_id, m.quoted_sender, m.is_starred, m.is_deleted,
+		COALESCE(NULLIF(c.name, ''), c.push_name, '')`
+
+// scanMessages scans rows selected with messageListColumns.
 func scanMessages(rows *sql.Rows) ([]Message, error) {
 	var messages []Message
 	for rows.Next() {
 		var msg Message
 		err := rows.Scan(
-			&msg.ID, &msg.ChatJID, &msg.Sender, &msg.Content, &msg.Timestamp, &msg.IsFromMe,
+			&msg.ID, &msg.ChatJID, &msg.Sender, &msg.Content, &msg.Kind, &msg.Timestamp, &msg.IsFromMe,
 			&msg.MediaType, &msg.Filename, &msg.MediaURL, &msg.QuotedID, &msg.QuotedSender, &msg.IsStarred, &msg.IsDeleted,
+			&msg.SenderName,
 		)
 		if err != nil {
 			return nil, err
@@ -299,6 +662,7 @@ func scanMessages(rows *sql.Rows) ([]Message, error) {
 // SQLiteChatRepo implements ChatRepository.
 type SQLiteChatRepo struct {
 	db *sql.DB
+	ro *sql.DB
 }
 
 func (r *SQLiteChatRepo) Upsert(ctx context.Context, chat *Chat) error {
@@ -317,8 +681,8 @@ func (r *SQLiteChatRepo) Upsert(ctx context.Context, chat *Chat) error {
 			updated_at = excluded.updated_at
 	`
 	_, err := r.db.ExecContext(ctx, query,
-		chat.JID, chat.Name, chat.IsGroup, chat.LastMessageTime, chat.UnreadCount,
-		chat.Archived, chat.Pinned, chat.Muted, chat.MutedUntil, time.Now(),
+		chat.JID, chat.Name, chat.IsGroup, chat.LastMessageTime.UTC(), chat.UnreadCount,
+		chat.Archived, chat.Pinned, chat.Muted, utc(chat.MutedUntil), time.Now().UTC(),
 	)
 	return err
 }
@@ -330,7 +694,26 @@ func (r *SQLiteChatRepo) List(ctx context.Context, limit int) ([]Chat, error) {
 		ORDER BY last_message_time DESC
 		LIMIT ?
 	`
-	rows, err := r.db.QueryContext(ctx, query, limit)
+	rows, err := r.ro.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanChats(rows)
+}
+
+// ListUnread returns the chats with unread messages or marked as unread,
+// most recently active first.
+func (r *SQLiteChatRepo) ListUnread(ctx context.Context, limit int) ([]Chat, error) {
+	query := `
+		SELECT jid, name, is_group, last_message_time, unread_count, archived, pinned, muted, muted_until, updated_at
+		FROM chats
+		WHERE unread_count != 0
+		ORDER BY last_message_time DESC
+		LIMIT ?
+	`
+	rows, err := r.ro.QueryContext(ctx, query, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -344,7 +727,7 @@ func (r *SQLiteChatRepo) GetByJID(ctx context.Context, jid string) (*Chat, error
 		SELECT jid, name, is_group, last_message_time, unread_count, archived, pinned, muted, muted_until, updated_at
 		FROM chats WHERE jid = ?
 	`
-	row := r.db.QueryRowContext(ctx, query, jid)
+	row := r.ro.QueryRowContext(ctx, query, jid)
 
 	var chat Chat
 	var lastMsgTime sql.NullTime
@@ -369,22 +752,49 @@ func (r *SQLiteChatRepo) GetByJID(ctx context.Context, jid string) (*Chat, error
 }
 
 func (r *SQLiteChatRepo) UpdateLastMessage(ctx context.Context, jid string, t time.Time) error {
-	_, err := r.db.ExecContext(ctx, "UPDATE chats SET last_message_time = ?, updated_at = ? WHERE jid = ?", t, time.Now(), jid)
+	_, err := r.db.ExecContext(ctx, "UPDATE chats SET last_message_time = ?, updated_at = ? WHERE jid = ?", t.UTC(), time.Now().UTC(), jid)
 	return err
 }
 
 func (r *SQLiteChatRepo) Archive(ctx context.Context, jid string, archived bool) error {
-	_, err := r.db.ExecContext(ctx, "UPDATE chats SET archived = ?, updated_at = ? WHERE jid = ?", archived, time.Now(), jid)
+	_, err := r.db.ExecContext(ctx, "UPDATE chats SET archived = ?, updated_at = ? WHERE jid = ?", archived, time.Now().UTC(), jid)
 	return err
 }
 
 func (r *SQLiteChatRepo) Pin(ctx context.Context, jid string, pinned bool) error {
-	_, err := r.db.ExecContext(ctx, "UPDATE chats SET pinned = ?, updated_at = ? WHERE jid = ?", pinned, time.Now(), jid)
+	_, err := r.db.ExecContext(ctx, "UPDATE chats SET pinned = ?, updated_at = ? WHERE jid = ?", pinned, time.Now().UTC(), jid)
 	return err
 }
 
+// CountPinned returns how many chats are pinned.
+func (r *SQLiteChatRepo) CountPinned(ctx context.Context) (int, error) {
+	var count int
+	err := r.ro.QueryRowContext(ctx, "SELECT COUNT(*) FROM chats WHERE pinned").Scan(&count)
+	return count, err
+}
+
 func (r *SQLiteChatRepo) Mute(ctx context.Context, jid string, muted bool, until *time.Time) error {
-	_, err := r.db.ExecContext(ctx, "UPDATE chats SET muted = ?, muted_until = ?, updated_at = ? WHERE jid = ?", muted, until, time.Now(), jid)
+	_, err := r.db.ExecContext(ctx, "UPDATE chats SET muted = ?, muted_until = ?, updated_at = ? WHERE jid = ?", muted, utc(until), time.Now().UTC(), jid)
+	return err
+}
+
+// IncrementUnread adds one to a chat's unread count. A chat marked as
+// unread counts from zero.
+func (r *SQLiteChatRepo) IncrementUnread(ctx context.Context, jid string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE chats SET unread_count = MAX(unread_count, 0) + 1, updated_at = ? WHERE jid = ?", time.Now().UTC(), jid)
+	return err
+}
+
+// ResetUnread sets a chat's unread count back to zero.
+func (r *SQLiteChatRepo) ResetUnread(ctx context.Context, jid string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE chats SET unread_count = 0, updated_at = ? WHERE jid = ?", time.Now().UTC(), jid)
+	return err
+}
+
+// MarkUnread sets a read chat's unread count to UnreadMarked. Chats that
+// already have unread messages keep their count.
+func (r *SQLiteChatRepo) MarkUnread(ctx context.Context, jid string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE chats SET unread_count = ?, updated_at = ? WHERE jid = ? AND unread_count = 0", UnreadMarked, time.Now().UTC(), jid)
 	return err
 }
 
@@ -395,10 +805,19 @@ func (r *SQLiteChatRepo) Delete(ctx context.Context, jid string) error {
 
 func (r *SQLiteChatRepo) Count(ctx context.Context) (int, error) {
 	var count int
-	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM chats").Scan(&count)
+	err := r.ro.QueryRowContext(ctx, "SELECT COUNT(*) FROM chats").Scan(&count)
 	return count, err
 }
 
+// utc returns t in UTC, or nil for nil.
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
 func scanChats(rows *sql.Rows) ([]Chat, error) {
 	var chats []Chat
 	for rows.Next() {
@@ -426,6 +845,7 @@ func scanChats(rows *sql.Rows) ([]Chat, error) {
 // SQLiteContactRepo implements ContactRepository.
 type SQLiteContactRepo struct {
 	db *sql.DB
+	ro *sql.DB
 }
 
 func (r *SQLiteContactRepo) Upsert(ctx context.Context, contact *Contact) error {
@@ -445,6 +865,38 @@ func (r *SQLiteContactRepo) Upsert(ctx context.Context, contact *Contact) error
 	return err
 }
 
+// UpsertPushName records the push name a contact chose for themselves. It
+// only touches push_name, so a saved Name and other fields are preserved;
+// unknown senders get a new row with just the JID and push name.
+func (r *SQLiteContactRepo) UpsertPushName(ctx context.Context, jid, pushName string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO contacts (jid, push_name, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET push_name = excluded.push_name, updated_at = excluded.updated_at
+	`, jid, pushName, time.Now())
+	return err
+}
+
+// UpsertBusinessName records a contact's verified business name, creating
+// the contact if needed. Other fields are left unchanged.
+func (r *SQLiteContactRepo) UpsertBusinessName(ctx context.Context, jid, businessName string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO contacts (jid, business_name, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET business_name = excluded.business_name, updated_at = excluded.updated_at
+	`, jid, businessName, time.Now())
+	return err
+}
+
+// UpsertSavedName records the name a contact is saved under in the phone's
+// address book and marks it saved. An empty name means the contact was
+// removed from the address book.
+func (r *SQLiteContactRepo) UpsertSavedName(ctx context.Context, jid, name string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO contacts (jid, name, is_saved, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET name = excluded.name, is_saved = excluded.is_saved, updated_at = excluded.updated_at
+	`, jid, name, name != "", time.Now())
+	return err
+}
+
 func (r *SQLiteContactRepo) Search(ctx context.Context, query string, limit int) ([]Contact, error) {
 	sqlQuery := `
 		SELECT jid, name, push_name, phone, business_name, blocked, is_saved, updated_at
@@ -453,7 +905,7 @@ func (r *SQLiteContactRepo) Search(ctx context.Context, query string, limit int)
 		LIMIT ?
 	`
 	pattern := "%" + query + "%"
-	rows, err := r.db.QueryContext(ctx, sqlQuery, pattern, pattern, pattern, pattern, limit)
+	rows, err := r.ro.QueryContext(ctx, sqlQuery, pattern, pattern, pattern, pattern, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -464,7 +916,7 @@ func (r *SQLiteContactRepo) Search(ctx context.Context, query string, limit int)
 
 func (r *SQLiteContactRepo) GetByJID(ctx context.Context, jid string) (*Contact, error) {
 	query := `SELECT jid, name, push_name, phone, business_name, blocked, is_saved, updated_at FROM contacts WHERE jid = ?`
-	row := r.db.QueryRowContext(ctx, query, jid)
+	row := r.ro.QueryRowContext(ctx, query, jid)
 
 	var contact Contact
 	err := row.Scan(&contact.JID, &contact.Name, &contact.PushName, &contact.Phone, &contact.BusinessName, &contact.Blocked, &contact.IsSaved, &contact.UpdatedAt)
@@ -477,13 +929,65 @@ func (r *SQLiteContactRepo) GetByJID(ctx context.Context, jid string) (*Contact,
 	return &contact, nil
 }
 
+// List returns stored contacts ordered by JID, skipping the first offset.
+// A limit of zero or less returns every remaining contact.
+func (r *SQLiteContactRepo) List(ctx context.Context, limit, offset int) ([]Contact, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := r.ro.QueryContext(ctx, "SELECT jid, name, push_name, phone, business_name, blocked, is_saved, updated_at FROM contacts ORDER BY jid LIMIT ? OFFSET ?", limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanContacts(rows)
+}
+
+// ListWithChats pages through contacts like List, adding whether each one
+// has a chat and when its last message was.
+func (r *SQLiteContactRepo) ListWithChats(ctx context.Context, limit, offset int) ([]ContactWithChat, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	query := `
+		SELECT c.jid, c.name, c.push_name, c.phone, c.business_name, c.blocked, c.is_saved, c.updated_at,
+			ch.jid IS NOT NULL, ch.last_message_time
+		FROM contacts c
+		LEFT JOIN chats ch ON ch.jid = c.jid
+		ORDER BY c.jid
+		LIMIT ? OFFSET ?
+	`
+	rows, err := r.ro.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []ContactWithChat
+	for rows.Next() {
+		var e ContactWithChat
+		var lastMsgTime sql.NullTime
+		err := rows.Scan(&e.JID, &e.Name, &e.PushName, &e.Phone, &e.BusinessName, &e.Blocked, &e.IsSaved, &e.UpdatedAt,
+			&e.HasChat, &lastMsgTime)
+		if err != nil {
+			return nil, err
+		}
+		if lastMsgTime.Valid {
+			e.LastMessageTime = &lastMsgTime.Time
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
 func (r *SQLiteContactRepo) Block(ctx context.Context, jid string, blocked bool) error {
 	_, err := r.db.ExecContext(ctx, "UPDATE contacts SET blocked = ?, updated_at = ? WHERE jid = ?", blocked, time.Now(), jid)
 	return err
 }
 
 func (r *SQLiteContactRepo) GetBlocked(ctx context.Context) ([]Contact, error) {
-	rows, err := r.db.QueryContext(ctx, "SELECT jid, name, push_name, phone, business_name, blocked, is_saved, updated_at FROM contacts WHERE blocked = TRUE")
+	rows, err := r.ro.QueryContext(ctx, "SELECT jid, name, push_name, phone, business_name, blocked, is_saved, updated_at FROM contacts WHERE blocked = TRUE")
 	if err != nil {
 		return nil, err
 	}
@@ -499,7 +1003,7 @@ func (r *SQLiteContactRepo) Delete(ctx context.Context, jid string) error {
 
 func (r *SQLiteContactRepo) Count(ctx context.Context) (int, error) {
 	var count int
-	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM contacts").Scan(&count)
+	err := r.ro.QueryRowContext(ctx, "SELECT COUNT(*) FROM contacts").Scan(&count)
 	return count, err
 }
 
@@ -557,6 +1061,54 @@ func (r *SQLiteGroupRepo) GetByJID(ctx context.Context, jid string) (*Group, err
 	return &group, nil
 }
 
+// SetAnnounce records whether only admins can send messages in a group,
+// creating a placeholder row if the group has not been stored yet.
+func (r *SQLiteGroupRepo) SetAnnounce(ctx context.Context, jid string, announce bool) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO groups (jid, is_announce, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET is_announce = excluded.is_announce, updated_at = excluded.updated_at
+	`, jid, announce, time.Now())
+	return err
+}
+
+// SetLocked records whether only admins can edit a group's info, creating a
+// placeholder row if the group has not been stored yet.
+func (r *SQLiteGroupRepo) SetLocked(ctx context.Context, jid string, locked bool) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO groups (jid, is_locked, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET is_locked = excluded.is_locked, updated_at = excluded.updated_at
+	`, jid, locked, time.Now())
+	return err
+}
+
+// SetInviteLink caches a group's invite link with the time it was fetched,
+// creating a placeholder row if the group has not been stored yet.
+func (r *SQLiteGroupRepo) SetInviteLink(ctx context.Context, jid, link string, fetchedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO groups (jid, invite_link, invite_link_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			invite_link = excluded.invite_link,
+			invite_link_at = excluded.invite_link_at,
+			updated_at = excluded.updated_at
+	`, jid, link, fetchedAt.UTC(), time.Now())
+	return err
+}
+
+// GetInviteLink returns a group's cached invite link and when it was
+// fetched, or ErrNotFound if none has been cached.
+func (r *SQLiteGroupRepo) GetInviteLink(ctx context.Context, jid string) (string, time.Time, error) {
+	var link string
+	var fetchedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, "SELECT invite_link, invite_link_at FROM groups WHERE jid = ?", jid).Scan(&link, &fetchedAt)
+	if err == sql.ErrNoRows || (err == nil && (link == "" || !fetchedAt.Valid)) {
+		return "", time.Time{}, ErrNotFound
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return link, fetchedAt.Time, nil
+}
+
 func (r *SQLiteGroupRepo) UpdateParticipants(ctx context.Context, groupJID string, participants []GroupParticipant) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -613,6 +1165,38 @@ func (r *SQLiteGroupRepo) GetParticipants(ctx context.Context, groupJID string)
 	return participants, rows.Err()
 }
 
+// ListCommon returns the stored groups that have userJID among their
+// participants, ordered by name.
+func (r *SQLiteGroupRepo) ListCommon(ctx context.Context, userJID string) ([]Group, error) {
+	query := `
+		SELECT g.jid, g.name, g.topic, g.created_at, g.created_by, g.invite_link, g.is_announce, g.is_locked, g.participant_count, g.updated_at
+		FROM groups g
+		JOIN group_participants p ON p.group_jid = g.jid
+		WHERE p.user_jid = ?
+		ORDER BY g.name, g.jid
+	`
+	rows, err := r.db.QueryContext(ctx, query, userJID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []Group{}
+	for rows.Next() {
+		var group Group
+		var createdAt sql.NullTime
+		err := rows.Scan(&group.JID, &group.Name, &group.Topic, &createdAt, &group.CreatedBy, &group.InviteLink, &group.IsAnnounce, &group.IsLocked, &group.ParticipantCount, &group.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if createdAt.Valid {
+			group.CreatedAt = createdAt.Time
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
 func (r *SQLiteGroupRepo) Delete(ctx context.Context, jid string) error {
 	_, err := r.db.ExecContext(ctx, "DELETE FROM groups WHERE jid = ?", jid)
 	return err
@@ -652,6 +1236,21 @@ func (r *SQLiteStatusRepo) GetByContact(ctx context.Context, contactJID string)
 	return scanStatuses(rows)
 }
 
+// GetByID returns a status whether or not it has expired, or ErrNotFound.
+func (r *SQLiteStatusRepo) GetByID(ctx context.Context, statusID string) (*StatusUpdate, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT id, sender_jid, media_type, content, posted_at, expires_at, viewed FROM status_updates WHERE id = ?", statusID)
+
+	var s StatusUpdate
+	err := row.Scan(&s.ID, &s.SenderJID, &s.MediaType, &s.Content, &s.PostedAt, &s.ExpiresAt, &s.Viewed)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
 func (r *SQLiteStatusRepo) Delete(ctx context.Context, statusID string) error {
 	_, err := r.db.ExecContext(ctx, "DELETE FROM status_updates WHERE id = ?", statusID)
 	return err
@@ -675,6 +1274,146 @@ func scanStatuses(rows *sql.Rows) ([]StatusUpdate, error) {
 	return statuses, rows.Err()
 }
 
+// SQLiteLabelRepo implements LabelRepository.
+type SQLiteLabelRepo struct {
+	db *sql.DB
+}
+
+func (r *SQLiteLabelRepo) Upsert(ctx context.Context, label *Label) error {
+	query := `
+		INSERT INTO labels (id, name, color, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			color = excluded.color,
+			updated_at = excluded.updated_at
+	`
+	_, err := r.db.ExecContext(ctx, query, label.ID, label.Name, label.Color, time.Now())
+	return err
+}
+
+func (r *SQLiteLabelRepo) List(ctx context.Context) ([]Label, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name, color, updated_at FROM labels ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var labels []Label
+	index := make(map[string]int)
+	for rows.Next() {
+		var l Label
+		if err := rows.Scan(&l.ID, &l.Name, &l.Color, &l.UpdatedAt); err != nil {
+			return nil, err
+		}
+		index[l.ID] = len(labels)
+		labels = append(labels, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	assoc, err := r.db.QueryContext(ctx, "SELECT label_id, chat_jid FROM chat_labels ORDER BY chat_jid")
+	if err != nil {
+		return nil, err
+	}
+	defer assoc.Close()
+
+	for assoc.Next() {
+		var labelID, chatJID string
+		if err := assoc.Scan(&labelID, &chatJID); err != nil {
+			return nil, err
+		}
+		if i, ok := index[labelID]; ok {
+			labels[i].ChatJIDs = append(labels[i].ChatJIDs, chatJID)
+		}
+	}
+	return labels, assoc.Err()
+}
+
+func (r *SQLiteLabelRepo) Delete(ctx context.Context, id string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM chat_labels WHERE label_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM labels WHERE id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteLabelRepo) SetChatLabel(ctx context.Context, chatJID, labelID string, labeled bool) error {
+	if labeled {
+		_, err := r.db.ExecContext(ctx, "INSERT OR IGNORE INTO chat_labels (chat_jid, label_id) VALUES (?, ?)", chatJID, labelID)
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, "DELETE FROM chat_labels WHERE chat_jid = ? AND label_id = ?", chatJID, labelID)
+	return err
+}
+
+// SQLitePresenceRepo implements PresenceRepository.
+type SQLitePresenceRepo struct {
+	db *sql.DB
+}
+
+// SetOnline records an availability update. Going offline also clears the
+// typing state. A zero lastSeen (hidden by privacy settings) keeps the
+// previously known value.
+func (r *SQLitePresenceRepo) SetOnline(ctx context.Context, jid string, online bool, lastSeen time.Time) error {
+	var seen interface{}
+	if !lastSeen.IsZero() {
+		seen = lastSeen
+	}
+	query := `
+		INSERT INTO presence (jid, online, last_seen, composing, updated_at)
+		VALUES (?, ?, ?, FALSE, ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			online = excluded.online,
+			last_seen = COALESCE(excluded.last_seen, presence.last_seen),
+			composing = CASE WHEN excluded.online THEN presence.composing ELSE FALSE END,
+			updated_at = excluded.updated_at
+	`
+	_, err := r.db.ExecContext(ctx, query, jid, online, seen, time.Now())
+	return err
+}
+
+// SetComposing records whether the contact is currently typing.
+func (r *SQLitePresenceRepo) SetComposing(ctx context.Context, jid string, composing bool) error {
+	query := `
+		INSERT INTO presence (jid, composing, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			composing = excluded.composing,
+			updated_at = excluded.updated_at
+	`
+	_, err := r.db.ExecContext(ctx, query, jid, composing, time.Now())
+	return err
+}
+
+func (r *SQLitePresenceRepo) Get(ctx context.Context, jid string) (*Presence, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT jid, online, last_seen, composing, updated_at FROM presence WHERE jid = ?", jid)
+
+	var p Presence
+	var lastSeen sql.NullTime
+	err := row.Scan(&p.JID, &p.Online, &lastSeen, &p.Composing, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if lastSeen.Valid {
+		p.LastSeen = &lastSeen.Time
+	}
+	return &p, nil
+}
+
 // SQLiteStateRepo implements StateRepository.
 type SQLiteStateRepo struct {
 	db *sql.DB
@@ -695,18 +1434,39 @@ func (r *SQLiteStateRepo) SaveState(ctx context.Context, s state.State) error {
 }
 
 func (r *SQLiteStateRepo) LogTransition(ctx context.Context, from, to state.State, trigger string) error {
+	return r.LogTransitionWithError(ctx, from, to, trigger, "")
+}
+
+func (r *SQLiteStateRepo) LogTransitionWithError(ctx context.Context, from, to state.State, trigger, errText string) error {
 	_, err := r.db.ExecContext(ctx,
-		"INSERT INTO transitions (from_state, to_state, trigger, timestamp) VALUES (?, ?, ?, ?)",
-		string(from), string(to), trigger, time.Now(),
+		"INSERT INTO transitions (from_state, to_state, trigger, timestamp, error) VALUES (?, ?, ?, ?, ?)",
+		string(from), string(to), trigger, time.Now(), errText,
 	)
 	return err
 }
 
 func (r *SQLiteStateRepo) GetTransitionHistory(ctx context.Context, limit int) ([]Transition, error) {
-	rows, err := r.db.QueryContext(ctx,
-		"SELECT id, from_state, to_state, trigger, timestamp, error FROM transitions ORDER BY timestamp DESC LIMIT ?",
-		limit,
-	)
+	return r.ListTransitions(ctx, TransitionFilter{Limit: limit})
+}
+
+func (r *SQLiteStateRepo) ListTransitions(ctx context.Context, filter TransitionFilter) ([]Transition, error) {
+	query := "SELECT id, from_state, to_state, trigger, timestamp, error FROM transitions WHERE 1=1"
+	var args []interface{}
+
+	if !filter.Since.IsZero() {
+		// Timestamps are written with time.Now(), so compare in the same zone.
+		query += " AND timestamp >= ?"
+		args = append(args, filter.Since.Local())
+	}
+	if filter.State != "" {
+		query += " AND to_state = ?"
+		args = append(args, string(filter.State))
+	}
+
+	query += " ORDER BY timestamp DESC, id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -726,3 +1486,123 @@ func (r *SQLiteStateRepo) GetTransitionHistory(ctx context.Context, limit int) (
 	}
 	return transitions, rows.Err()
 }
+
+// SQLiteAuditRepo implements AuditRepository.
+type SQLiteAuditRepo struct {
+	db *sql.DB
+}
+
+func (r *SQLiteAuditRepo) Append(ctx context.Context, entry *AuditEntry) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	res, err := r.db.ExecContext(ctx,
+		"INSERT INTO audit_log (tool, target_jid, timestamp, success, error_code) VALUES (?, ?, ?, ?, ?)",
+		entry.Tool, entry.TargetJID, entry.Timestamp, entry.Success, entry.ErrorCode,
+	)
+	if err != nil {
+		return err
+	}
+	entry.ID, err = res.LastInsertId()
+	return err
+}
+
+// List returns audit entries newest first.
+func (r *SQLiteAuditRepo) List(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	query := "SELECT id, tool, target_jid, timestamp, success, error_code FROM audit_log WHERE 1=1"
+	var args []interface{}
+
+	// Timestamps are written with time.Now(), so compare in the same zone.
+	if !filter.Since.IsZero() {
+		query += " AND timestamp >= ?"
+		args = append(args, filter.Since.Local())
+	}
+	if !filter.Until.IsZero() {
+		query += " AND timestamp < ?"
+		args = append(args, filter.Until.Local())
+	}
+	if filter.Tool != "" {
+		query += " AND tool = ?"
+		args = append(args, filter.Tool)
+	}
+
+	query += " ORDER BY timestamp DESC, id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Tool, &e.TargetJID, &e.Timestamp, &e.Success, &e.ErrorCode); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// SQLiteDeadLetterRepo implements DeadLetterRepository.
+type SQLiteDeadLetterRepo struct {
+	db *sql.DB
+}
+
+func (r *SQLiteDeadLetterRepo) Add(ctx context.Context, msg *Message, storeErr error) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO failed_stores
+		(message_id, chat_jid, sender, content, timestamp, is_from_me, media_type, thumbnail, error, failed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		msg.ID, msg.ChatJID, msg.Sender, msg.Content, msg.Timestamp.UTC(), msg.IsFromMe,
+		msg.MediaType, msg.Thumbnail, storeErr.Error(), time.Now().UTC(),
+	)
+	return err
+}
+
+// List returns failed stores oldest first.
+func (r *SQLiteDeadLetterRepo) List(ctx context.Context, limit int) ([]FailedStore, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, message_id, chat_jid, sender, content, timestamp, is_from_me, media_type, thumbnail, error, attempts, failed_at
+		FROM failed_stores
+		ORDER BY id
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var failed []FailedStore
+	for rows.Next() {
+		var f FailedStore
+		m := &f.Message
+		if err := rows.Scan(&f.ID, &m.ID, &m.ChatJID, &m.Sender, &m.Content, &m.Timestamp, &m.IsFromMe,
+			&m.MediaType, &m.Thumbnail, &f.Error, &f.Attempts, &f.FailedAt); err != nil {
+			return nil, err
+		}
+		failed = append(failed, f)
+	}
+	return failed, rows.Err()
+}
+
+// RecordAttempt notes another failed attempt to store the entry.
+func (r *SQLiteDeadLetterRepo) RecordAttempt(ctx context.Context, id int64, storeErr error) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE failed_stores SET attempts = attempts + 1, error = ?, failed_at = ? WHERE id = ?",
+		storeErr.Error(), time.Now().UTC(), id,
+	)
+	return err
+}
+
+func (r *SQLiteDeadLetterRepo) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM failed_stores WHERE id = ?", id)
+	return err
+}
+
+func (r *SQLiteDeadLetterRepo) Count(ctx context.Context) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM failed_stores").Scan(&n)
+	return n, err
+}
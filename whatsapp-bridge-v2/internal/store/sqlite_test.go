@@ -2,6 +2,11 @@ package store
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -17,6 +22,120 @@ func setupTestDB(t *testing.T) *SQLiteStore {
 	return store
 }
 
+func TestSQLiteStore_ConcurrentWriters(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "messages.db")
+
+	// Two stores on one file stand in for separate processes, each with its
+	// own connection competing for the write lock.
+	stores := make([]*SQLiteStore, 2)
+	for i := range stores {
+		s, err := NewSQLiteStoreWithOptions(path, Options{BusyTimeout: 5 * time.Second})
+		require.NoError(t, err)
+		t.Cleanup(func() { s.Close() })
+		stores[i] = s
+	}
+	require.NoError(t, stores[0].Chats.Upsert(ctx, &Chat{JID: "123@s.whatsapp.net"}))
+
+	const writers, perWriter = 4, 200
+	errs := make(chan error, len(stores)*writers*perWriter)
+	var wg sync.WaitGroup
+	for si, s := range stores {
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func(s *SQLiteStore, prefix string) {
+				defer wg.Done()
+				for i := 0; i < perWriter; i++ {
+					errs <- s.Messages.Store(ctx, &Message{
+						ID:        fmt.Sprintf("%s-%d", prefix, i),
+						ChatJID:   "123@s.whatsapp.net",
+						Sender:    "a",
+						Timestamp: time.Now(),
+					})
+				}
+			}(s, fmt.Sprintf("s%d-w%d", si, w))
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	count, err := stores[1].Messages.Count(ctx, "123@s.whatsapp.net")
+	require.NoError(t, err)
+	assert.Equal(t, len(stores)*writers*perWriter, count)
+}
+
+func TestSQLiteStore_ReadsDuringWrites(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewSQLiteStoreWithOptions(filepath.Join(t.TempDir(), "messages.db"), Options{BusyTimeout: 5 * time.Second, ReadConns: 4})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	require.NotSame(t, s.db, s.ro, "file databases get a separate read pool")
+	_, err = s.ro.ExecContext(ctx, "DELETE FROM messages")
+	require.Error(t, err, "read pool must be read-only")
+
+	chatJID := "123@s.whatsapp.net"
+	require.NoError(t, s.Chats.Upsert(ctx, &Chat{JID: chatJID}))
+
+	const writes, readers = 300, 4
+	done := make(chan struct{})
+	writeErr := make(chan error, 1)
+	go func() {
+		defer close(done)
+		for i := 0; i < writes; i++ {
+			msg := &Message{ID: fmt.Sprintf("m%d", i), ChatJID: chatJID, Sender: "a", Content: "hello", Timestamp: time.Now()}
+			if err := s.Messages.Store(ctx, msg); err != nil {
+				writeErr <- err
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	readErrs := make(chan error, readers)
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if _, err := s.Messages.List(ctx, chatJID, 50, "", DirectionAll); err != nil {
+					readErrs <- err
+					return
+				}
+				if _, err := s.Messages.Search(ctx, "hello", 50); err != nil {
+					readErrs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(readErrs)
+
+	select {
+	case err := <-writeErr:
+		t.Fatalf("write failed: %v", err)
+	default:
+	}
+	for err := range readErrs {
+		require.NoError(t, err)
+	}
+
+	// Committed writes are visible on the read pool
+	count, err := s.Messages.Count(ctx, chatJID)
+	require.NoError(t, err)
+	assert.Equal(t, writes, count)
+}
+
 // Message Repository Tests
 
 func TestSQLiteMessageRepo_Store(t *testing.T) {
@@ -45,6 +164,50 @@ func TestSQLiteMessageRepo_Store(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, msg.Content, retrieved.Content)
 	assert.Equal(t, msg.Sender, retrieved.Sender)
+	assert.Equal(t, MessageKindUser, retrieved.Kind, "kind defaults to user")
+
+	require.NoError(t, store.Messages.Store(ctx, &Message{
+		ID: "sys1", ChatJID: msg.ChatJID, Sender: msg.Sender, Content: "Alice added Bob",
+		Kind: MessageKindSystem, Timestamp: time.Now(),
+	}))
+	listed, err := store.Messages.List(ctx, msg.ChatJID, 10, "", DirectionAll)
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, MessageKindSystem, listed[0].Kind)
+}
+
+func TestSQLiteStore_TimestampsRoundTripAsUTC(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	want := time.Date(2024, 6, 1, 12, 30, 45, 0, time.UTC)
+	// The same instant as seen from a UTC+05:30 machine
+	local := want.In(time.FixedZone("IST", 5*3600+1800))
+
+	require.NoError(t, store.Chats.Upsert(ctx, &Chat{JID: "123@s.whatsapp.net", LastMessageTime: local, MutedUntil: &local}))
+	require.NoError(t, store.Messages.Store(ctx, &Message{ID: "m1", ChatJID: "123@s.whatsapp.net", Sender: "me", Timestamp: local}))
+	require.NoError(t, store.Messages.SetReaction(ctx, "123@s.whatsapp.net", "m1", Reaction{Sender: "456@s.whatsapp.net", Emoji: "👍", ReactedAt: local}))
+
+	messages, err := store.Messages.List(ctx, "123@s.whatsapp.net", 10, "", DirectionAll)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, want, messages[0].Timestamp)
+
+	reactions, err := store.Messages.GetReactions(ctx, "123@s.whatsapp.net", "m1")
+	require.NoError(t, err)
+	require.Len(t, reactions, 1)
+	assert.Equal(t, want, reactions[0].ReactedAt)
+
+	chat, err := store.Chats.GetByJID(ctx, "123@s.whatsapp.net")
+	require.NoError(t, err)
+	assert.Equal(t, want, chat.LastMessageTime)
+	require.NotNil(t, chat.MutedUntil)
+	assert.Equal(t, want, *chat.MutedUntil)
+	assert.Equal(t, time.UTC, chat.UpdatedAt.Location())
+
+	encoded, err := json.Marshal(messages[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"timestamp":"2024-06-01T12:30:45Z"`)
 }
 
 func TestSQLiteMessageRepo_GetByChat(t *testing.T) {
@@ -71,7 +234,7 @@ func TestSQLiteMessageRepo_GetByChat(t *testing.T) {
 	}
 
 	// Get with limit
-	messages, err := store.Messages.List(ctx, "123@s.whatsapp.net", 3, "")
+	messages, err := store.Messages.List(ctx, "123@s.whatsapp.net", 3, "", DirectionAll)
 	require.NoError(t, err)
 	assert.Len(t, messages, 3)
 
@@ -79,6 +242,57 @@ func TestSQLiteMessageRepo_GetByChat(t *testing.T) {
 	assert.True(t, messages[0].Timestamp.After(messages[1].Timestamp))
 }
 
+func TestSQLiteMessageRepo_ListDirection(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.Chats.Upsert(ctx, &Chat{JID: "123@s.whatsapp.net"}))
+
+	now := time.Now()
+	for i, fromMe := range []bool{false, true, false, true, true} {
+		msg := &Message{
+			ID:        "msg" + string(rune('0'+i)),
+			ChatJID:   "123@s.whatsapp.net",
+			Sender:    "123@s.whatsapp.net",
+			Timestamp: now.Add(time.Duration(i) * time.Minute),
+			IsFromMe:  fromMe,
+		}
+		require.NoError(t, store.Messages.Store(ctx, msg))
+	}
+
+	tests := []struct {
+		direction string
+		wantIDs   []string
+	}{
+		{"", []string{"msg4", "msg3", "msg2", "msg1", "msg0"}},
+		{DirectionAll, []string{"msg4", "msg3", "msg2", "msg1", "msg0"}},
+		{DirectionIncoming, []string{"msg2", "msg0"}},
+		{DirectionOutgoing, []string{"msg4", "msg3", "msg1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run("direction="+tt.direction, func(t *testing.T) {
+			messages, err := store.Messages.List(ctx, "123@s.whatsapp.net", 10, "", tt.direction)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, m := range messages {
+				ids = append(ids, m.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+
+	// Direction combines with the before cursor
+	messages, err := store.Messages.List(ctx, "123@s.whatsapp.net", 10, "msg3", DirectionOutgoing)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "msg1", messages[0].ID)
+
+	_, err = store.Messages.List(ctx, "123@s.whatsapp.net", 10, "", "sideways")
+	assert.Error(t, err)
+}
+
 func TestSQLiteMessageRepo_Search(t *testing.T) {
 	store := setupTestDB(t)
 	ctx := context.Background()
@@ -105,6 +319,122 @@ func TestSQLiteMessageRepo_Search(t *testing.T) {
 	assert.Len(t, results, 2)
 }
 
+func TestSQLiteMessageRepo_SearchInChat(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	for _, jid := range []string{"123@s.whatsapp.net", "456@s.whatsapp.net"} {
+		require.NoError(t, store.Chats.Upsert(ctx, &Chat{JID: jid}))
+	}
+
+	now := time.Now()
+	for _, msg := range []Message{
+		{ID: "1", ChatJID: "123@s.whatsapp.net", Sender: "a", Content: "lunch at noon", Timestamp: now.Add(-2 * time.Minute)},
+		{ID: "2", ChatJID: "123@s.whatsapp.net", Sender: "a", Content: "lunch moved to one", Timestamp: now.Add(-time.Minute)},
+		{ID: "3", ChatJID: "456@s.whatsapp.net", Sender: "b", Content: "lunch tomorrow?", Timestamp: now},
+		{ID: "4", ChatJID: "123@s.whatsapp.net", Sender: "a", Content: "see you", Timestamp: now},
+	} {
+		require.NoError(t, store.Messages.Store(ctx, &msg))
+	}
+
+	results, err := store.Messages.SearchInChat(ctx, "123@s.whatsapp.net", "LUNCH", 50)
+	require.NoError(t, err)
+	require.Len(t, results, 2, "matches in other chats are excluded")
+	assert.Equal(t, "2", results[0].ID, "newest first")
+	assert.Equal(t, "1", results[1].ID)
+
+	results, err = store.Messages.SearchInChat(ctx, "123@s.whatsapp.net", "lunch", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "2", results[0].ID)
+
+	results, err = store.Messages.SearchInChat(ctx, "789@s.whatsapp.net", "lunch", 50)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestSQLiteMessageRepo_PendingMedia(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	for _, jid := range []string{"123@s.whatsapp.net", "456@s.whatsapp.net"} {
+		require.NoError(t, store.Chats.Upsert(ctx, &Chat{JID: jid}))
+	}
+	now := time.Now()
+	for _, msg := range []Message{
+		{ID: "img", ChatJID: "123@s.whatsapp.net", Sender: "a", MediaType: "image", FileLength: 2048, Timestamp: now.Add(-3 * time.Minute)},
+		{ID: "doc", ChatJID: "123@s.whatsapp.net", Sender: "a", MediaType: "document", Filename: "a.pdf", FileLength: 4096, Timestamp: now.Add(-2 * time.Minute)},
+		{ID: "text", ChatJID: "123@s.whatsapp.net", Sender: "a", Content: "hi", Timestamp: now.Add(-time.Minute)},
+		{ID: "gone", ChatJID: "123@s.whatsapp.net", Sender: "a", MediaType: "video", IsDeleted: true, Timestamp: now},
+		{ID: "vid", ChatJID: "456@s.whatsapp.net", Sender: "b", MediaType: "video", FileLength: 1 << 20, Timestamp: now},
+	} {
+		require.NoError(t, store.Messages.Store(ctx, &msg))
+	}
+
+	pending, err := store.Messages.ListPendingMedia(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 3)
+	assert.Equal(t, []string{"vid", "doc", "img"}, []string{pending[0].MessageID, pending[1].MessageID, pending[2].MessageID})
+	assert.Equal(t, uint64(4096), pending[1].FileLength)
+	assert.Equal(t, "a.pdf", pending[1].Filename)
+
+	pending, err = store.Messages.ListPendingMedia(ctx, "123@s.whatsapp.net", 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+
+	// Downloaded media drops out of the list
+	require.NoError(t, store.Messages.SetLocalPath(ctx, "123@s.whatsapp.net", "doc", "/tmp/a.pdf"))
+	pending, err = store.Messages.ListPendingMedia(ctx, "123@s.whatsapp.net", 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "img", pending[0].MessageID)
+
+	err = store.Messages.SetLocalPath(ctx, "123@s.whatsapp.net", "missing", "/tmp/x")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSQLiteMessageRepo_StoreAgainKeepsLocalState(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	jid := "123@s.whatsapp.net"
+	require.NoError(t, store.Chats.Upsert(ctx, &Chat{JID: jid}))
+	msg := Message{
+		ID: "img", ChatJID: jid, Sender: jid, MediaType: "image", Timestamp: time.Now(),
+		DirectPath: "/v/t62/abc", MediaKey: []byte("key"), FileSHA256: []byte("sha"), FileEncSHA256: []byte("enc"), FileLength: 2048,
+	}
+	require.NoError(t, store.Messages.Store(ctx, &msg))
+	require.NoError(t, store.Messages.SetLocalPath(ctx, jid, "img", "/tmp/img.jpg"))
+	require.NoError(t, store.Messages.SetStarred(ctx, jid, "img", true))
+	require.NoError(t, store.Messages.SetReaction(ctx, jid, "img", Reaction{Sender: "456@s.whatsapp.net", Emoji: "👍", ReactedAt: time.Now()}))
+
+	// The same message arrives again, e.g. in a history sync
+	msg.Content = "caption"
+	require.NoError(t, store.Messages.Store(ctx, &msg))
+
+	pending, err := store.Messages.ListPendingMedia(ctx, jid, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending, "downloaded media stays downloaded")
+
+	got, err := store.Messages.GetByID(ctx, jid, "img")
+	require.NoError(t, err)
+	assert.Equal(t, "caption", got.Content)
+	assert.True(t, got.IsStarred)
+	reactions, err := store.Messages.GetReactions(ctx, jid, "img")
+	require.NoError(t, err)
+	assert.Len(t, reactions, 1)
+
+	media, err := store.Messages.Media(ctx, jid, "img")
+	require.NoError(t, err)
+	assert.Equal(t, "/v/t62/abc", media.DirectPath)
+	assert.Equal(t, []byte("key"), media.MediaKey)
+	assert.Equal(t, []byte("enc"), media.FileEncSHA256)
+	assert.Equal(t, uint64(2048), media.FileLength)
+
+	_, err = store.Messages.Media(ctx, jid, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
 func TestSQLiteMessageRepo_Delete(t *testing.T) {
 	store := setupTestDB(t)
 	ctx := context.Background()
@@ -127,6 +457,83 @@ func TestSQLiteMessageRepo_Delete(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestSQLiteMessageRepo_Oldest(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	chat := &Chat{JID: "123@s.whatsapp.net", Name: "Test Chat"}
+	require.NoError(t, store.Chats.Upsert(ctx, chat))
+
+	_, err := store.Messages.Oldest(ctx, chat.JID)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	now := time.Now()
+	for i, id := range []string{"new", "old", "mid"} {
+		offsets := []time.Duration{0, -2 * time.Hour, -time.Hour}
+		msg := &Message{ID: id, ChatJID: chat.JID, Sender: "a", Content: id, Timestamp: now.Add(offsets[i])}
+		require.NoError(t, store.Messages.Store(ctx, msg))
+	}
+
+	oldest, err := store.Messages.Oldest(ctx, chat.JID)
+	require.NoError(t, err)
+	assert.Equal(t, "old", oldest.ID)
+}
+
+func TestSQLiteMessageRepo_LatestReceived(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.Chats.Upsert(ctx, &Chat{JID: "123@s.whatsapp.net"}))
+
+	_, err := store.Messages.LatestReceived(ctx)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	received := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	require.NoError(t, store.Messages.Store(ctx, &Message{ID: "in", ChatJID: "123@s.whatsapp.net", Sender: "a", Timestamp: received}))
+	require.NoError(t, store.Messages.Store(ctx, &Message{ID: "out", ChatJID: "123@s.whatsapp.net", Sender: "me", IsFromMe: true, Timestamp: time.Now()}))
+
+	latest, err := store.Messages.LatestReceived(ctx)
+	require.NoError(t, err)
+	assert.True(t, latest.Equal(received), "got %v, want %v", latest, received)
+}
+
+func TestSQLiteMessageRepo_Reactions(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	chatJID := "123@s.whatsapp.net"
+	require.NoError(t, store.Chats.Upsert(ctx, &Chat{JID: chatJID}))
+	require.NoError(t, store.Messages.Store(ctx, &Message{ID: "msg1", ChatJID: chatJID, Sender: "a", Timestamp: time.Now()}))
+
+	reactions, err := store.Messages.GetReactions(ctx, chatJID, "msg1")
+	require.NoError(t, err)
+	assert.NotNil(t, reactions)
+	assert.Empty(t, reactions)
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, store.Messages.SetReaction(ctx, chatJID, "msg1", Reaction{Sender: "a", Emoji: "👍", ReactedAt: at}))
+	require.NoError(t, store.Messages.SetReaction(ctx, chatJID, "msg1", Reaction{Sender: "b", Emoji: "😂", ReactedAt: at}))
+	require.NoError(t, store.Messages.SetReaction(ctx, chatJID, "msg1", Reaction{Sender: "a", Emoji: "❤️", ReactedAt: at}))
+
+	reactions, err = store.Messages.GetReactions(ctx, chatJID, "msg1")
+	require.NoError(t, err)
+	require.Len(t, reactions, 2)
+	assert.Equal(t, Reaction{Sender: "b", Emoji: "😂", ReactedAt: at}, reactions[0])
+	assert.Equal(t, "a", reactions[1].Sender)
+	assert.Equal(t, "❤️", reactions[1].Emoji, "reacting again replaces the earlier reaction")
+
+	// An empty emoji removes the sender's reaction
+	require.NoError(t, store.Messages.SetReaction(ctx, chatJID, "msg1", Reaction{Sender: "b"}))
+	reactions, err = store.Messages.GetReactions(ctx, chatJID, "msg1")
+	require.NoError(t, err)
+	require.Len(t, reactions, 1)
+	assert.Equal(t, "a", reactions[0].Sender)
+
+	_, err = store.Messages.GetReactions(ctx, chatJID, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, store.Messages.SetReaction(ctx, chatJID, "missing", Reaction{Sender: "a", Emoji: "👍"}), ErrNotFound)
+}
+
 // Chat Repository Tests
 
 func TestSQLiteChatRepo_Upsert(t *testing.T) {
@@ -212,6 +619,54 @@ func TestSQLiteChatRepo_Pin(t *testing.T) {
 
 	retrieved, _ := store.Chats.GetByJID(ctx, chat.JID)
 	assert.True(t, retrieved.Pinned)
+
+	require.NoError(t, store.Chats.Upsert(ctx, &Chat{JID: "456@s.whatsapp.net", Pinned: true}))
+	require.NoError(t, store.Chats.Upsert(ctx, &Chat{JID: "789@s.whatsapp.net"}))
+	count, err := store.Chats.CountPinned(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestSQLiteChatRepo_Unread(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	chat := &Chat{JID: "123@s.whatsapp.net", Name: "Test", UnreadCount: 2}
+	require.NoError(t, store.Chats.Upsert(ctx, chat))
+
+	// Increment
+	require.NoError(t, store.Chats.IncrementUnread(ctx, chat.JID))
+	require.NoError(t, store.Chats.IncrementUnread(ctx, chat.JID))
+	retrieved, err := store.Chats.GetByJID(ctx, chat.JID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, retrieved.UnreadCount)
+
+	// Reset
+	require.NoError(t, store.Chats.ResetUnread(ctx, chat.JID))
+	retrieved, err = store.Chats.GetByJID(ctx, chat.JID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retrieved.UnreadCount)
+
+	// Marking a read chat unread sets the sentinel; new messages count from zero
+	require.NoError(t, store.Chats.MarkUnread(ctx, chat.JID))
+	retrieved, err = store.Chats.GetByJID(ctx, chat.JID)
+	require.NoError(t, err)
+	assert.Equal(t, UnreadMarked, retrieved.UnreadCount)
+	require.NoError(t, store.Chats.IncrementUnread(ctx, chat.JID))
+	retrieved, err = store.Chats.GetByJID(ctx, chat.JID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retrieved.UnreadCount)
+
+	// A chat with unread messages keeps its count
+	require.NoError(t, store.Chats.MarkUnread(ctx, chat.JID))
+	retrieved, err = store.Chats.GetByJID(ctx, chat.JID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retrieved.UnreadCount)
+
+	// Unknown chats are left alone
+	require.NoError(t, store.Chats.IncrementUnread(ctx, "missing@s.whatsapp.net"))
+	_, err = store.Chats.GetByJID(ctx, "missing@s.whatsapp.net")
+	assert.ErrorIs(t, err, ErrNotFound)
 }
 
 func TestSQLiteChatRepo_Mute(t *testing.T) {
@@ -258,6 +713,133 @@ func TestSQLiteContactRepo_Upsert(t *testing.T) {
 	assert.Equal(t, "John Doe", retrieved.Name)
 }
 
+func TestSQLiteContactRepo_UpsertPushName(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	// Unknown senders get a row with just the push name
+	require.NoError(t, store.Contacts.UpsertPushName(ctx, "1@s.whatsapp.net", "Johnny"))
+	retrieved, err := store.Contacts.GetByJID(ctx, "1@s.whatsapp.net")
+	require.NoError(t, err)
+	assert.Equal(t, "", retrieved.Name)
+	assert.Equal(t, "Johnny", retrieved.PushName)
+
+	// A saved name survives later push name updates
+	require.NoError(t, store.Contacts.Upsert(ctx, &Contact{JID: "2@s.whatsapp.net", Name: "Jane Doe", IsSaved: true, Blocked: true}))
+	require.NoError(t, store.Contacts.UpsertPushName(ctx, "2@s.whatsapp.net", "jd"))
+	retrieved, err = store.Contacts.GetByJID(ctx, "2@s.whatsapp.net")
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", retrieved.Name)
+	assert.Equal(t, "jd", retrieved.PushName)
+	assert.True(t, retrieved.IsSaved)
+	assert.True(t, retrieved.Blocked)
+}
+
+func TestSQLiteContactRepo_UpsertBusinessAndSavedName(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.Contacts.Upsert(ctx, &Contact{JID: "1@s.whatsapp.net", PushName: "Bo"}))
+	require.NoError(t, store.Contacts.UpsertBusinessName(ctx, "1@s.whatsapp.net", "Bo's Bikes"))
+	require.NoError(t, store.Contacts.UpsertSavedName(ctx, "1@s.whatsapp.net", "Bo Jensen"))
+
+	retrieved, err := store.Contacts.GetByJID(ctx, "1@s.whatsapp.net")
+	require.NoError(t, err)
+	assert.Equal(t, "Bo", retrieved.PushName)
+	assert.Equal(t, "Bo's Bikes", retrieved.BusinessName)
+	assert.Equal(t, "Bo Jensen", retrieved.Name)
+	assert.True(t, retrieved.IsSaved)
+
+	// Removing the contact from the address book clears the saved name
+	require.NoError(t, store.Contacts.UpsertSavedName(ctx, "1@s.whatsapp.net", ""))
+	retrieved, err = store.Contacts.GetByJID(ctx, "1@s.whatsapp.net")
+	require.NoError(t, err)
+	assert.Equal(t, "", retrieved.Name)
+	assert.False(t, retrieved.IsSaved)
+}
+
+func TestSQLiteMessageRepo_Stats(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	chatJID := "120363000000000000@g.us"
+	require.NoError(t, store.Chats.Upsert(ctx, &Chat{JID: chatJID, IsGroup: true}))
+	require.NoError(t, store.Contacts.Upsert(ctx, &Contact{JID: "1@s.whatsapp.net", Name: "Jane Doe"}))
+
+	start := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	seed := []struct {
+		sender    string
+		mediaType string
+	}{
+		{"1@s.whatsapp.net", ""},
+		{"1@s.whatsapp.net", "image"},
+		{"me", ""},
+		{"1@s.whatsapp.net", ""},
+		{"2@s.whatsapp.net", "document"},
+		{"me", "image"},
+	}
+	for i, m := range seed {
+		require.NoError(t, store.Messages.Store(ctx, &Message{
+			ID: fmt.Sprintf("msg%d", i), ChatJID: chatJID, Sender: m.sender, MediaType: m.mediaType,
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+
+	stats, err := store.Messages.Stats(ctx, chatJID)
+	require.NoError(t, err)
+	assert.Equal(t, 6, stats.MessageCount)
+	assert.Equal(t, map[string]int{"text": 3, "image": 2, "document": 1}, stats.ByMediaType)
+	assert.Equal(t, []SenderCount{
+		{Sender: "1@s.whatsapp.net", Name: "Jane Doe", Count: 3},
+		{Sender: "me", Count: 2},
+		{Sender: "2@s.whatsapp.net", Count: 1},
+	}, stats.BySender)
+	require.NotNil(t, stats.FirstMessage)
+	require.NotNil(t, stats.LastMessage)
+	assert.True(t, start.Equal(*stats.FirstMessage))
+	assert.True(t, start.Add(5*time.Minute).Equal(*stats.LastMessage))
+
+	// An empty chat has zero counts and no timestamps
+	empty, err := store.Messages.Stats(ctx, "other@s.whatsapp.net")
+	require.NoError(t, err)
+	assert.Zero(t, empty.MessageCount)
+	assert.Empty(t, empty.ByMediaType)
+	assert.Nil(t, empty.FirstMessage)
+}
+
+func TestSQLiteMessageRepo_ListSenderName(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	chatJID := "120363000000000000@g.us"
+	require.NoError(t, store.Chats.Upsert(ctx, &Chat{JID: chatJID, IsGroup: true}))
+	require.NoError(t, store.Contacts.Upsert(ctx, &Contact{JID: "1@s.whatsapp.net", Name: "Jane Doe", PushName: "jd"}))
+	require.NoError(t, store.Contacts.UpsertPushName(ctx, "2@s.whatsapp.net", "Johnny"))
+
+	now := time.Now()
+	for i, sender := range []string{"1@s.whatsapp.net", "2@s.whatsapp.net", "3@s.whatsapp.net"} {
+		require.NoError(t, store.Messages.Store(ctx, &Message{
+			ID: fmt.Sprintf("msg%d", i), ChatJID: chatJID, Sender: sender, Content: "hi", Timestamp: now.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	messages, err := store.Messages.List(ctx, chatJID, 10, "", "")
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	names := map[string]string{}
+	for _, m := range messages {
+		names[m.Sender] = m.SenderName
+	}
+	assert.Equal(t, "Jane Doe", names["1@s.whatsapp.net"], "saved name wins over push name")
+	assert.Equal(t, "Johnny", names["2@s.whatsapp.net"])
+	assert.Equal(t, "", names["3@s.whatsapp.net"])
+
+	results, err := store.Messages.Search(ctx, "hi", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, "Johnny", results[1].SenderName)
+}
+
 func TestSQLiteContactRepo_Search(t *testing.T) {
 	store := setupTestDB(t)
 	ctx := context.Background()
@@ -278,6 +860,36 @@ func TestSQLiteContactRepo_Search(t *testing.T) {
 	assert.Len(t, results, 2)
 }
 
+func TestSQLiteContactRepo_List(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	for _, jid := range []string{"3@s.whatsapp.net", "1@s.whatsapp.net", "2@s.whatsapp.net"} {
+		require.NoError(t, store.Contacts.Upsert(ctx, &Contact{JID: jid}))
+	}
+	lastMsg := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, store.Chats.Upsert(ctx, &Chat{JID: "2@s.whatsapp.net", LastMessageTime: lastMsg}))
+
+	page, err := store.Contacts.List(ctx, 2, 1)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "2@s.whatsapp.net", page[0].JID)
+	assert.Equal(t, "3@s.whatsapp.net", page[1].JID)
+
+	all, err := store.Contacts.List(ctx, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	withChats, err := store.Contacts.ListWithChats(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, withChats, 3)
+	assert.False(t, withChats[0].HasChat)
+	assert.Nil(t, withChats[0].LastMessageTime)
+	assert.True(t, withChats[1].HasChat)
+	require.NotNil(t, withChats[1].LastMessageTime)
+	assert.True(t, lastMsg.Equal(*withChats[1].LastMessageTime))
+}
+
 func TestSQLiteContactRepo_Block(t *testing.T) {
 	store := setupTestDB(t)
 	ctx := context.Background()
@@ -301,8 +913,115 @@ func TestSQLiteContactRepo_Block(t *testing.T) {
 	assert.Len(t, blocked, 0)
 }
 
+// Label Repository Tests
+
+func TestSQLiteLabelRepo_UpsertAndList(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.Labels.Upsert(ctx, &Label{ID: "1", Name: "New customer", Color: 2}))
+	require.NoError(t, store.Labels.Upsert(ctx, &Label{ID: "2", Name: "Follow up", Color: 5}))
+
+	// Upsert updates an existing label in place
+	require.NoError(t, store.Labels.Upsert(ctx, &Label{ID: "1", Name: "Lead", Color: 3}))
+
+	labels, err := store.Labels.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, labels, 2)
+	assert.Equal(t, "Follow up", labels[0].Name)
+	assert.Equal(t, "Lead", labels[1].Name)
+	assert.Equal(t, int32(3), labels[1].Color)
+}
+
+func TestSQLiteLabelRepo_ChatLabels(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.Labels.Upsert(ctx, &Label{ID: "1", Name: "Lead"}))
+	require.NoError(t, store.Labels.SetChatLabel(ctx, "123@s.whatsapp.net", "1", true))
+	require.NoError(t, store.Labels.SetChatLabel(ctx, "456@s.whatsapp.net", "1", true))
+	// Labeling twice is a no-op
+	require.NoError(t, store.Labels.SetChatLabel(ctx, "123@s.whatsapp.net", "1", true))
+
+	labels, err := store.Labels.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, labels, 1)
+	assert.Equal(t, []string{"123@s.whatsapp.net", "456@s.whatsapp.net"}, labels[0].ChatJIDs)
+
+	// Unlabel
+	require.NoError(t, store.Labels.SetChatLabel(ctx, "123@s.whatsapp.net", "1", false))
+	labels, _ = store.Labels.List(ctx)
+	assert.Equal(t, []string{"456@s.whatsapp.net"}, labels[0].ChatJIDs)
+
+	// Deleting a label removes its associations
+	require.NoError(t, store.Labels.Delete(ctx, "1"))
+	labels, _ = store.Labels.List(ctx)
+	assert.Empty(t, labels)
+
+	require.NoError(t, store.Labels.Upsert(ctx, &Label{ID: "1", Name: "Lead"}))
+	labels, _ = store.Labels.List(ctx)
+	require.Len(t, labels, 1)
+	assert.Empty(t, labels[0].ChatJIDs)
+}
+
 // State Repository Tests
 
+func TestSQLitePresenceRepo(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+	jid := "123@s.whatsapp.net"
+
+	_, err := store.Presence.Get(ctx, jid)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	lastSeen := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, store.Presence.SetOnline(ctx, jid, false, lastSeen))
+	require.NoError(t, store.Presence.SetComposing(ctx, jid, true))
+
+	p, err := store.Presence.Get(ctx, jid)
+	require.NoError(t, err)
+	assert.False(t, p.Online)
+	assert.True(t, p.Composing)
+	require.NotNil(t, p.LastSeen)
+	assert.True(t, lastSeen.Equal(*p.LastSeen))
+
+	// A hidden last seen keeps the previous value
+	require.NoError(t, store.Presence.SetOnline(ctx, jid, true, time.Time{}))
+	p, err = store.Presence.Get(ctx, jid)
+	require.NoError(t, err)
+	assert.True(t, p.Online)
+	require.NotNil(t, p.LastSeen)
+	assert.True(t, lastSeen.Equal(*p.LastSeen))
+}
+
+func TestSQLiteGroupRepo_InviteLink(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+	jid := "120363000000000001@g.us"
+
+	_, _, err := store.Groups.GetInviteLink(ctx, jid)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// A group that was never stored gets a placeholder row
+	fetchedAt := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, store.Groups.SetInviteLink(ctx, jid, "https://chat.whatsapp.com/old", fetchedAt))
+	link, gotAt, err := store.Groups.GetInviteLink(ctx, jid)
+	require.NoError(t, err)
+	assert.Equal(t, "https://chat.whatsapp.com/old", link)
+	assert.True(t, fetchedAt.Equal(gotAt))
+
+	// Later fetches replace the link without touching the rest of the group
+	require.NoError(t, store.Groups.Upsert(ctx, &Group{JID: jid, Name: "Book Club", InviteLink: link}))
+	require.NoError(t, store.Groups.SetInviteLink(ctx, jid, "https://chat.whatsapp.com/new", fetchedAt.Add(time.Hour)))
+	group, err := store.Groups.GetByJID(ctx, jid)
+	require.NoError(t, err)
+	assert.Equal(t, "Book Club", group.Name)
+	assert.Equal(t, "https://chat.whatsapp.com/new", group.InviteLink)
+	_, gotAt, err = store.Groups.GetInviteLink(ctx, jid)
+	require.NoError(t, err)
+	assert.True(t, fetchedAt.Add(time.Hour).Equal(gotAt))
+}
+
 func TestSQLiteStateRepo_SaveAndGet(t *testing.T) {
 	store := setupTestDB(t)
 	ctx := context.Background()
@@ -341,3 +1060,141 @@ func TestSQLiteStateRepo_LogTransition(t *testing.T) {
 	assert.Equal(t, state.StateConnecting, history[0].FromState)
 	assert.Equal(t, state.StateReady, history[0].ToState)
 }
+
+func TestSQLiteStateRepo_ListTransitions_Filters(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	seed := []struct {
+		from, to state.State
+		trigger  string
+		at       time.Time
+		errText  string
+	}{
+		{state.StateReady, state.StateReconnecting, "connection_lost", now.Add(-48 * time.Hour), ""},
+		{state.StateReconnecting, state.StateReady, "reconnected", now.Add(-47 * time.Hour), ""},
+		{state.StateReady, state.StateReconnecting, "connection_lost", now.Add(-2 * time.Hour), ""},
+		{state.StateReconnecting, state.StateFatalError, "fatal_error", now.Add(-1 * time.Hour), "stream replaced"},
+	}
+	for _, s := range seed {
+		_, err := store.db.ExecContext(ctx,
+			"INSERT INTO transitions (from_state, to_state, trigger, timestamp, error) VALUES (?, ?, ?, ?, ?)",
+			string(s.from), string(s.to), s.trigger, s.at, s.errText,
+		)
+		require.NoError(t, err)
+	}
+
+	all, err := store.State.ListTransitions(ctx, TransitionFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+
+	reconnecting, err := store.State.ListTransitions(ctx, TransitionFilter{Limit: 10, State: state.StateReconnecting})
+	require.NoError(t, err)
+	assert.Len(t, reconnecting, 2)
+
+	today, err := store.State.ListTransitions(ctx, TransitionFilter{Limit: 10, Since: now.Add(-24 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, today, 2)
+	assert.Equal(t, state.StateFatalError, today[0].ToState)
+	assert.Equal(t, "stream replaced", today[0].Error)
+
+	both, err := store.State.ListTransitions(ctx, TransitionFilter{
+		Limit: 10,
+		Since: now.Add(-24 * time.Hour).UTC(),
+		State: state.StateReconnecting,
+	})
+	require.NoError(t, err)
+	require.Len(t, both, 1)
+	assert.Equal(t, "connection_lost", both[0].Trigger)
+}
+
+func TestSQLiteDeadLetterRepo(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	// The chat doesn't exist, which is one way a message store fails
+	msg := &Message{ID: "M1", ChatJID: "404@s.whatsapp.net", Sender: "404@s.whatsapp.net", Content: "hi",
+		Timestamp: time.Now(), MediaType: "image", Thumbnail: []byte("jpeg")}
+	storeErr := store.Messages.Store(ctx, msg)
+	require.Error(t, storeErr)
+
+	require.NoError(t, store.DeadLetters.Add(ctx, msg, storeErr))
+	require.NoError(t, store.DeadLetters.Add(ctx, &Message{ID: "M2", ChatJID: "404@s.whatsapp.net", Timestamp: time.Now()}, storeErr))
+
+	failed, err := store.DeadLetters.List(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 2)
+	assert.Equal(t, "M1", failed[0].Message.ID, "oldest first")
+	assert.Equal(t, "hi", failed[0].Message.Content)
+	assert.Equal(t, []byte("jpeg"), failed[0].Message.Thumbnail)
+	assert.Equal(t, storeErr.Error(), failed[0].Error)
+	assert.Equal(t, 1, failed[0].Attempts)
+
+	require.NoError(t, store.DeadLetters.RecordAttempt(ctx, failed[0].ID, errors.New("database is locked")))
+	require.NoError(t, store.DeadLetters.Delete(ctx, failed[1].ID))
+
+	failed, err = store.DeadLetters.List(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, 2, failed[0].Attempts)
+	assert.Equal(t, "database is locked", failed[0].Error)
+
+	n, err := store.DeadLetters.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestSQLiteAuditRepo(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	entries := []AuditEntry{
+		{Tool: "send_message", TargetJID: "123@s.whatsapp.net", Timestamp: now.Add(-2 * time.Hour), Success: true},
+		{Tool: "leave_group", TargetJID: "456@g.us", Timestamp: now.Add(-time.Hour), Success: false, ErrorCode: "INTERNAL_ERROR"},
+		{Tool: "send_message", TargetJID: "789@s.whatsapp.net", Timestamp: now, Success: true},
+	}
+	for i := range entries {
+		require.NoError(t, store.Audit.Append(ctx, &entries[i]))
+		assert.NotZero(t, entries[i].ID)
+	}
+
+	all, err := store.Audit.List(ctx, AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "789@s.whatsapp.net", all[0].TargetJID, "newest first")
+	assert.Equal(t, "INTERNAL_ERROR", all[1].ErrorCode)
+	assert.False(t, all[1].Success)
+
+	sends, err := store.Audit.List(ctx, AuditFilter{Limit: 10, Tool: "send_message"})
+	require.NoError(t, err)
+	assert.Len(t, sends, 2)
+
+	window, err := store.Audit.List(ctx, AuditFilter{Limit: 10, Since: now.Add(-90 * time.Minute), Until: now.Add(-time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, window, 1)
+	assert.Equal(t, "leave_group", window[0].Tool)
+
+	// The log is append-only
+	_, err = store.db.ExecContext(ctx, "UPDATE audit_log SET success = 1")
+	assert.Error(t, err)
+	_, err = store.db.ExecContext(ctx, "DELETE FROM audit_log")
+	assert.Error(t, err)
+}
+
+func TestSQLiteStateRepo_LogTransitionWithError(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	err := store.State.LogTransitionWithError(ctx, state.StateReady, state.StateSessionExpired, "session_invalid", "401: logged out from another device")
+	require.NoError(t, err)
+	err = store.State.LogTransition(ctx, state.StateSessionExpired, state.StateConnecting, "connect")
+	require.NoError(t, err)
+
+	history, err := store.State.GetTransitionHistory(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Empty(t, history[0].Error)
+	assert.Equal(t, "401: logged out from another device", history[1].Error)
+}
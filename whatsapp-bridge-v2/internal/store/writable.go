@@ -0,0 +1,58 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// ErrNotWritable is returned by CheckWritable when files cannot be created in
+// the store directory.
+var ErrNotWritable = errors.New("store directory is not writable")
+
+// CheckWritable verifies that new files can be created in dir by writing and
+// removing a small temporary file. SQLite reports a read-only directory or a
+// full disk as a generic open or migration failure, so this is meant to run
+// before the store is opened. The returned error wraps ErrNotWritable and
+// names the directory along with a hint on how to fix it.
+func CheckWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return notWritable(dir, err)
+	}
+	name := f.Name()
+	defer os.Remove(name)
+
+	if _, err := f.Write([]byte("ok")); err != nil {
+		f.Close()
+		return notWritable(dir, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return notWritable(dir, err)
+	}
+	if err := f.Close(); err != nil {
+		return notWritable(dir, err)
+	}
+	if err := os.Remove(name); err != nil {
+		return notWritable(dir, err)
+	}
+	return nil
+}
+
+func notWritable(dir string, err error) error {
+	var hint string
+	switch {
+	case errors.Is(err, fs.ErrPermission), errors.Is(err, syscall.EROFS):
+		hint = "check that the directory is owned by the current user and not on a read-only filesystem"
+	case errors.Is(err, syscall.ENOSPC):
+		hint = "the disk is full; free some space"
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, syscall.ENOTDIR):
+		hint = "the path does not exist or is not a directory"
+	default:
+		hint = "check the directory permissions and free disk space"
+	}
+	return fmt.Errorf("%w: %s: %s (%v)", ErrNotWritable, dir, hint, err)
+}
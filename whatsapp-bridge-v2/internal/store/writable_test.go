@@ -0,0 +1,44 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, CheckWritable(dir))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the probe file must be removed")
+}
+
+func TestCheckWritable_ReadOnlyDir(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.Chmod(dir, 0500))
+	t.Cleanup(func() { os.Chmod(dir, 0700) })
+
+	err := CheckWritable(dir)
+	require.ErrorIs(t, err, ErrNotWritable)
+	assert.Contains(t, err.Error(), dir)
+	assert.Contains(t, err.Error(), "owned by the current user")
+}
+
+func TestCheckWritable_NotADirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, nil, 0600))
+
+	err := CheckWritable(path)
+	require.ErrorIs(t, err, ErrNotWritable)
+	assert.Contains(t, err.Error(), path)
+	assert.Contains(t, err.Error(), "not a directory")
+}
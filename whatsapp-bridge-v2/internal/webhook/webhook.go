@@ -0,0 +1,219 @@
+// Package webhook forwards bridge events to an HTTP endpoint as signed JSON.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/bridge"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/config"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body, prefixed
+// with "sha256=". It is only set when a secret is configured.
+const SignatureHeader = "X-Webhook-Signature"
+
+// queueSize bounds the number of payloads waiting for delivery.
+const queueSize = 256
+
+// Payload is the JSON body POSTed for each event.
+type Payload struct {
+	Type      string      `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// MessageData describes an incoming message.
+type MessageData struct {
+	ID        string    `json:"id"`
+	ChatJID   string    `json:"chat_jid"`
+	Sender    string    `json:"sender"`
+	Content   string    `json:"content"`
+	MediaType string    `json:"media_type,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// StateChangeData describes a bridge state transition.
+type StateChangeData struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Trigger string `json:"trigger"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Notifier delivers bridge events to a webhook URL in the background.
+type Notifier struct {
+	mu         sync.RWMutex
+	url        string
+	secret     string
+	maxRetries int
+	client     *http.Client
+	log        *slog.Logger
+
+	queue chan Payload
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewNotifier creates a notifier from the webhook settings in cfg.
+func NewNotifier(cfg *config.Config, log *slog.Logger) *Notifier {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Notifier{
+		url:        cfg.WebhookURL,
+		secret:     cfg.WebhookSecret,
+		maxRetries: cfg.WebhookMaxRetries,
+		client:     &http.Client{Timeout: cfg.WebhookTimeout},
+		log:        log,
+		queue:      make(chan Payload, queueSize),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start begins delivering queued events.
+func (n *Notifier) Start() {
+	n.wg.Add(1)
+	go n.run()
+}
+
+// Stop cancels pending deliveries and waits for the worker to exit.
+func (n *Notifier) Stop() {
+	n.cancel()
+	n.wg.Wait()
+}
+
+// SetURL changes where events are delivered. Payloads already being
+// retried go to the new URL too. An empty URL stops delivery until a URL is
+// set again.
+func (n *Notifier) SetURL(url string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.url = url
+}
+
+func (n *Notifier) currentURL() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.url
+}
+
+// HandleEvent is a bridge event listener. Incoming messages and state
+// changes are queued for delivery; everything else is ignored.
+func (n *Notifier) HandleEvent(evt bridge.Event) {
+	if n.currentURL() == "" {
+		return
+	}
+
+	var data interface{}
+
+	switch p := evt.Payload.(type) {
+	case bridge.MessagePayload:
+		if p.IsFromMe {
+			return
+		}
+		data = MessageData{
+			ID:        p.ID,
+			ChatJID:   p.ChatJID,
+			Sender:    p.Sender,
+			Content:   p.Content,
+			MediaType: p.MediaType,
+			Timestamp: p.Timestamp,
+		}
+	case bridge.StateChangePayload:
+		data = StateChangeData{
+			From:    p.From,
+			To:      p.To,
+			Trigger: p.Trigger,
+			Error:   p.Error,
+		}
+	default:
+		return
+	}
+
+	payload := Payload{
+		Type:      evt.Type.String(),
+		Timestamp: evt.Timestamp,
+		Data:      data,
+	}
+
+	select {
+	case n.queue <- payload:
+	default:
+		n.log.Warn("webhook queue full, dropping event", "type", payload.Type)
+	}
+}
+
+func (n *Notifier) run() {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case payload := <-n.queue:
+			if err := n.deliver(payload); err != nil {
+				n.log.Error("webhook delivery failed", "type", payload.Type, "error", err)
+			}
+		}
+	}
+}
+
+// deliver POSTs a payload, retrying with exponential backoff on transport
+// errors and non-2xx responses.
+func (n *Notifier) deliver(payload Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = 500 * time.Millisecond
+	bo.MaxInterval = 10 * time.Second
+
+	return backoff.Retry(func() error {
+		return n.post(body)
+	}, backoff.WithContext(backoff.WithMaxRetries(bo, uint64(n.maxRetries)), n.ctx))
+}
+
+func (n *Notifier) post(body []byte) error {
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, n.currentURL(), bytes.NewReader(body))
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(n.secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of body using secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
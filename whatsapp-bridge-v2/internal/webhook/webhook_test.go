@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/bridge"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/config"
+)
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func newTestNotifier(t *testing.T, url string) *Notifier {
+	cfg := config.DefaultConfig()
+	cfg.WebhookURL = url
+	cfg.WebhookSecret = "s3cret"
+	cfg.WebhookTimeout = time.Second
+
+	n := NewNotifier(cfg, testLogger())
+	n.Start()
+	t.Cleanup(n.Stop)
+	return n
+}
+
+func messageEvent(fromMe bool) bridge.Event {
+	return bridge.NewEvent(bridge.EventMessage, bridge.MessagePayload{
+		ID:        "MSG1",
+		ChatJID:   "1234567890@s.whatsapp.net",
+		Sender:    "1234567890",
+		Content:   "hello",
+		IsFromMe:  fromMe,
+		Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Persisted: true,
+	})
+}
+
+func TestNotifier_MessageEvent(t *testing.T) {
+	received := make(chan request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- request{header: r.Header.Clone(), body: body}
+	}))
+	defer srv.Close()
+
+	n := newTestNotifier(t, srv.URL)
+	n.HandleEvent(messageEvent(false))
+
+	var req request
+	select {
+	case req = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+
+	assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+	assert.Equal(t, "sha256="+Sign("s3cret", req.body), req.header.Get(SignatureHeader))
+
+	var payload struct {
+		Type      string    `json:"type"`
+		Timestamp time.Time `json:"timestamp"`
+		Data      struct {
+			ID        string    `json:"id"`
+			ChatJID   string    `json:"chat_jid"`
+			Sender    string    `json:"sender"`
+			Content   string    `json:"content"`
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(req.body, &payload))
+	assert.Equal(t, "message", payload.Type)
+	assert.False(t, payload.Timestamp.IsZero())
+	assert.Equal(t, "MSG1", payload.Data.ID)
+	assert.Equal(t, "1234567890@s.whatsapp.net", payload.Data.ChatJID)
+	assert.Equal(t, "1234567890", payload.Data.Sender)
+	assert.Equal(t, "hello", payload.Data.Content)
+	assert.True(t, payload.Data.Timestamp.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+}
+
+func TestNotifier_RetriesOnServerError(t *testing.T) {
+	var calls atomic.Int32
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		close(done)
+	}))
+	defer srv.Close()
+
+	n := newTestNotifier(t, srv.URL)
+	n.HandleEvent(messageEvent(false))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not retried")
+	}
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestNotifier_SkipsOutgoingMessages(t *testing.T) {
+	received := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload Payload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload.Type
+	}))
+	defer srv.Close()
+
+	n := newTestNotifier(t, srv.URL)
+	n.HandleEvent(messageEvent(true))
+	n.HandleEvent(bridge.NewEvent(bridge.EventStateChange, bridge.StateChangePayload{
+		From:    "connecting",
+		To:      "ready",
+		Trigger: "sync_complete",
+	}))
+
+	select {
+	case typ := <-received:
+		assert.Equal(t, "state_change", typ, "outgoing message must not be delivered")
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+}
+
+func TestNotifier_SetURL(t *testing.T) {
+	received := make(chan string, 2)
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) { received <- name }
+	}
+	oldSrv := httptest.NewServer(handler("old"))
+	defer oldSrv.Close()
+	newSrv := httptest.NewServer(handler("new"))
+	defer newSrv.Close()
+
+	n := newTestNotifier(t, oldSrv.URL)
+
+	// Without a URL events are dropped rather than queued
+	n.SetURL("")
+	n.HandleEvent(messageEvent(false))
+
+	n.SetURL(newSrv.URL)
+	n.HandleEvent(messageEvent(false))
+
+	select {
+	case name := <-received:
+		assert.Equal(t, "new", name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+	assert.Empty(t, received)
+}
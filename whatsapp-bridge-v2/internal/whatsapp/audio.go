@@ -0,0 +1,263 @@
+package whatsapp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+)
+
+const (
+	// waveformSamples is the number of bars in a voice note's waveform, the
+	// same as the official clients send.
+	waveformSamples = 64
+
+	// audioSourceLimit bounds how much of a file is read to inspect it;
+	// WhatsApp audio can't be larger than this anyway.
+	audioSourceLimit = 16 << 20
+
+	// opusSampleRate is the rate Ogg Opus granule positions are counted in,
+	// regardless of the input's original rate.
+	opusSampleRate = 48000
+)
+
+// audioMetadata inspects the Ogg (Opus or Vorbis) or MP3 file at path and
+// returns its duration in whole seconds and a waveform for voice notes. It
+// is best-effort: seconds is 0 when the length can't be derived and the
+// waveform is nil when it can't be built.
+func audioMetadata(path string) (seconds uint32, waveform []byte) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, nil
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, audioSourceLimit))
+	if err != nil {
+		return 0, nil
+	}
+
+	var duration float64
+	var sizes []int
+	if bytes.HasPrefix(data, []byte("OggS")) {
+		duration, sizes = parseOgg(data)
+	} else {
+		duration, sizes = parseMP3(data)
+	}
+
+	if duration > 0 {
+		seconds = uint32(math.Max(1, math.Round(duration)))
+	}
+	return seconds, buildWaveform(sizes)
+}
+
+// parseOgg returns the duration of the first logical stream of an Ogg file
+// and the sizes of its audio packets. The duration comes from the granule
+// position of the stream's last page, less the Opus pre-skip.
+func parseOgg(data []byte) (float64, []int) {
+	var (
+		serial      uint32
+		sampleRate  uint32
+		preSkip     uint64
+		lastGranule uint64
+		packets     int
+		packet      int
+		sizes       []int
+	)
+
+	for pos := 0; pos+27 <= len(data); {
+		if !bytes.Equal(data[pos:pos+4], []byte("OggS")) {
+			break
+		}
+		granule := binary.LittleEndian.Uint64(data[pos+6:])
+		pageSerial := binary.LittleEndian.Uint32(data[pos+14:])
+		segments := int(data[pos+26])
+		bodyStart := pos + 27 + segments
+		if bodyStart > len(data) {
+			break
+		}
+		lacing := data[pos+27 : bodyStart]
+
+		bodyLen := 0
+		for _, l := range lacing {
+			bodyLen += int(l)
+		}
+		if bodyStart+bodyLen > len(data) {
+			break
+		}
+
+		if packets == 0 && packet == 0 {
+			serial = pageSerial
+		}
+		if pageSerial == serial {
+			// The first packet identifies the codec; complete packets after
+			// the two header packets are audio
+			body := data[bodyStart : bodyStart+bodyLen]
+			offset := 0
+			for _, l := range lacing {
+				packet += int(l)
+				if l == 255 {
+					continue
+				}
+				if packets == 0 {
+					sampleRate, preSkip = oggCodecInfo(body[offset : offset+packet])
+					if sampleRate == 0 {
+						return 0, nil
+					}
+				} else if packets >= 2 {
+					sizes = append(sizes, packet)
+				}
+				offset += packet
+				packet = 0
+				packets++
+			}
+			// -1 marks a page on which no packet ends
+			if granule != math.MaxUint64 {
+				lastGranule = granule
+			}
+		}
+		pos = bodyStart + bodyLen
+	}
+
+	if sampleRate == 0 || lastGranule <= preSkip {
+		return 0, sizes
+	}
+	return float64(lastGranule-preSkip) / float64(sampleRate), sizes
+}
+
+// oggCodecInfo reads the rate granule positions are counted in and the
+// Opus pre-skip from an Ogg stream's identification header. It returns a
+// zero rate for codecs other than Opus and Vorbis.
+func oggCodecInfo(header []byte) (sampleRate uint32, preSkip uint64) {
+	switch {
+	case len(header) >= 19 && bytes.HasPrefix(header, []byte("OpusHead")):
+		return opusSampleRate, uint64(binary.LittleEndian.Uint16(header[10:]))
+	case len(header) >= 16 && bytes.HasPrefix(header, []byte("\x01vorbis")):
+		return binary.LittleEndian.Uint32(header[12:]), 0
+	}
+	return 0, 0
+}
+
+// mp3Bitrates holds bitrates in kbit/s by [MPEG-1 or not][layer 1-3][index].
+var mp3Bitrates = [2][3][16]int{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+}
+
+// mp3SampleRates holds sample rates in Hz for MPEG-1 by index; MPEG-2 and
+// 2.5 use a half and a quarter of these.
+var mp3SampleRates = [3]int{44100, 48000, 32000}
+
+// parseMP3 walks the MPEG audio frames of an MP3 file, skipping a leading
+// ID3v2 tag, and returns the summed frame durations and the frame sizes.
+func parseMP3(data []byte) (float64, []int) {
+	pos := 0
+	if len(data) >= 10 && bytes.HasPrefix(data, []byte("ID3")) {
+		// The tag size is a 28-bit syncsafe integer, excluding the header
+		size := int(data[6]&0x7f)<<21 | int(data[7]&0x7f)<<14 | int(data[8]&0x7f)<<7 | int(data[9]&0x7f)
+		pos = 10 + size
+	}
+
+	var duration float64
+	var sizes []int
+	for pos+4 <= len(data) {
+		frameLen, samples, rate := mp3Frame(data[pos : pos+4])
+		if frameLen == 0 {
+			// Not a frame header: resync only before the first frame, so
+			// trailing tags don't get mistaken for audio
+			if len(sizes) > 0 {
+				break
+			}
+			pos++
+			continue
+		}
+		duration += float64(samples) / float64(rate)
+		sizes = append(sizes, frameLen)
+		pos += frameLen
+	}
+	return duration, sizes
+}
+
+// mp3Frame decodes an MPEG audio frame header, returning the frame length
+// in bytes, the samples it holds and its sample rate. frameLen is 0 if
+// header is not a valid frame header.
+func mp3Frame(header []byte) (frameLen, samples, rate int) {
+	if header[0] != 0xff || header[1]&0xe0 != 0xe0 {
+		return 0, 0, 0
+	}
+	version := (header[1] >> 3) & 0x03 // 0: 2.5, 2: 2, 3: 1
+	layer := (header[1] >> 1) & 0x03   // 1: III, 2: II, 3: I
+	bitrateIdx := header[2] >> 4
+	rateIdx := (header[2] >> 2) & 0x03
+	padding := int(header[2]>>1) & 0x01
+	if version == 1 || layer == 0 || bitrateIdx == 0 || bitrateIdx == 15 || rateIdx == 3 {
+		return 0, 0, 0
+	}
+
+	mpeg1 := version == 3
+	table := 1
+	if mpeg1 {
+		table = 0
+	}
+	bitrate := mp3Bitrates[table][3-layer][bitrateIdx] * 1000
+
+	rate = mp3SampleRates[rateIdx]
+	switch version {
+	case 2:
+		rate /= 2
+	case 0:
+		rate /= 4
+	}
+
+	switch {
+	case layer == 3: // Layer I
+		return (12*bitrate/rate + padding) * 4, 384, rate
+	case layer == 1 && !mpeg1: // Layer III, MPEG-2/2.5
+		return 72*bitrate/rate + padding, 576, rate
+	default:
+		return 144*bitrate/rate + padding, 1152, rate
+	}
+}
+
+// buildWaveform turns packet or frame sizes into waveformSamples bars on a
+// 0-100 scale. Compressed size tracks how much is going on in the signal,
+// which is a fair stand-in for loudness without decoding the audio. It
+// returns nil when there are too few sizes or they are all equal, as with
+// constant-bitrate MP3, since a flat line carries no information.
+func buildWaveform(sizes []int) []byte {
+	if len(sizes) < waveformSamples {
+		return nil
+	}
+
+	bars := make([]float64, waveformSamples)
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i := range bars {
+		from := i * len(sizes) / waveformSamples
+		to := (i + 1) * len(sizes) / waveformSamples
+		sum := 0
+		for _, s := range sizes[from:to] {
+			sum += s
+		}
+		bars[i] = float64(sum) / float64(to-from)
+		lo = math.Min(lo, bars[i])
+		hi = math.Max(hi, bars[i])
+	}
+	if hi == lo {
+		return nil
+	}
+
+	waveform := make([]byte, waveformSamples)
+	for i, v := range bars {
+		waveform[i] = byte(math.Round((v - lo) / (hi - lo) * 100))
+	}
+	return waveform
+}
@@ -0,0 +1,101 @@
+package whatsapp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// oggPage builds an Ogg page holding the given complete packets. The CRC is
+// left zero; the parser doesn't check it.
+func oggPage(granule uint64, seq uint32, packets ...[]byte) []byte {
+	var lacing, body []byte
+	for _, p := range packets {
+		n := len(p)
+		for ; n >= 255; n -= 255 {
+			lacing = append(lacing, 255)
+		}
+		lacing = append(lacing, byte(n))
+		body = append(body, p...)
+	}
+
+	page := make([]byte, 27)
+	copy(page, "OggS")
+	binary.LittleEndian.PutUint64(page[6:], granule)
+	binary.LittleEndian.PutUint32(page[14:], 0x1234)
+	binary.LittleEndian.PutUint32(page[18:], seq)
+	page[26] = byte(len(lacing))
+	page = append(page, lacing...)
+	return append(page, body...)
+}
+
+func writeTempAudio(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAudioMetadata_OggOpus(t *testing.T) {
+	const preSkip = 312
+
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1 // version
+	head[9] = 1 // channels
+	binary.LittleEndian.PutUint16(head[10:], preSkip)
+	binary.LittleEndian.PutUint32(head[12:], 16000)
+
+	var file bytes.Buffer
+	file.Write(oggPage(0, 0, head))
+	file.Write(oggPage(0, 1, []byte("OpusTags\x00\x00\x00\x00\x00\x00\x00\x00")))
+
+	// 150 packets of 20ms: 3 seconds, getting louder towards the end
+	var granule uint64 = preSkip
+	for i := 0; i < 150; i++ {
+		granule += 960
+		file.Write(oggPage(granule, uint32(i+2), make([]byte, 20+i)))
+	}
+
+	seconds, waveform := audioMetadata(writeTempAudio(t, "voice.ogg", file.Bytes()))
+	if seconds != 3 {
+		t.Errorf("seconds = %d, want 3", seconds)
+	}
+	if len(waveform) != waveformSamples {
+		t.Fatalf("waveform has %d samples, want %d", len(waveform), waveformSamples)
+	}
+	if waveform[0] != 0 || waveform[waveformSamples-1] != 100 {
+		t.Errorf("waveform should rise from 0 to 100, got %d..%d", waveform[0], waveform[waveformSamples-1])
+	}
+}
+
+func TestAudioMetadata_MP3(t *testing.T) {
+	// ID3v2 tag with a 20 byte body, then 77 MPEG-1 Layer III frames at
+	// 128 kbit/s and 44.1 kHz: 417 bytes and 1152 samples each, ~2.01s
+	file := []byte("ID3\x04\x00\x00\x00\x00\x00\x14")
+	file = append(file, make([]byte, 20)...)
+	for i := 0; i < 77; i++ {
+		frame := make([]byte, 417)
+		copy(frame, []byte{0xff, 0xfb, 0x90, 0x00})
+		file = append(file, frame...)
+	}
+
+	seconds, waveform := audioMetadata(writeTempAudio(t, "song.mp3", file))
+	if seconds != 2 {
+		t.Errorf("seconds = %d, want 2", seconds)
+	}
+	if waveform != nil {
+		t.Errorf("expected no waveform for constant-bitrate frames, got %v", waveform)
+	}
+}
+
+func TestAudioMetadata_Undecodable(t *testing.T) {
+	seconds, waveform := audioMetadata(writeTempAudio(t, "noise.bin", []byte("definitely not audio")))
+	if seconds != 0 || waveform != nil {
+		t.Errorf("got seconds=%d waveform=%v, want nothing", seconds, waveform)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,14 +15,15 @@ import (
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/appstate"
 	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/proto/waSyncAction"
 	"go.mau.fi/whatsmeow/store/sqlstore"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
-	waLog "go.mau.fi/whatsmeow/util/log"
 	"google.golang.org/protobuf/proto"
 
 	_ "github.com/mattn/go-sqlite3"
 
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/logging"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/state"
 )
 
@@ -35,6 +35,10 @@ var (
 	ErrInvalidRecipient = errors.New("invalid recipient")
 	ErrInvalidGroup     = errors.New("invalid group JID")
 	ErrNoParticipants   = errors.New("no participants provided")
+	ErrNotBusiness      = errors.New("unsupported on non-business account")
+	ErrNoJoinApproval   = errors.New("group does not require admin approval to join")
+	ErrNotGroupAdmin    = errors.New("only group admins can manage join requests")
+	ErrMediaTooLarge    = errors.New("media file too large")
 )
 
 // Client wraps the whatsmeow client with additional functionality.
@@ -45,17 +49,42 @@ type Client struct {
 	stateMgr  *state.Machine
 
 	mu          sync.RWMutex
-	qrChan      chan string
-	eventChan   chan interface{}
 	handlers    []func(interface{})
 	isConnected bool
+
+	// QR pairing. qrChan has a single consumer and is closed after pairing.
+	qrMu           sync.Mutex
+	qrChan         chan string
+	qrClosed       bool
+	qrCancel       context.CancelFunc
+	qrDone         chan struct{}
+	qrFirstTimeout time.Duration
+	qrNextTimeout  time.Duration
+
+	mediaAllowedDirs []string
+	mediaLimit       func(mediaType string) int64
+	waLogLevel       string
+
+	// Live location shares in progress, keyed by chat JID.
+	liveMu        sync.Mutex
+	liveLocations map[string]*liveLocation
 }
 
 // Config holds configuration for the WhatsApp client.
 type Config struct {
 	StorePath string
-	LogLevel  string
 	StateMgr  *state.Machine
+
+	// LogLevel is the minimum level of whatsmeow's own logs.
+	LogLevel string
+
+	// MediaAllowedDirs restricts which files may be uploaded. Empty means
+	// fall back to the system directory denylist.
+	MediaAllowedDirs []string
+
+	// MediaLimit returns the largest file, in bytes, that may be uploaded as
+	// the given media type. Nil means no limit.
+	MediaLimit func(mediaType string) int64
 }
 
 // NewClient creates a new WhatsApp client.
@@ -67,7 +96,7 @@ func NewClient(ctx context.Context, cfg *Config, log *slog.Logger) (*Client, err
 	}
 
 	// Create database logger adapter
-	dbLog := &slogAdapter{log: log.With("component", "whatsmeow-db")}
+	dbLog := logging.NewWhatsmeowLogger(log.With("component", "whatsmeow-db"), cfg.LogLevel)
 
 	// Open database
 	container, err := sqlstore.New(ctx, "sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on", cfg.StorePath), dbLog)
@@ -79,8 +108,14 @@ func NewClient(ctx context.Context, cfg *Config, log *slog.Logger) (*Client, err
 		container: container,
 		log:       log,
 		stateMgr:  cfg.StateMgr,
-		qrChan:    make(chan string, 10),
-		eventChan: make(chan interface{}, 100),
+
+		qrChan:         make(chan string, 1),
+		qrFirstTimeout: qrFirstCodeTimeout,
+		qrNextTimeout:  qrNextCodeTimeout,
+
+		mediaAllowedDirs: cfg.MediaAllowedDirs,
+		mediaLimit:       cfg.MediaLimit,
+		waLogLevel:       cfg.LogLevel,
 	}, nil
 }
 
@@ -101,7 +136,7 @@ func (c *Client) Connect(ctx context.Context) error {
 	}
 
 	// Create client logger adapter
-	clientLog := &slogAdapter{log: c.log.With("component", "whatsmeow")}
+	clientLog := logging.NewWhatsmeowLogger(c.log.With("component", "whatsmeow"), c.waLogLevel)
 
 	// Create whatsmeow client
 	c.client = whatsmeow.NewClient(deviceStore, clientLog)
@@ -127,7 +162,7 @@ func (c *Client) Connect(ctx context.Context) error {
 	}
 
 	// Connect with existing session
-	if err := c.client.Connect(); err != nil {
+	if err := c.connectSocket(ctx); err != nil {
 		if c.stateMgr != nil {
 			_ = c.stateMgr.Fire(ctx, state.TriggerFatalError)
 		}
@@ -140,6 +175,37 @@ func (c *Client) Connect(ctx context.Context) error {
 	return nil
 }
 
+// connectSocket opens the websocket, giving up when ctx is done. whatsmeow's
+// Connect has no deadline of its own, and the context taken by its
+// ConnectContext also bounds the connection's lifetime, so ctx cannot simply
+// be passed down. An abandoned attempt is disconnected once it finishes.
+func (c *Client) connectSocket(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() { done <- c.client.Connect() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		go func() {
+			if <-done == nil {
+				c.client.Disconnect()
+			}
+		}()
+		return ctx.Err()
+	}
+}
+
+// NeedsPairing reports whether there is no stored session, so connecting
+// will wait for a QR code to be scanned.
+func (c *Client) NeedsPairing(ctx context.Context) (bool, error) {
+	device, err := c.container.GetFirstDevice(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get device store: %w", err)
+	}
+	return device.ID == nil, nil
+}
+
 // pairWithQR initiates QR code pairing.
 func (c *Client) pairWithQR(ctx context.Context) error {
 	// Transition to QR pending state
@@ -148,7 +214,7 @@ func (c *Client) pairWithQR(ctx context.Context) error {
 	}
 
 	// Connect - this will trigger QR events via the event handler
-	if err := c.client.Connect(); err != nil {
+	if err := c.connectSocket(ctx); err != nil {
 		return fmt.Errorf("failed to connect for QR: %w", err)
 	}
 
@@ -204,6 +270,17 @@ func (c *Client) IsReady() bool {
 	return c.IsConnected() && c.IsLoggedIn()
 }
 
+// IsBusiness returns true if the paired device is a WhatsApp Business account.
+func (c *Client) IsBusiness() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.client == nil || c.client.Store.ID == nil {
+		return false
+	}
+	// Business apps pair with an "smb" platform (smba/smbi)
+	return c.client.Store.BusinessName != "" || strings.HasPrefix(c.client.Store.Platform, "smb")
+}
+
 // CurrentState returns the current state machine state.
 func (c *Client) CurrentState() state.State {
 	if c.stateMgr != nil {
@@ -212,7 +289,8 @@ func (c *Client) CurrentState() state.State {
 	return state.StateDisconnected
 }
 
-// GetQRChannel returns a channel for receiving QR codes.
+// GetQRChannel returns a channel for receiving QR codes. Each code replaces
+// the previous one, and the channel is closed once pairing succeeds.
 func (c *Client) GetQRChannel() <-chan string {
 	return c.qrChan
 }
@@ -229,24 +307,18 @@ func (c *Client) handleEvent(evt interface{}) {
 	// Log the event type
 	c.log.Debug("WhatsApp event", "type", fmt.Sprintf("%T", evt))
 
-	// Handle QR events specially - send to qrChan
-	// Only send the first code (currently active). WhatsApp sends multiple rotation
-	// codes in one event; sending all of them causes the file to be overwritten with
-	// a future code that isn't valid yet. Whatsmeow fires a new QR event on rotation.
+	// Handle QR events specially - rotate codes through qrChan.
+	// WhatsApp sends all rotation codes in one event; each is only valid after
+	// the previous one expires, so they are published one at a time.
 	if qr, ok := evt.(*events.QR); ok {
-		c.log.Info("QR code received via event handler")
-		if len(qr.Codes) > 0 {
-			select {
-			case c.qrChan <- qr.Codes[0]:
-			default:
-				c.log.Warn("QR channel full, dropping code")
-			}
-		}
+		c.log.Info("QR code received via event handler", "codes", len(qr.Codes))
+		c.handleQR(qr.Codes)
 	}
 
 	// Handle successful pairing
 	if _, ok := evt.(*events.PairSuccess); ok {
 		c.log.Info("Pairing successful!")
+		c.closeQR()
 		c.mu.Lock()
 		c.isConnected = true
 		c.mu.Unlock()
@@ -260,13 +332,6 @@ func (c *Client) handleEvent(evt interface{}) {
 		c.mu.Unlock()
 	}
 
-	// Send to event channel
-	select {
-	case c.eventChan <- evt:
-	default:
-		c.log.Warn("Event channel full, dropping event")
-	}
-
 	// Call registered handlers
 	c.mu.RLock()
 	handlers := make([]func(interface{}), len(c.handlers))
@@ -280,6 +345,7 @@ func (c *Client) handleEvent(evt interface{}) {
 
 // Close closes the client and releases resources.
 func (c *Client) Close() error {
+	c.closeQR()
 	c.Disconnect()
 	if c.container != nil {
 		return c.container.Close()
@@ -297,36 +363,35 @@ func (c *Client) GetRawClient() *whatsmeow.Client {
 
 // --- Messaging Operations ---
 
-// SendMessage sends a text message to a JID.
-func (c *Client) SendMessage(ctx context.Context, jid string, text string) (string, error) {
+// SendMessage sends a text message to a JID. In group chats, @<phone> tokens
+// in the text and the explicit mentions become real WhatsApp mentions.
+func (c *Client) SendMessage(ctx context.Context, jid string, text string, mentions []string) (whatsmeow.SendResponse, error) {
 	if !c.IsReady() {
-		return "", ErrNotConnected
+		return whatsmeow.SendResponse{}, ErrNotConnected
 	}
 
 	recipient, err := types.ParseJID(jid)
 	if err != nil {
-		return "", fmt.Errorf("invalid JID: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid JID: %w", err)
 	}
 
-	resp, err := c.client.SendMessage(ctx, recipient, &waE2E.Message{
-		Conversation: &text,
-	})
+	resp, err := c.client.SendMessage(ctx, recipient, buildTextMessage(recipient, text, mentions))
 	if err != nil {
-		return "", fmt.Errorf("failed to send message: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to send message: %w", err)
 	}
 
-	return resp.ID, nil
+	return resp, nil
 }
 
 // ReplyToMessage sends a reply to a specific message.
-func (c *Client) ReplyToMessage(ctx context.Context, chatJID, messageID, text string) (string, error) {
+func (c *Client) ReplyToMessage(ctx context.Context, chatJID, messageID, text string) (whatsmeow.SendResponse, error) {
 	if !c.IsReady() {
-		return "", ErrNotConnected
+		return whatsmeow.SendResponse{}, ErrNotConnected
 	}
 
 	recipient, err := types.ParseJID(chatJID)
 	if err != nil {
-		return "", fmt.Errorf("invalid JID: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid JID: %w", err)
 	}
 
 	// Create message with context info for reply
@@ -341,23 +406,94 @@ func (c *Client) ReplyToMessage(ctx context.Context, chatJID, messageID, text st
 		},
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to reply: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to reply: %w", err)
 	}
 
-	return resp.ID, nil
+	return resp, nil
+}
+
+// ReplyToStatus replies privately to a contact's status. The reply goes to
+// the chat with the status owner and quotes the status, which WhatsApp
+// shows as coming from status@broadcast.
+func (c *Client) ReplyToStatus(ctx context.Context, statusID, senderJID, text string) (whatsmeow.SendResponse, error) {
+	if !c.IsReady() {
+		return whatsmeow.SendResponse{}, ErrNotConnected
+	}
+
+	sender, err := types.ParseJID(senderJID)
+	if err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid JID: %w", err)
+	}
+	recipient := sender.ToNonAD()
+
+	msg := withQuote(buildTextMessage(recipient, text, nil), recipient, types.StatusBroadcastJID, statusID, recipient.String(), "")
+	resp, err := c.client.SendMessage(ctx, recipient, msg)
+	if err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to reply to status: %w", err)
+	}
+
+	return resp, nil
+}
+
+// SendQuotedMessage sends a text message quoting a message that may belong
+// to a different chat. The quoted content travels with the message, so the
+// recipient sees the preview even though it has no copy of the original.
+func (c *Client) SendQuotedMessage(ctx context.Context, jid, text string, mentions []string, quotedChatJID, quotedID, quotedSender, quotedText string) (whatsmeow.SendResponse, error) {
+	if !c.IsReady() {
+		return whatsmeow.SendResponse{}, ErrNotConnected
+	}
+
+	recipient, err := types.ParseJID(jid)
+	if err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid JID: %w", err)
+	}
+	quotedChat, err := types.ParseJID(quotedChatJID)
+	if err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid quoted chat JID: %w", err)
+	}
+
+	msg := withQuote(buildTextMessage(recipient, text, mentions), recipient, quotedChat, quotedID, quotedSender, quotedText)
+	resp, err := c.client.SendMessage(ctx, recipient, msg)
+	if err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return resp, nil
+}
+
+// withQuote turns a text message into an extended text message quoting
+// quotedID. RemoteJID is only set when the quote comes from another chat;
+// WhatsApp uses it to show where the quoted message came from.
+func withQuote(msg *waE2E.Message, recipient, quotedChat types.JID, quotedID, quotedSender, quotedText string) *waE2E.Message {
+	ext := msg.GetExtendedTextMessage()
+	if ext == nil {
+		ext = &waE2E.ExtendedTextMessage{Text: proto.String(msg.GetConversation())}
+	}
+	if ext.ContextInfo == nil {
+		ext.ContextInfo = &waE2E.ContextInfo{}
+	}
+	ext.ContextInfo.StanzaID = proto.String(quotedID)
+	ext.ContextInfo.QuotedMessage = &waE2E.Message{Conversation: proto.String(quotedText)}
+	if quotedSender != "" {
+		ext.ContextInfo.Participant = proto.String(quotedSender)
+	}
+	if quotedChat != recipient {
+		ext.ContextInfo.RemoteJID = proto.String(quotedChat.String())
+	}
+	return &waE2E.Message{ExtendedTextMessage: ext}
 }
 
 // ForwardMessage forwards a message to another chat.
 // Note: WhatsApp forward is essentially resending the message with forward metadata.
 // Since we need the original message content, this requires integration with the message store.
-func (c *Client) ForwardMessage(ctx context.Context, sourceChatJID, messageID, targetJID string) (string, error) {
+func (c *Client) ForwardMessage(ctx context.Context, sourceChatJID, messageID, targetJID string) (whatsmeow.SendResponse, error) {
 	if !c.IsReady() {
-		return "", ErrNotConnected
+		return whatsmeow.SendResponse{}, ErrNotConnected
 	}
 
 	target, err := types.ParseJID(targetJID)
 	if err != nil {
-		return "", fmt.Errorf("invalid target JID: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid target JID: %w", err)
 	}
 
 	// For now, we can only forward by sending a new message indicating it's forwarded
@@ -366,11 +502,11 @@ func (c *Client) ForwardMessage(ctx context.Context, sourceChatJID, messageID, t
 
 	// Return not implemented for now since we need message store integration
 	_ = target
-	return "", errors.New("forward_message is not yet implemented; use send_message to send text instead")
+	return whatsmeow.SendResponse{}, errors.New("forward_message is not yet implemented; use send_message to send text instead")
 }
 
 // EditMessage edits a previously sent message.
-func (c *Client) EditMessage(ctx context.Context, chatJID, messageID, newContent string) error {
+func (c *Client) EditMessage(ctx context.Context, chatJID, messageID, newContent, mediaType string) error {
 	if !c.IsReady() {
 		return ErrNotConnected
 	}
@@ -380,9 +516,12 @@ func (c *Client) EditMessage(ctx context.Context, chatJID, messageID, newContent
 		return fmt.Errorf("invalid JID: %w", err)
 	}
 
-	_, err = c.client.SendMessage(ctx, recipient, c.client.BuildEdit(recipient, messageID, &waE2E.Message{
-		Conversation: &newContent,
-	}))
+	content, err := buildEditContent(newContent, mediaType)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.client.SendMessage(ctx, recipient, c.client.BuildEdit(recipient, messageID, content))
 	return err
 }
 
@@ -400,12 +539,58 @@ func (c *Client) DeleteMessage(ctx context.Context, chatJID, messageID string, f
 	if forEveryone {
 		_, err = c.client.SendMessage(ctx, recipient, c.client.BuildRevoke(recipient, types.EmptyJID, messageID))
 	} else {
-		return errors.New("delete for self only is not supported by whatsmeow; use forEveryone=true to retract the message")
+		return errors.New("delete for me needs the message sender and timestamp; use DeleteMessageForMe")
 	}
 
 	return err
 }
 
+// DeleteMessageForMe hides a message on this account's devices via the
+// deleteMessageForMe app-state action. Other chat members are unaffected.
+func (c *Client) DeleteMessageForMe(ctx context.Context, chatJID, senderJID, messageID string, fromMe bool, timestamp time.Time) error {
+	if !c.IsReady() {
+		return ErrNotConnected
+	}
+
+	chat, err := types.ParseJID(chatJID)
+	if err != nil {
+		return fmt.Errorf("invalid JID: %w", err)
+	}
+
+	// Only group messages carry the participant in the index
+	participant := "0"
+	if chat.Server == types.GroupServer {
+		if fromMe {
+			participant = c.client.Store.ID.ToNonAD().String()
+		} else {
+			sender, err := types.ParseJID(senderJID)
+			if err != nil {
+				return fmt.Errorf("invalid sender JID: %w", err)
+			}
+			participant = sender.ToNonAD().String()
+		}
+	}
+
+	isFromMe := "0"
+	if fromMe {
+		isFromMe = "1"
+	}
+
+	return c.client.SendAppState(ctx, appstate.PatchInfo{
+		Type: appstate.WAPatchRegularHigh,
+		Mutations: []appstate.MutationInfo{{
+			Index:   []string{appstate.IndexDeleteMessageForMe, chat.String(), messageID, isFromMe, participant},
+			Version: 3,
+			Value: &waSyncAction.SyncActionValue{
+				DeleteMessageForMeAction: &waSyncAction.DeleteMessageForMeAction{
+					DeleteMedia:      proto.Bool(false),
+					MessageTimestamp: proto.Int64(timestamp.Unix()),
+				},
+			},
+		}},
+	})
+}
+
 // ReactToMessage adds an emoji reaction to a message.
 func (c *Client) ReactToMessage(ctx context.Context, chatJID, messageID, emoji string) error {
 	if !c.IsReady() {
@@ -486,6 +671,21 @@ func (c *Client) MuteChat(ctx context.Context, jid string, mute bool, duration s
 	return c.client.SendAppState(ctx, appstate.BuildMute(target, mute, muteDuration))
 }
 
+// SetDisappearingTimer sets the disappearing-messages timer for a chat.
+// A zero duration turns disappearing messages off.
+func (c *Client) SetDisappearingTimer(ctx context.Context, jid string, duration time.Duration) error {
+	if !c.IsReady() {
+		return ErrNotConnected
+	}
+
+	target, err := types.ParseJID(jid)
+	if err != nil {
+		return fmt.Errorf("invalid JID: %w", err)
+	}
+
+	return c.client.SetDisappearingTimer(ctx, target, duration, time.Now())
+}
+
 // MarkChatRead marks a chat as read.
 func (c *Client) MarkChatRead(ctx context.Context, jid string) error {
 	if !c.IsReady() {
@@ -500,6 +700,21 @@ func (c *Client) MarkChatRead(ctx context.Context, jid string) error {
 	return c.client.MarkRead(ctx, []types.MessageID{}, time.Now(), chatJID, types.EmptyJID)
 }
 
+// MarkChatUnread marks a chat as unread on all linked devices, as the
+// phone's "Mark as unread" does.
+func (c *Client) MarkChatUnread(ctx context.Context, jid string) error {
+	if !c.IsReady() {
+		return ErrNotConnected
+	}
+
+	target, err := types.ParseJID(jid)
+	if err != nil {
+		return fmt.Errorf("invalid JID: %w", err)
+	}
+
+	return c.client.SendAppState(ctx, appstate.BuildMarkChatAsRead(target, false, time.Time{}, nil))
+}
+
 // DeleteChat deletes a chat.
 func (c *Client) DeleteChat(ctx context.Context, jid string) error {
 	if !c.IsReady() {
@@ -514,6 +729,70 @@ func (c *Client) DeleteChat(ctx context.Context, jid string) error {
 	return c.client.SendAppState(ctx, appstate.BuildDeleteChat(target, time.Time{}, nil, false))
 }
 
+// GetChatSettings returns the mute/pin/archive state whatsmeow has synced
+// from app state for a chat. Found is false if nothing has been synced yet.
+func (c *Client) GetChatSettings(ctx context.Context, jid string) (types.LocalChatSettings, error) {
+	if !c.IsReady() {
+		return types.LocalChatSettings{}, ErrNotConnected
+	}
+
+	target, err := types.ParseJID(jid)
+	if err != nil {
+		return types.LocalChatSettings{}, fmt.Errorf("invalid JID: %w", err)
+	}
+
+	return c.client.Store.ChatSettings.GetChatSettings(ctx, target)
+}
+
+// RequestHistorySync asks the primary device for up to count messages older
+// than the given message. The messages arrive later as an on-demand history
+// sync event.
+func (c *Client) RequestHistorySync(ctx context.Context, chatJID, oldestID string, oldestFromMe bool, oldestTimestamp time.Time, count int) error {
+	if !c.IsReady() {
+		return ErrNotConnected
+	}
+
+	chat, err := types.ParseJID(chatJID)
+	if err != nil {
+		return fmt.Errorf("invalid JID: %w", err)
+	}
+
+	oldest := &types.MessageInfo{
+		MessageSource: types.MessageSource{Chat: chat, IsFromMe: oldestFromMe},
+		ID:            oldestID,
+		Timestamp:     oldestTimestamp,
+	}
+
+	_, err = c.client.SendPeerMessage(ctx, c.client.BuildHistorySyncRequest(oldest, count))
+	return err
+}
+
+// AddChatLabel applies a business label to a chat.
+func (c *Client) AddChatLabel(ctx context.Context, chatJID, labelID string) error {
+	return c.setChatLabel(ctx, chatJID, labelID, true)
+}
+
+// RemoveChatLabel removes a business label from a chat.
+func (c *Client) RemoveChatLabel(ctx context.Context, chatJID, labelID string) error {
+	return c.setChatLabel(ctx, chatJID, labelID, false)
+}
+
+func (c *Client) setChatLabel(ctx context.Context, chatJID, labelID string, labeled bool) error {
+	if !c.IsReady() {
+		return ErrNotConnected
+	}
+	if !c.IsBusiness() {
+		return ErrNotBusiness
+	}
+
+	target, err := types.ParseJID(chatJID)
+	if err != nil {
+		return fmt.Errorf("invalid JID: %w", err)
+	}
+
+	return c.client.SendAppState(ctx, appstate.BuildLabelChat(target, labelID, labeled))
+}
+
 // --- Contact Operations ---
 
 // BlockContact blocks or unblocks a contact.
@@ -645,7 +924,7 @@ func (c *Client) CreateGroup(ctx context.Context, name string, participants []st
 }
 
 // GetGroupInfo returns information about a group.
-func (c *Client) GetGroupInfo(ctx context.Context, jid string) (interface{}, error) {
+func (c *Client) GetGroupInfo(ctx context.Context, jid string) (*types.GroupInfo, error) {
 	if !c.IsReady() {
 		return nil, ErrNotConnected
 	}
@@ -663,6 +942,38 @@ func (c *Client) GetGroupInfo(ctx context.Context, jid string) (interface{}, err
 	return info, nil
 }
 
+// GetCommonGroups returns the JIDs of the joined groups that jid is also a
+// member of. WhatsApp has no mutual-groups query, so this scans the
+// participant lists of every joined group, matching the contact by phone
+// number or LID.
+func (c *Client) GetCommonGroups(ctx context.Context, jid string) ([]string, error) {
+	if !c.IsReady() {
+		return nil, ErrNotConnected
+	}
+
+	target, err := types.ParseJID(jid)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JID: %w", err)
+	}
+	target = target.ToNonAD()
+
+	groups, err := c.client.GetJoinedGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get joined groups: %w", err)
+	}
+
+	common := []string{}
+	for _, group := range groups {
+		for _, p := range group.Participants {
+			if p.JID.ToNonAD() == target || p.PhoneNumber.ToNonAD() == target || p.LID.ToNonAD() == target {
+				common = append(common, group.JID.String())
+				break
+			}
+		}
+	}
+	return common, nil
+}
+
 // LeaveGroup leaves a group.
 func (c *Client) LeaveGroup(ctx context.Context, jid string) error {
 	if !c.IsReady() {
@@ -801,6 +1112,34 @@ func (c *Client) SetGroupTopic(ctx context.Context, groupJID, topic string) erro
 	return c.client.SetGroupTopic(ctx, jid, "", "", topic)
 }
 
+// SetGroupAnnounce sets whether only admins can send messages in the group.
+func (c *Client) SetGroupAnnounce(ctx context.Context, groupJID string, announce bool) error {
+	if !c.IsReady() {
+		return ErrNotConnected
+	}
+
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return fmt.Errorf("invalid group JID: %w", err)
+	}
+
+	return c.client.SetGroupAnnounce(ctx, jid, announce)
+}
+
+// SetGroupLocked sets whether only admins can edit the group info.
+func (c *Client) SetGroupLocked(ctx context.Context, groupJID string, locked bool) error {
+	if !c.IsReady() {
+		return ErrNotConnected
+	}
+
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return fmt.Errorf("invalid group JID: %w", err)
+	}
+
+	return c.client.SetGroupLocked(ctx, jid, locked)
+}
+
 // SetGroupPhoto changes the group photo.
 func (c *Client) SetGroupPhoto(ctx context.Context, groupJID, imagePath string) error {
 	if !c.IsReady() {
@@ -812,7 +1151,7 @@ func (c *Client) SetGroupPhoto(ctx context.Context, groupJID, imagePath string)
 		return fmt.Errorf("invalid group JID: %w", err)
 	}
 
-	if err := validateFilePath(imagePath); err != nil {
+	if _, err := ValidateFilePath(imagePath, c.mediaAllowedDirs); err != nil {
 		return err
 	}
 
@@ -825,6 +1164,22 @@ func (c *Client) SetGroupPhoto(ctx context.Context, groupJID, imagePath string)
 	return err
 }
 
+// RemoveGroupPhoto clears the group photo.
+func (c *Client) RemoveGroupPhoto(ctx context.Context, groupJID string) error {
+	if !c.IsReady() {
+		return ErrNotConnected
+	}
+
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return fmt.Errorf("invalid group JID: %w", err)
+	}
+
+	// whatsmeow removes the photo when given no image
+	_, err = c.client.SetGroupPhoto(ctx, jid, nil)
+	return err
+}
+
 // GetInviteLink gets the group invite link.
 func (c *Client) GetInviteLink(ctx context.Context, groupJID string) (string, error) {
 	if !c.IsReady() {
@@ -877,6 +1232,105 @@ func (c *Client) JoinViaInvite(ctx context.Context, inviteLink string) (string,
 	return groupJID.String(), nil
 }
 
+// GetGroupInfoFromLink resolves an invite link to the group it points at
+// without joining. inviteLink may be a full chat.whatsapp.com URL or the bare code.
+func (c *Client) GetGroupInfoFromLink(ctx context.Context, inviteLink string) (*types.GroupInfo, error) {
+	if !c.IsReady() {
+		return nil, ErrNotConnected
+	}
+
+	info, err := c.client.GetGroupInfoFromLink(ctx, inviteLink)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group info from link: %w", err)
+	}
+
+	return info, nil
+}
+
+// GetGroupJoinRequests lists pending requests to join a group that requires
+// admin approval.
+func (c *Client) GetGroupJoinRequests(ctx context.Context, groupJID string) ([]types.GroupParticipantRequest, error) {
+	if !c.IsReady() {
+		return nil, ErrNotConnected
+	}
+
+	jid, err := c.joinApprovalGroup(ctx, groupJID)
+	if err != nil {
+		return nil, err
+	}
+
+	requests, err := c.client.GetGroupRequestParticipants(ctx, jid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get join requests: %w", err)
+	}
+	return requests, nil
+}
+
+// ApproveGroupJoinRequest admits pending participants to a group.
+func (c *Client) ApproveGroupJoinRequest(ctx context.Context, groupJID string, participants []string) error {
+	return c.updateJoinRequests(ctx, groupJID, participants, whatsmeow.ParticipantChangeApprove)
+}
+
+// RejectGroupJoinRequest declines pending requests to join a group.
+func (c *Client) RejectGroupJoinRequest(ctx context.Context, groupJID string, participants []string) error {
+	return c.updateJoinRequests(ctx, groupJID, participants, whatsmeow.ParticipantChangeReject)
+}
+
+func (c *Client) updateJoinRequests(ctx context.Context, groupJID string, participants []string, action whatsmeow.ParticipantRequestChange) error {
+	if !c.IsReady() {
+		return ErrNotConnected
+	}
+
+	jid, err := c.joinApprovalGroup(ctx, groupJID)
+	if err != nil {
+		return err
+	}
+
+	jids := make([]types.JID, len(participants))
+	for i, p := range participants {
+		pjid, err := types.ParseJID(p)
+		if err != nil {
+			return fmt.Errorf("invalid participant JID %s: %w", p, err)
+		}
+		jids[i] = pjid
+	}
+
+	_, err = c.client.UpdateGroupRequestParticipants(ctx, jid, jids, action)
+	return err
+}
+
+// joinApprovalGroup parses groupJID and checks that the group has join
+// approval enabled and that we are one of its admins, so callers get a clear
+// error instead of an opaque IQ failure.
+func (c *Client) joinApprovalGroup(ctx context.Context, groupJID string) (types.JID, error) {
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return types.EmptyJID, fmt.Errorf("invalid group JID: %w", err)
+	}
+
+	info, err := c.client.GetGroupInfo(ctx, jid)
+	if err != nil {
+		return types.EmptyJID, fmt.Errorf("failed to get group info: %w", err)
+	}
+	if !info.IsJoinApprovalRequired {
+		return types.EmptyJID, ErrNoJoinApproval
+	}
+
+	own := c.client.Store.GetJID().ToNonAD()
+	ownLID := c.client.Store.GetLID().ToNonAD()
+	for _, p := range info.Participants {
+		isSelf := p.JID.ToNonAD() == own || p.PhoneNumber.ToNonAD() == own ||
+			(!ownLID.IsEmpty() && (p.JID.ToNonAD() == ownLID || p.LID.ToNonAD() == ownLID))
+		if isSelf {
+			if p.IsAdmin || p.IsSuperAdmin {
+				return jid, nil
+			}
+			break
+		}
+	}
+	return types.EmptyJID, ErrNotGroupAdmin
+}
+
 // --- Status Operations ---
 
 // PostTextStatus posts a text status.
@@ -923,28 +1377,24 @@ func (c *Client) PostImageStatus(ctx context.Context, imagePath, caption string)
 	// Status broadcast JID
 	statusJID := types.StatusBroadcastJID
 
-	if err := validateFilePath(imagePath); err != nil {
+	if _, err := ValidateFilePath(imagePath, c.mediaAllowedDirs); err != nil {
+		return err
+	}
+	if err := c.checkMediaSize(imagePath, "image"); err != nil {
 		return err
 	}
 
-	// Read image file
-	data, err := os.ReadFile(imagePath)
+	// Upload to WhatsApp servers
+	uploaded, err := uploadFile(ctx, c.client, imagePath, whatsmeow.MediaImage)
 	if err != nil {
-		return fmt.Errorf("failed to read image file: %w", err)
+		return fmt.Errorf("failed to upload image: %w", err)
 	}
 
-	// Detect MIME type
-	mimeType := http.DetectContentType(data)
+	mimeType := uploaded.MimeType
 	if !strings.HasPrefix(mimeType, "image/") {
 		mimeType = "image/jpeg"
 	}
 
-	// Upload to WhatsApp servers
-	uploaded, err := c.client.Upload(ctx, data, whatsmeow.MediaImage)
-	if err != nil {
-		return fmt.Errorf("failed to upload image: %w", err)
-	}
-
 	// Build and send image status message
 	msg := &waE2E.Message{
 		ImageMessage: &waE2E.ImageMessage{
@@ -955,7 +1405,8 @@ func (c *Client) PostImageStatus(ctx context.Context, imagePath, caption string)
 			MediaKey:      uploaded.MediaKey,
 			FileEncSHA256: uploaded.FileEncSHA256,
 			FileSHA256:    uploaded.FileSHA256,
-			FileLength:    proto.Uint64(uint64(len(data))),
+			FileLength:    proto.Uint64(uploaded.FileLength),
+			JPEGThumbnail: thumbnailFromFile(imagePath),
 		},
 	}
 
@@ -1007,39 +1458,36 @@ func parseColorToARGB(hex string) uint32 {
 
 // --- Media Operations ---
 
-// SendImage sends an image to a chat.
-func (c *Client) SendImage(ctx context.Context, jid, imagePath, caption string) (string, error) {
+// SendImage sends an image to a chat. A view-once image can only be opened
+// once by the recipient.
+func (c *Client) SendImage(ctx context.Context, jid, imagePath, caption string, viewOnce bool) (whatsmeow.SendResponse, error) {
 	if !c.IsReady() {
-		return "", ErrNotConnected
+		return whatsmeow.SendResponse{}, ErrNotConnected
 	}
 
 	recipient, err := types.ParseJID(jid)
 	if err != nil {
-		return "", fmt.Errorf("invalid JID: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid JID: %w", err)
 	}
 
-	if err := validateFilePath(imagePath); err != nil {
-		return "", err
+	if _, err := ValidateFilePath(imagePath, c.mediaAllowedDirs); err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	if err := c.checkMediaSize(imagePath, "image"); err != nil {
+		return whatsmeow.SendResponse{}, err
 	}
 
-	// Read image file
-	data, err := os.ReadFile(imagePath)
+	// Upload to WhatsApp servers
+	uploaded, err := uploadFile(ctx, c.client, imagePath, whatsmeow.MediaImage)
 	if err != nil {
-		return "", fmt.Errorf("failed to read image file: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to upload image: %w", err)
 	}
 
-	// Detect MIME type
-	mimeType := http.DetectContentType(data)
+	mimeType := uploaded.MimeType
 	if !strings.HasPrefix(mimeType, "image/") {
 		mimeType = "image/jpeg" // Default fallback
 	}
 
-	// Upload to WhatsApp servers
-	uploaded, err := c.client.Upload(ctx, data, whatsmeow.MediaImage)
-	if err != nil {
-		return "", fmt.Errorf("failed to upload image: %w", err)
-	}
-
 	// Build and send image message
 	msg := &waE2E.Message{
 		ImageMessage: &waE2E.ImageMessage{
@@ -1050,96 +1498,172 @@ func (c *Client) SendImage(ctx context.Context, jid, imagePath, caption string)
 			MediaKey:      uploaded.MediaKey,
 			FileEncSHA256: uploaded.FileEncSHA256,
 			FileSHA256:    uploaded.FileSHA256,
-			FileLength:    proto.Uint64(uint64(len(data))),
+			FileLength:    proto.Uint64(uploaded.FileLength),
+			JPEGThumbnail: thumbnailFromFile(imagePath),
 		},
 	}
+	if viewOnce {
+		msg.ImageMessage.ViewOnce = proto.Bool(true)
+	}
 
 	resp, err := c.client.SendMessage(ctx, recipient, msg)
 	if err != nil {
-		return "", fmt.Errorf("failed to send image: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to send image: %w", err)
 	}
 
-	return resp.ID, nil
+	return resp, nil
 }
 
-// SendVideo sends a video to a chat.
-func (c *Client) SendVideo(ctx context.Context, jid, videoPath, caption string) (string, error) {
+// SendVideo sends a video to a chat. A view-once video can only be opened
+// once by the recipient.
+func (c *Client) SendVideo(ctx context.Context, jid, videoPath, caption string, viewOnce bool) (whatsmeow.SendResponse, error) {
 	if !c.IsReady() {
-		return "", ErrNotConnected
+		return whatsmeow.SendResponse{}, ErrNotConnected
 	}
 
 	recipient, err := types.ParseJID(jid)
 	if err != nil {
-		return "", fmt.Errorf("invalid JID: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid JID: %w", err)
 	}
 
-	if err := validateFilePath(videoPath); err != nil {
-		return "", err
+	if _, err := ValidateFilePath(videoPath, c.mediaAllowedDirs); err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	if err := c.checkMediaSize(videoPath, "video"); err != nil {
+		return whatsmeow.SendResponse{}, err
 	}
 
-	// Read video file
-	data, err := os.ReadFile(videoPath)
+	// Upload to WhatsApp servers
+	uploaded, err := uploadFile(ctx, c.client, videoPath, whatsmeow.MediaVideo)
 	if err != nil {
-		return "", fmt.Errorf("failed to read video file: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to upload video: %w", err)
 	}
 
-	// Detect MIME type
-	mimeType := http.DetectContentType(data)
+	mimeType := uploaded.MimeType
 	if !strings.HasPrefix(mimeType, "video/") {
 		mimeType = "video/mp4" // Default fallback
 	}
 
+	// Build and send video message. No JPEGThumbnail: extracting a frame
+	// needs a video decoder, so recipients get the server-side preview.
+	msg := &waE2E.Message{
+		VideoMessage: &waE2E.VideoMessage{
+			Caption:       proto.String(caption),
+			Mimetype:      proto.String(mimeType),
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uploaded.FileLength),
+		},
+	}
+
+	if viewOnce {
+		msg.VideoMessage.ViewOnce = proto.Bool(true)
+	}
+
+	resp, err := c.client.SendMessage(ctx, recipient, msg)
+	if err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to send video: %w", err)
+	}
+
+	return resp, nil
+}
+
+// SendGIF sends an MP4 video that WhatsApp plays inline as a looping GIF.
+// WhatsApp does not accept literal GIF files; they must be converted to MP4 first.
+func (c *Client) SendGIF(ctx context.Context, jid, gifPath, caption string) (whatsmeow.SendResponse, error) {
+	if !c.IsReady() {
+		return whatsmeow.SendResponse{}, ErrNotConnected
+	}
+
+	recipient, err := types.ParseJID(jid)
+	if err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid JID: %w", err)
+	}
+
+	if _, err := ValidateFilePath(gifPath, c.mediaAllowedDirs); err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	if err := c.checkMediaSize(gifPath, "video"); err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+
+	mimeType, err := sniffFileMimeType(gifPath)
+	if err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to read GIF file: %w", err)
+	}
+	if err := checkGIFMimeType(mimeType); err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+
 	// Upload to WhatsApp servers
-	uploaded, err := c.client.Upload(ctx, data, whatsmeow.MediaVideo)
+	uploaded, err := uploadFile(ctx, c.client, gifPath, whatsmeow.MediaVideo)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload video: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to upload GIF: %w", err)
 	}
 
-	// Build and send video message
+	// Build and send video message with GIF playback
 	msg := &waE2E.Message{
 		VideoMessage: &waE2E.VideoMessage{
 			Caption:       proto.String(caption),
-			Mimetype:      proto.String(mimeType),
+			Mimetype:      proto.String("video/mp4"),
+			GifPlayback:   proto.Bool(true),
 			URL:           proto.String(uploaded.URL),
 			DirectPath:    proto.String(uploaded.DirectPath),
 			MediaKey:      uploaded.MediaKey,
 			FileEncSHA256: uploaded.FileEncSHA256,
 			FileSHA256:    uploaded.FileSHA256,
-			FileLength:    proto.Uint64(uint64(len(data))),
+			FileLength:    proto.Uint64(uploaded.FileLength),
 		},
 	}
 
 	resp, err := c.client.SendMessage(ctx, recipient, msg)
 	if err != nil {
-		return "", fmt.Errorf("failed to send video: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to send GIF: %w", err)
 	}
 
-	return resp.ID, nil
+	return resp, nil
+}
+
+// checkGIFMimeType ensures a GIF send is backed by an MP4 file.
+func checkGIFMimeType(mimeType string) error {
+	switch mimeType {
+	case "video/mp4":
+		return nil
+	case "image/gif":
+		return errors.New("WhatsApp GIFs must be MP4 videos; convert the .gif first (e.g. ffmpeg -i in.gif -movflags faststart -pix_fmt yuv420p out.mp4)")
+	default:
+		return fmt.Errorf("unsupported GIF format %q: expected video/mp4", mimeType)
+	}
 }
 
 // SendAudio sends an audio file.
-func (c *Client) SendAudio(ctx context.Context, jid, audioPath string, asVoice bool) (string, error) {
+func (c *Client) SendAudio(ctx context.Context, jid, audioPath string, asVoice bool) (whatsmeow.SendResponse, error) {
 	if !c.IsReady() {
-		return "", ErrNotConnected
+		return whatsmeow.SendResponse{}, ErrNotConnected
 	}
 
 	recipient, err := types.ParseJID(jid)
 	if err != nil {
-		return "", fmt.Errorf("invalid JID: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid JID: %w", err)
 	}
 
-	if err := validateFilePath(audioPath); err != nil {
-		return "", err
+	if _, err := ValidateFilePath(audioPath, c.mediaAllowedDirs); err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	if err := c.checkMediaSize(audioPath, "audio"); err != nil {
+		return whatsmeow.SendResponse{}, err
 	}
 
-	// Read audio file
-	data, err := os.ReadFile(audioPath)
+	// Upload to WhatsApp servers
+	uploaded, err := uploadFile(ctx, c.client, audioPath, whatsmeow.MediaAudio)
 	if err != nil {
-		return "", fmt.Errorf("failed to read audio file: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to upload audio: %w", err)
 	}
 
-	// Detect MIME type
-	mimeType := http.DetectContentType(data)
+	mimeType := uploaded.MimeType
 	if !strings.HasPrefix(mimeType, "audio/") {
 		if asVoice {
 			mimeType = "audio/ogg; codecs=opus" // Voice message format
@@ -1148,12 +1672,6 @@ func (c *Client) SendAudio(ctx context.Context, jid, audioPath string, asVoice b
 		}
 	}
 
-	// Upload to WhatsApp servers
-	uploaded, err := c.client.Upload(ctx, data, whatsmeow.MediaAudio)
-	if err != nil {
-		return "", fmt.Errorf("failed to upload audio: %w", err)
-	}
-
 	// Build and send audio message
 	msg := &waE2E.Message{
 		AudioMessage: &waE2E.AudioMessage{
@@ -1163,85 +1681,91 @@ func (c *Client) SendAudio(ctx context.Context, jid, audioPath string, asVoice b
 			MediaKey:      uploaded.MediaKey,
 			FileEncSHA256: uploaded.FileEncSHA256,
 			FileSHA256:    uploaded.FileSHA256,
-			FileLength:    proto.Uint64(uint64(len(data))),
+			FileLength:    proto.Uint64(uploaded.FileLength),
 			PTT:           proto.Bool(asVoice), // Push-to-talk (voice message)
 		},
 	}
 
+	// Without a duration the recipient sees a 0:00 voice note
+	seconds, waveform := audioMetadata(audioPath)
+	if seconds > 0 {
+		msg.AudioMessage.Seconds = proto.Uint32(seconds)
+	}
+	if asVoice && waveform != nil {
+		msg.AudioMessage.Waveform = waveform
+	}
+
 	resp, err := c.client.SendMessage(ctx, recipient, msg)
 	if err != nil {
-		return "", fmt.Errorf("failed to send audio: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to send audio: %w", err)
 	}
 
-	return resp.ID, nil
+	return resp, nil
 }
 
-// SendDocument sends a document.
-func (c *Client) SendDocument(ctx context.Context, jid, filePath, filename string) (string, error) {
+// SendDocument sends a document with an optional caption.
+func (c *Client) SendDocument(ctx context.Context, jid, filePath, filename, caption string) (whatsmeow.SendResponse, error) {
 	if !c.IsReady() {
-		return "", ErrNotConnected
+		return whatsmeow.SendResponse{}, ErrNotConnected
 	}
 
 	recipient, err := types.ParseJID(jid)
 	if err != nil {
-		return "", fmt.Errorf("invalid JID: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid JID: %w", err)
 	}
 
-	if err := validateFilePath(filePath); err != nil {
-		return "", err
+	if _, err := ValidateFilePath(filePath, c.mediaAllowedDirs); err != nil {
+		return whatsmeow.SendResponse{}, err
 	}
-
-	// Read document file
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read document file: %w", err)
+	if err := c.checkMediaSize(filePath, "document"); err != nil {
+		return whatsmeow.SendResponse{}, err
 	}
 
-	// Detect MIME type
-	mimeType := http.DetectContentType(data)
-
 	// Use provided filename or extract from path
 	if filename == "" {
 		filename = filepath.Base(filePath)
 	}
 
 	// Upload to WhatsApp servers
-	uploaded, err := c.client.Upload(ctx, data, whatsmeow.MediaDocument)
+	uploaded, err := uploadFile(ctx, c.client, filePath, whatsmeow.MediaDocument)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload document: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to upload document: %w", err)
 	}
 
 	// Build and send document message
 	msg := &waE2E.Message{
 		DocumentMessage: &waE2E.DocumentMessage{
 			FileName:      proto.String(filename),
-			Mimetype:      proto.String(mimeType),
+			Mimetype:      proto.String(uploaded.MimeType),
 			URL:           proto.String(uploaded.URL),
 			DirectPath:    proto.String(uploaded.DirectPath),
 			MediaKey:      uploaded.MediaKey,
 			FileEncSHA256: uploaded.FileEncSHA256,
 			FileSHA256:    uploaded.FileSHA256,
-			FileLength:    proto.Uint64(uint64(len(data))),
+			FileLength:    proto.Uint64(uploaded.FileLength),
 		},
 	}
+	if caption != "" {
+		msg.DocumentMessage.Caption = proto.String(caption)
+	}
 
 	resp, err := c.client.SendMessage(ctx, recipient, msg)
 	if err != nil {
-		return "", fmt.Errorf("failed to send document: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to send document: %w", err)
 	}
 
-	return resp.ID, nil
+	return resp, nil
 }
 
 // SendLocation sends a location.
-func (c *Client) SendLocation(ctx context.Context, jid string, lat, lon float64, name, address string) (string, error) {
+func (c *Client) SendLocation(ctx context.Context, jid string, lat, lon float64, name, address string) (whatsmeow.SendResponse, error) {
 	if !c.IsReady() {
-		return "", ErrNotConnected
+		return whatsmeow.SendResponse{}, ErrNotConnected
 	}
 
 	recipient, err := types.ParseJID(jid)
 	if err != nil {
-		return "", fmt.Errorf("invalid JID: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid JID: %w", err)
 	}
 
 	resp, err := c.client.SendMessage(ctx, recipient, &waE2E.Message{
@@ -1253,142 +1777,164 @@ func (c *Client) SendLocation(ctx context.Context, jid string, lat, lon float64,
 		},
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to send location: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to send location: %w", err)
 	}
 
-	return resp.ID, nil
+	return resp, nil
 }
 
 // SendContactCard sends a contact card.
-func (c *Client) SendContactCard(ctx context.Context, jid, contactJID string) (string, error) {
+func (c *Client) SendContactCard(ctx context.Context, jid, contactJID string) (whatsmeow.SendResponse, error) {
 	if !c.IsReady() {
-		return "", ErrNotConnected
+		return whatsmeow.SendResponse{}, ErrNotConnected
 	}
 
 	recipient, err := types.ParseJID(jid)
 	if err != nil {
-		return "", fmt.Errorf("invalid recipient JID: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid recipient JID: %w", err)
 	}
 
 	contactInfo, err := types.ParseJID(contactJID)
 	if err != nil {
-		return "", fmt.Errorf("invalid contact JID: %w", err)
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid contact JID: %w", err)
 	}
 
-	// Use the contact JID to build display name
-	displayName := contactInfo.User
-	phoneNumber := "+" + contactInfo.User
+	return c.sendContactCard(ctx, recipient, c.contactCard(ctx, contactInfo))
+}
 
-	// Try to get contact name from store if available
-	if c.client.Store != nil {
-		if contact, err := c.client.Store.Contacts.GetContact(ctx, contactInfo); err == nil && contact.FullName != "" {
-			displayName = contact.FullName
-		} else if contact.PushName != "" {
-			displayName = contact.PushName
-		}
+// DownloadMedia downloads a message's media to savePath and returns the
+// path. The caller looks up media in its store and checks savePath.
+func (c *Client) DownloadMedia(ctx context.Context, media MediaFile, savePath string) (string, error) {
+	if !c.IsReady() {
+		return "", ErrNotConnected
 	}
-
-	// Build vCard
-	vcard := fmt.Sprintf("BEGIN:VCARD\nVERSION:3.0\nFN:%s\nTEL;type=CELL;type=VOICE;waid=%s:%s\nEND:VCARD",
-		displayName, contactInfo.User, phoneNumber)
-
-	// Build and send contact message
-	msg := &waE2E.Message{
-		ContactMessage: &waE2E.ContactMessage{
-			DisplayName: proto.String(displayName),
-			Vcard:       proto.String(vcard),
-		},
+	if err := downloadFile(ctx, c.client, media, savePath); err != nil {
+		return "", err
 	}
+	return savePath, nil
+}
 
-	resp, err := c.client.SendMessage(ctx, recipient, msg)
+// checkMediaSize rejects files larger than the configured limit for
+// mediaType, so oversized files fail before they are read into memory.
+func (c *Client) checkMediaSize(path, mediaType string) error {
+	if c.mediaLimit == nil {
+		return nil
+	}
+	info, err := os.Stat(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to send contact card: %w", err)
+		return err
 	}
-
-	return resp.ID, nil
-}
-
-// DownloadMedia downloads media from a message.
-// Note: This requires having the message with media info stored locally.
-func (c *Client) DownloadMedia(ctx context.Context, chatJID, messageID, savePath string) (string, error) {
-	if !c.IsReady() {
-		return "", ErrNotConnected
+	if limit := c.mediaLimit(mediaType); info.Size() > limit {
+		return fmt.Errorf("%w: %s is %d bytes, %s media is limited to %d bytes", ErrMediaTooLarge, path, info.Size(), mediaType, limit)
 	}
-
-	// For now, return not implemented since we need the message info
-	// from our store to download the media, which requires integration
-	// with the message repository
-	return "", errors.New("download_media is not yet implemented")
+	return nil
 }
 
-func validateFilePath(path string) error {
+// ValidateFilePath checks that a media file may be read and returns its
+// info. When allowedDirs is non-empty the resolved path (after following
+// symlinks) must live inside one of them; otherwise a denylist of system
+// directories applies. Directories are rejected.
+func ValidateFilePath(path string, allowedDirs []string) (os.FileInfo, error) {
 	cleanPath := filepath.Clean(path)
 
 	if cleanPath == "." || cleanPath == "" {
-		return errors.New("path is required")
-	}
-
-	forbiddenPrefixes := []string{
-		"/etc",
-		"/proc",
-		"/sys",
-		"/bin",
-		"/sbin",
-		"/usr",
-		"/boot",
-		"/dev",
-		"/lib",
-		"/lib64",
-		"/root",
-		"~/.ssh",
-	}
-	for _, prefix := range forbiddenPrefixes {
-		if cleanPath == prefix || strings.HasPrefix(cleanPath, prefix+string(filepath.Separator)) {
-			return fmt.Errorf("path %q is not allowed", cleanPath)
-		}
+		return nil, errors.New("path is required")
 	}
 
-	if strings.Contains(cleanPath, "..") {
-		return errors.New("path traversal is not allowed")
+	if HasParentRef(cleanPath) {
+		return nil, errors.New("path traversal is not allowed")
 	}
 
-	if _, err := os.Stat(cleanPath); err != nil {
-		return err
+	if len(allowedDirs) > 0 {
+		resolved, err := filepath.EvalSymlinks(cleanPath)
+		if err != nil {
+			return nil, err
+		}
+		if !IsWithinDirs(resolved, allowedDirs) {
+			return nil, fmt.Errorf("path %q is outside the allowed media directories", cleanPath)
+		}
+	} else if IsForbiddenPath(cleanPath) {
+		return nil, fmt.Errorf("path %q is not allowed", cleanPath)
 	}
 
-	return nil
-}
-
-// --- Helper functions ---
-
-func ptrString(s string) *string {
-	return &s
-}
-
-// slogAdapter adapts slog.Logger to whatsmeow's log interface.
-type slogAdapter struct {
-	log *slog.Logger
+	info, err := os.Stat(cleanPath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("path %q is a directory", cleanPath)
+	}
+	return info, nil
 }
 
-func (s *slogAdapter) Debugf(msg string, args ...interface{}) {
-	s.log.Debug(fmt.Sprintf(msg, args...))
+// forbiddenPathPrefixes is the denylist used when no media allow-list is configured.
+var forbiddenPathPrefixes = []string{
+	"/etc",
+	"/proc",
+	"/sys",
+	"/bin",
+	"/sbin",
+	"/usr",
+	"/boot",
+	"/dev",
+	"/lib",
+	"/lib64",
+	"/root",
+	"~/.ssh",
+}
+
+// IsForbiddenPath reports whether the cleaned path is in one of the system
+// directories that media may not be read from or written to.
+func IsForbiddenPath(cleanPath string) bool {
+	for _, prefix := range forbiddenPathPrefixes {
+		if cleanPath == prefix || strings.HasPrefix(cleanPath, prefix+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
 }
 
-func (s *slogAdapter) Infof(msg string, args ...interface{}) {
-	s.log.Info(fmt.Sprintf(msg, args...))
+// HasParentRef reports whether path has a ".." element. Call it on a cleaned
+// path: cleaning folds away any ".." that doesn't climb above the start of
+// the path. Names that merely contain two dots, like "a..b.jpg", are fine.
+func HasParentRef(path string) bool {
+	for _, elem := range strings.Split(filepath.ToSlash(path), "/") {
+		if elem == ".." {
+			return true
+		}
+	}
+	return false
 }
 
-func (s *slogAdapter) Warnf(msg string, args ...interface{}) {
-	s.log.Warn(fmt.Sprintf(msg, args...))
+// IsWithinDirs reports whether the already-resolved path is inside one of dirs.
+// The dirs themselves are resolved too so that symlinked roots still match.
+func IsWithinDirs(resolved string, dirs []string) bool {
+	resolved, err := filepath.Abs(resolved)
+	if err != nil {
+		return false
+	}
+	for _, dir := range dirs {
+		root, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			continue
+		}
+		root, err = filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, resolved)
+		if err != nil {
+			continue
+		}
+		if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+			return true
+		}
+	}
+	return false
 }
 
-func (s *slogAdapter) Errorf(msg string, args ...interface{}) {
-	s.log.Error(fmt.Sprintf(msg, args...))
-}
+// --- Helper functions ---
 
-func (s *slogAdapter) Sub(module string) waLog.Logger {
-	return &slogAdapter{log: s.log.With("module", module)}
+func ptrString(s string) *string {
+	return &s
 }
-
-// Ensure slogAdapter implements waLog.Logger
-var _ waLog.Logger = (*slogAdapter)(nil)
@@ -1,6 +1,14 @@
 package whatsapp
 
 import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -211,3 +219,171 @@ func parseParticipantJIDsTest(participants []string) ([]types.JID, error) {
 
 	return jids, nil
 }
+
+func TestValidateFilePathAllowedDirs(t *testing.T) {
+	allowed := t.TempDir()
+	outside := t.TempDir()
+
+	inside := filepath.Join(allowed, "photo.jpg")
+	if err := os.WriteFile(inside, []byte("img"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(outside, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(allowed, "escape.txt")
+	if err := os.Symlink(secret, link); err != nil {
+		t.Fatal(err)
+	}
+	dotted := filepath.Join(allowed, "foo..bar.jpg")
+	if err := os.WriteFile(dotted, []byte("img"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// A relative path keeps its leading ".." after filepath.Clean
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	relSecret, err := filepath.Rel(wd, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"file inside allowed dir", inside, false},
+		{"file outside allowed dir", secret, true},
+		{"symlink escaping allowed dir", link, true},
+		{"traversal out of allowed dir", allowed + "/../" + filepath.Base(outside) + "/secret.txt", true},
+		{"relative traversal", relSecret, true},
+		{"dots inside a file name", dotted, false},
+		{"missing file", filepath.Join(allowed, "missing.jpg"), true},
+		{"directory", allowed, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateFilePath(tt.path, []string{allowed})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFilePath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHasParentRef(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"../etc/passwd", true},
+		{"a/../../b", true},
+		{"..", true},
+		{"/tmp/foo..bar.jpg", false},
+		{"/tmp/..hidden", false},
+		{"/tmp/photo.jpg", false},
+	}
+	for _, tt := range tests {
+		if got := HasParentRef(filepath.Clean(tt.path)); got != tt.want {
+			t.Errorf("HasParentRef(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestValidateFilePathDenylist(t *testing.T) {
+	if _, err := ValidateFilePath("/etc/passwd", nil); err == nil {
+		t.Error("expected /etc/passwd to be rejected")
+	}
+
+	file := filepath.Join(t.TempDir(), "ok.txt")
+	if err := os.WriteFile(file, []byte("ok"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateFilePath(file, nil); err != nil {
+		t.Errorf("expected %q to be allowed, got %v", file, err)
+	}
+	if _, err := ValidateFilePath(filepath.Dir(file), nil); err == nil {
+		t.Error("expected a directory to be rejected")
+	}
+}
+
+func TestCheckGIFMimeType(t *testing.T) {
+	if err := checkGIFMimeType("video/mp4"); err != nil {
+		t.Errorf("video/mp4 should be accepted, got %v", err)
+	}
+	if err := checkGIFMimeType("image/gif"); err == nil || !strings.Contains(err.Error(), "MP4") {
+		t.Errorf("image/gif should be rejected with a conversion hint, got %v", err)
+	}
+	if err := checkGIFMimeType("video/webm"); err == nil {
+		t.Error("video/webm should be rejected")
+	}
+}
+
+func TestGenerateThumbnail(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for x := 0; x < 400; x++ {
+		for y := 0; y < 200; y++ {
+			src.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	thumb := generateThumbnail(bytes.NewReader(buf.Bytes()))
+	if len(thumb) == 0 {
+		t.Fatal("expected a thumbnail for a valid PNG")
+	}
+
+	decoded, err := jpeg.Decode(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("thumbnail is not a valid JPEG: %v", err)
+	}
+	if got := decoded.Bounds().Dx(); got != thumbnailMaxSide {
+		t.Errorf("thumbnail width = %d, want %d", got, thumbnailMaxSide)
+	}
+	if got := decoded.Bounds().Dy(); got != thumbnailMaxSide/2 {
+		t.Errorf("thumbnail height = %d, want %d", got, thumbnailMaxSide/2)
+	}
+}
+
+func TestGenerateThumbnailInvalidInput(t *testing.T) {
+	if thumb := generateThumbnail(strings.NewReader("definitely not an image")); thumb != nil {
+		t.Errorf("expected nil thumbnail for invalid input, got %d bytes", len(thumb))
+	}
+}
+
+func TestCheckMediaSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clip.mp4")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(2 << 20); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	c := &Client{mediaLimit: func(mediaType string) int64 {
+		if mediaType == "video" {
+			return 1 << 20
+		}
+		return 4 << 20
+	}}
+	if err := c.checkMediaSize(path, "video"); !errors.Is(err, ErrMediaTooLarge) {
+		t.Errorf("checkMediaSize(video) = %v, want ErrMediaTooLarge", err)
+	}
+	if err := c.checkMediaSize(path, "document"); err != nil {
+		t.Errorf("checkMediaSize(document) = %v, want nil", err)
+	}
+
+	// Without a limit function nothing is rejected
+	if err := (&Client{}).checkMediaSize(path, "video"); err != nil {
+		t.Errorf("checkMediaSize without limit = %v, want nil", err)
+	}
+}
@@ -0,0 +1,83 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waAdv"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// DeviceInfo describes one device linked to the account.
+type DeviceInfo struct {
+	JID       string `json:"jid"`
+	DeviceID  uint16 `json:"device_id"`
+	IsPrimary bool   `json:"is_primary"`
+	IsCurrent bool   `json:"is_current"`
+	// Platform is only known for the primary phone, as reported when this
+	// bridge was paired.
+	Platform string `json:"platform,omitempty"`
+	// LinkedAt is only known for the bridge's own device.
+	LinkedAt *time.Time `json:"linked_at,omitempty"`
+}
+
+// GetLinkedDevices lists the devices linked to the account: the primary
+// phone (device 0), this bridge and any other companions. WhatsApp does not
+// share when other companions were linked or last active, so those fields
+// are only filled in where the local device store knows them.
+func (c *Client) GetLinkedDevices(ctx context.Context) ([]DeviceInfo, error) {
+	if !c.IsReady() {
+		return nil, ErrNotConnected
+	}
+
+	own := c.client.Store.ID
+	if own == nil {
+		return nil, ErrNotLoggedIn
+	}
+
+	devices, err := c.client.GetUserDevices(ctx, []types.JID{own.ToNonAD()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linked devices: %w", err)
+	}
+
+	return buildDeviceList(*own, devices, c.client.Store.Platform, linkedAt(c.client.Store.Account)), nil
+}
+
+// buildDeviceList turns the device JIDs of our own account into DeviceInfo,
+// primary phone first.
+func buildDeviceList(own types.JID, devices []types.JID, primaryPlatform string, ownLinkedAt time.Time) []DeviceInfo {
+	infos := make([]DeviceInfo, 0, len(devices))
+	for _, d := range devices {
+		info := DeviceInfo{
+			JID:       d.String(),
+			DeviceID:  d.Device,
+			IsPrimary: d.Device == 0,
+			IsCurrent: d.Device == own.Device,
+		}
+		if info.IsPrimary {
+			info.Platform = primaryPlatform
+		}
+		if info.IsCurrent && !ownLinkedAt.IsZero() {
+			t := ownLinkedAt
+			info.LinkedAt = &t
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].DeviceID < infos[j].DeviceID })
+	return infos
+}
+
+// linkedAt reads the pairing time from the signed device identity.
+func linkedAt(account *waAdv.ADVSignedDeviceIdentity) time.Time {
+	if account == nil {
+		return time.Time{}
+	}
+	var identity waAdv.ADVDeviceIdentity
+	if err := proto.Unmarshal(account.GetDetails(), &identity); err != nil || identity.GetTimestamp() == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(identity.GetTimestamp()), 0)
+}
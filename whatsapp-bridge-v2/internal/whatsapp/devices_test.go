@@ -0,0 +1,33 @@
+package whatsapp
+
+import (
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestBuildDeviceList(t *testing.T) {
+	own := types.NewADJID("1234567890", 0, 12)
+	devices := []types.JID{
+		types.NewADJID("1234567890", 0, 12),
+		types.NewADJID("1234567890", 0, 0),
+		types.NewADJID("1234567890", 0, 3),
+	}
+	linked := time.Unix(1750000000, 0)
+
+	got := buildDeviceList(own, devices, "android", linked)
+
+	if len(got) != 3 {
+		t.Fatalf("got %d devices, want 3", len(got))
+	}
+	if !got[0].IsPrimary || got[0].DeviceID != 0 || got[0].Platform != "android" {
+		t.Errorf("first device = %+v, want the primary android phone", got[0])
+	}
+	if got[1].DeviceID != 3 || got[1].IsCurrent || got[1].Platform != "" || got[1].LinkedAt != nil {
+		t.Errorf("other companion = %+v, want no platform or link time", got[1])
+	}
+	if !got[2].IsCurrent || got[2].LinkedAt == nil || !got[2].LinkedAt.Equal(linked) {
+		t.Errorf("own device = %+v, want is_current with linked_at %s", got[2], linked)
+	}
+}
@@ -0,0 +1,67 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// ErrNoMediaKeys is returned when a message's media cannot be downloaded
+// because the keys from the original message were not kept.
+var ErrNoMediaKeys = errors.New("media download keys are not stored for this message")
+
+// MediaFile identifies a media attachment on WhatsApp's servers: the path
+// and keys the original message carried, plus the bridge's media type
+// ("image", "video", "audio", "document" or "sticker").
+type MediaFile struct {
+	MediaType     string
+	DirectPath    string
+	MediaKey      []byte
+	FileSHA256    []byte
+	FileEncSHA256 []byte
+	FileLength    uint64
+}
+
+// mediaDownloader is the part of the whatsmeow client used to download media.
+type mediaDownloader interface {
+	DownloadMediaWithPath(ctx context.Context, directPath string, encFileHash, fileHash, mediaKey []byte, fileLength int, mediaType whatsmeow.MediaType, mmsType string) ([]byte, error)
+}
+
+// downloadMediaTypes maps the bridge's media types to the keys whatsmeow
+// decrypts them with. Stickers are encrypted like images.
+var downloadMediaTypes = map[string]whatsmeow.MediaType{
+	"image":    whatsmeow.MediaImage,
+	"video":    whatsmeow.MediaVideo,
+	"audio":    whatsmeow.MediaAudio,
+	"document": whatsmeow.MediaDocument,
+	"sticker":  whatsmeow.MediaImage,
+}
+
+// downloadFile fetches and decrypts media and writes it to path. whatsmeow
+// checks the file's hashes and length, so a file is only written once it
+// is known to be intact.
+func downloadFile(ctx context.Context, d mediaDownloader, media MediaFile, path string) error {
+	mediaType, ok := downloadMediaTypes[media.MediaType]
+	if !ok {
+		return fmt.Errorf("unsupported media type %q", media.MediaType)
+	}
+	if media.DirectPath == "" || len(media.MediaKey) == 0 {
+		return ErrNoMediaKeys
+	}
+
+	fileLength := -1
+	if media.FileLength > 0 {
+		fileLength = int(media.FileLength)
+	}
+	data, err := d.DownloadMediaWithPath(ctx, media.DirectPath, media.FileEncSHA256, media.FileSHA256, media.MediaKey, fileLength, mediaType, "")
+	if err != nil {
+		return fmt.Errorf("failed to download media: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save media: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// fakeDownloader returns data for any request and records the media type
+// and length it was asked for.
+type fakeDownloader struct {
+	data       []byte
+	err        error
+	mediaType  whatsmeow.MediaType
+	fileLength int
+}
+
+func (d *fakeDownloader) DownloadMediaWithPath(ctx context.Context, directPath string, encFileHash, fileHash, mediaKey []byte, fileLength int, mediaType whatsmeow.MediaType, mmsType string) ([]byte, error) {
+	d.mediaType = mediaType
+	d.fileLength = fileLength
+	return d.data, d.err
+}
+
+func TestDownloadFile(t *testing.T) {
+	d := &fakeDownloader{data: []byte("sticker bytes")}
+	path := filepath.Join(t.TempDir(), "sticker.webp")
+	media := MediaFile{MediaType: "sticker", DirectPath: "/v/t62/abc", MediaKey: []byte("key"), FileLength: 13}
+
+	if err := downloadFile(context.Background(), d, media, path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "sticker bytes" {
+		t.Errorf("saved %q, want %q", got, "sticker bytes")
+	}
+	if d.mediaType != whatsmeow.MediaImage || d.fileLength != 13 {
+		t.Errorf("downloaded as %q with length %d, want %q and 13", d.mediaType, d.fileLength, whatsmeow.MediaImage)
+	}
+}
+
+func TestDownloadFile_Errors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	ctx := context.Background()
+
+	err := downloadFile(ctx, &fakeDownloader{}, MediaFile{MediaType: "image"}, path)
+	if !errors.Is(err, ErrNoMediaKeys) {
+		t.Errorf("missing keys: got %v, want ErrNoMediaKeys", err)
+	}
+
+	err = downloadFile(ctx, &fakeDownloader{}, MediaFile{MediaType: "poll", DirectPath: "/x", MediaKey: []byte("k")}, path)
+	if err == nil {
+		t.Error("unsupported media type: expected an error")
+	}
+
+	failed := errors.New("boom")
+	err = downloadFile(ctx, &fakeDownloader{err: failed}, MediaFile{MediaType: "image", DirectPath: "/x", MediaKey: []byte("k")}, path)
+	if !errors.Is(err, failed) {
+		t.Errorf("download failure: got %v, want %v", err, failed)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("nothing should be written when the download fails")
+	}
+}
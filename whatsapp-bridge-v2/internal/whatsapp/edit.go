@@ -0,0 +1,26 @@
+package whatsapp
+
+import (
+	"fmt"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+)
+
+// buildEditContent builds the replacement body for an edit. Text messages
+// are replaced with a plain conversation; media messages keep their type and
+// only the caption changes, otherwise the edit would turn them into text.
+func buildEditContent(newContent, mediaType string) (*waE2E.Message, error) {
+	switch mediaType {
+	case "":
+		return &waE2E.Message{Conversation: proto.String(newContent)}, nil
+	case "image":
+		return &waE2E.Message{ImageMessage: &waE2E.ImageMessage{Caption: proto.String(newContent)}}, nil
+	case "video":
+		return &waE2E.Message{VideoMessage: &waE2E.VideoMessage{Caption: proto.String(newContent)}}, nil
+	case "document":
+		return &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{Caption: proto.String(newContent)}}, nil
+	default:
+		return nil, fmt.Errorf("%s messages have no caption to edit", mediaType)
+	}
+}
@@ -0,0 +1,37 @@
+package whatsapp
+
+import "testing"
+
+func TestBuildEditContentText(t *testing.T) {
+	msg, err := buildEditContent("fixed typo", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg.GetConversation() != "fixed typo" {
+		t.Errorf("conversation = %q, want %q", msg.GetConversation(), "fixed typo")
+	}
+}
+
+func TestBuildEditContentImageCaption(t *testing.T) {
+	msg, err := buildEditContent("new caption", "image")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg.Conversation != nil {
+		t.Errorf("image caption edit must not become a text message")
+	}
+	if msg.GetImageMessage() == nil {
+		t.Fatalf("expected an image message, got %v", msg)
+	}
+	if got := msg.GetImageMessage().GetCaption(); got != "new caption" {
+		t.Errorf("caption = %q, want %q", got, "new caption")
+	}
+}
+
+func TestBuildEditContentNoCaption(t *testing.T) {
+	for _, mediaType := range []string{"audio", "sticker"} {
+		if _, err := buildEditContent("caption", mediaType); err == nil {
+			t.Errorf("expected an error editing a %s message", mediaType)
+		}
+	}
+}
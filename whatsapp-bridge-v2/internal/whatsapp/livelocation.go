@@ -0,0 +1,79 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// liveLocation is a live location share in progress with one chat.
+type liveLocation struct {
+	started  time.Time
+	expires  time.Time
+	sequence int64
+}
+
+// SendLiveLocation shares a live location with a chat for durationSec
+// seconds. The first call for a chat sends the initial live location
+// message. Calls for the same chat before the share expires send position
+// updates with an increasing sequence number and the time offset since the
+// share started; durationSec is ignored for those. The live location
+// message has no duration field, so the share ends when the caller stops
+// feeding coordinates or the duration runs out, whichever is first.
+func (c *Client) SendLiveLocation(ctx context.Context, jid string, lat, lon float64, durationSec int) (whatsmeow.SendResponse, error) {
+	if !c.IsReady() {
+		return whatsmeow.SendResponse{}, ErrNotConnected
+	}
+
+	recipient, err := types.ParseJID(jid)
+	if err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid JID: %w", err)
+	}
+
+	seq, offset := c.nextLiveLocation(recipient.String(), time.Now(), time.Duration(durationSec)*time.Second)
+
+	resp, err := c.client.SendMessage(ctx, recipient, &waE2E.Message{
+		LiveLocationMessage: &waE2E.LiveLocationMessage{
+			DegreesLatitude:  &lat,
+			DegreesLongitude: &lon,
+			SequenceNumber:   proto.Int64(seq),
+			TimeOffset:       proto.Uint32(offset),
+		},
+	})
+	if err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to send live location: %w", err)
+	}
+
+	return resp, nil
+}
+
+// nextLiveLocation returns the sequence number and time offset, in seconds,
+// of the next live location message for chat. It starts a new share lasting
+// duration when there is none or the previous one has expired, and drops
+// any other expired shares so the map stays bounded.
+func (c *Client) nextLiveLocation(chat string, now time.Time, duration time.Duration) (int64, uint32) {
+	c.liveMu.Lock()
+	defer c.liveMu.Unlock()
+
+	if c.liveLocations == nil {
+		c.liveLocations = make(map[string]*liveLocation)
+	}
+	for k, l := range c.liveLocations {
+		if !now.Before(l.expires) {
+			delete(c.liveLocations, k)
+		}
+	}
+
+	l, ok := c.liveLocations[chat]
+	if !ok {
+		c.liveLocations[chat] = &liveLocation{started: now, expires: now.Add(duration)}
+		return 0, 0
+	}
+	l.sequence++
+	return l.sequence, uint32(now.Sub(l.started) / time.Second)
+}
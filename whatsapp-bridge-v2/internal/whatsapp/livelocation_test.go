@@ -0,0 +1,36 @@
+package whatsapp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextLiveLocation(t *testing.T) {
+	c := &Client{}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	chat := "1234567890@s.whatsapp.net"
+
+	seq, offset := c.nextLiveLocation(chat, start, time.Minute)
+	if seq != 0 || offset != 0 {
+		t.Fatalf("first message: got seq %d offset %d, want 0 0", seq, offset)
+	}
+
+	seq, offset = c.nextLiveLocation(chat, start.Add(30*time.Second), time.Hour)
+	if seq != 1 || offset != 30 {
+		t.Fatalf("update: got seq %d offset %d, want 1 30", seq, offset)
+	}
+
+	// Other chats have their own share
+	if seq, _ := c.nextLiveLocation("other@s.whatsapp.net", start.Add(30*time.Second), time.Minute); seq != 0 {
+		t.Fatalf("other chat: got seq %d, want 0", seq)
+	}
+
+	// Once the first share's minute is up the next call starts a new one
+	seq, offset = c.nextLiveLocation(chat, start.Add(time.Minute), time.Minute)
+	if seq != 0 || offset != 0 {
+		t.Fatalf("after expiry: got seq %d offset %d, want 0 0", seq, offset)
+	}
+	if len(c.liveLocations) != 2 {
+		t.Fatalf("got %d shares, want 2", len(c.liveLocations))
+	}
+}
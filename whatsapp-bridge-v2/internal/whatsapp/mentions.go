@@ -0,0 +1,62 @@
+package whatsapp
+
+import (
+	"regexp"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// mentionPattern matches @<phone> tokens that start the text or follow a
+// non-word character, so email addresses are not treated as mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@(\d{7,15})\b`)
+
+// parseMentions returns the user JIDs of the @<phone> tokens in text, in
+// order of first appearance.
+func parseMentions(text string) []string {
+	var jids []string
+	seen := make(map[string]bool)
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		jid := types.NewJID(m[1], types.DefaultUserServer).String()
+		if !seen[jid] {
+			seen[jid] = true
+			jids = append(jids, jid)
+		}
+	}
+	return jids
+}
+
+// buildTextMessage builds a text message. Mentions only exist in groups, so
+// for other chats the mentions are ignored and a plain conversation message
+// is sent.
+func buildTextMessage(recipient types.JID, text string, mentions []string) *waE2E.Message {
+	if recipient.Server != types.GroupServer {
+		return &waE2E.Message{Conversation: proto.String(text)}
+	}
+
+	mentioned := parseMentions(text)
+	seen := make(map[string]bool, len(mentioned))
+	for _, jid := range mentioned {
+		seen[jid] = true
+	}
+	for _, jid := range mentions {
+		if !seen[jid] {
+			seen[jid] = true
+			mentioned = append(mentioned, jid)
+		}
+	}
+
+	if len(mentioned) == 0 {
+		return &waE2E.Message{Conversation: proto.String(text)}
+	}
+
+	return &waE2E.Message{
+		ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+			Text: proto.String(text),
+			ContextInfo: &waE2E.ContextInfo{
+				MentionedJID: mentioned,
+			},
+		},
+	}
+}
@@ -0,0 +1,115 @@
+package whatsapp
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "single mention",
+			text: "hi @1234567890 how are you",
+			want: []string{"1234567890@s.whatsapp.net"},
+		},
+		{
+			name: "mention at start and end with punctuation",
+			text: "@1234567890, meet @447700900123.",
+			want: []string{"1234567890@s.whatsapp.net", "447700900123@s.whatsapp.net"},
+		},
+		{
+			name: "duplicates collapsed",
+			text: "@1234567890 @1234567890",
+			want: []string{"1234567890@s.whatsapp.net"},
+		},
+		{
+			name: "email address is not a mention",
+			text: "mail me at user@1234567890.com",
+			want: nil,
+		},
+		{
+			name: "too short to be a phone number",
+			text: "room @123",
+			want: nil,
+		},
+		{
+			name: "no mentions",
+			text: "hello world",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseMentions(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMentions(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTextMessage_GroupMentions(t *testing.T) {
+	group := types.NewJID("120363000000000000", types.GroupServer)
+
+	msg := buildTextMessage(group, "hey @1234567890", []string{"447700900123@s.whatsapp.net", "1234567890@s.whatsapp.net"})
+
+	if msg.GetConversation() != "" {
+		t.Fatal("expected an extended text message for a group mention")
+	}
+	ext := msg.GetExtendedTextMessage()
+	if ext.GetText() != "hey @1234567890" {
+		t.Errorf("text = %q", ext.GetText())
+	}
+	want := []string{"1234567890@s.whatsapp.net", "447700900123@s.whatsapp.net"}
+	if got := ext.GetContextInfo().GetMentionedJID(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MentionedJID = %v, want %v", got, want)
+	}
+}
+
+func TestBuildTextMessage_DirectChatIgnoresMentions(t *testing.T) {
+	user := types.NewJID("1234567890", types.DefaultUserServer)
+
+	msg := buildTextMessage(user, "hey @447700900123", []string{"447700900123@s.whatsapp.net"})
+
+	if msg.GetExtendedTextMessage() != nil {
+		t.Fatal("1:1 chats must not carry mentions")
+	}
+	if msg.GetConversation() != "hey @447700900123" {
+		t.Errorf("conversation = %q", msg.GetConversation())
+	}
+}
+
+func TestWithQuote_CrossChat(t *testing.T) {
+	user := types.NewJID("1234567890", types.DefaultUserServer)
+	group := types.NewJID("120363000000000000", types.GroupServer)
+
+	msg := withQuote(buildTextMessage(user, "see this", nil), user, group, "ABC123", "447700900123@s.whatsapp.net", "original")
+
+	ext := msg.GetExtendedTextMessage()
+	if ext.GetText() != "see this" {
+		t.Errorf("text = %q", ext.GetText())
+	}
+	info := ext.GetContextInfo()
+	if info.GetStanzaID() != "ABC123" || info.GetParticipant() != "447700900123@s.whatsapp.net" {
+		t.Errorf("StanzaID = %q, Participant = %q", info.GetStanzaID(), info.GetParticipant())
+	}
+	if info.GetQuotedMessage().GetConversation() != "original" {
+		t.Errorf("quoted text = %q", info.GetQuotedMessage().GetConversation())
+	}
+	if info.GetRemoteJID() != group.String() {
+		t.Errorf("RemoteJID = %q, want %q", info.GetRemoteJID(), group.String())
+	}
+
+	// Quoting within the same chat leaves RemoteJID unset
+	msg = withQuote(buildTextMessage(user, "see this", nil), user, user, "ABC123", "", "original")
+	if msg.GetExtendedTextMessage().GetContextInfo().RemoteJID != nil {
+		t.Error("RemoteJID must not be set for a same-chat quote")
+	}
+}
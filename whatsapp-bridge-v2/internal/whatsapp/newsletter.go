@@ -0,0 +1,47 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// GetSubscribedNewsletters lists the channels (newsletters) the account follows.
+func (c *Client) GetSubscribedNewsletters(ctx context.Context) ([]*types.NewsletterMetadata, error) {
+	if !c.IsReady() {
+		return nil, ErrNotConnected
+	}
+
+	newsletters, err := c.client.GetSubscribedNewsletters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get newsletters: %w", err)
+	}
+
+	return newsletters, nil
+}
+
+// GetNewsletterMessages fetches the most recent count messages posted to a
+// channel. Channel messages are not end-to-end encrypted, so the server
+// returns them in full rather than through history sync.
+func (c *Client) GetNewsletterMessages(ctx context.Context, newsletterJID string, count int) ([]*types.NewsletterMessage, error) {
+	if !c.IsReady() {
+		return nil, ErrNotConnected
+	}
+
+	jid, err := types.ParseJID(newsletterJID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JID: %w", err)
+	}
+	if jid.Server != types.NewsletterServer {
+		return nil, fmt.Errorf("invalid JID: %s is not a newsletter", newsletterJID)
+	}
+
+	messages, err := c.client.GetNewsletterMessages(ctx, jid, &whatsmeow.GetNewsletterMessagesParams{Count: count})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get newsletter messages: %w", err)
+	}
+
+	return messages, nil
+}
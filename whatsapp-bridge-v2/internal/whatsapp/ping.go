@@ -0,0 +1,29 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Ping measures the round trip to the WhatsApp server with the same empty
+// "w:p" query whatsmeow uses for its keepalives.
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	if !c.IsReady() {
+		return 0, ErrNotConnected
+	}
+
+	start := time.Now()
+	_, err := c.client.DangerousInternals().SendIQ(ctx, whatsmeow.DangerousInfoQuery{
+		Namespace: "w:p",
+		Type:      "get",
+		To:        types.ServerJID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("ping failed: %w", err)
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,91 @@
+package whatsapp
+
+import (
+	"context"
+	"time"
+)
+
+// QR rotation intervals, matching whatsmeow's own QR channel: the first code
+// is valid for a minute, each following code for 20 seconds.
+const (
+	qrFirstCodeTimeout = 60 * time.Second
+	qrNextCodeTimeout  = 20 * time.Second
+)
+
+// handleQR starts publishing the rotation codes of a QR event to qrChan,
+// replacing any rotation already in progress. Codes are sent one at a time
+// so the consumer always holds the currently valid code.
+func (c *Client) handleQR(codes []string) {
+	c.qrMu.Lock()
+	defer c.qrMu.Unlock()
+
+	if c.qrClosed {
+		c.log.Warn("QR code received after pairing completed, ignoring")
+		return
+	}
+
+	c.stopQRRotationLocked()
+	if len(codes) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	c.qrCancel = cancel
+	c.qrDone = done
+
+	go c.rotateQR(ctx, codes, done)
+}
+
+// rotateQR sends each code and waits for it to expire before sending the next.
+func (c *Client) rotateQR(ctx context.Context, codes []string, done chan struct{}) {
+	defer close(done)
+
+	for i, code := range codes {
+		select {
+		case c.qrChan <- code:
+		case <-ctx.Done():
+			return
+		}
+
+		timeout := c.qrNextTimeout
+		if i == 0 {
+			timeout = c.qrFirstTimeout
+		}
+
+		timer := time.NewTimer(timeout)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// closeQR stops any rotation in progress and closes qrChan so its consumer
+// exits. It is called once pairing succeeds and is safe to call repeatedly.
+func (c *Client) closeQR() {
+	c.qrMu.Lock()
+	defer c.qrMu.Unlock()
+
+	if c.qrClosed {
+		return
+	}
+
+	c.stopQRRotationLocked()
+	close(c.qrChan)
+	c.qrClosed = true
+}
+
+// stopQRRotationLocked cancels the current rotation and waits for it to exit.
+// The caller must hold qrMu.
+func (c *Client) stopQRRotationLocked() {
+	if c.qrCancel == nil {
+		return
+	}
+	c.qrCancel()
+	<-c.qrDone
+	c.qrCancel = nil
+	c.qrDone = nil
+}
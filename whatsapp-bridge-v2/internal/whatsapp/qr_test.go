@@ -0,0 +1,75 @@
+package whatsapp
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func newQRTestClient(first, next time.Duration) *Client {
+	return &Client{
+		log:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+		qrChan:         make(chan string, 1),
+		qrFirstTimeout: first,
+		qrNextTimeout:  next,
+	}
+}
+
+func TestQRRotationStopsAfterPairSuccess(t *testing.T) {
+	// The second code of each event never expires within the test, so the
+	// only way to see a later code is for a new QR event to replace it.
+	c := newQRTestClient(10*time.Millisecond, time.Hour)
+
+	received := make(chan string, 10)
+	consumerDone := make(chan struct{})
+	go func() {
+		defer close(consumerDone)
+		for code := range c.GetQRChannel() {
+			received <- code
+		}
+	}()
+
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-received:
+			if got != want {
+				t.Fatalf("got QR code %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for QR code %q", want)
+		}
+	}
+
+	c.handleEvent(&events.QR{Codes: []string{"code-1", "code-2", "code-3"}})
+	expect("code-1")
+	expect("code-2")
+
+	c.handleEvent(&events.QR{Codes: []string{"code-4", "code-5"}})
+	expect("code-4")
+
+	c.handleEvent(&events.PairSuccess{})
+
+	select {
+	case <-consumerDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("QR consumer did not stop after PairSuccess")
+	}
+
+	if c.qrCancel != nil || c.qrDone != nil {
+		t.Error("QR rotation goroutine still registered after PairSuccess")
+	}
+
+	// Late events and repeated closes must not panic or reopen the channel.
+	c.handleEvent(&events.QR{Codes: []string{"code-6"}})
+	c.closeQR()
+
+	select {
+	case code := <-received:
+		t.Errorf("unexpected QR code after pairing: %q", code)
+	default:
+	}
+}
@@ -0,0 +1,71 @@
+package whatsapp
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"io"
+	"os"
+
+	// Register decoders for the image formats we accept for sending.
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// thumbnailMaxSide is the longest edge of generated thumbnails, matching
+	// the size of previews produced by the official clients.
+	thumbnailMaxSide = 72
+	thumbnailQuality = 60
+
+	// thumbnailSourceLimit bounds how much of a file is read to decode it;
+	// WhatsApp images can't be larger than this anyway.
+	thumbnailSourceLimit = 16 << 20
+)
+
+// thumbnailFromFile generates a thumbnail for the image at path, reading at
+// most thumbnailSourceLimit bytes of it. It returns nil on any error.
+func thumbnailFromFile(path string) []byte {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	return generateThumbnail(io.LimitReader(f, thumbnailSourceLimit))
+}
+
+// generateThumbnail returns a small JPEG preview of an image for use as a
+// message's JpegThumbnail. It is best-effort: if r can't be decoded as an
+// image (e.g. a video or an unsupported format), it returns nil.
+func generateThumbnail(r io.Reader) []byte {
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil
+	}
+
+	// Scale so the longest side is thumbnailMaxSide, preserving aspect ratio
+	if width >= height {
+		height = max(1, height*thumbnailMaxSide/width)
+		width = thumbnailMaxSide
+	} else {
+		width = max(1, width*thumbnailMaxSide/height)
+		height = thumbnailMaxSide
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
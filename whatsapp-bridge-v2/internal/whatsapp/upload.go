@@ -0,0 +1,72 @@
+package whatsapp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// sniffLen is how much of a file http.DetectContentType looks at.
+const sniffLen = 512
+
+// mediaUploader is the part of the whatsmeow client used to upload media.
+type mediaUploader interface {
+	UploadReader(ctx context.Context, plaintext io.Reader, tempFile io.ReadWriteSeeker, appInfo whatsmeow.MediaType) (whatsmeow.UploadResponse, error)
+}
+
+// uploadedFile is the result of uploadFile: the upload response plus the
+// MIME type sniffed from the file.
+type uploadedFile struct {
+	whatsmeow.UploadResponse
+	MimeType string
+}
+
+// uploadFile streams the file at path to WhatsApp. whatsmeow encrypts it
+// into a temporary file and hashes it as it goes, so memory use stays flat
+// no matter how large the file is. FileLength in the response is the
+// plaintext size.
+func uploadFile(ctx context.Context, u mediaUploader, path string, mediaType whatsmeow.MediaType) (*uploadedFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	mimeType, err := sniffMimeType(f)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := u.UploadReader(ctx, f, nil, mediaType)
+	if err != nil {
+		return nil, err
+	}
+	return &uploadedFile{UploadResponse: resp, MimeType: mimeType}, nil
+}
+
+// sniffFileMimeType detects the content type of the file at path from its
+// first few hundred bytes.
+func sniffFileMimeType(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return sniffMimeType(f)
+}
+
+// sniffMimeType detects the content type from the start of r and rewinds it.
+func sniffMimeType(r io.ReadSeeker) (string, error) {
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
@@ -0,0 +1,83 @@
+package whatsapp
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// countingUploader consumes the upload stream without keeping it, the way
+// whatsmeow streams it into an encrypted temp file.
+type countingUploader struct {
+	read int64
+}
+
+func (u *countingUploader) UploadReader(ctx context.Context, plaintext io.Reader, tempFile io.ReadWriteSeeker, appInfo whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	n, err := io.Copy(io.Discard, plaintext)
+	u.read = n
+	return whatsmeow.UploadResponse{FileLength: uint64(n)}, err
+}
+
+// largeMP4 creates a sparse file of size bytes that sniffs as video/mp4.
+func largeMP4(tb testing.TB, size int64) string {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "big.mp4")
+	f, err := os.Create(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write([]byte("\x00\x00\x00\x18ftypmp42")); err != nil {
+		tb.Fatal(err)
+	}
+	if err := f.Truncate(size); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+func TestUploadFile_Streams(t *testing.T) {
+	const size = 64 << 20
+	path := largeMP4(t, size)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	u := &countingUploader{}
+	uploaded, err := uploadFile(context.Background(), u, path, whatsmeow.MediaVideo)
+	if err != nil {
+		t.Fatalf("uploadFile() error = %v", err)
+	}
+
+	runtime.ReadMemStats(&after)
+
+	if u.read != size {
+		t.Errorf("uploader read %d bytes, want %d", u.read, size)
+	}
+	if uploaded.FileLength != size {
+		t.Errorf("FileLength = %d, want %d", uploaded.FileLength, size)
+	}
+	if uploaded.MimeType != "video/mp4" {
+		t.Errorf("MimeType = %q, want video/mp4", uploaded.MimeType)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > size/16 {
+		t.Errorf("uploading a %d byte file allocated %d bytes; it should stream", size, allocated)
+	}
+}
+
+func BenchmarkUploadFile(b *testing.B) {
+	path := largeMP4(b, 32<<20)
+	u := &countingUploader{}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := uploadFile(context.Background(), u, path, whatsmeow.MediaVideo); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// ContactCard is the content of a shared contact. Phone is the number in
+// international format without formatting; Organization and Email are
+// optional.
+type ContactCard struct {
+	Name         string
+	Phone        string
+	Organization string
+	Email        string
+}
+
+// vCard renders the card as a vCard 3.0. The phone line carries the number
+// as its WhatsApp ID so recipients get a "Message" button when the number
+// is on WhatsApp.
+func (c ContactCard) vCard() string {
+	var b strings.Builder
+	b.WriteString("BEGIN:VCARD\nVERSION:3.0\n")
+	fmt.Fprintf(&b, "FN:%s\n", escapeVCard(c.Name))
+	if c.Organization != "" {
+		fmt.Fprintf(&b, "ORG:%s\n", escapeVCard(c.Organization))
+	}
+	fmt.Fprintf(&b, "TEL;type=CELL;type=VOICE;waid=%s:+%s\n", c.Phone, c.Phone)
+	if c.Email != "" {
+		fmt.Fprintf(&b, "EMAIL:%s\n", escapeVCard(c.Email))
+	}
+	b.WriteString("END:VCARD")
+	return b.String()
+}
+
+// vCardEscaper escapes the characters that are special in vCard values,
+// so a name can't end its line or inject properties.
+var vCardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func escapeVCard(s string) string {
+	return vCardEscaper.Replace(s)
+}
+
+// SendVCard sends a contact card built from card rather than from a
+// WhatsApp contact, so any phone number can be shared.
+func (c *Client) SendVCard(ctx context.Context, jid string, card ContactCard) (whatsmeow.SendResponse, error) {
+	if !c.IsReady() {
+		return whatsmeow.SendResponse{}, ErrNotConnected
+	}
+
+	recipient, err := types.ParseJID(jid)
+	if err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid recipient JID: %w", err)
+	}
+
+	return c.sendContactCard(ctx, recipient, card)
+}
+
+// SendContactCards shares several WhatsApp contacts in one contacts array
+// message.
+func (c *Client) SendContactCards(ctx context.Context, jid string, contactJIDs []string) (whatsmeow.SendResponse, error) {
+	if !c.IsReady() {
+		return whatsmeow.SendResponse{}, ErrNotConnected
+	}
+
+	recipient, err := types.ParseJID(jid)
+	if err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("invalid recipient JID: %w", err)
+	}
+
+	contacts := make([]*waE2E.ContactMessage, 0, len(contactJIDs))
+	for _, contactJID := range contactJIDs {
+		contactInfo, err := types.ParseJID(contactJID)
+		if err != nil {
+			return whatsmeow.SendResponse{}, fmt.Errorf("invalid contact JID %s: %w", contactJID, err)
+		}
+		card := c.contactCard(ctx, contactInfo)
+		contacts = append(contacts, &waE2E.ContactMessage{
+			DisplayName: proto.String(card.Name),
+			Vcard:       proto.String(card.vCard()),
+		})
+	}
+
+	msg := &waE2E.Message{
+		ContactsArrayMessage: &waE2E.ContactsArrayMessage{
+			DisplayName: proto.String(fmt.Sprintf("%d contacts", len(contacts))),
+			Contacts:    contacts,
+		},
+	}
+
+	resp, err := c.client.SendMessage(ctx, recipient, msg)
+	if err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to send contact cards: %w", err)
+	}
+
+	return resp, nil
+}
+
+// contactCard builds the card for a WhatsApp contact, named as in the
+// address book, else by push name, else by number.
+func (c *Client) contactCard(ctx context.Context, contactInfo types.JID) ContactCard {
+	card := ContactCard{Name: contactInfo.User, Phone: contactInfo.User}
+	if c.client.Store != nil {
+		if contact, err := c.client.Store.Contacts.GetContact(ctx, contactInfo); err == nil && contact.FullName != "" {
+			card.Name = contact.FullName
+		} else if contact.PushName != "" {
+			card.Name = contact.PushName
+		}
+	}
+	return card
+}
+
+func (c *Client) sendContactCard(ctx context.Context, recipient types.JID, card ContactCard) (whatsmeow.SendResponse, error) {
+	msg := &waE2E.Message{
+		ContactMessage: &waE2E.ContactMessage{
+			DisplayName: proto.String(card.Name),
+			Vcard:       proto.String(card.vCard()),
+		},
+	}
+
+	resp, err := c.client.SendMessage(ctx, recipient, msg)
+	if err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("failed to send contact card: %w", err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,26 @@
+package whatsapp
+
+import "testing"
+
+func TestContactCardVCard(t *testing.T) {
+	card := ContactCard{Name: "Doe; Jane, MD", Phone: "442079460958", Organization: "City Clinic", Email: "jane@example.com"}
+	want := "BEGIN:VCARD\nVERSION:3.0\n" +
+		`FN:Doe\; Jane\, MD` + "\n" +
+		"ORG:City Clinic\n" +
+		"TEL;type=CELL;type=VOICE;waid=442079460958:+442079460958\n" +
+		"EMAIL:jane@example.com\n" +
+		"END:VCARD"
+	if got := card.vCard(); got != want {
+		t.Fatalf("vCard() =\n%s\nwant\n%s", got, want)
+	}
+
+	// A newline in a value must not start a new property
+	card = ContactCard{Name: "Jane\nTEL:+15555550100", Phone: "15555550199"}
+	want = "BEGIN:VCARD\nVERSION:3.0\n" +
+		`FN:Jane\nTEL:+15555550100` + "\n" +
+		"TEL;type=CELL;type=VOICE;waid=15555550199:+15555550199\n" +
+		"END:VCARD"
+	if got := card.vCard(); got != want {
+		t.Fatalf("vCard() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
+)
+
+// readOnlyTools lists the tools that change nothing on WhatsApp or in the
+// store beyond caching, and so are left out of the audit log. Any tool not
+// listed here is audited, so new tools are audited until marked otherwise.
+var readOnlyTools = map[string]bool{
+	ToolGetReactions:                true,
+	ToolMessageExists:               true,
+	ToolListChats:                   true,
+	ToolGetChat:                     true,
+	ToolGetChatByPhone:              true,
+	ToolGetChatSettings:             true,
+	ToolGetChatStats:                true,
+	ToolRequestHistorySync:          true,
+	ToolListMessages:                true,
+	ToolSearchMessages:              true,
+	ToolListLabels:                  true,
+	ToolSearchContacts:              true,
+	ToolListContacts:                true,
+	ToolGetContact:                  true,
+	ToolGetBlockedContacts:          true,
+	ToolCheckPhoneRegistered:        true,
+	ToolExportContacts:              true,
+	ToolGetGroupInfo:                true,
+	ToolGetCommonGroups:             true,
+	ToolGetInviteLink:               true,
+	ToolGetGroupInviteInfo:          true,
+	ToolListJoinRequests:            true,
+	ToolGetMediaThumbnail:           true,
+	ToolListPendingMedia:            true,
+	ToolSubscribePresence:           true,
+	ToolGetPresence:                 true,
+	ToolGetChatParticipantsPresence: true,
+	ToolGetStatusUpdates:            true,
+	ToolListNewsletters:             true,
+	ToolGetNewsletterMessages:       true,
+	ToolGetBridgeStatus:             true,
+	ToolPingWhatsApp:                true,
+	ToolGetConnectionHistory:        true,
+	ToolSelfTest:                    true,
+	ToolListLinkedDevices:           true,
+	ToolGetAuditLog:                 true,
+	ToolGetToolUsageStats:           true,
+	ToolGetSyncProgress:             true,
+}
+
+// auditTargetArgs are the arguments naming the chat, contact or group a
+// tool acts on, in order of preference.
+var auditTargetArgs = []string{"recipient", "jid", "chat_jid", "group_jid", "target_jid"}
+
+// audit appends a mutating tool call to the audit log. Only the tool, its
+// target and the outcome are kept, never message content. Dry runs change
+// nothing and are skipped. A failed write is logged but doesn't fail the
+// call, which has already happened.
+func (h *Handler) audit(ctx context.Context, name string, args map[string]interface{}, result *mcp.CallToolResult, callErr error) {
+	if readOnlyTools[name] || isDryRun(name, args) {
+		return
+	}
+
+	entry := &store.AuditEntry{Tool: name, TargetJID: auditTarget(args), Success: true}
+	switch {
+	case callErr != nil:
+		entry.Success = false
+		entry.ErrorCode = ErrInternal
+	case result != nil && result.IsError:
+		entry.Success = false
+		entry.ErrorCode = ErrInternal
+		if mcpErr, ok := result.StructuredContent.(*MCPError); ok {
+			entry.ErrorCode = mcpErr.Code
+		}
+	}
+
+	// Record the call even if the caller has gone away
+	if err := h.store.Audit.Append(context.WithoutCancel(ctx), entry); err != nil {
+		slog.Warn("failed to write audit log", "error", err, "tool", name)
+	}
+}
+
+// auditTarget returns the JID a call acts on, or the recipients joined by
+// commas for broadcasts.
+func auditTarget(args map[string]interface{}) string {
+	for _, key := range auditTargetArgs {
+		if s, ok := args[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	if recipients, ok := args["recipients"].([]interface{}); ok {
+		jids := make([]string, 0, len(recipients))
+		for _, r := range recipients {
+			if s, ok := r.(string); ok {
+				jids = append(jids, s)
+			}
+		}
+		return strings.Join(jids, ",")
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/whatsapp"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
+)
+
+// dryRunTools lists the send tools that accept a dry_run argument.
+var dryRunTools = map[string]bool{
+	ToolSendMessage:      true,
+	ToolSendImage:        true,
+	ToolSendVideo:        true,
+	ToolSendGIF:          true,
+	ToolSendAudio:        true,
+	ToolSendDocument:     true,
+	ToolSendFile:         true,
+	ToolSendLocation:     true,
+	ToolSendLiveLocation: true,
+	ToolSendContactCard:  true,
+	ToolSendContacts:     true,
+}
+
+// isDryRun reports whether a call to the named tool asks for a dry run.
+func isDryRun(name string, args map[string]interface{}) bool {
+	return dryRunTools[name] && getBool(args, "dry_run", false)
+}
+
+// mediaPathTypes maps each media path argument to the media type whose size
+// limit applies to it.
+var mediaPathTypes = map[string]string{
+	"image_path": "image",
+	"video_path": "video",
+	"gif_path":   "video",
+	"audio_path": "audio",
+	"file_path":  "document",
+}
+
+// sendTarget is the validated destination (and optional media file) of a send.
+type sendTarget struct {
+	JID      string
+	Path     string
+	MimeType string
+	Size     int64
+	// SendAs is the media type send_file picked for the file.
+	SendAs string
+}
+
+// resolveAndValidate validates the recipient argument and, when pathKey is
+// set, the media file it names. The recipient is normalized to a full JID.
+func (h *Handler) resolveAndValidate(args map[string]interface{}, pathKey string) (*sendTarget, *MCPError) {
+	recipient := getString(args, "recipient")
+	if recipient == "" {
+		return nil, NewInvalidInputError("recipient is required")
+	}
+	if err := validateJID(recipient); err != nil {
+		return nil, NewInvalidJIDError(recipient)
+	}
+
+	target := &sendTarget{JID: normalizeJID(recipient)}
+	if pathKey == "" {
+		return target, nil
+	}
+
+	path := getString(args, pathKey)
+	if path == "" {
+		return nil, NewInvalidInputError(pathKey + " is required")
+	}
+
+	info, err := whatsapp.ValidateFilePath(path, h.config.MediaAllowedDirs)
+	if err != nil {
+		return nil, NewInvalidInputError(pathKey + ": " + err.Error())
+	}
+	if mediaType, ok := mediaPathTypes[pathKey]; ok {
+		if limit := h.config.MediaLimit(mediaType); info.Size() > limit {
+			return nil, NewInvalidInputError(fmt.Sprintf("%s: file is %s, larger than the %s limit for %s media",
+				pathKey, formatMiB(info.Size()), formatMiB(limit), mediaType))
+		}
+	}
+
+	mimeType, err := detectMimeType(path)
+	if err != nil {
+		return nil, NewInvalidInputError(pathKey + ": " + err.Error())
+	}
+
+	target.Path = path
+	target.MimeType = mimeType
+	target.Size = info.Size()
+	return target, nil
+}
+
+// dryRunResult reports what a send would have done without doing it.
+func (h *Handler) dryRunResult(target *sendTarget) (*mcp.CallToolResult, error) {
+	result := map[string]interface{}{
+		"would_send":   true,
+		"resolved_jid": target.JID,
+	}
+	if target.Path != "" {
+		result["mime_type"] = target.MimeType
+		result["file_size"] = target.Size
+	}
+	if target.SendAs != "" {
+		result["send_as"] = target.SendAs
+	}
+	return h.successResult(result)
+}
+
+// formatMiB renders a byte count in mebibytes for error messages.
+func formatMiB(n int64) string {
+	return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+}
+
+// detectMimeType sniffs the content type the same way the client does on upload.
+func detectMimeType(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
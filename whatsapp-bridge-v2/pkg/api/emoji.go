@@ -0,0 +1,105 @@
+package api
+
+import "unicode"
+
+// Code points that combine with emoji into a single grapheme.
+const (
+	zeroWidthJoiner   = '\u200d'
+	variationSelector = '\ufe0f'
+	combiningKeycap   = '\u20e3'
+	cancelTag         = '\U000e007f'
+)
+
+// pictographic approximates Unicode's Extended_Pictographic property: the
+// code points that can start or be joined into an emoji. The standard
+// library has no emoji tables, so this is a curated list.
+var pictographic = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00a9, Hi: 0x00ae, Stride: 5},
+		{Lo: 0x203c, Hi: 0x2049, Stride: 13},
+		{Lo: 0x2122, Hi: 0x2139, Stride: 23},
+		{Lo: 0x2194, Hi: 0x2199, Stride: 1},
+		{Lo: 0x21a9, Hi: 0x21aa, Stride: 1},
+		{Lo: 0x231a, Hi: 0x231b, Stride: 1},
+		{Lo: 0x2328, Hi: 0x2328, Stride: 1},
+		{Lo: 0x23cf, Hi: 0x23cf, Stride: 1},
+		{Lo: 0x23e9, Hi: 0x23f3, Stride: 1},
+		{Lo: 0x23f8, Hi: 0x23fa, Stride: 1},
+		{Lo: 0x24c2, Hi: 0x24c2, Stride: 1},
+		{Lo: 0x25aa, Hi: 0x25ab, Stride: 1},
+		{Lo: 0x25b6, Hi: 0x25c0, Stride: 10},
+		{Lo: 0x25fb, Hi: 0x25fe, Stride: 1},
+		{Lo: 0x2600, Hi: 0x27bf, Stride: 1},
+		{Lo: 0x2934, Hi: 0x2935, Stride: 1},
+		{Lo: 0x2b05, Hi: 0x2b07, Stride: 1},
+		{Lo: 0x2b1b, Hi: 0x2b1c, Stride: 1},
+		{Lo: 0x2b50, Hi: 0x2b55, Stride: 5},
+		{Lo: 0x3030, Hi: 0x303d, Stride: 13},
+		{Lo: 0x3297, Hi: 0x3299, Stride: 2},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f000, Hi: 0x1faff, Stride: 1},
+	},
+	LatinOffset: 1,
+}
+
+func isRegionalIndicator(r rune) bool { return r >= 0x1f1e6 && r <= 0x1f1ff }
+func isSkinTone(r rune) bool          { return r >= 0x1f3fb && r <= 0x1f3ff }
+func isTag(r rune) bool               { return r >= 0xe0020 && r <= 0xe007f }
+
+// isSingleEmoji reports whether s is exactly one emoji as a user would see
+// it: a flag, a keycap, or one pictograph with optional variation selector,
+// skin tone and tag sequence, possibly joined to more with zero-width
+// joiners (family, profession and similar sequences).
+func isSingleEmoji(s string) bool {
+	rs := []rune(s)
+	if len(rs) == 0 {
+		return false
+	}
+
+	// Flags are a pair of regional indicators
+	if isRegionalIndicator(rs[0]) {
+		return len(rs) == 2 && isRegionalIndicator(rs[1])
+	}
+
+	// Keycaps: digit, # or *, optional variation selector, combining keycap
+	if (rs[0] >= '0' && rs[0] <= '9') || rs[0] == '#' || rs[0] == '*' {
+		rest := rs[1:]
+		if len(rest) > 0 && rest[0] == variationSelector {
+			rest = rest[1:]
+		}
+		return len(rest) == 1 && rest[0] == combiningKeycap
+	}
+
+	i := 0
+	for {
+		r := rs[i]
+		if !unicode.Is(pictographic, r) || isRegionalIndicator(r) || isSkinTone(r) {
+			return false
+		}
+		i++
+		if i < len(rs) && rs[i] == variationSelector {
+			i++
+		}
+		if i < len(rs) && isSkinTone(rs[i]) {
+			i++
+		}
+		// Subdivision flags end in a run of tags closed by a cancel tag
+		if i < len(rs) && isTag(rs[i]) {
+			for i < len(rs) && isTag(rs[i]) && rs[i] != cancelTag {
+				i++
+			}
+			if i == len(rs) {
+				return false
+			}
+			i++
+		}
+		if i == len(rs) {
+			return true
+		}
+		if rs[i] != zeroWidthJoiner || i+1 == len(rs) {
+			return false
+		}
+		i++
+	}
+}
@@ -4,6 +4,7 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 // Error codes
@@ -15,7 +16,9 @@ const (
 	ErrNotFound       = "NOT_FOUND"
 	ErrRateLimited    = "RATE_LIMITED"
 	ErrSessionExpired = "SESSION_EXPIRED"
+	ErrTemporaryBan   = "TEMPORARY_BAN"
 	ErrInvalidInput   = "INVALID_INPUT"
+	ErrUnsupported    = "UNSUPPORTED"
 	ErrInternal       = "INTERNAL_ERROR"
 )
 
@@ -24,6 +27,8 @@ type MCPError struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 	Retry   bool   `json:"retry"`
+	// RetryAfter is the earliest time retrying can succeed, when known.
+	RetryAfter *time.Time `json:"retry_after,omitempty"`
 }
 
 // Error implements the error interface.
@@ -46,6 +51,34 @@ func NewNotReadyError(state string) *MCPError {
 	}
 }
 
+// NewTemporaryBanError creates an error for when WhatsApp has temporarily
+// banned the account. Retrying before the ban is lifted only prolongs it,
+// so the error is not retryable and carries the lift time if known.
+func NewTemporaryBanError(reason string, liftAt *time.Time) *MCPError {
+	msg := "Account temporarily banned by WhatsApp: " + reason
+	if liftAt != nil {
+		msg += fmt.Sprintf(" (expected to be lifted at %s)", liftAt.UTC().Format(time.RFC3339))
+	}
+	return &MCPError{
+		Code:       ErrTemporaryBan,
+		Message:    msg,
+		Retry:      false,
+		RetryAfter: liftAt,
+	}
+}
+
+// NewRateLimitedError creates an error for a send refused because the
+// recipient was messaged too recently.
+func NewRateLimitedError(jid string, wait time.Duration) *MCPError {
+	retryAfter := time.Now().Add(wait)
+	return &MCPError{
+		Code:       ErrRateLimited,
+		Message:    fmt.Sprintf("Too many messages to %s, retry in %s", jid, wait.Round(time.Millisecond)),
+		Retry:      true,
+		RetryAfter: &retryAfter,
+	}
+}
+
 // NewInvalidJIDError creates an error for invalid JID.
 func NewInvalidJIDError(jid string) *MCPError {
 	return &MCPError{
@@ -82,6 +115,15 @@ func NewInvalidInputError(message string) *MCPError {
 	}
 }
 
+// NewUnsupportedError creates an error for features the account cannot use.
+func NewUnsupportedError(message string) *MCPError {
+	return &MCPError{
+		Code:    ErrUnsupported,
+		Message: message,
+		Retry:   false,
+	}
+}
+
 // NewInternalError creates an error for internal errors.
 func NewInternalError(err error) *MCPError {
 	return &MCPError{
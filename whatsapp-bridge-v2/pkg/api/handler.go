@@ -4,10 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/bridge"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/config"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/health"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/state"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/whatsapp"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
 )
 
@@ -16,30 +26,54 @@ type Bridge interface {
 	// State
 	CurrentState() state.State
 	IsReady() bool
+	IsConnected() bool
+	IsBusiness() bool
+	TemporaryBan() (bridge.BanInfo, bool)
+	DroppedEvents() int64
+	SyncProgress() bridge.SyncProgress
+	GetLinkedDevices(ctx context.Context) ([]whatsapp.DeviceInfo, error)
+	Ping(ctx context.Context) (time.Duration, error)
+	RetryFailedStores(ctx context.Context, limit int) (stored, failed int, err error)
 
 	// Messaging
-	SendMessage(ctx context.Context, jid string, text string) (string, error)
-	ReplyToMessage(ctx context.Context, chatJID, messageID, text string) (string, error)
-	ForwardMessage(ctx context.Context, sourceChatJID, messageID, targetJID string) (string, error)
+	SendMessage(ctx context.Context, jid string, text string, mentions []string) (bridge.SendMessageResult, error)
+	SendQuotedMessage(ctx context.Context, jid, text string, mentions []string, quotedChatJID, quotedID, quotedSender, quotedText string) (bridge.SendMessageResult, error)
+	ReplyToMessage(ctx context.Context, chatJID, messageID, text string) (bridge.SendMessageResult, error)
+	ForwardMessage(ctx context.Context, sourceChatJID, messageID, targetJID string) (bridge.SendMessageResult, error)
 	EditMessage(ctx context.Context, chatJID, messageID, newContent string) error
 	DeleteMessage(ctx context.Context, chatJID, messageID string, forEveryone bool) error
 	ReactToMessage(ctx context.Context, chatJID, messageID, emoji string) error
+	SendBroadcast(ctx context.Context, recipients []string, text string) ([]string, []error, error)
+	SendBatch(ctx context.Context, jids, texts []string, continueOnError bool) ([]string, []error, error)
 
 	// Media
-	SendImage(ctx context.Context, jid, imagePath, caption string) (string, error)
-	SendVideo(ctx context.Context, jid, videoPath, caption string) (string, error)
-	SendAudio(ctx context.Context, jid, audioPath string, asVoice bool) (string, error)
-	SendDocument(ctx context.Context, jid, filePath, filename string) (string, error)
-	SendLocation(ctx context.Context, jid string, lat, lon float64, name, address string) (string, error)
-	SendContactCard(ctx context.Context, jid, contactJID string) (string, error)
+	SendImage(ctx context.Context, jid, imagePath, caption string, viewOnce bool) (bridge.SendMessageResult, error)
+	SendVideo(ctx context.Context, jid, videoPath, caption string, viewOnce bool) (bridge.SendMessageResult, error)
+	SendGIF(ctx context.Context, jid, gifPath, caption string) (bridge.SendMessageResult, error)
+	SendAudio(ctx context.Context, jid, audioPath string, asVoice bool) (bridge.SendMessageResult, error)
+	SendDocument(ctx context.Context, jid, filePath, filename, caption string) (bridge.SendMessageResult, error)
+	SendLocation(ctx context.Context, jid string, lat, lon float64, name, address string) (bridge.SendMessageResult, error)
+	SendLiveLocation(ctx context.Context, jid string, lat, lon float64, durationSec int) (bridge.SendMessageResult, error)
+	SendContactCard(ctx context.Context, jid, contactJID string) (bridge.SendMessageResult, error)
+	SendVCard(ctx context.Context, jid string, card whatsapp.ContactCard) (bridge.SendMessageResult, error)
+	SendContactCards(ctx context.Context, jid string, contactJIDs []string) (bridge.SendMessageResult, error)
 	DownloadMedia(ctx context.Context, chatJID, messageID, savePath string) (string, error)
 
 	// Chats
 	ArchiveChat(ctx context.Context, jid string, archive bool) error
 	PinChat(ctx context.Context, jid string, pin bool) error
 	MuteChat(ctx context.Context, jid string, mute bool, duration string) error
+	ArchiveChats(ctx context.Context, jids []string, archive bool) ([]error, error)
+	MuteChats(ctx context.Context, jids []string, mute bool, duration string) ([]error, error)
+	MarkAllRead(ctx context.Context, jids []string) ([]error, error)
+	SetDisappearingTimer(ctx context.Context, jid string, duration time.Duration) error
 	MarkChatRead(ctx context.Context, jid string) error
+	MarkChatUnread(ctx context.Context, jid string) error
 	DeleteChat(ctx context.Context, jid string) error
+	GetChatSettings(ctx context.Context, jid string) (types.LocalChatSettings, error)
+	AddChatLabel(ctx context.Context, chatJID, labelID string) error
+	RemoveChatLabel(ctx context.Context, chatJID, labelID string) error
+	RequestHistorySync(ctx context.Context, chatJID string, count int) error
 
 	// Contacts
 	BlockContact(ctx context.Context, jid string, block bool) error
@@ -47,7 +81,7 @@ type Bridge interface {
 
 	// Groups
 	CreateGroup(ctx context.Context, name string, participants []string) (string, error)
-	GetGroupInfo(ctx context.Context, jid string) (interface{}, error)
+	GetGroupInfo(ctx context.Context, jid string) (*types.GroupInfo, error)
 	LeaveGroup(ctx context.Context, jid string) error
 	AddGroupMembers(ctx context.Context, groupJID string, participants []string) error
 	RemoveGroupMembers(ctx context.Context, groupJID string, participants []string) error
@@ -55,13 +89,22 @@ type Bridge interface {
 	DemoteAdmin(ctx context.Context, groupJID string, participants []string) error
 	SetGroupName(ctx context.Context, groupJID, name string) error
 	SetGroupTopic(ctx context.Context, groupJID, topic string) error
+	SetGroupAnnounce(ctx context.Context, groupJID string, announce bool) error
+	SetGroupLocked(ctx context.Context, groupJID string, locked bool) error
 	SetGroupPhoto(ctx context.Context, groupJID, imagePath string) error
-	GetInviteLink(ctx context.Context, groupJID string) (string, error)
+	RemoveGroupPhoto(ctx context.Context, groupJID string) error
+	GetInviteLink(ctx context.Context, groupJID string, refresh bool) (bridge.InviteLink, error)
 	RevokeInviteLink(ctx context.Context, groupJID string) (string, error)
 	JoinViaInvite(ctx context.Context, inviteLink string) (string, error)
+	GetGroupInfoFromLink(ctx context.Context, inviteLink string) (*types.GroupInfo, error)
+	GetCommonGroups(ctx context.Context, jid string) ([]string, error)
+	GetGroupJoinRequests(ctx context.Context, groupJID string) ([]types.GroupParticipantRequest, error)
+	ApproveGroupJoinRequest(ctx context.Context, groupJID string, participants []string) error
+	RejectGroupJoinRequest(ctx context.Context, groupJID string, participants []string) error
 
 	// Presence
 	SubscribePresence(ctx context.Context, jid string) error
+	SubscribeGroupPresence(ctx context.Context, groupJID string) ([]string, []error, error)
 	SendTyping(ctx context.Context, jid string) error
 	SendRecording(ctx context.Context, jid string) error
 	SetOnline(ctx context.Context) error
@@ -71,19 +114,32 @@ type Bridge interface {
 	PostTextStatus(ctx context.Context, text, backgroundColor string) error
 	PostImageStatus(ctx context.Context, imagePath, caption string) error
 	DeleteStatus(ctx context.Context, statusID string) error
+	ReplyToStatus(ctx context.Context, statusID, senderJID, text string) (bridge.SendMessageResult, error)
+
+	// Channels
+	GetSubscribedNewsletters(ctx context.Context) ([]*types.NewsletterMetadata, error)
+	GetNewsletterMessages(ctx context.Context, newsletterJID string, count int) ([]bridge.NewsletterPost, error)
 }
 
 // Handler implements the MCP ToolHandler interface.
 type Handler struct {
-	store   *store.SQLiteStore
+	config  *config.Config
+	store   *store.Store
 	health  *health.Monitor
 	bridge  Bridge
 	stateM  *state.Machine
+
+	// calls counts in-flight tool calls; once draining is set, Drain waits
+	// for them and new calls are refused.
+	calls    sync.WaitGroup
+	drainMu  sync.Mutex
+	draining bool
 }
 
 // NewHandler creates a new tool handler.
-func NewHandler(storeDB *store.SQLiteStore, health *health.Monitor, bridge Bridge, stateM *state.Machine) *Handler {
+func NewHandler(cfg *config.Config, storeDB *store.Store, health *health.Monitor, bridge Bridge, stateM *state.Machine) *Handler {
 	return &Handler{
+		config: cfg,
 		store:  storeDB,
 		health: health,
 		bridge: bridge,
@@ -98,55 +154,163 @@ func (h *Handler) GetTools() []mcp.Tool {
 
 // HandleTool handles a tool invocation and returns the result.
 func (h *Handler) HandleTool(ctx context.Context, name string, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	if !h.beginCall() {
+		return h.errorResult(NewNotReadyError(string(state.StateShuttingDown)))
+	}
+	defer h.calls.Done()
+
+	h.health.RecordToolCall(name)
+	start := time.Now()
+	result, err := h.handleTool(ctx, name, args)
+	h.health.RecordToolDuration(name, time.Since(start))
+	h.audit(ctx, name, args, result, err)
+	return result, err
+}
+
+// beginCall registers a tool call with the in-flight count, or reports
+// false if the handler is draining for shutdown.
+func (h *Handler) beginCall() bool {
+	h.drainMu.Lock()
+	defer h.drainMu.Unlock()
+	if h.draining {
+		return false
+	}
+	h.calls.Add(1)
+	return true
+}
+
+// Drain stops accepting tool calls and waits up to timeout for those in
+// flight, such as a send halfway through an upload, to finish. It reports
+// whether they all did. Calls made after Drain fail with NOT_READY.
+func (h *Handler) Drain(timeout time.Duration) bool {
+	h.drainMu.Lock()
+	h.draining = true
+	h.drainMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.calls.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (h *Handler) handleTool(ctx context.Context, name string, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	if mcpErr := validateArgs(name, args); mcpErr != nil {
+		return h.errorResult(mcpErr)
+	}
+
 	// Check bridge state for tools that require ready state
-	if requiresReady(name) && (h.bridge == nil || !h.bridge.IsReady()) {
+	// Dry runs never touch the client, so they are allowed in any state.
+	if requiresReady(name) && !isDryRun(name, args) && (h.bridge == nil || !h.bridge.IsReady()) {
 		currentState := "disconnected"
 		if h.bridge != nil {
+			// A ban gets its own error so agents back off instead of retrying
+			if ban, banned := h.bridge.TemporaryBan(); banned {
+				return h.errorResult(NewTemporaryBanError(ban.Reason, ban.ExpiresAt))
+			}
 			currentState = string(h.bridge.CurrentState())
 		}
 		return h.errorResult(NewNotReadyError(currentState))
 	}
+	ctx = withSendContext(ctx, name, args)
 
 	switch name {
 	// Bridge
 	case ToolGetBridgeStatus:
 		return h.handleGetBridgeStatus(ctx, args)
+	case ToolPingWhatsApp:
+		return h.handlePingWhatsApp(ctx, args)
 	case ToolGetConnectionHistory:
 		return h.handleGetConnectionHistory(ctx, args)
+	case ToolSelfTest:
+		return h.handleSelfTest(ctx, args)
+	case ToolListLinkedDevices:
+		return h.handleListLinkedDevices(ctx, args)
+	case ToolGetAuditLog:
+		return h.handleGetAuditLog(ctx, args)
+	case ToolGetToolUsageStats:
+		return h.handleGetToolUsageStats(ctx, args)
+	case ToolRetryFailedStores:
+		return h.handleRetryFailedStores(ctx, args)
+	case ToolGetSyncProgress:
+		return h.handleGetSyncProgress(ctx, args)
 
 	// Chats
 	case ToolListChats:
 		return h.handleListChats(ctx, args)
 	case ToolGetChat:
 		return h.handleGetChat(ctx, args)
+	case ToolGetChatByPhone:
+		return h.handleGetChatByPhone(ctx, args)
 	case ToolListMessages:
 		return h.handleListMessages(ctx, args)
+	case ToolSearchMessages:
+		return h.handleSearchMessages(ctx, args)
 	case ToolArchiveChat, ToolUnarchiveChat:
 		return h.handleArchiveChat(ctx, args, name == ToolArchiveChat)
 	case ToolPinChat, ToolUnpinChat:
 		return h.handlePinChat(ctx, args, name == ToolPinChat)
 	case ToolMuteChat, ToolUnmuteChat:
 		return h.handleMuteChat(ctx, args, name == ToolMuteChat)
+	case ToolArchiveChats:
+		return h.handleArchiveChats(ctx, args)
+	case ToolMuteChats:
+		return h.handleMuteChats(ctx, args)
+	case ToolSetDisappearingMessages:
+		return h.handleSetDisappearingMessages(ctx, args)
 	case ToolMarkChatRead:
 		return h.handleMarkChatRead(ctx, args)
+	case ToolMarkChatUnread:
+		return h.handleMarkChatUnread(ctx, args)
+	case ToolMarkAllRead:
+		return h.handleMarkAllRead(ctx, args)
 	case ToolDeleteChat:
 		return h.handleDeleteChat(ctx, args)
+	case ToolGetChatSettings:
+		return h.handleGetChatSettings(ctx, args)
+	case ToolGetChatStats:
+		return h.handleGetChatStats(ctx, args)
+	case ToolRequestHistorySync:
+		return h.handleRequestHistorySync(ctx, args)
+	case ToolListLabels:
+		return h.handleListLabels(ctx, args)
+	case ToolLabelChat, ToolUnlabelChat:
+		return h.handleLabelChat(ctx, args, name == ToolLabelChat)
 
 	// Contacts
 	case ToolSearchContacts:
 		return h.handleSearchContacts(ctx, args)
+	case ToolListContacts:
+		return h.handleListContacts(ctx, args)
 	case ToolGetContact:
 		return h.handleGetContact(ctx, args)
 	case ToolBlockContact, ToolUnblockContact:
 		return h.handleBlockContact(ctx, args, name == ToolBlockContact)
 	case ToolGetBlockedContacts:
 		return h.handleGetBlockedContacts(ctx, args)
+	case ToolExportContacts:
+		return h.handleExportContacts(ctx, args)
+	case ToolImportContacts:
+		return h.handleImportContacts(ctx, args)
 	case ToolCheckPhoneRegistered:
 		return h.handleCheckPhoneRegistered(ctx, args)
 
 	// Messaging
 	case ToolSendMessage:
 		return h.handleSendMessage(ctx, args)
+	case ToolSendBroadcast:
+		return h.handleSendBroadcast(ctx, args)
+	case ToolSendMessages:
+		return h.handleSendMessages(ctx, args)
 	case ToolReplyToMessage:
 		return h.handleReplyToMessage(ctx, args)
 	case ToolForwardMessage:
@@ -157,6 +321,10 @@ func (h *Handler) HandleTool(ctx context.Context, name string, args map[string]i
 		return h.handleDeleteMessage(ctx, args)
 	case ToolReactToMessage:
 		return h.handleReactToMessage(ctx, args)
+	case ToolGetReactions:
+		return h.handleGetReactions(ctx, args)
+	case ToolMessageExists:
+		return h.handleMessageExists(ctx, args)
 	case ToolStarMessage, ToolUnstarMessage:
 		return h.handleStarMessage(ctx, args, name == ToolStarMessage)
 
@@ -165,6 +333,8 @@ func (h *Handler) HandleTool(ctx context.Context, name string, args map[string]i
 		return h.handleCreateGroup(ctx, args)
 	case ToolGetGroupInfo:
 		return h.handleGetGroupInfo(ctx, args)
+	case ToolGetCommonGroups:
+		return h.handleGetCommonGroups(ctx, args)
 	case ToolLeaveGroup:
 		return h.handleLeaveGroup(ctx, args)
 	case ToolAddGroupMembers:
@@ -179,34 +349,64 @@ func (h *Handler) HandleTool(ctx context.Context, name string, args map[string]i
 		return h.handleSetGroupName(ctx, args)
 	case ToolSetGroupTopic:
 		return h.handleSetGroupTopic(ctx, args)
+	case ToolSetGroupAnnounce:
+		return h.handleSetGroupAnnounce(ctx, args)
+	case ToolSetGroupLocked:
+		return h.handleSetGroupLocked(ctx, args)
 	case ToolSetGroupPhoto:
 		return h.handleSetGroupPhoto(ctx, args)
+	case ToolRemoveGroupPhoto:
+		return h.handleRemoveGroupPhoto(ctx, args)
 	case ToolGetInviteLink:
 		return h.handleGetInviteLink(ctx, args)
 	case ToolRevokeInviteLink:
 		return h.handleRevokeInviteLink(ctx, args)
 	case ToolJoinViaInvite:
 		return h.handleJoinViaInvite(ctx, args)
+	case ToolGetGroupInviteInfo:
+		return h.handleGetGroupInviteInfo(ctx, args)
+	case ToolListJoinRequests:
+		return h.handleListJoinRequests(ctx, args)
+	case ToolApproveJoinRequest:
+		return h.handleApproveJoinRequest(ctx, args)
+	case ToolRejectJoinRequest:
+		return h.handleRejectJoinRequest(ctx, args)
 
 	// Media
 	case ToolSendImage:
 		return h.handleSendImage(ctx, args)
 	case ToolSendVideo:
 		return h.handleSendVideo(ctx, args)
+	case ToolSendGIF:
+		return h.handleSendGIF(ctx, args)
 	case ToolSendAudio:
 		return h.handleSendAudio(ctx, args)
 	case ToolSendDocument:
 		return h.handleSendDocument(ctx, args)
+	case ToolSendFile:
+		return h.handleSendFile(ctx, args)
 	case ToolSendLocation:
 		return h.handleSendLocation(ctx, args)
+	case ToolSendLiveLocation:
+		return h.handleSendLiveLocation(ctx, args)
 	case ToolSendContactCard:
 		return h.handleSendContactCard(ctx, args)
+	case ToolSendContacts:
+		return h.handleSendContacts(ctx, args)
 	case ToolDownloadMedia:
 		return h.handleDownloadMedia(ctx, args)
+	case ToolGetMediaThumbnail:
+		return h.handleGetMediaThumbnail(ctx, args)
+	case ToolListPendingMedia:
+		return h.handleListPendingMedia(ctx, args)
 
 	// Presence
 	case ToolSubscribePresence:
 		return h.handleSubscribePresence(ctx, args)
+	case ToolGetPresence:
+		return h.handleGetPresence(ctx, args)
+	case ToolGetChatParticipantsPresence:
+		return h.handleGetChatParticipantsPresence(ctx, args)
 	case ToolSendTyping:
 		return h.handleSendTyping(ctx, args)
 	case ToolSendRecording:
@@ -225,6 +425,14 @@ func (h *Handler) HandleTool(ctx context.Context, name string, args map[string]i
 		return h.handleGetStatusUpdates(ctx, args)
 	case ToolDeleteStatus:
 		return h.handleDeleteStatus(ctx, args)
+	case ToolReplyToStatus:
+		return h.handleReplyToStatus(ctx, args)
+
+	// Channels
+	case ToolListNewsletters:
+		return h.handleListNewsletters(ctx, args)
+	case ToolGetNewsletterMessages:
+		return h.handleGetNewsletterMessages(ctx, args)
 
 	default:
 		return h.errorResult(NewInvalidInputError(fmt.Sprintf("Unknown tool: %s", name)))
@@ -236,7 +444,11 @@ func requiresReady(name string) bool {
 	// These tools can work without ready state
 	switch name {
 	case ToolGetBridgeStatus, ToolGetConnectionHistory, ToolListChats, ToolGetChat,
-		ToolListMessages, ToolSearchContacts, ToolGetContact, ToolGetBlockedContacts:
+		ToolGetChatSettings, ToolGetChatStats, ToolListMessages, ToolSearchContacts, ToolListContacts, ToolGetContact, ToolGetBlockedContacts,
+		ToolExportContacts, ToolImportContacts, ToolGetPresence, ToolSelfTest, ToolGetReactions, ToolSearchMessages,
+		ToolGetCommonGroups, ToolGetAuditLog, ToolGetMediaThumbnail, ToolListPendingMedia,
+		ToolGetToolUsageStats, ToolRetryFailedStores, ToolGetChatParticipantsPresence,
+		ToolMessageExists, ToolGetSyncProgress:
 		return false
 	default:
 		return true
@@ -255,26 +467,59 @@ func (h *Handler) successResult(data interface{}) (*mcp.CallToolResult, error) {
 	}, nil
 }
 
+// errorResult reports err both as JSON text, for older clients, and as
+// structured content so the code and retry flag can be read directly.
 func (h *Handler) errorResult(err *MCPError) (*mcp.CallToolResult, error) {
 	return &mcp.CallToolResult{
-		Content: []mcp.ContentBlock{mcp.TextContent(err.JSON())},
-		IsError: true,
+		Content:           []mcp.ContentBlock{mcp.TextContent(err.JSON())},
+		StructuredContent: err,
+		IsError:           true,
 	}, nil
 }
 
+// getString returns a string argument. Some clients send numeric-looking
+// values such as message IDs as JSON numbers, so whole numbers are accepted
+// and formatted without an exponent. Numbers a float64 can't hold exactly
+// would come back with different digits, so they count as missing.
 func getString(args map[string]interface{}, key string) string {
-	if v, ok := args[key].(string); ok {
+	switch v := args[key].(type) {
+	case string:
 		return v
+	case float64:
+		if isExactInteger(v) {
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	case int:
+		return strconv.Itoa(v)
 	}
 	return ""
 }
 
+// maxExactInteger is the largest magnitude below which every integer has an
+// exact float64 representation.
+const maxExactInteger = 1 << 53
+
+// isExactInteger reports whether v is a whole number that JSON decoding
+// into a float64 kept exactly.
+func isExactInteger(v float64) bool {
+	return v == math.Trunc(v) && math.Abs(v) <= maxExactInteger
+}
+
+// getInt returns an integer argument, accepting numbers sent as strings.
+// Anything else, including a string that isn't an integer or a number too
+// large to convert, gets defaultVal.
 func getInt(args map[string]interface{}, key string, defaultVal int) int {
-	if v, ok := args[key].(float64); ok {
-		return int(v)
-	}
-	if v, ok := args[key].(int); ok {
+	switch v := args[key].(type) {
+	case float64:
+		if isExactInteger(v) {
+			return int(v)
+		}
+	case int:
 		return v
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n
+		}
 	}
 	return defaultVal
 }
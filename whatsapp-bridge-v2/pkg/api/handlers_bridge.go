@@ -2,7 +2,14 @@ package api
 
 import (
 	"context"
+	"errors"
+	"path/filepath"
+	"time"
 
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/bridge"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/health"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/state"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
 )
 
@@ -10,16 +17,132 @@ import (
 
 func (h *Handler) handleGetBridgeStatus(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
 	status := h.health.GetStatus()
+	if h.bridge != nil {
+		status.EventsDropped = h.bridge.DroppedEvents()
+	}
 	return h.successResult(status)
 }
 
+func (h *Handler) handlePingWhatsApp(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	latency, err := h.bridge.Ping(ctx)
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+	h.health.RecordLatency(latency)
+
+	return h.successResult(map[string]interface{}{
+		"success":    true,
+		"latency_ms": float64(latency.Microseconds()) / 1000,
+	})
+}
+
 func (h *Handler) handleGetConnectionHistory(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
-	limit := getInt(args, "limit", 20)
+	filter := store.TransitionFilter{
+		Limit: getInt(args, "limit", 20),
+		State: state.State(getString(args, "state")),
+	}
 
-	history, err := h.store.State.GetTransitionHistory(ctx, limit)
+	if since := getString(args, "since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return h.errorResult(NewInvalidInputError("since must be an RFC3339 timestamp"))
+		}
+		filter.Since = t
+	}
+
+	history, err := h.store.State.ListTransitions(ctx, filter)
 	if err != nil {
 		return h.errorResult(NewInternalError(err))
 	}
 
 	return h.successResult(history)
 }
+
+func (h *Handler) handleGetAuditLog(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	filter := store.AuditFilter{
+		Limit: getInt(args, "limit", 50),
+		Tool:  getString(args, "tool"),
+	}
+
+	for key, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := getString(args, key); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return h.errorResult(NewInvalidInputError(key + " must be an RFC3339 timestamp"))
+			}
+			*dst = t
+		}
+	}
+
+	entries, err := h.store.Audit.List(ctx, filter)
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	return h.successResult(map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
+
+func (h *Handler) handleSelfTest(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	report := h.health.SelfTest(ctx, health.SelfTestProbes{
+		DB:        h.store,
+		Connected: h.bridge.IsConnected,
+		StoreDir:  filepath.Dir(h.config.StorePath),
+		LastReceived: func(ctx context.Context) (time.Time, error) {
+			last, err := h.store.Messages.LatestReceived(ctx)
+			if errors.Is(err, store.ErrNotFound) {
+				return time.Time{}, nil
+			}
+			return last, err
+		},
+	})
+	return h.successResult(report)
+}
+
+func (h *Handler) handleListLinkedDevices(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	devices, err := h.bridge.GetLinkedDevices(ctx)
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	return h.successResult(map[string]interface{}{
+		"devices": devices,
+		"count":   len(devices),
+	})
+}
+
+func (h *Handler) handleGetToolUsageStats(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	return h.successResult(map[string]interface{}{
+		"tools":          h.health.GetToolUsage(),
+		"uptime_seconds": h.health.GetStatus().UptimeSeconds,
+	})
+}
+
+// handleGetSyncProgress works in any state so clients can poll it from
+// the first connection attempt until the store is synced.
+func (h *Handler) handleGetSyncProgress(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	if h.bridge == nil {
+		return h.successResult(bridge.SyncProgress{State: h.stateM.MustState()})
+	}
+	return h.successResult(h.bridge.SyncProgress())
+}
+
+func (h *Handler) handleRetryFailedStores(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	stored, failed, err := h.bridge.RetryFailedStores(ctx, getInt(args, "limit", 100))
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+	remaining, err := h.store.DeadLetters.Count(ctx)
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	return h.successResult(map[string]interface{}{
+		"success":   true,
+		"stored":    stored,
+		"failed":    failed,
+		"remaining": remaining,
+	})
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/bridge"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
+)
+
+// Channel (newsletter) tool handlers
+
+const (
+	defaultNewsletterMessages = 20
+	maxNewsletterMessages     = 100
+)
+
+// newsletterSummary is one followed channel in list_newsletters.
+type newsletterSummary struct {
+	JID         string `json:"jid"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Subscribers int    `json:"subscribers"`
+	Verified    bool   `json:"verified"`
+	Role        string `json:"role,omitempty"`
+	Muted       bool   `json:"muted"`
+	InviteCode  string `json:"invite_code,omitempty"`
+}
+
+func (h *Handler) handleListNewsletters(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	newsletters, err := h.bridge.GetSubscribedNewsletters(ctx)
+	if err != nil {
+		return h.newsletterErrorResult(err)
+	}
+
+	summaries := make([]newsletterSummary, 0, len(newsletters))
+	for _, n := range newsletters {
+		s := newsletterSummary{
+			JID:         n.ID.String(),
+			Name:        n.ThreadMeta.Name.Text,
+			Description: n.ThreadMeta.Description.Text,
+			Subscribers: n.ThreadMeta.SubscriberCount,
+			Verified:    n.ThreadMeta.VerificationState == types.NewsletterVerificationStateVerified,
+			InviteCode:  n.ThreadMeta.InviteCode,
+		}
+		if n.ViewerMeta != nil {
+			s.Role = string(n.ViewerMeta.Role)
+			s.Muted = n.ViewerMeta.Mute == types.NewsletterMuteOn
+		}
+		summaries = append(summaries, s)
+	}
+
+	return h.successResult(map[string]interface{}{
+		"newsletters": summaries,
+		"count":       len(summaries),
+	})
+}
+
+func (h *Handler) handleGetNewsletterMessages(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	jid := getString(args, "jid")
+	if jid == "" {
+		return h.errorResult(NewInvalidInputError("jid is required"))
+	}
+	if err := validateNewsletterJID(jid); err != nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
+
+	count := getInt(args, "count", defaultNewsletterMessages)
+	if count < 1 || count > maxNewsletterMessages {
+		return h.errorResult(NewInvalidInputError(fmt.Sprintf("count must be between 1 and %d", maxNewsletterMessages)))
+	}
+
+	posts, err := h.bridge.GetNewsletterMessages(ctx, jid, count)
+	if err != nil {
+		return h.newsletterErrorResult(err)
+	}
+
+	return h.successResult(map[string]interface{}{
+		"jid":      jid,
+		"messages": posts,
+		"count":    len(posts),
+	})
+}
+
+// newsletterErrorResult reports accounts without channel support as
+// UNSUPPORTED rather than as a generic failure.
+func (h *Handler) newsletterErrorResult(err error) (*mcp.CallToolResult, error) {
+	if errors.Is(err, bridge.ErrNewslettersUnsupported) {
+		return h.errorResult(NewUnsupportedError(err.Error()))
+	}
+	return h.errorResult(NewInternalError(err))
+}
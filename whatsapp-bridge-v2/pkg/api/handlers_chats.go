@@ -2,7 +2,15 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	wastore "go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/bridge"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
 )
@@ -26,9 +34,13 @@ func (h *Handler) handleGetChat(ctx context.Context, args map[string]interface{}
 		return h.errorResult(NewInvalidInputError("jid is required"))
 	}
 
+	if validateJID(jid) == nil {
+		jid = normalizeJID(jid)
+	}
+
 	chat, err := h.store.Chats.GetByJID(ctx, jid)
 	if err == store.ErrNotFound {
-		return h.errorResult(NewNotFoundError("chat"))
+		return h.missingChatResult(ctx, jid)
 	}
 	if err != nil {
 		return h.errorResult(NewInternalError(err))
@@ -37,16 +49,203 @@ func (h *Handler) handleGetChat(ctx context.Context, args map[string]interface{}
 	return h.successResult(chat)
 }
 
+// missingChat stands in for a chat with no history yet, so an agent can
+// address a known contact without first receiving a message from them.
+type missingChat struct {
+	JID     string `json:"jid"`
+	Name    string `json:"name"`
+	IsGroup bool   `json:"is_group"`
+	Exists  bool   `json:"exists"`
+}
+
+// missingChatResult answers get_chat for a JID with no chat row. Stored
+// contacts and phone-number user JIDs get a placeholder; anything else is
+// NOT_FOUND.
+func (h *Handler) missingChatResult(ctx context.Context, jid string) (*mcp.CallToolResult, error) {
+	contact, err := h.store.Contacts.GetByJID(ctx, jid)
+	switch {
+	case err == nil:
+		name := contact.Name
+		if name == "" {
+			name = contact.PushName
+		}
+		return h.successResult(missingChat{JID: jid, Name: name})
+	case !errors.Is(err, store.ErrNotFound):
+		return h.errorResult(NewInternalError(err))
+	}
+
+	parsed, err := types.ParseJID(jid)
+	if err != nil || parsed.Server != types.DefaultUserServer {
+		return h.errorResult(NewNotFoundError("chat"))
+	}
+	if _, ok := normalizePhone(parsed.User); !ok {
+		return h.errorResult(NewNotFoundError("chat"))
+	}
+	return h.successResult(missingChat{JID: jid})
+}
+
+// phoneChat is the result of get_chat_by_phone.
+type phoneChat struct {
+	Phone      string      `json:"phone"`
+	JID        string      `json:"jid"`
+	Registered bool        `json:"registered"`
+	Chat       interface{} `json:"chat"`
+}
+
+// handleGetChatByPhone resolves a phone number to its user JID and returns
+// the chat with it alongside whether the number uses WhatsApp.
+func (h *Handler) handleGetChatByPhone(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	phone, ok := normalizePhone(getString(args, "phone"))
+	if !ok {
+		return h.errorResult(NewInvalidInputError("phone must be a phone number with country code"))
+	}
+	jid := types.NewJID(phone, types.DefaultUserServer).String()
+
+	registered, err := h.bridge.CheckPhoneRegistered(ctx, "+"+phone)
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+	result := phoneChat{Phone: phone, JID: jid, Registered: registered}
+
+	chat, err := h.store.Chats.GetByJID(ctx, jid)
+	switch {
+	case err == nil:
+		result.Chat = chat
+	case errors.Is(err, store.ErrNotFound):
+		stub := missingChat{JID: jid}
+		if contact, err := h.store.Contacts.GetByJID(ctx, jid); err == nil {
+			stub.Name = contact.Name
+			if stub.Name == "" {
+				stub.Name = contact.PushName
+			}
+		}
+		result.Chat = stub
+	default:
+		return h.errorResult(NewInternalError(err))
+	}
+
+	return h.successResult(result)
+}
+
+// chatSettings is the normalized mute/pin/archive/unread state of a chat.
+type chatSettings struct {
+	JID         string     `json:"jid"`
+	Muted       bool       `json:"muted"`
+	MutedUntil  *time.Time `json:"muted_until,omitempty"` // Omitted when muted forever
+	Pinned      bool       `json:"pinned"`
+	Archived    bool       `json:"archived"`
+	UnreadCount int        `json:"unread_count"`
+	Source      string     `json:"source"` // "store" or "live"
+}
+
+func (h *Handler) handleGetChatStats(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	jid := getString(args, "jid")
+	if jid == "" {
+		return h.errorResult(NewInvalidInputError("jid is required"))
+	}
+	if err := validateJID(jid); err != nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
+	jid = normalizeJID(jid)
+
+	if _, err := h.store.Chats.GetByJID(ctx, jid); err == store.ErrNotFound {
+		return h.errorResult(NewNotFoundError("chat"))
+	} else if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	stats, err := h.store.Messages.Stats(ctx, jid)
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	return h.successResult(stats)
+}
+
+func (h *Handler) handleGetChatSettings(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	jid := getString(args, "jid")
+	if jid == "" {
+		return h.errorResult(NewInvalidInputError("jid is required"))
+	}
+	if err := validateJID(jid); err != nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
+	jid = normalizeJID(jid)
+
+	settings := chatSettings{JID: jid, Source: "store"}
+	found := false
+
+	chat, err := h.store.Chats.GetByJID(ctx, jid)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return h.errorResult(NewInternalError(err))
+	}
+	if chat != nil {
+		found = true
+		settings.Pinned = chat.Pinned
+		settings.Archived = chat.Archived
+		settings.UnreadCount = chat.UnreadCount
+		settings.setMuted(chat.Muted, chat.MutedUntil)
+	}
+
+	// Prefer the app-state values whatsmeow has synced, when available
+	if h.bridge != nil && h.bridge.IsReady() {
+		if live, err := h.bridge.GetChatSettings(ctx, jid); err == nil && live.Found {
+			found = true
+			settings.Source = "live"
+			settings.Pinned = live.Pinned
+			settings.Archived = live.Archived
+			until := live.MutedUntil
+			settings.setMuted(!until.IsZero(), &until)
+		}
+	}
+
+	if !found {
+		return h.errorResult(NewNotFoundError("chat"))
+	}
+
+	return h.successResult(settings)
+}
+
+// setMuted normalizes a mute flag and end time. Expired mutes are reported
+// as unmuted and a mute with no end (or whatsmeow's "forever") has no until.
+func (s *chatSettings) setMuted(muted bool, until *time.Time) {
+	s.Muted = false
+	s.MutedUntil = nil
+	if !muted {
+		return
+	}
+	if until == nil || until.IsZero() || until.Equal(wastore.MutedForever) {
+		s.Muted = true
+		return
+	}
+	if until.After(time.Now()) {
+		s.Muted = true
+		u := *until
+		s.MutedUntil = &u
+	}
+}
+
 func (h *Handler) handleListMessages(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
 	chatJID := getString(args, "chat_jid")
 	if chatJID == "" {
 		return h.errorResult(NewInvalidInputError("chat_jid is required"))
 	}
+	if err := validateJID(chatJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(chatJID))
+	}
+	chatJID = normalizeJID(chatJID)
 
 	limit := getInt(args, "limit", 50)
 	before := getString(args, "before")
 
-	messages, err := h.store.Messages.List(ctx, chatJID, limit, before)
+	direction := getString(args, "direction")
+	switch direction {
+	case "", store.DirectionAll, store.DirectionIncoming, store.DirectionOutgoing:
+	default:
+		return h.errorResult(NewInvalidInputError("direction must be all, incoming, or outgoing"))
+	}
+
+	messages, err := h.store.Messages.List(ctx, chatJID, limit, before, direction)
 	if err != nil {
 		return h.errorResult(NewInternalError(err))
 	}
@@ -54,11 +253,108 @@ func (h *Handler) handleListMessages(ctx context.Context, args map[string]interf
 	return h.successResult(messages)
 }
 
+// searchHit is a search_messages result: the message plus a snippet of its
+// content around the match, with the match's byte offsets in the snippet.
+type searchHit struct {
+	store.Message
+	Snippet    string `json:"snippet"`
+	MatchStart int    `json:"match_start"`
+	MatchEnd   int    `json:"match_end"`
+}
+
+func (h *Handler) handleSearchMessages(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	query := getString(args, "query")
+	if query == "" {
+		return h.errorResult(NewInvalidInputError("query is required"))
+	}
+
+	limit := getInt(args, "limit", 20)
+	if limit < 1 {
+		return h.errorResult(NewInvalidInputError("limit must be at least 1"))
+	}
+
+	var messages []store.Message
+	var err error
+	chatJID := getString(args, "chat_jid")
+	if chatJID != "" {
+		if err := validateJID(chatJID); err != nil {
+			return h.errorResult(NewInvalidJIDError(chatJID))
+		}
+		chatJID = normalizeJID(chatJID)
+		messages, err = h.store.Messages.SearchInChat(ctx, chatJID, query, limit)
+	} else {
+		messages, err = h.store.Messages.Search(ctx, query, limit)
+	}
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	hits := make([]searchHit, len(messages))
+	for i, msg := range messages {
+		hits[i].Message = msg
+		hits[i].Snippet, hits[i].MatchStart, hits[i].MatchEnd = snippet(msg.Content, query)
+	}
+
+	resp := map[string]interface{}{
+		"query":   query,
+		"results": hits,
+		"count":   len(hits),
+	}
+	if chatJID != "" {
+		resp["chat_jid"] = chatJID
+	}
+	return h.successResult(resp)
+}
+
+// History sync request sizes. WhatsApp recommends 50 messages per on-demand
+// request; larger counts are capped rather than rejected.
+const (
+	defaultHistorySyncCount = 50
+	maxHistorySyncCount     = 500
+)
+
+func (h *Handler) handleRequestHistorySync(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	chatJID := getString(args, "chat_jid")
+	if chatJID == "" {
+		return h.errorResult(NewInvalidInputError("chat_jid is required"))
+	}
+	if err := validateJID(chatJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(chatJID))
+	}
+	chatJID = normalizeJID(chatJID)
+
+	count := getInt(args, "count", defaultHistorySyncCount)
+	if count < 1 {
+		return h.errorResult(NewInvalidInputError("count must be at least 1"))
+	}
+	if count > maxHistorySyncCount {
+		count = maxHistorySyncCount
+	}
+
+	err := h.bridge.RequestHistorySync(ctx, chatJID, count)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.errorResult(NewInvalidInputError("no stored messages in chat to sync history from"))
+	}
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	return h.successResult(map[string]interface{}{
+		"success": true,
+		"count":   count,
+		"message": "History sync requested; older messages are stored as they arrive",
+	})
+}
+
 func (h *Handler) handleArchiveChat(ctx context.Context, args map[string]interface{}, archive bool) (*mcp.CallToolResult, error) {
 	jid := getString(args, "jid")
 	if jid == "" {
 		return h.errorResult(NewInvalidInputError("jid is required"))
 	}
+	if err := validateJID(jid); err != nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
+	jid = normalizeJID(jid)
 
 	if err := h.bridge.ArchiveChat(ctx, jid, archive); err != nil {
 		return h.errorResult(NewInternalError(err))
@@ -80,8 +376,15 @@ func (h *Handler) handlePinChat(ctx context.Context, args map[string]interface{}
 	if jid == "" {
 		return h.errorResult(NewInvalidInputError("jid is required"))
 	}
+	if err := validateJID(jid); err != nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
+	jid = normalizeJID(jid)
 
 	if err := h.bridge.PinChat(ctx, jid, pin); err != nil {
+		if errors.Is(err, bridge.ErrTooManyPinnedChats) {
+			return h.errorResult(NewInvalidInputError(err.Error()))
+		}
 		return h.errorResult(NewInternalError(err))
 	}
 
@@ -101,6 +404,10 @@ func (h *Handler) handleMuteChat(ctx context.Context, args map[string]interface{
 	if jid == "" {
 		return h.errorResult(NewInvalidInputError("jid is required"))
 	}
+	if err := validateJID(jid); err != nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
+	jid = normalizeJID(jid)
 
 	duration := getString(args, "duration")
 
@@ -119,11 +426,137 @@ func (h *Handler) handleMuteChat(ctx context.Context, args map[string]interface{
 	})
 }
 
+// maxBulkChats bounds a single archive_chats, mute_chats or mark_all_read
+// call.
+const maxBulkChats = 100
+
+// bulkChatResult is the outcome of a bulk chat operation for one chat.
+type bulkChatResult struct {
+	JID     string `json:"jid"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (h *Handler) handleArchiveChats(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	archive := getBool(args, "archive", true)
+	return h.bulkChatOperation(args, func(jids []string) ([]error, error) {
+		return h.bridge.ArchiveChats(ctx, jids, archive)
+	})
+}
+
+func (h *Handler) handleMuteChats(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	mute := getBool(args, "mute", true)
+	duration := getString(args, "duration")
+	return h.bulkChatOperation(args, func(jids []string) ([]error, error) {
+		return h.bridge.MuteChats(ctx, jids, mute, duration)
+	})
+}
+
+// bulkChatOperation validates the jids argument, runs apply on the valid
+// ones and aggregates the outcome per chat. Invalid JIDs are reported as
+// failed entries rather than failing the whole call.
+func (h *Handler) bulkChatOperation(args map[string]interface{}, apply func(jids []string) ([]error, error)) (*mcp.CallToolResult, error) {
+	raw := getStringArray(args, "jids")
+	if len(raw) == 0 {
+		return h.errorResult(NewInvalidInputError("jids is required"))
+	}
+	if len(raw) > maxBulkChats {
+		return h.errorResult(NewInvalidInputError(fmt.Sprintf("at most %d chats are allowed", maxBulkChats)))
+	}
+
+	results := make([]bulkChatResult, len(raw))
+	var jids []string
+	var indexes []int
+	for i, jid := range raw {
+		if err := validateJID(jid); err != nil {
+			results[i] = bulkChatResult{JID: jid, Error: NewInvalidJIDError(jid).Message}
+			continue
+		}
+		results[i].JID = normalizeJID(jid)
+		jids = append(jids, results[i].JID)
+		indexes = append(indexes, i)
+	}
+
+	if len(jids) > 0 {
+		errs, err := apply(jids)
+		if err != nil {
+			return h.errorResult(NewInternalError(err))
+		}
+		for k, i := range indexes {
+			if errs[k] != nil {
+				results[i].Error = errs[k].Error()
+				continue
+			}
+			results[i].Success = true
+		}
+	}
+
+	succeeded := 0
+	for _, r := range results {
+		if r.Success {
+			succeeded++
+		}
+	}
+
+	return h.successResult(map[string]interface{}{
+		"success":   succeeded == len(results),
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   results,
+	})
+}
+
+// disappearingTimers maps the set_disappearing_messages durations to the
+// timer values WhatsApp accepts; anything else is rejected.
+var disappearingTimers = map[string]time.Duration{
+	"off": 0,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+}
+
+func (h *Handler) handleSetDisappearingMessages(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	jid := getString(args, "jid")
+	if jid == "" {
+		return h.errorResult(NewInvalidInputError("jid is required"))
+	}
+	if err := validateJID(jid); err != nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
+	jid = normalizeJID(jid)
+
+	duration := strings.ToLower(strings.TrimSpace(getString(args, "duration")))
+	if duration == "" {
+		return h.errorResult(NewInvalidInputError("duration is required"))
+	}
+	timer, ok := disappearingTimers[duration]
+	if !ok {
+		return h.errorResult(NewInvalidInputError(fmt.Sprintf("unsupported duration %q: must be one of off, 24h, 7d, 90d", duration)))
+	}
+
+	if err := h.bridge.SetDisappearingTimer(ctx, jid, timer); err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	return h.successResult(map[string]interface{}{
+		"success":  true,
+		"duration": duration,
+	})
+}
+
 func (h *Handler) handleMarkChatRead(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
 	jid := getString(args, "jid")
 	if jid == "" {
 		return h.errorResult(NewInvalidInputError("jid is required"))
 	}
+	if err := validateJID(jid); err != nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
+	jid = normalizeJID(jid)
+
+	if h.holdReadReceipts(args) {
+		return h.readReceiptsHeld()
+	}
 
 	if err := h.bridge.MarkChatRead(ctx, jid); err != nil {
 		return h.errorResult(NewInternalError(err))
@@ -135,11 +568,113 @@ func (h *Handler) handleMarkChatRead(ctx context.Context, args map[string]interf
 	})
 }
 
+// holdReadReceipts reports whether a mark-read call must not send read
+// receipts: send_read_receipts is off and the call doesn't pass force.
+func (h *Handler) holdReadReceipts(args map[string]interface{}) bool {
+	return !h.config.SendReadReceipts && !getBool(args, "force", false)
+}
+
+// readReceiptsHeld is the result of a mark-read call that holdReadReceipts
+// stopped. Nothing is marked read, locally or on WhatsApp, so the chat
+// still shows as unread everywhere.
+func (h *Handler) readReceiptsHeld() (*mcp.CallToolResult, error) {
+	return h.successResult(map[string]interface{}{
+		"success": true,
+		"skipped": true,
+		"message": "Read receipts are disabled (send_read_receipts: false); pass force to send them anyway",
+	})
+}
+
+func (h *Handler) handleMarkChatUnread(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	jid := getString(args, "jid")
+	if jid == "" {
+		return h.errorResult(NewInvalidInputError("jid is required"))
+	}
+	if err := validateJID(jid); err != nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
+	jid = normalizeJID(jid)
+
+	if err := h.bridge.MarkChatUnread(ctx, jid); err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	return h.successResult(map[string]interface{}{
+		"success": true,
+		"message": "Chat marked as unread",
+	})
+}
+
+func (h *Handler) handleMarkAllRead(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	limit := getInt(args, "limit", maxBulkChats)
+	if limit < 1 || limit > maxBulkChats {
+		return h.errorResult(NewInvalidInputError(fmt.Sprintf("limit must be between 1 and %d", maxBulkChats)))
+	}
+
+	if h.holdReadReceipts(args) {
+		return h.readReceiptsHeld()
+	}
+
+	// Fetch one extra chat to tell whether the limit cut the list short
+	list := h.store.Chats.ListUnread
+	if !getBool(args, "unread_only", true) {
+		list = h.store.Chats.List
+	}
+	chats, err := list(ctx, limit+1)
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+	more := len(chats) > limit
+	if more {
+		chats = chats[:limit]
+	}
+
+	results := make([]bulkChatResult, len(chats))
+	jids := make([]string, len(chats))
+	for i, chat := range chats {
+		jids[i] = chat.JID
+		results[i].JID = chat.JID
+	}
+
+	succeeded := 0
+	if len(jids) > 0 {
+		errs, err := h.bridge.MarkAllRead(ctx, jids)
+		if err != nil {
+			return h.errorResult(NewInternalError(err))
+		}
+		for i, jid := range jids {
+			if errs[i] != nil {
+				results[i].Error = errs[i].Error()
+				continue
+			}
+			results[i].Success = true
+			succeeded++
+			// The read receipt is already sent, so a store failure only
+			// leaves the local count stale; report it and keep going.
+			if err := h.store.Chats.ResetUnread(ctx, jid); err != nil {
+				results[i].Error = "marked read, but the stored unread count was not reset: " + err.Error()
+			}
+		}
+	}
+
+	return h.successResult(map[string]interface{}{
+		"success":   succeeded == len(results),
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"more":      more,
+		"results":   results,
+	})
+}
+
 func (h *Handler) handleDeleteChat(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
 	jid := getString(args, "jid")
 	if jid == "" {
 		return h.errorResult(NewInvalidInputError("jid is required"))
 	}
+	if err := validateJID(jid); err != nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
+	jid = normalizeJID(jid)
 
 	if err := h.bridge.DeleteChat(ctx, jid); err != nil {
 		return h.errorResult(NewInternalError(err))
@@ -150,3 +685,62 @@ func (h *Handler) handleDeleteChat(ctx context.Context, args map[string]interfac
 		"message": "Chat deleted",
 	})
 }
+
+// errNotBusiness is returned by the label tools on personal accounts.
+var errNotBusiness = NewUnsupportedError("labels are unsupported on non-business account")
+
+func (h *Handler) handleListLabels(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	if !h.bridge.IsBusiness() {
+		return h.errorResult(errNotBusiness)
+	}
+
+	labels, err := h.store.Labels.List(ctx)
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+	if labels == nil {
+		labels = []store.Label{}
+	}
+
+	return h.successResult(labels)
+}
+
+func (h *Handler) handleLabelChat(ctx context.Context, args map[string]interface{}, labeled bool) (*mcp.CallToolResult, error) {
+	jid := getString(args, "jid")
+	if jid == "" {
+		return h.errorResult(NewInvalidInputError("jid is required"))
+	}
+	if err := validateJID(jid); err != nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
+	jid = normalizeJID(jid)
+
+	labelID := getString(args, "label_id")
+	if labelID == "" {
+		return h.errorResult(NewInvalidInputError("label_id is required"))
+	}
+
+	if !h.bridge.IsBusiness() {
+		return h.errorResult(errNotBusiness)
+	}
+
+	var err error
+	if labeled {
+		err = h.bridge.AddChatLabel(ctx, jid, labelID)
+	} else {
+		err = h.bridge.RemoveChatLabel(ctx, jid, labelID)
+	}
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	action := "labeled"
+	if !labeled {
+		action = "unlabeled"
+	}
+
+	return h.successResult(map[string]interface{}{
+		"success": true,
+		"message": "Chat " + action,
+	})
+}
@@ -2,8 +2,12 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"os"
 
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/whatsapp"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
 )
 
@@ -25,6 +29,52 @@ func (h *Handler) handleSearchContacts(ctx context.Context, args map[string]inte
 	return h.successResult(contacts)
 }
 
+func (h *Handler) handleListContacts(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	limit := getInt(args, "limit", 50)
+	offset := getInt(args, "offset", 0)
+	if limit < 1 {
+		return h.errorResult(NewInvalidInputError("limit must be at least 1"))
+	}
+	if offset < 0 {
+		return h.errorResult(NewInvalidInputError("offset must not be negative"))
+	}
+
+	total, err := h.store.Contacts.Count(ctx)
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	var contacts interface{}
+	var count int
+	if getBool(args, "include_chats", false) {
+		entries, err := h.store.Contacts.ListWithChats(ctx, limit, offset)
+		if err != nil {
+			return h.errorResult(NewInternalError(err))
+		}
+		if entries == nil {
+			entries = []store.ContactWithChat{}
+		}
+		contacts, count = entries, len(entries)
+	} else {
+		entries, err := h.store.Contacts.List(ctx, limit, offset)
+		if err != nil {
+			return h.errorResult(NewInternalError(err))
+		}
+		if entries == nil {
+			entries = []store.Contact{}
+		}
+		contacts, count = entries, len(entries)
+	}
+
+	return h.successResult(map[string]interface{}{
+		"contacts": contacts,
+		"count":    count,
+		"total":    total,
+		"offset":   offset,
+		"has_more": offset+count < total,
+	})
+}
+
 func (h *Handler) handleGetContact(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
 	jid := getString(args, "jid")
 	if jid == "" {
@@ -88,3 +138,114 @@ func (h *Handler) handleCheckPhoneRegistered(ctx context.Context, args map[strin
 		"registered": registered,
 	})
 }
+
+func (h *Handler) handleExportContacts(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	contacts, err := h.store.Contacts.List(ctx, 0, 0)
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+	if contacts == nil {
+		contacts = []store.Contact{}
+	}
+
+	savePath := getString(args, "save_path")
+	if savePath == "" {
+		return h.successResult(map[string]interface{}{
+			"count":    len(contacts),
+			"contacts": contacts,
+		})
+	}
+
+	if err := validateSavePath(savePath, h.config.MediaAllowedDirs); err != nil {
+		return h.errorResult(NewInvalidInputError(err.Error()))
+	}
+
+	data, err := encodeContacts(contacts)
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+	if err := os.WriteFile(savePath, data, 0600); err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	return h.successResult(map[string]interface{}{
+		"success":   true,
+		"count":     len(contacts),
+		"file_path": savePath,
+	})
+}
+
+func (h *Handler) handleImportContacts(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	var data []byte
+
+	path := getString(args, "path")
+	inline := getString(args, "data")
+	switch {
+	case path != "" && inline != "":
+		return h.errorResult(NewInvalidInputError("provide either path or data, not both"))
+	case path != "":
+		if _, err := whatsapp.ValidateFilePath(path, h.config.MediaAllowedDirs); err != nil {
+			return h.errorResult(NewInvalidInputError("path: " + err.Error()))
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return h.errorResult(NewInvalidInputError("path: " + err.Error()))
+		}
+		data = b
+	case inline != "":
+		data = []byte(inline)
+	default:
+		return h.errorResult(NewInvalidInputError("path or data is required"))
+	}
+
+	contacts, skipped, err := decodeContacts(data)
+	if err != nil {
+		return h.errorResult(NewInvalidInputError(err.Error()))
+	}
+
+	imported := 0
+	for i := range contacts {
+		if err := h.store.Contacts.Upsert(ctx, &contacts[i]); err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", contacts[i].JID, err))
+			continue
+		}
+		imported++
+	}
+
+	return h.successResult(map[string]interface{}{
+		"imported": imported,
+		"skipped":  len(skipped),
+		"errors":   skipped,
+	})
+}
+
+// encodeContacts serializes contacts in the format read by decodeContacts.
+func encodeContacts(contacts []store.Contact) ([]byte, error) {
+	return json.MarshalIndent(contacts, "", "  ")
+}
+
+// decodeContacts parses a JSON array of contacts. Entries that cannot be
+// decoded or have an invalid JID are skipped and described in skipped; only
+// input that is not a JSON array at all is an error.
+func decodeContacts(data []byte) (contacts []store.Contact, skipped []string, err error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, nil, fmt.Errorf("contacts must be a JSON array: %w", err)
+	}
+
+	skipped = []string{}
+	for i, entry := range entries {
+		var c store.Contact
+		if err := json.Unmarshal(entry, &c); err != nil {
+			skipped = append(skipped, fmt.Sprintf("entry %d: %v", i, err))
+			continue
+		}
+		if err := validateJID(c.JID); err != nil {
+			skipped = append(skipped, fmt.Sprintf("entry %d: invalid jid: %v", i, err))
+			continue
+		}
+		c.JID = normalizeJID(c.JID)
+		contacts = append(contacts, c)
+	}
+	return contacts, skipped, nil
+}
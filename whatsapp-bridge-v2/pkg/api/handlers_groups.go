@@ -2,6 +2,12 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
 
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
 )
@@ -18,6 +24,10 @@ func (h *Handler) handleCreateGroup(ctx context.Context, args map[string]interfa
 	if len(participants) == 0 {
 		return h.errorResult(NewInvalidInputError("participants is required"))
 	}
+	participants, badJID, err := normalizeJIDs(participants)
+	if err != nil {
+		return h.errorResult(NewInvalidJIDError(badJID))
+	}
 
 	groupJID, err := h.bridge.CreateGroup(ctx, name, participants)
 	if err != nil {
@@ -35,13 +45,152 @@ func (h *Handler) handleGetGroupInfo(ctx context.Context, args map[string]interf
 	if jid == "" {
 		return h.errorResult(NewInvalidInputError("jid is required"))
 	}
+	if err := validateGroupJID(jid); err != nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
 
 	info, err := h.bridge.GetGroupInfo(ctx, jid)
 	if err != nil {
 		return h.errorResult(NewInternalError(err))
 	}
 
-	return h.successResult(info)
+	return h.successResult(newGroupInfoDTO(info))
+}
+
+// Participant roles in GroupInfoDTO.
+const (
+	roleSuperAdmin = "superadmin"
+	roleAdmin      = "admin"
+	roleMember     = "member"
+)
+
+// GroupInfoDTO is the get_group_info response. It keeps the field names
+// stable whatever whatsmeow calls them.
+type GroupInfoDTO struct {
+	JID          string                `json:"jid"`
+	Name         string                `json:"name"`
+	Topic        string                `json:"topic,omitempty"`
+	Owner        string                `json:"owner,omitempty"`
+	CreatedAt    *time.Time            `json:"created_at,omitempty"`
+	Participants []GroupParticipantDTO `json:"participants"`
+	Settings     GroupSettingsDTO      `json:"settings"`
+}
+
+// GroupParticipantDTO is a group member and their role: superadmin (the
+// group's creator), admin or member.
+type GroupParticipantDTO struct {
+	JID   string `json:"jid"`
+	Phone string `json:"phone,omitempty"` // phone number JID, when JID is a LID
+	Role  string `json:"role"`
+}
+
+// GroupSettingsDTO holds a group's settings.
+type GroupSettingsDTO struct {
+	AnnounceOnly         bool   `json:"announce_only"` // only admins can send messages
+	Locked               bool   `json:"locked"`        // only admins can edit group info
+	JoinApprovalRequired bool   `json:"join_approval_required"`
+	AdminsOnlyAdd        bool   `json:"admins_only_add"`
+	DisappearingSeconds  uint32 `json:"disappearing_seconds"` // 0 when off
+	IsCommunity          bool   `json:"is_community"`
+	ParentCommunity      string `json:"parent_community,omitempty"`
+}
+
+// newGroupInfoDTO maps whatsmeow's group info to GroupInfoDTO.
+func newGroupInfoDTO(info *types.GroupInfo) GroupInfoDTO {
+	dto := GroupInfoDTO{
+		JID:          info.JID.String(),
+		Name:         info.Name,
+		Topic:        info.Topic,
+		Participants: make([]GroupParticipantDTO, 0, len(info.Participants)),
+		Settings: GroupSettingsDTO{
+			AnnounceOnly:         info.IsAnnounce,
+			Locked:               info.IsLocked,
+			JoinApprovalRequired: info.IsJoinApprovalRequired,
+			AdminsOnlyAdd:        info.MemberAddMode == types.GroupMemberAddModeAdmin,
+			IsCommunity:          info.IsParent,
+		},
+	}
+	if !info.OwnerJID.IsEmpty() {
+		dto.Owner = info.OwnerJID.String()
+	}
+	if !info.GroupCreated.IsZero() {
+		created := info.GroupCreated
+		dto.CreatedAt = &created
+	}
+	if info.IsEphemeral {
+		dto.Settings.DisappearingSeconds = info.DisappearingTimer
+	}
+	if !info.LinkedParentJID.IsEmpty() {
+		dto.Settings.ParentCommunity = info.LinkedParentJID.String()
+	}
+
+	for _, p := range info.Participants {
+		participant := GroupParticipantDTO{JID: p.JID.String(), Role: roleMember}
+		switch {
+		case p.IsSuperAdmin:
+			participant.Role = roleSuperAdmin
+		case p.IsAdmin:
+			participant.Role = roleAdmin
+		}
+		if !p.PhoneNumber.IsEmpty() && p.PhoneNumber != p.JID {
+			participant.Phone = p.PhoneNumber.String()
+		}
+		dto.Participants = append(dto.Participants, participant)
+	}
+	return dto
+}
+
+// commonGroup is one entry in a get_common_groups response.
+type commonGroup struct {
+	JID  string `json:"jid"`
+	Name string `json:"name"`
+}
+
+func (h *Handler) handleGetCommonGroups(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	jid := getString(args, "jid")
+	if jid == "" {
+		return h.errorResult(NewInvalidInputError("jid is required"))
+	}
+	if err := validateJID(jid); err != nil || validateGroupJID(jid) == nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
+	jid = normalizeJID(jid)
+
+	source := "store"
+	groups := []commonGroup{}
+
+	// Prefer the live participant lists; fall back to what has been stored
+	var live []string
+	if h.bridge != nil && h.bridge.IsReady() {
+		if groupJIDs, err := h.bridge.GetCommonGroups(ctx, jid); err == nil {
+			live = groupJIDs
+		}
+	}
+	if live != nil {
+		source = "live"
+		for _, groupJID := range live {
+			group := commonGroup{JID: groupJID}
+			if stored, err := h.store.Groups.GetByJID(ctx, groupJID); err == nil {
+				group.Name = stored.Name
+			}
+			groups = append(groups, group)
+		}
+	} else {
+		stored, err := h.store.Groups.ListCommon(ctx, jid)
+		if err != nil {
+			return h.errorResult(NewInternalError(err))
+		}
+		for _, g := range stored {
+			groups = append(groups, commonGroup{JID: g.JID, Name: g.Name})
+		}
+	}
+
+	return h.successResult(map[string]interface{}{
+		"jid":    jid,
+		"source": source,
+		"groups": groups,
+		"count":  len(groups),
+	})
 }
 
 func (h *Handler) handleLeaveGroup(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
@@ -49,6 +198,9 @@ func (h *Handler) handleLeaveGroup(ctx context.Context, args map[string]interfac
 	if jid == "" {
 		return h.errorResult(NewInvalidInputError("jid is required"))
 	}
+	if err := validateGroupJID(jid); err != nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
 
 	if err := h.bridge.LeaveGroup(ctx, jid); err != nil {
 		return h.errorResult(NewInternalError(err))
@@ -65,11 +217,18 @@ func (h *Handler) handleAddGroupMembers(ctx context.Context, args map[string]int
 	if groupJID == "" {
 		return h.errorResult(NewInvalidInputError("group_jid is required"))
 	}
+	if err := validateGroupJID(groupJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(groupJID))
+	}
 
 	participants := getStringArray(args, "participants")
 	if len(participants) == 0 {
 		return h.errorResult(NewInvalidInputError("participants is required"))
 	}
+	participants, badJID, err := normalizeJIDs(participants)
+	if err != nil {
+		return h.errorResult(NewInvalidJIDError(badJID))
+	}
 
 	if err := h.bridge.AddGroupMembers(ctx, groupJID, participants); err != nil {
 		return h.errorResult(NewInternalError(err))
@@ -86,11 +245,18 @@ func (h *Handler) handleRemoveGroupMembers(ctx context.Context, args map[string]
 	if groupJID == "" {
 		return h.errorResult(NewInvalidInputError("group_jid is required"))
 	}
+	if err := validateGroupJID(groupJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(groupJID))
+	}
 
 	participants := getStringArray(args, "participants")
 	if len(participants) == 0 {
 		return h.errorResult(NewInvalidInputError("participants is required"))
 	}
+	participants, badJID, err := normalizeJIDs(participants)
+	if err != nil {
+		return h.errorResult(NewInvalidJIDError(badJID))
+	}
 
 	if err := h.bridge.RemoveGroupMembers(ctx, groupJID, participants); err != nil {
 		return h.errorResult(NewInternalError(err))
@@ -107,11 +273,18 @@ func (h *Handler) handlePromoteAdmin(ctx context.Context, args map[string]interf
 	if groupJID == "" {
 		return h.errorResult(NewInvalidInputError("group_jid is required"))
 	}
+	if err := validateGroupJID(groupJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(groupJID))
+	}
 
 	participants := getStringArray(args, "participants")
 	if len(participants) == 0 {
 		return h.errorResult(NewInvalidInputError("participants is required"))
 	}
+	participants, badJID, err := normalizeJIDs(participants)
+	if err != nil {
+		return h.errorResult(NewInvalidJIDError(badJID))
+	}
 
 	if err := h.bridge.PromoteAdmin(ctx, groupJID, participants); err != nil {
 		return h.errorResult(NewInternalError(err))
@@ -128,11 +301,18 @@ func (h *Handler) handleDemoteAdmin(ctx context.Context, args map[string]interfa
 	if groupJID == "" {
 		return h.errorResult(NewInvalidInputError("group_jid is required"))
 	}
+	if err := validateGroupJID(groupJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(groupJID))
+	}
 
 	participants := getStringArray(args, "participants")
 	if len(participants) == 0 {
 		return h.errorResult(NewInvalidInputError("participants is required"))
 	}
+	participants, badJID, err := normalizeJIDs(participants)
+	if err != nil {
+		return h.errorResult(NewInvalidJIDError(badJID))
+	}
 
 	if err := h.bridge.DemoteAdmin(ctx, groupJID, participants); err != nil {
 		return h.errorResult(NewInternalError(err))
@@ -149,6 +329,9 @@ func (h *Handler) handleSetGroupName(ctx context.Context, args map[string]interf
 	if groupJID == "" {
 		return h.errorResult(NewInvalidInputError("group_jid is required"))
 	}
+	if err := validateGroupJID(groupJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(groupJID))
+	}
 
 	name := getString(args, "name")
 	if name == "" {
@@ -170,6 +353,9 @@ func (h *Handler) handleSetGroupTopic(ctx context.Context, args map[string]inter
 	if groupJID == "" {
 		return h.errorResult(NewInvalidInputError("group_jid is required"))
 	}
+	if err := validateGroupJID(groupJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(groupJID))
+	}
 
 	topic := getString(args, "topic")
 	if topic == "" {
@@ -186,11 +372,60 @@ func (h *Handler) handleSetGroupTopic(ctx context.Context, args map[string]inter
 	})
 }
 
+func (h *Handler) handleSetGroupAnnounce(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	groupJID := getString(args, "group_jid")
+	if groupJID == "" {
+		return h.errorResult(NewInvalidInputError("group_jid is required"))
+	}
+	if err := validateGroupJID(groupJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(groupJID))
+	}
+
+	announce := getBool(args, "announce", true)
+	if err := h.bridge.SetGroupAnnounce(ctx, groupJID, announce); err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+	if err := h.store.Groups.SetAnnounce(ctx, groupJID, announce); err != nil {
+		return h.errorResult(NewInternalError(fmt.Errorf("setting applied but not stored: %w", err)))
+	}
+
+	return h.successResult(map[string]interface{}{
+		"success":  true,
+		"announce": announce,
+	})
+}
+
+func (h *Handler) handleSetGroupLocked(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	groupJID := getString(args, "group_jid")
+	if groupJID == "" {
+		return h.errorResult(NewInvalidInputError("group_jid is required"))
+	}
+	if err := validateGroupJID(groupJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(groupJID))
+	}
+
+	locked := getBool(args, "locked", true)
+	if err := h.bridge.SetGroupLocked(ctx, groupJID, locked); err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+	if err := h.store.Groups.SetLocked(ctx, groupJID, locked); err != nil {
+		return h.errorResult(NewInternalError(fmt.Errorf("setting applied but not stored: %w", err)))
+	}
+
+	return h.successResult(map[string]interface{}{
+		"success": true,
+		"locked":  locked,
+	})
+}
+
 func (h *Handler) handleSetGroupPhoto(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
 	groupJID := getString(args, "group_jid")
 	if groupJID == "" {
 		return h.errorResult(NewInvalidInputError("group_jid is required"))
 	}
+	if err := validateGroupJID(groupJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(groupJID))
+	}
 
 	imagePath := getString(args, "image_path")
 	if imagePath == "" {
@@ -207,19 +442,43 @@ func (h *Handler) handleSetGroupPhoto(ctx context.Context, args map[string]inter
 	})
 }
 
+func (h *Handler) handleRemoveGroupPhoto(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	groupJID := getString(args, "group_jid")
+	if groupJID == "" {
+		return h.errorResult(NewInvalidInputError("group_jid is required"))
+	}
+	if err := validateGroupJID(groupJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(groupJID))
+	}
+
+	if err := h.bridge.RemoveGroupPhoto(ctx, groupJID); err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	return h.successResult(map[string]interface{}{
+		"success": true,
+		"message": "Group photo removed",
+	})
+}
+
 func (h *Handler) handleGetInviteLink(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
 	groupJID := getString(args, "group_jid")
 	if groupJID == "" {
 		return h.errorResult(NewInvalidInputError("group_jid is required"))
 	}
+	if err := validateGroupJID(groupJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(groupJID))
+	}
 
-	link, err := h.bridge.GetInviteLink(ctx, groupJID)
+	link, err := h.bridge.GetInviteLink(ctx, groupJID, getBool(args, "refresh", false))
 	if err != nil {
 		return h.errorResult(NewInternalError(err))
 	}
 
 	return h.successResult(map[string]interface{}{
-		"invite_link": link,
+		"invite_link": link.Link,
+		"fetched_at":  link.FetchedAt,
+		"cached":      link.Cached,
 	})
 }
 
@@ -228,6 +487,9 @@ func (h *Handler) handleRevokeInviteLink(ctx context.Context, args map[string]in
 	if groupJID == "" {
 		return h.errorResult(NewInvalidInputError("group_jid is required"))
 	}
+	if err := validateGroupJID(groupJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(groupJID))
+	}
 
 	newLink, err := h.bridge.RevokeInviteLink(ctx, groupJID)
 	if err != nil {
@@ -256,3 +518,138 @@ func (h *Handler) handleJoinViaInvite(ctx context.Context, args map[string]inter
 		"group_jid": groupJID,
 	})
 }
+
+// inviteCodePattern matches the code part of a group invite link.
+var inviteCodePattern = regexp.MustCompile(`^[A-Za-z0-9]{16,32}$`)
+
+// parseInviteCode extracts the invite code from a chat.whatsapp.com link,
+// with or without scheme, or accepts a bare code.
+func parseInviteCode(link string) (string, bool) {
+	code := strings.TrimSpace(link)
+	code = strings.TrimPrefix(code, "https://")
+	code = strings.TrimPrefix(code, "http://")
+	code = strings.TrimPrefix(code, "chat.whatsapp.com/")
+	code = strings.TrimSuffix(code, "/")
+	if !inviteCodePattern.MatchString(code) {
+		return "", false
+	}
+	return code, true
+}
+
+func (h *Handler) handleGetGroupInviteInfo(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	inviteLink := getString(args, "invite_link")
+	if inviteLink == "" {
+		return h.errorResult(NewInvalidInputError("invite_link is required"))
+	}
+	code, ok := parseInviteCode(inviteLink)
+	if !ok {
+		return h.errorResult(NewInvalidInputError("invite_link is not a valid chat.whatsapp.com invite link"))
+	}
+
+	info, err := h.bridge.GetGroupInfoFromLink(ctx, code)
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	participants := info.ParticipantCount
+	if participants == 0 {
+		participants = len(info.Participants)
+	}
+
+	result := map[string]interface{}{
+		"group_jid":         info.JID.String(),
+		"name":              info.Name,
+		"topic":             info.Topic,
+		"participant_count": participants,
+	}
+	if !info.OwnerJID.IsEmpty() {
+		result["creator"] = info.OwnerJID.String()
+	}
+	if !info.GroupCreated.IsZero() {
+		result["created_at"] = info.GroupCreated
+	}
+	return h.successResult(result)
+}
+
+func (h *Handler) handleListJoinRequests(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	groupJID := getString(args, "group_jid")
+	if groupJID == "" {
+		return h.errorResult(NewInvalidInputError("group_jid is required"))
+	}
+	if err := validateGroupJID(groupJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(groupJID))
+	}
+
+	requests, err := h.bridge.GetGroupJoinRequests(ctx, groupJID)
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	list := make([]map[string]interface{}, len(requests))
+	for i, r := range requests {
+		list[i] = map[string]interface{}{
+			"jid":          r.JID.String(),
+			"requested_at": r.RequestedAt,
+		}
+	}
+
+	return h.successResult(map[string]interface{}{
+		"group_jid": groupJID,
+		"count":     len(list),
+		"requests":  list,
+	})
+}
+
+func (h *Handler) handleApproveJoinRequest(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	groupJID, participants, mcpErr := joinRequestArgs(args)
+	if mcpErr != nil {
+		return h.errorResult(mcpErr)
+	}
+
+	if err := h.bridge.ApproveGroupJoinRequest(ctx, groupJID, participants); err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	return h.successResult(map[string]interface{}{
+		"success":  true,
+		"approved": participants,
+	})
+}
+
+func (h *Handler) handleRejectJoinRequest(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	groupJID, participants, mcpErr := joinRequestArgs(args)
+	if mcpErr != nil {
+		return h.errorResult(mcpErr)
+	}
+
+	if err := h.bridge.RejectGroupJoinRequest(ctx, groupJID, participants); err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	return h.successResult(map[string]interface{}{
+		"success":  true,
+		"rejected": participants,
+	})
+}
+
+// joinRequestArgs validates the group_jid and participants arguments shared
+// by the approve and reject tools.
+func joinRequestArgs(args map[string]interface{}) (string, []string, *MCPError) {
+	groupJID := getString(args, "group_jid")
+	if groupJID == "" {
+		return "", nil, NewInvalidInputError("group_jid is required")
+	}
+	if err := validateGroupJID(groupJID); err != nil {
+		return "", nil, NewInvalidJIDError(groupJID)
+	}
+
+	participants := getStringArray(args, "participants")
+	if len(participants) == 0 {
+		return "", nil, NewInvalidInputError("participants is required")
+	}
+	participants, badJID, err := normalizeJIDs(participants)
+	if err != nil {
+		return "", nil, NewInvalidJIDError(badJID)
+	}
+	return groupJID, participants, nil
+}
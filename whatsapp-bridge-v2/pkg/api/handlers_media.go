@@ -2,161 +2,377 @@ package api
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"path/filepath"
 	"strings"
 
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/bridge"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/whatsapp"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
 )
 
 // Media tool handlers
 
 func (h *Handler) handleSendImage(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
-	recipient := getString(args, "recipient")
-	if recipient == "" {
-		return h.errorResult(NewInvalidInputError("recipient is required"))
+	target, mcpErr := h.resolveAndValidate(args, "image_path")
+	if mcpErr != nil {
+		return h.errorResult(mcpErr)
 	}
 
-	imagePath := getString(args, "image_path")
-	if imagePath == "" {
-		return h.errorResult(NewInvalidInputError("image_path is required"))
+	caption := getString(args, "caption")
+	viewOnce := getBool(args, "view_once", false)
+
+	if getBool(args, "dry_run", false) {
+		return h.dryRunResult(target)
+	}
+
+	sent, err := h.bridge.SendImage(ctx, target.JID, target.Path, caption, viewOnce)
+	if err != nil {
+		return h.errorResult(sendFailed(err, NewInternalError))
+	}
+
+	return h.sendResult(ctx, sent, map[string]interface{}{
+		"success": true,
+	})
+}
+
+func (h *Handler) handleSendVideo(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, mcpErr := h.resolveAndValidate(args, "video_path")
+	if mcpErr != nil {
+		return h.errorResult(mcpErr)
 	}
 
 	caption := getString(args, "caption")
+	viewOnce := getBool(args, "view_once", false)
+
+	if getBool(args, "dry_run", false) {
+		return h.dryRunResult(target)
+	}
 
-	msgID, err := h.bridge.SendImage(ctx, recipient, imagePath, caption)
+	sent, err := h.bridge.SendVideo(ctx, target.JID, target.Path, caption, viewOnce)
 	if err != nil {
-		return h.errorResult(NewInternalError(err))
+		return h.errorResult(sendFailed(err, NewInternalError))
 	}
 
-	return h.successResult(map[string]interface{}{
-		"success":    true,
-		"message_id": msgID,
+	return h.sendResult(ctx, sent, map[string]interface{}{
+		"success": true,
 	})
 }
 
-func (h *Handler) handleSendVideo(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
-	recipient := getString(args, "recipient")
-	if recipient == "" {
-		return h.errorResult(NewInvalidInputError("recipient is required"))
+func (h *Handler) handleSendGIF(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, mcpErr := h.resolveAndValidate(args, "gif_path")
+	if mcpErr != nil {
+		return h.errorResult(mcpErr)
 	}
 
-	videoPath := getString(args, "video_path")
-	if videoPath == "" {
-		return h.errorResult(NewInvalidInputError("video_path is required"))
+	// WhatsApp only plays MP4 videos as GIFs
+	switch target.MimeType {
+	case "video/mp4":
+	case "image/gif":
+		return h.errorResult(NewInvalidInputError("gif_path must be an MP4 video; convert the .gif first (e.g. ffmpeg -i in.gif -movflags faststart -pix_fmt yuv420p out.mp4)"))
+	default:
+		return h.errorResult(NewInvalidInputError(fmt.Sprintf("gif_path must be an MP4 video, got %s", target.MimeType)))
 	}
 
 	caption := getString(args, "caption")
 
-	msgID, err := h.bridge.SendVideo(ctx, recipient, videoPath, caption)
+	if getBool(args, "dry_run", false) {
+		return h.dryRunResult(target)
+	}
+
+	sent, err := h.bridge.SendGIF(ctx, target.JID, target.Path, caption)
 	if err != nil {
-		return h.errorResult(NewInternalError(err))
+		return h.errorResult(sendFailed(err, NewInternalError))
 	}
 
-	return h.successResult(map[string]interface{}{
-		"success":    true,
-		"message_id": msgID,
+	return h.sendResult(ctx, sent, map[string]interface{}{
+		"success": true,
 	})
 }
 
 func (h *Handler) handleSendAudio(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
-	recipient := getString(args, "recipient")
-	if recipient == "" {
-		return h.errorResult(NewInvalidInputError("recipient is required"))
-	}
-
-	audioPath := getString(args, "audio_path")
-	if audioPath == "" {
-		return h.errorResult(NewInvalidInputError("audio_path is required"))
+	target, mcpErr := h.resolveAndValidate(args, "audio_path")
+	if mcpErr != nil {
+		return h.errorResult(mcpErr)
 	}
 
 	asVoice := getBool(args, "as_voice", false)
 
-	msgID, err := h.bridge.SendAudio(ctx, recipient, audioPath, asVoice)
+	if getBool(args, "dry_run", false) {
+		return h.dryRunResult(target)
+	}
+
+	sent, err := h.bridge.SendAudio(ctx, target.JID, target.Path, asVoice)
 	if err != nil {
-		return h.errorResult(NewInternalError(err))
+		return h.errorResult(sendFailed(err, NewInternalError))
 	}
 
-	return h.successResult(map[string]interface{}{
-		"success":    true,
-		"message_id": msgID,
+	return h.sendResult(ctx, sent, map[string]interface{}{
+		"success": true,
 	})
 }
 
 func (h *Handler) handleSendDocument(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
-	recipient := getString(args, "recipient")
-	if recipient == "" {
-		return h.errorResult(NewInvalidInputError("recipient is required"))
+	target, mcpErr := h.resolveAndValidate(args, "file_path")
+	if mcpErr != nil {
+		return h.errorResult(mcpErr)
 	}
 
-	filePath := getString(args, "file_path")
-	if filePath == "" {
-		return h.errorResult(NewInvalidInputError("file_path is required"))
+	filename := getString(args, "filename")
+	caption := getString(args, "caption")
+
+	if getBool(args, "dry_run", false) {
+		return h.dryRunResult(target)
 	}
 
+	sent, err := h.bridge.SendDocument(ctx, target.JID, target.Path, filename, caption)
+	if err != nil {
+		return h.errorResult(sendFailed(err, NewInternalError))
+	}
+
+	return h.sendResult(ctx, sent, map[string]interface{}{
+		"success": true,
+	})
+}
+
+// sendFileTypes maps the MIME types WhatsApp plays inline to the media type
+// send_file sends them as. Anything else goes as a document.
+var sendFileTypes = map[string]string{
+	"image/jpeg":      "image",
+	"image/png":       "image",
+	"image/webp":      "image",
+	"video/mp4":       "video",
+	"video/3gpp":      "video",
+	"audio/mpeg":      "audio",
+	"audio/aac":       "audio",
+	"audio/amr":       "audio",
+	"audio/mp4":       "audio",
+	"audio/ogg":       "audio",
+	"application/ogg": "audio",
+}
+
+// sendFileType picks the media type for a file of the given MIME type and
+// size. A file too large for its media type is sent as a document instead,
+// which WhatsApp allows up to a much larger size.
+func (h *Handler) sendFileType(mimeType string, size int64) string {
+	mediaType, ok := sendFileTypes[mimeType]
+	if !ok || size > h.config.MediaLimit(mediaType) {
+		return "document"
+	}
+	return mediaType
+}
+
+func (h *Handler) handleSendFile(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, mcpErr := h.resolveAndValidate(args, "file_path")
+	if mcpErr != nil {
+		return h.errorResult(mcpErr)
+	}
+
+	target.SendAs = "document"
+	if !getBool(args, "force_document", false) {
+		target.SendAs = h.sendFileType(target.MimeType, target.Size)
+	}
+
+	caption := getString(args, "caption")
 	filename := getString(args, "filename")
 
-	msgID, err := h.bridge.SendDocument(ctx, recipient, filePath, filename)
+	if getBool(args, "dry_run", false) {
+		return h.dryRunResult(target)
+	}
+
+	var sent bridge.SendMessageResult
+	var err error
+	switch target.SendAs {
+	case "image":
+		sent, err = h.bridge.SendImage(ctx, target.JID, target.Path, caption, false)
+	case "video":
+		sent, err = h.bridge.SendVideo(ctx, target.JID, target.Path, caption, false)
+	case "audio":
+		sent, err = h.bridge.SendAudio(ctx, target.JID, target.Path, false)
+	default:
+		sent, err = h.bridge.SendDocument(ctx, target.JID, target.Path, filename, caption)
+	}
 	if err != nil {
-		return h.errorResult(NewInternalError(err))
+		return h.errorResult(sendFailed(err, NewInternalError))
 	}
 
-	return h.successResult(map[string]interface{}{
-		"success":    true,
-		"message_id": msgID,
+	return h.sendResult(ctx, sent, map[string]interface{}{
+		"success": true,
+		"sent_as": target.SendAs,
 	})
 }
 
 func (h *Handler) handleSendLocation(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
-	recipient := getString(args, "recipient")
-	if recipient == "" {
-		return h.errorResult(NewInvalidInputError("recipient is required"))
+	target, mcpErr := h.resolveAndValidate(args, "")
+	if mcpErr != nil {
+		return h.errorResult(mcpErr)
 	}
 
 	latitude := getFloat(args, "latitude")
 	longitude := getFloat(args, "longitude")
+	if mcpErr := validateCoordinates(latitude, longitude); mcpErr != nil {
+		return h.errorResult(mcpErr)
+	}
 
-	if latitude < -90 || latitude > 90 {
-		return h.errorResult(NewInvalidInputError("latitude must be between -90 and 90"))
+	name := getString(args, "name")
+	address := getString(args, "address")
+
+	if getBool(args, "dry_run", false) {
+		return h.dryRunResult(target)
 	}
 
-	if longitude < -180 || longitude > 180 {
-		return h.errorResult(NewInvalidInputError("longitude must be between -180 and 180"))
+	sent, err := h.bridge.SendLocation(ctx, target.JID, latitude, longitude, name, address)
+	if err != nil {
+		return h.errorResult(sendFailed(err, NewInternalError))
 	}
 
-	name := getString(args, "name")
-	address := getString(args, "address")
+	return h.sendResult(ctx, sent, map[string]interface{}{
+		"success": true,
+	})
+}
+
+// Live location durations, in seconds. WhatsApp's own clients offer 15
+// minutes, 1 hour and 8 hours.
+const (
+	defaultLiveLocationSeconds = 15 * 60
+	maxLiveLocationSeconds     = 8 * 60 * 60
+)
+
+func (h *Handler) handleSendLiveLocation(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, mcpErr := h.resolveAndValidate(args, "")
+	if mcpErr != nil {
+		return h.errorResult(mcpErr)
+	}
+
+	latitude := getFloat(args, "latitude")
+	longitude := getFloat(args, "longitude")
+	if mcpErr := validateCoordinates(latitude, longitude); mcpErr != nil {
+		return h.errorResult(mcpErr)
+	}
+
+	duration := getInt(args, "duration_seconds", defaultLiveLocationSeconds)
+	if duration < 1 || duration > maxLiveLocationSeconds {
+		return h.errorResult(NewInvalidInputError(fmt.Sprintf("duration_seconds must be between 1 and %d", maxLiveLocationSeconds)))
+	}
 
-	msgID, err := h.bridge.SendLocation(ctx, recipient, latitude, longitude, name, address)
+	if getBool(args, "dry_run", false) {
+		return h.dryRunResult(target)
+	}
+
+	sent, err := h.bridge.SendLiveLocation(ctx, target.JID, latitude, longitude, duration)
 	if err != nil {
-		return h.errorResult(NewInternalError(err))
+		return h.errorResult(sendFailed(err, NewInternalError))
 	}
 
-	return h.successResult(map[string]interface{}{
-		"success":    true,
-		"message_id": msgID,
+	return h.sendResult(ctx, sent, map[string]interface{}{
+		"success":          true,
+		"duration_seconds": duration,
 	})
 }
 
+// validateCoordinates checks that latitude and longitude are in range.
+func validateCoordinates(latitude, longitude float64) *MCPError {
+	if latitude < -90 || latitude > 90 {
+		return NewInvalidInputError("latitude must be between -90 and 90")
+	}
+	if longitude < -180 || longitude > 180 {
+		return NewInvalidInputError("longitude must be between -180 and 180")
+	}
+	return nil
+}
+
 func (h *Handler) handleSendContactCard(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
-	recipient := getString(args, "recipient")
-	if recipient == "" {
-		return h.errorResult(NewInvalidInputError("recipient is required"))
+	target, mcpErr := h.resolveAndValidate(args, "")
+	if mcpErr != nil {
+		return h.errorResult(mcpErr)
 	}
 
+	// The card is either an existing contact or built from name and phone
 	contactJID := getString(args, "contact_jid")
-	if contactJID == "" {
-		return h.errorResult(NewInvalidInputError("contact_jid is required"))
+	card := whatsapp.ContactCard{
+		Name:         strings.TrimSpace(getString(args, "name")),
+		Organization: strings.TrimSpace(getString(args, "organization")),
+		Email:        strings.TrimSpace(getString(args, "email")),
+	}
+	phone := getString(args, "phone")
+	raw := card.Name != "" || phone != "" || card.Organization != "" || card.Email != ""
+	switch {
+	case contactJID != "" && raw:
+		return h.errorResult(NewInvalidInputError("provide either contact_jid or name and phone, not both"))
+	case contactJID == "" && !raw:
+		return h.errorResult(NewInvalidInputError("contact_jid, or name and phone, is required"))
+	case raw && (card.Name == "" || phone == ""):
+		return h.errorResult(NewInvalidInputError("name and phone are both required when contact_jid is not given"))
+	}
+	if contactJID != "" {
+		if err := validateJID(contactJID); err != nil {
+			return h.errorResult(NewInvalidJIDError(contactJID))
+		}
+		contactJID = normalizeJID(contactJID)
+	} else {
+		digits, ok := normalizePhone(phone)
+		if !ok {
+			return h.errorResult(NewInvalidInputError(fmt.Sprintf("phone %q is not a valid international phone number", phone)))
+		}
+		card.Phone = digits
+	}
+
+	if getBool(args, "dry_run", false) {
+		return h.dryRunResult(target)
 	}
 
-	msgID, err := h.bridge.SendContactCard(ctx, recipient, contactJID)
+	var sent bridge.SendMessageResult
+	var err error
+	if contactJID != "" {
+		sent, err = h.bridge.SendContactCard(ctx, target.JID, contactJID)
+	} else {
+		sent, err = h.bridge.SendVCard(ctx, target.JID, card)
+	}
 	if err != nil {
-		return h.errorResult(NewInternalError(err))
+		return h.errorResult(sendFailed(err, NewInternalError))
 	}
 
-	return h.successResult(map[string]interface{}{
-		"success":    true,
-		"message_id": msgID,
+	return h.sendResult(ctx, sent, map[string]interface{}{
+		"success": true,
+	})
+}
+
+// maxContactCards bounds a single send_contacts call.
+const maxContactCards = 50
+
+func (h *Handler) handleSendContacts(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, mcpErr := h.resolveAndValidate(args, "")
+	if mcpErr != nil {
+		return h.errorResult(mcpErr)
+	}
+
+	contactJIDs := getStringArray(args, "contact_jids")
+	if len(contactJIDs) == 0 {
+		return h.errorResult(NewInvalidInputError("contact_jids must list at least one contact"))
+	}
+	if len(contactJIDs) > maxContactCards {
+		return h.errorResult(NewInvalidInputError(fmt.Sprintf("at most %d contacts are allowed", maxContactCards)))
+	}
+	contactJIDs, badJID, err := normalizeJIDs(contactJIDs)
+	if err != nil {
+		return h.errorResult(NewInvalidJIDError(badJID))
+	}
+
+	if getBool(args, "dry_run", false) {
+		return h.dryRunResult(target)
+	}
+
+	sent, err := h.bridge.SendContactCards(ctx, target.JID, contactJIDs)
+	if err != nil {
+		return h.errorResult(sendFailed(err, NewInternalError))
+	}
+
+	return h.sendResult(ctx, sent, map[string]interface{}{
+		"success": true,
+		"count":   len(contactJIDs),
 	})
 }
 
@@ -165,6 +381,10 @@ func (h *Handler) handleDownloadMedia(ctx context.Context, args map[string]inter
 	if chatJID == "" {
 		return h.errorResult(NewInvalidInputError("chat_jid is required"))
 	}
+	if err := validateJID(chatJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(chatJID))
+	}
+	chatJID = normalizeJID(chatJID)
 
 	messageID := getString(args, "message_id")
 	if messageID == "" {
@@ -172,12 +392,17 @@ func (h *Handler) handleDownloadMedia(ctx context.Context, args map[string]inter
 	}
 
 	savePath := getString(args, "save_path")
-	if err := validateSavePath(savePath); err != nil {
+	if err := validateSavePath(savePath, h.config.MediaAllowedDirs); err != nil {
 		return h.errorResult(NewInvalidInputError(err.Error()))
 	}
 
 	filePath, err := h.bridge.DownloadMedia(ctx, chatJID, messageID, savePath)
-	if err != nil {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return h.errorResult(NewNotFoundError("message"))
+	case errors.Is(err, bridge.ErrNoMedia), errors.Is(err, whatsapp.ErrNoMediaKeys):
+		return h.errorResult(NewInvalidInputError(err.Error()))
+	case err != nil:
 		return h.errorResult(NewInternalError(err))
 	}
 
@@ -187,36 +412,107 @@ func (h *Handler) handleDownloadMedia(ctx context.Context, args map[string]inter
 	})
 }
 
-func validateSavePath(path string) error {
+// validateSavePath checks that downloaded media may be written to path. When
+// allowedDirs is non-empty the target's parent directory, with symlinks
+// resolved, must be inside one of them; otherwise a system denylist applies.
+func validateSavePath(path string, allowedDirs []string) error {
 	cleanPath := filepath.Clean(path)
 
 	if cleanPath == "." {
 		return errors.New("save_path is required")
 	}
 
-	if strings.Contains(cleanPath, "..") {
+	if whatsapp.HasParentRef(cleanPath) {
 		return errors.New("save_path must not contain \"..\"")
 	}
 
-	forbiddenPrefixes := []string{
-		"/etc",
-		"/proc",
-		"/sys",
-		"/bin",
-		"/sbin",
-		"/usr",
-		"/boot",
-		"/dev",
-		"/lib",
-		"/lib64",
-		"/root",
-		"~/.ssh",
-	}
-	for _, prefix := range forbiddenPrefixes {
-		if cleanPath == prefix || strings.HasPrefix(cleanPath, prefix+string(filepath.Separator)) {
-			return errors.New("save_path is not allowed")
+	if len(allowedDirs) > 0 {
+		// The file itself may not exist yet, so resolve its parent directory.
+		parent, err := filepath.EvalSymlinks(filepath.Dir(cleanPath))
+		if err != nil {
+			return fmt.Errorf("save_path directory is not accessible: %w", err)
+		}
+		target := filepath.Join(parent, filepath.Base(cleanPath))
+		if resolved, err := filepath.EvalSymlinks(target); err == nil {
+			target = resolved
+		}
+		if !whatsapp.IsWithinDirs(target, allowedDirs) {
+			return errors.New("save_path is outside the allowed media directories")
 		}
+		return nil
+	}
+
+	if whatsapp.IsForbiddenPath(cleanPath) {
+		return errors.New("save_path is not allowed")
 	}
 
 	return nil
 }
+
+// maxPendingMedia caps list_pending_media so one call can't return the
+// whole store.
+const maxPendingMedia = 500
+
+func (h *Handler) handleListPendingMedia(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	chatJID := getString(args, "chat_jid")
+	if chatJID != "" {
+		if err := validateJID(chatJID); err != nil {
+			return h.errorResult(NewInvalidJIDError(chatJID))
+		}
+		chatJID = normalizeJID(chatJID)
+	}
+
+	limit := getInt(args, "limit", 50)
+	if limit < 1 || limit > maxPendingMedia {
+		return h.errorResult(NewInvalidInputError(fmt.Sprintf("limit must be between 1 and %d", maxPendingMedia)))
+	}
+
+	pending, err := h.store.Messages.ListPendingMedia(ctx, chatJID, limit)
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	var totalBytes uint64
+	for _, p := range pending {
+		totalBytes += p.FileLength
+	}
+	return h.successResult(map[string]interface{}{
+		"media":       pending,
+		"count":       len(pending),
+		"total_bytes": totalBytes,
+	})
+}
+
+// handleGetMediaThumbnail returns the preview WhatsApp sends along with
+// image, video and document messages, which is stored with the message, so
+// agents can look at media without fetching it.
+func (h *Handler) handleGetMediaThumbnail(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	chatJID := getString(args, "chat_jid")
+	if chatJID == "" {
+		return h.errorResult(NewInvalidInputError("chat_jid is required"))
+	}
+	if err := validateJID(chatJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(chatJID))
+	}
+	chatJID = normalizeJID(chatJID)
+
+	messageID := getString(args, "message_id")
+	if messageID == "" {
+		return h.errorResult(NewInvalidInputError("message_id is required"))
+	}
+
+	thumb, err := h.store.Messages.Thumbnail(ctx, chatJID, messageID)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.errorResult(NewNotFoundError("message"))
+	}
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+	if len(thumb) == 0 {
+		return h.errorResult(NewNotFoundError("thumbnail for this message"))
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.ContentBlock{mcp.ImageContent("image/jpeg", base64.StdEncoding.EncodeToString(thumb))},
+	}, nil
+}
@@ -2,16 +2,20 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/bridge"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
 )
 
 // Messaging tool handlers
 
 func (h *Handler) handleSendMessage(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
-	recipient := getString(args, "recipient")
-	if recipient == "" {
-		return h.errorResult(NewInvalidInputError("recipient is required"))
+	target, mcpErr := h.resolveAndValidate(args, "")
+	if mcpErr != nil {
+		return h.errorResult(mcpErr)
 	}
 
 	message := getString(args, "message")
@@ -19,14 +23,197 @@ func (h *Handler) handleSendMessage(ctx context.Context, args map[string]interfa
 		return h.errorResult(NewInvalidInputError("message is required"))
 	}
 
-	msgID, err := h.bridge.SendMessage(ctx, recipient, message)
+	// Explicit mentions, in addition to @<phone> tokens in the text
+	mentions, badJID, err := normalizeJIDs(getStringArray(args, "mentions"))
+	if err != nil {
+		return h.errorResult(NewInvalidJIDError(badJID))
+	}
+
+	// Optional quote, possibly of a message in another chat
+	var quotedChatJID, quotedID string
+	if raw, ok := args["context"]; ok {
+		quote, ok := raw.(map[string]interface{})
+		if !ok {
+			return h.errorResult(NewInvalidInputError("context must be an object"))
+		}
+		quotedID = getString(quote, "quoted_message_id")
+		if quotedID == "" {
+			return h.errorResult(NewInvalidInputError("context.quoted_message_id is required"))
+		}
+		quotedChatJID = getString(quote, "quoted_chat_jid")
+		if quotedChatJID == "" {
+			quotedChatJID = target.JID
+		} else if err := validateJID(quotedChatJID); err != nil {
+			return h.errorResult(NewInvalidJIDError(quotedChatJID))
+		}
+		quotedChatJID = normalizeJID(quotedChatJID)
+	}
+
+	if getBool(args, "dry_run", false) {
+		return h.dryRunResult(target)
+	}
+
+	result := map[string]interface{}{"success": true}
+
+	// The quote preview is built from the stored copy. Without one the
+	// message still goes out, just unquoted.
+	var quoted *store.Message
+	if quotedID != "" {
+		quoted, err = h.store.Messages.GetByID(ctx, quotedChatJID, quotedID)
+		if errors.Is(err, store.ErrNotFound) {
+			result["warning"] = fmt.Sprintf("quoted message %s not found in %s; sent without quote", quotedID, quotedChatJID)
+		} else if err != nil {
+			return h.errorResult(NewInternalError(err))
+		}
+	}
+
+	var sent bridge.SendMessageResult
+	if quoted != nil {
+		sent, err = h.bridge.SendQuotedMessage(ctx, target.JID, message, mentions, quoted.ChatJID, quoted.ID, quoted.Sender, quoted.Content)
+	} else {
+		sent, err = h.bridge.SendMessage(ctx, target.JID, message, mentions)
+	}
+	if err != nil {
+		return h.errorResult(sendFailed(err, NewMessageFailedError))
+	}
+
+	return h.sendResult(ctx, sent, result)
+}
+
+// maxBroadcastRecipients matches the WhatsApp broadcast list size limit.
+const maxBroadcastRecipients = 256
+
+// broadcastResult is the outcome of a broadcast for one recipient.
+type broadcastResult struct {
+	JID       string `json:"jid"`
+	MessageID string `json:"message_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (h *Handler) handleSendBroadcast(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	recipients := getStringArray(args, "recipients")
+	if len(recipients) == 0 {
+		return h.errorResult(NewInvalidInputError("recipients is required"))
+	}
+	if len(recipients) > maxBroadcastRecipients {
+		return h.errorResult(NewInvalidInputError(fmt.Sprintf("at most %d recipients are allowed", maxBroadcastRecipients)))
+	}
+
+	message := getString(args, "message")
+	if message == "" {
+		return h.errorResult(NewInvalidInputError("message is required"))
+	}
+
+	// Invalid recipients are reported individually rather than failing the batch
+	results := make([]broadcastResult, len(recipients))
+	var jids []string
+	var indexes []int
+	for i, recipient := range recipients {
+		if err := validateJID(recipient); err != nil {
+			results[i] = broadcastResult{JID: recipient, Error: NewInvalidJIDError(recipient).Message}
+			continue
+		}
+		results[i].JID = normalizeJID(recipient)
+		jids = append(jids, results[i].JID)
+		indexes = append(indexes, i)
+	}
+
+	if len(jids) > 0 {
+		msgIDs, errs, err := h.bridge.SendBroadcast(ctx, jids, message)
+		if err != nil {
+			return h.errorResult(NewMessageFailedError(err))
+		}
+		for k, i := range indexes {
+			if errs[k] != nil {
+				results[i].Error = errs[k].Error()
+				continue
+			}
+			results[i].MessageID = msgIDs[k]
+		}
+	}
+
+	sent := 0
+	for _, r := range results {
+		if r.Error == "" {
+			sent++
+		}
+	}
+
+	return h.successResult(map[string]interface{}{
+		"success": sent == len(results),
+		"sent":    sent,
+		"failed":  len(results) - sent,
+		"results": results,
+	})
+}
+
+// maxBatchMessages bounds a single send_messages call.
+const maxBatchMessages = 100
+
+// batchResult is the outcome of one entry of a send_messages batch.
+type batchResult struct {
+	Recipient string `json:"recipient"`
+	Success   bool   `json:"success"`
+	MessageID string `json:"message_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (h *Handler) handleSendMessages(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	items, _ := args["messages"].([]interface{})
+	if len(items) == 0 {
+		return h.errorResult(NewInvalidInputError("messages is required"))
+	}
+	if len(items) > maxBatchMessages {
+		return h.errorResult(NewInvalidInputError(fmt.Sprintf("at most %d messages are allowed", maxBatchMessages)))
+	}
+
+	// Validate the whole batch up front so a bad entry never leaves it half sent
+	jids := make([]string, len(items))
+	texts := make([]string, len(items))
+	for i, item := range items {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return h.errorResult(NewInvalidInputError(fmt.Sprintf("messages[%d] must be an object", i)))
+		}
+		recipient := getString(entry, "recipient")
+		if recipient == "" {
+			return h.errorResult(NewInvalidInputError(fmt.Sprintf("messages[%d].recipient is required", i)))
+		}
+		if err := validateJID(recipient); err != nil {
+			return h.errorResult(NewInvalidJIDError(recipient))
+		}
+		texts[i] = getString(entry, "message")
+		if texts[i] == "" {
+			return h.errorResult(NewInvalidInputError(fmt.Sprintf("messages[%d].message is required", i)))
+		}
+		jids[i] = normalizeJID(recipient)
+	}
+
+	continueOnError := getBool(args, "continue_on_error", true)
+
+	msgIDs, errs, err := h.bridge.SendBatch(ctx, jids, texts, continueOnError)
 	if err != nil {
 		return h.errorResult(NewMessageFailedError(err))
 	}
 
+	results := make([]batchResult, len(jids))
+	sent := 0
+	for i, jid := range jids {
+		results[i].Recipient = jid
+		if errs[i] != nil {
+			results[i].Error = errs[i].Error()
+			continue
+		}
+		results[i].Success = true
+		results[i].MessageID = msgIDs[i]
+		sent++
+	}
+
 	return h.successResult(map[string]interface{}{
-		"success":    true,
-		"message_id": msgID,
+		"success": sent == len(results),
+		"sent":    sent,
+		"failed":  len(results) - sent,
+		"results": results,
 	})
 }
 
@@ -35,6 +222,10 @@ func (h *Handler) handleReplyToMessage(ctx context.Context, args map[string]inte
 	if chatJID == "" {
 		return h.errorResult(NewInvalidInputError("chat_jid is required"))
 	}
+	if err := validateJID(chatJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(chatJID))
+	}
+	chatJID = normalizeJID(chatJID)
 
 	messageID := getString(args, "message_id")
 	if messageID == "" {
@@ -46,14 +237,13 @@ func (h *Handler) handleReplyToMessage(ctx context.Context, args map[string]inte
 		return h.errorResult(NewInvalidInputError("message is required"))
 	}
 
-	msgID, err := h.bridge.ReplyToMessage(ctx, chatJID, messageID, message)
+	sent, err := h.bridge.ReplyToMessage(ctx, chatJID, messageID, message)
 	if err != nil {
-		return h.errorResult(NewMessageFailedError(err))
+		return h.errorResult(sendFailed(err, NewMessageFailedError))
 	}
 
-	return h.successResult(map[string]interface{}{
-		"success":    true,
-		"message_id": msgID,
+	return h.sendResult(ctx, sent, map[string]interface{}{
+		"success": true,
 	})
 }
 
@@ -62,6 +252,10 @@ func (h *Handler) handleForwardMessage(ctx context.Context, args map[string]inte
 	if sourceChatJID == "" {
 		return h.errorResult(NewInvalidInputError("source_chat_jid is required"))
 	}
+	if err := validateJID(sourceChatJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(sourceChatJID))
+	}
+	sourceChatJID = normalizeJID(sourceChatJID)
 
 	messageID := getString(args, "message_id")
 	if messageID == "" {
@@ -72,15 +266,18 @@ func (h *Handler) handleForwardMessage(ctx context.Context, args map[string]inte
 	if targetJID == "" {
 		return h.errorResult(NewInvalidInputError("target_jid is required"))
 	}
+	if err := validateJID(targetJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(targetJID))
+	}
+	targetJID = normalizeJID(targetJID)
 
-	msgID, err := h.bridge.ForwardMessage(ctx, sourceChatJID, messageID, targetJID)
+	sent, err := h.bridge.ForwardMessage(ctx, sourceChatJID, messageID, targetJID)
 	if err != nil {
-		return h.errorResult(NewMessageFailedError(err))
+		return h.errorResult(sendFailed(err, NewMessageFailedError))
 	}
 
-	return h.successResult(map[string]interface{}{
-		"success":    true,
-		"message_id": msgID,
+	return h.sendResult(ctx, sent, map[string]interface{}{
+		"success": true,
 	})
 }
 
@@ -89,6 +286,10 @@ func (h *Handler) handleEditMessage(ctx context.Context, args map[string]interfa
 	if chatJID == "" {
 		return h.errorResult(NewInvalidInputError("chat_jid is required"))
 	}
+	if err := validateJID(chatJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(chatJID))
+	}
+	chatJID = normalizeJID(chatJID)
 
 	messageID := getString(args, "message_id")
 	if messageID == "" {
@@ -115,6 +316,10 @@ func (h *Handler) handleDeleteMessage(ctx context.Context, args map[string]inter
 	if chatJID == "" {
 		return h.errorResult(NewInvalidInputError("chat_jid is required"))
 	}
+	if err := validateJID(chatJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(chatJID))
+	}
+	chatJID = normalizeJID(chatJID)
 
 	messageID := getString(args, "message_id")
 	if messageID == "" {
@@ -123,10 +328,22 @@ func (h *Handler) handleDeleteMessage(ctx context.Context, args map[string]inter
 
 	forEveryone := getBool(args, "for_everyone", false)
 
-	if err := h.bridge.DeleteMessage(ctx, chatJID, messageID, forEveryone); err != nil {
+	err := h.bridge.DeleteMessage(ctx, chatJID, messageID, forEveryone)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.errorResult(NewNotFoundError("message"))
+	}
+	if err != nil {
 		return h.errorResult(NewMessageFailedError(err))
 	}
 
+	if !forEveryone {
+		return h.successResult(map[string]interface{}{
+			"success":         true,
+			"deleted_locally": true,
+			"message":         "Message deleted for me",
+		})
+	}
+
 	return h.successResult(map[string]interface{}{
 		"success": true,
 		"message": "Message deleted",
@@ -138,24 +355,95 @@ func (h *Handler) handleReactToMessage(ctx context.Context, args map[string]inte
 	if chatJID == "" {
 		return h.errorResult(NewInvalidInputError("chat_jid is required"))
 	}
+	if err := validateJID(chatJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(chatJID))
+	}
+	chatJID = normalizeJID(chatJID)
 
 	messageID := getString(args, "message_id")
 	if messageID == "" {
 		return h.errorResult(NewInvalidInputError("message_id is required"))
 	}
 
+	// An empty emoji removes our reaction; a missing one fails schema validation
 	emoji := getString(args, "emoji")
-	if emoji == "" {
-		return h.errorResult(NewInvalidInputError("emoji is required"))
+	if emoji != "" && !isSingleEmoji(emoji) {
+		return h.errorResult(NewInvalidInputError(fmt.Sprintf("emoji must be a single emoji, or empty to remove the reaction: %q", emoji)))
 	}
 
 	if err := h.bridge.ReactToMessage(ctx, chatJID, messageID, emoji); err != nil {
 		return h.errorResult(NewMessageFailedError(err))
 	}
 
+	message := "Reaction added"
+	if emoji == "" {
+		message = "Reaction removed"
+	}
 	return h.successResult(map[string]interface{}{
 		"success": true,
-		"message": "Reaction added",
+		"message": message,
+	})
+}
+
+// handleMessageExists answers from the store alone. An absent message is
+// a normal result, not an error, so agents can poll until it syncs.
+func (h *Handler) handleMessageExists(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	chatJID := getString(args, "chat_jid")
+	if chatJID == "" {
+		return h.errorResult(NewInvalidInputError("chat_jid is required"))
+	}
+	if err := validateJID(chatJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(chatJID))
+	}
+	chatJID = normalizeJID(chatJID)
+
+	messageID := getString(args, "message_id")
+	if messageID == "" {
+		return h.errorResult(NewInvalidInputError("message_id is required"))
+	}
+
+	status, err := h.store.Messages.Status(ctx, chatJID, messageID)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.successResult(map[string]interface{}{"exists": false})
+	}
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	return h.successResult(map[string]interface{}{
+		"exists":     true,
+		"is_from_me": status.IsFromMe,
+		"has_media":  status.HasMedia,
+		"is_deleted": status.IsDeleted,
+	})
+}
+
+func (h *Handler) handleGetReactions(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	chatJID := getString(args, "chat_jid")
+	if chatJID == "" {
+		return h.errorResult(NewInvalidInputError("chat_jid is required"))
+	}
+	if err := validateJID(chatJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(chatJID))
+	}
+	chatJID = normalizeJID(chatJID)
+
+	messageID := getString(args, "message_id")
+	if messageID == "" {
+		return h.errorResult(NewInvalidInputError("message_id is required"))
+	}
+
+	reactions, err := h.store.Messages.GetReactions(ctx, chatJID, messageID)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.errorResult(NewNotFoundError("message"))
+	}
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	return h.successResult(map[string]interface{}{
+		"reactions": reactions,
+		"count":     len(reactions),
 	})
 }
 
@@ -164,6 +452,10 @@ func (h *Handler) handleStarMessage(ctx context.Context, args map[string]interfa
 	if chatJID == "" {
 		return h.errorResult(NewInvalidInputError("chat_jid is required"))
 	}
+	if err := validateJID(chatJID); err != nil {
+		return h.errorResult(NewInvalidJIDError(chatJID))
+	}
+	chatJID = normalizeJID(chatJID)
 
 	messageID := getString(args, "message_id")
 	if messageID == "" {
@@ -171,7 +463,11 @@ func (h *Handler) handleStarMessage(ctx context.Context, args map[string]interfa
 	}
 
 	// Star/unstar via store (local operation)
-	if err := h.store.Messages.SetStarred(ctx, chatJID, messageID, star); err != nil {
+	err := h.store.Messages.SetStarred(ctx, chatJID, messageID, star)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.errorResult(NewNotFoundError("message"))
+	}
+	if err != nil {
 		return h.errorResult(NewInternalError(err))
 	}
 
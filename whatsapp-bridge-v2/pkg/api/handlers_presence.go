@@ -2,7 +2,11 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
 )
 
@@ -24,6 +28,119 @@ func (h *Handler) handleSubscribePresence(ctx context.Context, args map[string]i
 	})
 }
 
+func (h *Handler) handleGetPresence(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	jid := getString(args, "jid")
+	if jid == "" {
+		return h.errorResult(NewInvalidInputError("jid is required"))
+	}
+	if err := validateJID(jid); err != nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
+	jid = normalizeJID(jid)
+
+	presence, err := h.store.Presence.Get(ctx, jid)
+	if errors.Is(err, store.ErrNotFound) {
+		// WhatsApp only pushes presence for contacts we have subscribed to
+		return h.errorResult(&MCPError{
+			Code:    ErrNotFound,
+			Message: fmt.Sprintf("No presence data for %s yet; call subscribe_presence first and retry once updates arrive", jid),
+			Retry:   true,
+		})
+	}
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	return h.successResult(presence)
+}
+
+// participantPresence is one entry in a get_chat_participants_presence
+// response. Known is false while no presence update has arrived for the
+// participant.
+type participantPresence struct {
+	JID       string     `json:"jid"`
+	Known     bool       `json:"known"`
+	Online    bool       `json:"online"`
+	LastSeen  *time.Time `json:"last_seen,omitempty"`
+	Composing bool       `json:"composing"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+func (h *Handler) handleGetChatParticipantsPresence(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	jid := getString(args, "jid")
+	if jid == "" {
+		return h.errorResult(NewInvalidInputError("jid is required"))
+	}
+	if err := validateGroupJID(jid); err != nil {
+		return h.errorResult(NewInvalidJIDError(jid))
+	}
+
+	result := map[string]interface{}{"jid": jid}
+
+	// Subscribe through the live group when we can; otherwise read the
+	// participants and whatever presence has already been stored
+	var participants []string
+	if getBool(args, "subscribe", true) && h.bridge != nil && h.bridge.IsReady() {
+		jids, errs, err := h.bridge.SubscribeGroupPresence(ctx, jid)
+		if err != nil {
+			return h.errorResult(NewInternalError(err))
+		}
+		failed := 0
+		for _, err := range errs {
+			if err != nil {
+				failed++
+			}
+		}
+		participants = jids
+		result["source"] = "live"
+		result["subscribed"] = len(jids) - failed
+		result["subscribe_failed"] = failed
+	} else {
+		stored, err := h.store.Groups.GetParticipants(ctx, jid)
+		if err != nil {
+			return h.errorResult(NewInternalError(err))
+		}
+		for _, p := range stored {
+			participants = append(participants, p.UserJID)
+		}
+		result["source"] = "store"
+	}
+	if len(participants) == 0 {
+		return h.errorResult(NewNotFoundError("group participants"))
+	}
+
+	presences := make([]participantPresence, 0, len(participants))
+	online, unknown := 0, 0
+	for _, participant := range participants {
+		entry := participantPresence{JID: participant}
+		presence, err := h.store.Presence.Get(ctx, participant)
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			unknown++
+		case err != nil:
+			return h.errorResult(NewInternalError(err))
+		default:
+			entry.Known = true
+			entry.Online = presence.Online
+			entry.LastSeen = presence.LastSeen
+			entry.Composing = presence.Composing
+			entry.UpdatedAt = &presence.UpdatedAt
+			if presence.Online {
+				online++
+			}
+		}
+		presences = append(presences, entry)
+	}
+
+	result["participants"] = presences
+	result["count"] = len(presences)
+	result["online"] = online
+	if unknown > 0 {
+		result["warning"] = fmt.Sprintf("No presence yet for %d of %d participants: WhatsApp only sends presence after subscribing, and only for contacts who share it. Retry in a few seconds", unknown, len(presences))
+	}
+	return h.successResult(result)
+}
+
 func (h *Handler) handleSendTyping(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
 	jid := getString(args, "jid")
 	if jid == "" {
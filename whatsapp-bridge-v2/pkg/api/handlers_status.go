@@ -2,7 +2,9 @@ package api
 
 import (
 	"context"
+	"errors"
 
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
 )
 
@@ -78,3 +80,34 @@ func (h *Handler) handleDeleteStatus(ctx context.Context, args map[string]interf
 		"message": "Status deleted",
 	})
 }
+
+func (h *Handler) handleReplyToStatus(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	statusID := getString(args, "status_id")
+	if statusID == "" {
+		return h.errorResult(NewInvalidInputError("status_id is required"))
+	}
+
+	text := getString(args, "text")
+	if text == "" {
+		return h.errorResult(NewInvalidInputError("text is required"))
+	}
+
+	// The reply goes to whoever posted the status, which only the store knows
+	status, err := h.store.Status.GetByID(ctx, statusID)
+	if errors.Is(err, store.ErrNotFound) {
+		return h.errorResult(NewNotFoundError("status"))
+	}
+	if err != nil {
+		return h.errorResult(NewInternalError(err))
+	}
+
+	sent, err := h.bridge.ReplyToStatus(ctx, statusID, status.SenderJID, text)
+	if err != nil {
+		return h.errorResult(sendFailed(err, NewMessageFailedError))
+	}
+
+	return h.sendResult(ctx, sent, map[string]interface{}{
+		"success":   true,
+		"recipient": status.SenderJID,
+	})
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/bridge"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
+)
+
+// idempotentTools lists the single-message send tools that accept an
+// idempotency_key argument.
+var idempotentTools = map[string]bool{
+	ToolSendMessage:      true,
+	ToolReplyToMessage:   true,
+	ToolForwardMessage:   true,
+	ToolSendImage:        true,
+	ToolSendVideo:        true,
+	ToolSendGIF:          true,
+	ToolSendAudio:        true,
+	ToolSendDocument:     true,
+	ToolSendFile:         true,
+	ToolSendLocation:     true,
+	ToolSendLiveLocation: true,
+	ToolSendContactCard:  true,
+	ToolSendContacts:     true,
+	ToolReplyToStatus:    true,
+}
+
+// withSendContext prepares ctx for a single-message send tool: it attaches
+// the idempotency key, if any, and an attempt counter for sendResult.
+func withSendContext(ctx context.Context, name string, args map[string]interface{}) context.Context {
+	if !idempotentTools[name] {
+		return ctx
+	}
+	return bridge.WithAttemptCounter(withIdempotencyKey(ctx, name, args))
+}
+
+// sendResult reports a successful send with its message ID and server
+// timestamp, adding how many attempts the bridge needed when it actually
+// sent something.
+func (h *Handler) sendResult(ctx context.Context, sent bridge.SendMessageResult, result map[string]interface{}) (*mcp.CallToolResult, error) {
+	result["message_id"] = sent.ID
+	result["timestamp"] = sent.Timestamp
+	if n := bridge.SendAttempts(ctx); n > 0 {
+		result["attempts"] = n
+	}
+	return h.successResult(result)
+}
+
+// sendFailed maps a failed send to its MCP error: sends refused by the
+// per-recipient cooldown are RATE_LIMITED, anything else goes to fallback.
+func sendFailed(err error, fallback func(error) *MCPError) *MCPError {
+	var cooldown *bridge.CooldownError
+	if errors.As(err, &cooldown) {
+		return NewRateLimitedError(cooldown.JID, cooldown.Remaining)
+	}
+	return fallback(err)
+}
+
+// withIdempotencyKey attaches the call's idempotency_key, if any, to ctx so
+// the bridge returns the original message ID for a retried send.
+func withIdempotencyKey(ctx context.Context, name string, args map[string]interface{}) context.Context {
+	if !idempotentTools[name] {
+		return ctx
+	}
+	if key := getString(args, "idempotency_key"); key != "" {
+		return bridge.WithIdempotencyKey(ctx, name, key)
+	}
+	return ctx
+}
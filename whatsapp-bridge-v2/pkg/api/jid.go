@@ -0,0 +1,115 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// knownServers lists the JID servers the bridge can address.
+var knownServers = map[string]bool{
+	types.DefaultUserServer: true,
+	types.GroupServer:       true,
+	types.BroadcastServer:   true,
+	types.NewsletterServer:  true,
+	types.HiddenUserServer:  true,
+}
+
+// normalizePhone strips formatting characters (+, spaces, dashes, dots,
+// parentheses) from a phone number. It returns false if the remainder is not
+// a plausible E.164 number.
+func normalizePhone(s string) (string, bool) {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case '+', ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, s)
+
+	if len(digits) < 7 || len(digits) > 15 {
+		return "", false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	return digits, true
+}
+
+// validateJID checks that s is either a bare phone number or a JID on a
+// known WhatsApp server.
+func validateJID(s string) error {
+	if s == "" {
+		return errors.New("jid is empty")
+	}
+
+	if !strings.Contains(s, "@") {
+		if _, ok := normalizePhone(s); !ok {
+			return fmt.Errorf("%q is neither a JID nor a phone number", s)
+		}
+		return nil
+	}
+
+	jid, err := types.ParseJID(s)
+	if err != nil {
+		return err
+	}
+	if jid.User == "" {
+		return fmt.Errorf("%q has no user part", s)
+	}
+	if !knownServers[jid.Server] {
+		return fmt.Errorf("%q has unknown server %q", s, jid.Server)
+	}
+	return nil
+}
+
+// validateGroupJID checks that s is a group JID.
+func validateGroupJID(s string) error {
+	if err := validateJID(s); err != nil {
+		return err
+	}
+	if !strings.HasSuffix(s, "@"+types.GroupServer) {
+		return fmt.Errorf("%q is not a group JID", s)
+	}
+	return nil
+}
+
+// validateNewsletterJID checks that s is a channel (newsletter) JID.
+func validateNewsletterJID(s string) error {
+	if err := validateJID(s); err != nil {
+		return err
+	}
+	if !strings.HasSuffix(s, "@"+types.NewsletterServer) {
+		return fmt.Errorf("%q is not a newsletter JID", s)
+	}
+	return nil
+}
+
+// normalizeJID turns a bare phone number into a user JID. Anything else is
+// returned unchanged, so callers should run validateJID first.
+func normalizeJID(s string) string {
+	if strings.Contains(s, "@") {
+		return s
+	}
+	if phone, ok := normalizePhone(s); ok {
+		return types.NewJID(phone, types.DefaultUserServer).String()
+	}
+	return s
+}
+
+// normalizeJIDs validates and normalizes a list of participant JIDs. On
+// failure it returns the offending entry.
+func normalizeJIDs(jids []string) ([]string, string, error) {
+	result := make([]string, 0, len(jids))
+	for _, jid := range jids {
+		if err := validateJID(jid); err != nil {
+			return nil, jid, err
+		}
+		result = append(result, normalizeJID(jid))
+	}
+	return result, "", nil
+}
@@ -1,14 +1,31 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unicode/utf8"
 
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/bridge"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/config"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/health"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/state"
 	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/store"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/whatsapp"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -22,7 +39,7 @@ func setupTestHandler(t *testing.T) (*Handler, *store.SQLiteStore) {
 	sm := state.NewMachine()
 	hm := health.NewMonitor(cfg, sm)
 
-	handler := NewHandler(storeDB, hm, nil, sm)
+	handler := NewHandler(cfg, storeDB.Store(), hm, nil, sm)
 	return handler, storeDB
 }
 
@@ -31,6 +48,31 @@ func TestNewHandler(t *testing.T) {
 	assert.NotNil(t, handler)
 }
 
+// listOnlyChats is a ChatRepository that only supports List; any other
+// call panics on the nil embedded interface.
+type listOnlyChats struct {
+	store.ChatRepository
+	chats []store.Chat
+}
+
+func (r listOnlyChats) List(ctx context.Context, limit int) ([]store.Chat, error) {
+	return r.chats, nil
+}
+
+func TestNewHandler_AnyStoreBackend(t *testing.T) {
+	cfg := config.DefaultConfig()
+	sm := state.NewMachine()
+	repos := &store.Store{
+		Chats: listOnlyChats{chats: []store.Chat{{JID: "1@s.whatsapp.net", Name: "Not SQLite"}}},
+	}
+
+	handler := NewHandler(cfg, repos, health.NewMonitor(cfg, sm), nil, sm)
+	result, err := handler.HandleTool(context.Background(), ToolListChats, map[string]interface{}{})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, "Not SQLite")
+}
+
 func TestHandler_GetTools(t *testing.T) {
 	handler, _ := setupTestHandler(t)
 	tools := handler.GetTools()
@@ -58,6 +100,73 @@ func TestHandler_HandleGetBridgeStatus(t *testing.T) {
 	assert.False(t, result.IsError)
 }
 
+func TestHandler_GetToolUsageStats(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := handler.HandleTool(ctx, ToolListChats, map[string]interface{}{})
+		require.NoError(t, err)
+	}
+	_, err := handler.HandleTool(ctx, ToolGetBridgeStatus, map[string]interface{}{})
+	require.NoError(t, err)
+
+	result, err := handler.HandleTool(ctx, ToolGetToolUsageStats, map[string]interface{}{})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var stats struct {
+		Tools []health.ToolUsage `json:"tools"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &stats))
+	require.Len(t, stats.Tools, 3)
+	assert.Equal(t, ToolListChats, stats.Tools[0].Tool)
+	assert.Equal(t, int64(3), stats.Tools[0].Calls)
+	assert.Equal(t, ToolGetBridgeStatus, stats.Tools[1].Tool)
+	assert.Equal(t, int64(1), stats.Tools[1].Calls)
+	// The stats call itself is counted but still in flight
+	assert.Equal(t, ToolGetToolUsageStats, stats.Tools[2].Tool)
+	assert.Equal(t, int64(1), stats.Tools[2].Calls)
+	assert.Zero(t, stats.Tools[2].AvgLatencyMs)
+
+	_, err = handler.HandleTool(ctx, ToolListChats, map[string]interface{}{})
+	require.NoError(t, err)
+	result, err = handler.HandleTool(ctx, ToolGetToolUsageStats, map[string]interface{}{})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &stats))
+	assert.Equal(t, int64(4), stats.Tools[0].Calls)
+	assert.Equal(t, int64(2), stats.Tools[1].Calls)
+	assert.Equal(t, ToolGetToolUsageStats, stats.Tools[1].Tool)
+}
+
+func TestHandler_PingWhatsApp(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	fb.pingLatency = 87500 * time.Microsecond
+	ctx := context.Background()
+
+	// No latency is reported before the first ping
+	result, err := handler.HandleTool(ctx, ToolGetBridgeStatus, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.NotContains(t, result.Content[0].Text, "latency_ms")
+
+	result, err = handler.HandleTool(ctx, ToolPingWhatsApp, map[string]interface{}{})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	var ping struct {
+		LatencyMs float64 `json:"latency_ms"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &ping))
+	assert.Equal(t, 87.5, ping.LatencyMs)
+
+	result, err = handler.HandleTool(ctx, ToolGetBridgeStatus, map[string]interface{}{})
+	require.NoError(t, err)
+	var status health.Status
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &status))
+	require.NotNil(t, status.LatencyMs)
+	assert.Equal(t, 87.5, *status.LatencyMs)
+	assert.NotNil(t, status.LatencyMeasured)
+}
+
 func TestHandler_HandleListChats(t *testing.T) {
 	handler, storeDB := setupTestHandler(t)
 	ctx := context.Background()
@@ -111,6 +220,281 @@ func TestHandler_HandleGetChat_NotFound(t *testing.T) {
 	assert.True(t, result.IsError)
 }
 
+func TestHandler_HandleGetChat_ContactWithoutHistory(t *testing.T) {
+	handler, storeDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	require.NoError(t, storeDB.Contacts.Upsert(ctx, &store.Contact{JID: "1234567890@s.whatsapp.net", Name: "Alice"}))
+
+	result, err := handler.HandleTool(ctx, ToolGetChat, map[string]interface{}{"jid": "+1 234 567 890"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var chat map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &chat))
+	assert.Equal(t, "1234567890@s.whatsapp.net", chat["jid"])
+	assert.Equal(t, "Alice", chat["name"])
+	assert.Equal(t, false, chat["is_group"])
+	assert.Equal(t, false, chat["exists"])
+
+	// Unknown groups have nothing to fall back on
+	result, err = handler.HandleTool(ctx, ToolGetChat, map[string]interface{}{"jid": "120363000000000000@g.us"})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrNotFound)
+}
+
+func TestHandler_GetChatByPhone(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+	fb.registeredPhones = map[string]bool{"+447700900123": true}
+	require.NoError(t, handler.store.Chats.Upsert(ctx, &store.Chat{JID: "447700900123@s.whatsapp.net", Name: "Bob"}))
+
+	lookup := func(phone string) map[string]interface{} {
+		result, err := handler.HandleTool(ctx, ToolGetChatByPhone, map[string]interface{}{"phone": phone})
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].Text)
+		var out map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &out))
+		return out
+	}
+
+	// A registered number with history returns the stored chat
+	out := lookup("+44 7700 900123")
+	assert.Equal(t, "447700900123@s.whatsapp.net", out["jid"])
+	assert.Equal(t, true, out["registered"])
+	chat := out["chat"].(map[string]interface{})
+	assert.Equal(t, "Bob", chat["name"])
+
+	// An unregistered number gets a placeholder chat
+	out = lookup("+1 (555) 010-9999")
+	assert.Equal(t, "15550109999@s.whatsapp.net", out["jid"])
+	assert.Equal(t, false, out["registered"])
+	chat = out["chat"].(map[string]interface{})
+	assert.Equal(t, false, chat["exists"])
+	assert.Equal(t, "15550109999@s.whatsapp.net", chat["jid"])
+
+	result, err := handler.HandleTool(ctx, ToolGetChatByPhone, map[string]interface{}{"phone": "not a number"})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrInvalidInput)
+}
+
+func TestHandler_ReactToMessage_Emoji(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	// react returns whether the call failed and its text
+	react := func(emoji interface{}) (bool, string) {
+		args := map[string]interface{}{
+			"chat_jid":   "1234567890@s.whatsapp.net",
+			"message_id": "MSG1",
+		}
+		if emoji != nil {
+			args["emoji"] = emoji
+		}
+		result, err := handler.HandleTool(ctx, ToolReactToMessage, args)
+		require.NoError(t, err)
+		return result.IsError, result.Content[0].Text
+	}
+
+	isErr, text := react("👍")
+	assert.False(t, isErr, text)
+	assert.Contains(t, text, "Reaction added")
+
+	isErr, text = react("")
+	assert.False(t, isErr, text)
+	assert.Contains(t, text, "Reaction removed")
+	assert.Equal(t, []string{"ReactToMessage", "ReactToMessage"}, fb.Calls())
+
+	for _, bad := range []string{"ok", "👍👍", "👍 "} {
+		isErr, text = react(bad)
+		assert.True(t, isErr, bad)
+		assert.Contains(t, text, ErrInvalidInput)
+	}
+	isErr, text = react(nil)
+	assert.True(t, isErr)
+	assert.Contains(t, text, "missing required fields: emoji")
+	assert.Len(t, fb.Calls(), 2, "rejected reactions must not be sent")
+}
+
+func TestIsSingleEmoji(t *testing.T) {
+	valid := []string{
+		"👍", "❤️", "😂", "🙏",
+		"👍🏽",        // skin tone
+		"🇮🇳",        // flag
+		"1️⃣", "#⃣", // keycaps
+		"👨‍👩‍👧‍👦", // family ZWJ sequence
+		"👩🏻‍💻",    // profession with skin tone
+		"🏳️‍🌈",    // rainbow flag
+		"🏴󠁧󠁢󠁳󠁣󠁴󠁿", // Scotland (tag sequence)
+	}
+	for _, s := range valid {
+		assert.True(t, isSingleEmoji(s), "%q should be valid", s)
+	}
+
+	invalid := []string{"", "a", "ok", "1", "👍👍", "👍 ", "🇮", "🏽", "👨‍", "‍👨", "😀x"}
+	for _, s := range invalid {
+		assert.False(t, isSingleEmoji(s), "%q should be invalid", s)
+	}
+}
+
+func TestHandler_HandleGetChatStats(t *testing.T) {
+	handler, storeDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	chatJID := "1234567890@s.whatsapp.net"
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: chatJID}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{ID: "M1", ChatJID: chatJID, Sender: chatJID, Content: "hi", Timestamp: time.Now()}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{ID: "M2", ChatJID: chatJID, Sender: "me", MediaType: "image", Timestamp: time.Now()}))
+
+	result, err := handler.HandleTool(ctx, ToolGetChatStats, map[string]interface{}{"jid": "1234567890"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var stats store.ChatStats
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &stats))
+	assert.Equal(t, 2, stats.MessageCount)
+	assert.Equal(t, map[string]int{"text": 1, "image": 1}, stats.ByMediaType)
+	assert.Len(t, stats.BySender, 2)
+
+	result, err = handler.HandleTool(ctx, ToolGetChatStats, map[string]interface{}{"jid": "999@s.whatsapp.net"})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrNotFound)
+}
+
+func TestHandler_HandleGetReactions(t *testing.T) {
+	handler, storeDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	chatJID := "1234567890@s.whatsapp.net"
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: chatJID}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{ID: "msg1", ChatJID: chatJID, Sender: "me", IsFromMe: true, Timestamp: time.Now()}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{ID: "msg2", ChatJID: chatJID, Sender: "me", IsFromMe: true, Timestamp: time.Now()}))
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, storeDB.Messages.SetReaction(ctx, chatJID, "msg1", store.Reaction{Sender: "111@s.whatsapp.net", Emoji: "👍", ReactedAt: at}))
+	require.NoError(t, storeDB.Messages.SetReaction(ctx, chatJID, "msg1", store.Reaction{Sender: "222@s.whatsapp.net", Emoji: "🎉", ReactedAt: at.Add(time.Minute)}))
+
+	result, err := handler.HandleTool(ctx, ToolGetReactions, map[string]interface{}{"chat_jid": chatJID, "message_id": "msg1"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var resp struct {
+		Reactions []struct {
+			Sender    string    `json:"sender"`
+			Emoji     string    `json:"emoji"`
+			ReactedAt time.Time `json:"reacted_at"`
+		} `json:"reactions"`
+		Count int `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &resp))
+	assert.Equal(t, 2, resp.Count)
+	require.Len(t, resp.Reactions, 2)
+	assert.Equal(t, "111@s.whatsapp.net", resp.Reactions[0].Sender)
+	assert.Equal(t, "👍", resp.Reactions[0].Emoji)
+	assert.True(t, resp.Reactions[0].ReactedAt.Equal(at))
+	assert.Equal(t, "222@s.whatsapp.net", resp.Reactions[1].Sender)
+	assert.Equal(t, "🎉", resp.Reactions[1].Emoji)
+
+	// No reactions is an empty list, not an error
+	result, err = handler.HandleTool(ctx, ToolGetReactions, map[string]interface{}{"chat_jid": chatJID, "message_id": "msg2"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, `"reactions": []`)
+
+	result, err = handler.HandleTool(ctx, ToolGetReactions, map[string]interface{}{"chat_jid": chatJID, "message_id": "missing"})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrNotFound)
+}
+
+func TestHandler_StarMessage(t *testing.T) {
+	handler, storeDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	chatJID := "1234567890@s.whatsapp.net"
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: chatJID}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{ID: "MSG1", ChatJID: chatJID, Sender: "me", Timestamp: time.Now()}))
+
+	// A bare phone number finds the stored chat
+	result, err := handler.handleStarMessage(ctx, map[string]interface{}{"chat_jid": "1234567890", "message_id": "MSG1"}, true)
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	msg, err := storeDB.Messages.GetByID(ctx, chatJID, "MSG1")
+	require.NoError(t, err)
+	assert.True(t, msg.IsStarred)
+
+	result, err = handler.handleStarMessage(ctx, map[string]interface{}{"chat_jid": chatJID, "message_id": "missing"}, false)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrNotFound)
+
+	result, err = handler.handleStarMessage(ctx, map[string]interface{}{"chat_jid": "garbage", "message_id": "MSG1"}, true)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrInvalidJID)
+}
+
+func TestHandler_ListMessages_NormalizesChatJID(t *testing.T) {
+	handler, storeDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	chatJID := "1234567890@s.whatsapp.net"
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: chatJID}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{ID: "MSG1", ChatJID: chatJID, Sender: "me", Timestamp: time.Now()}))
+
+	// A bare phone number lists the stored chat's messages
+	result, err := handler.HandleTool(ctx, ToolListMessages, map[string]interface{}{"chat_jid": "1234567890"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	var messages []store.Message
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &messages))
+	require.Len(t, messages, 1)
+	assert.Equal(t, "MSG1", messages[0].ID)
+
+	result, err = handler.HandleTool(ctx, ToolListMessages, map[string]interface{}{"chat_jid": "garbage"})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrInvalidJID)
+}
+
+func TestHandler_MessageExists(t *testing.T) {
+	handler, storeDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	chatJID := "1234567890@s.whatsapp.net"
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: chatJID}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{
+		ID: "IMG1", ChatJID: chatJID, Sender: "me", IsFromMe: true, Content: "secret caption",
+		MediaType: "image", Timestamp: time.Now(),
+	}))
+
+	result, err := handler.HandleTool(ctx, ToolMessageExists, map[string]interface{}{"chat_jid": "1234567890", "message_id": "IMG1"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &resp))
+	assert.Equal(t, map[string]interface{}{
+		"exists": true, "is_from_me": true, "has_media": true, "is_deleted": false,
+	}, resp)
+	assert.NotContains(t, result.Content[0].Text, "secret caption")
+
+	// An unsynced message is a normal answer, not an error
+	result, err = handler.HandleTool(ctx, ToolMessageExists, map[string]interface{}{"chat_jid": chatJID, "message_id": "missing"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	resp = nil
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &resp))
+	assert.Equal(t, map[string]interface{}{"exists": false}, resp)
+
+	result, err = handler.HandleTool(ctx, ToolMessageExists, map[string]interface{}{"chat_jid": chatJID})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
 func TestHandler_HandleSearchContacts(t *testing.T) {
 	handler, storeDB := setupTestHandler(t)
 	ctx := context.Background()
@@ -134,6 +518,47 @@ func TestHandler_HandleSearchContacts(t *testing.T) {
 	assert.Len(t, contacts, 2)
 }
 
+func TestHandler_HandleListContacts(t *testing.T) {
+	handler, storeDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	require.NoError(t, storeDB.Contacts.Upsert(ctx, &store.Contact{JID: "1@s.whatsapp.net", Name: "John Doe"}))
+	require.NoError(t, storeDB.Contacts.Upsert(ctx, &store.Contact{JID: "2@s.whatsapp.net", Name: "Jane Doe"}))
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: "2@s.whatsapp.net", LastMessageTime: time.Now()}))
+
+	result, err := handler.HandleTool(ctx, ToolListContacts, map[string]interface{}{
+		"limit":         1.0,
+		"offset":        1.0,
+		"include_chats": true,
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var page struct {
+		Contacts []store.ContactWithChat `json:"contacts"`
+		Total    int                     `json:"total"`
+		HasMore  bool                    `json:"has_more"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &page))
+	require.Len(t, page.Contacts, 1)
+	assert.Equal(t, "Jane Doe", page.Contacts[0].Name)
+	assert.True(t, page.Contacts[0].HasChat)
+	assert.NotNil(t, page.Contacts[0].LastMessageTime)
+	assert.Equal(t, 2, page.Total)
+	assert.False(t, page.HasMore)
+
+	// Without include_chats the join fields are left out
+	result, err = handler.HandleTool(ctx, ToolListContacts, map[string]interface{}{"limit": 1.0})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.NotContains(t, result.Content[0].Text, "has_chat")
+	assert.Contains(t, result.Content[0].Text, `"has_more": true`)
+
+	result, err = handler.HandleTool(ctx, ToolListContacts, map[string]interface{}{"offset": -1.0})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
 func TestHandler_HandleArchiveChat_RequiresBridge(t *testing.T) {
 	handler, storeDB := setupTestHandler(t)
 	ctx := context.Background()
@@ -182,3 +607,2742 @@ func TestHandler_HandleUnknownTool(t *testing.T) {
 	require.NotNil(t, result)
 	assert.True(t, result.IsError) // But the result is marked as error
 }
+
+func TestValidateSavePath_AllowedDirs(t *testing.T) {
+	allowed := t.TempDir()
+	outside := t.TempDir()
+
+	link := filepath.Join(allowed, "escape")
+	require.NoError(t, os.Symlink(outside, link))
+
+	assert.NoError(t, validateSavePath(filepath.Join(allowed, "photo.jpg"), []string{allowed}))
+	assert.Error(t, validateSavePath(filepath.Join(outside, "photo.jpg"), []string{allowed}))
+	assert.Error(t, validateSavePath(filepath.Join(link, "photo.jpg"), []string{allowed}))
+	assert.Error(t, validateSavePath(allowed+"/../photo.jpg", []string{allowed}))
+	assert.NoError(t, validateSavePath(filepath.Join(allowed, "foo..bar.jpg"), []string{allowed}))
+
+	// A relative path keeps its leading ".." after filepath.Clean
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	rel, err := filepath.Rel(wd, filepath.Join(outside, "photo.jpg"))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(rel, ".."))
+	assert.ErrorContains(t, validateSavePath(rel, []string{allowed}), `".."`)
+}
+
+func TestValidateSavePath_Denylist(t *testing.T) {
+	assert.Error(t, validateSavePath("/etc/cron.d/job", nil))
+	assert.NoError(t, validateSavePath(filepath.Join(t.TempDir(), "photo.jpg"), nil))
+}
+
+func TestValidateJID(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+		want    string
+	}{
+		{"user JID", "1234567890@s.whatsapp.net", false, "1234567890@s.whatsapp.net"},
+		{"group JID", "123456789-987654321@g.us", false, "123456789-987654321@g.us"},
+		{"bare phone", "+1 (234) 567-890", false, "1234567890@s.whatsapp.net"},
+		{"garbage", "not a jid", true, ""},
+		{"unknown server", "1234567890@example.com", true, ""},
+		{"missing user", "@s.whatsapp.net", true, ""},
+		{"empty", "", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateJID(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, normalizeJID(tt.input))
+		})
+	}
+}
+
+func TestValidateGroupJID(t *testing.T) {
+	assert.NoError(t, validateGroupJID("123456789-987654321@g.us"))
+	assert.Error(t, validateGroupJID("1234567890@s.whatsapp.net"))
+	assert.Error(t, validateGroupJID("1234567890"))
+}
+
+func TestGetString(t *testing.T) {
+	args := map[string]interface{}{
+		"string":    "3EB0A1",
+		"number":    float64(1234567890),
+		"decimal":   1.5,
+		"imprecise": float64(12345678901234567890),
+		"int":       42,
+		"bool":      true,
+	}
+	assert.Equal(t, "3EB0A1", getString(args, "string"))
+	assert.Equal(t, "1234567890", getString(args, "number"), "no exponent for large numbers")
+	assert.Equal(t, "", getString(args, "decimal"))
+	assert.Equal(t, "", getString(args, "imprecise"), "digits beyond float64 precision would be invented")
+	assert.Equal(t, "42", getString(args, "int"))
+	assert.Equal(t, "", getString(args, "bool"))
+	assert.Equal(t, "", getString(args, "missing"))
+}
+
+func TestGetInt(t *testing.T) {
+	args := map[string]interface{}{
+		"number":  float64(25),
+		"int":     7,
+		"string":  "30",
+		"spaced":  " 12 ",
+		"garbage": "ten",
+		"decimal": "2.5",
+		"huge":    1e300,
+	}
+	assert.Equal(t, 25, getInt(args, "number", 50))
+	assert.Equal(t, 7, getInt(args, "int", 50))
+	assert.Equal(t, 30, getInt(args, "string", 50))
+	assert.Equal(t, 12, getInt(args, "spaced", 50))
+	assert.Equal(t, 50, getInt(args, "garbage", 50))
+	assert.Equal(t, 50, getInt(args, "decimal", 50))
+	assert.Equal(t, 50, getInt(args, "huge", 50), "int conversion would overflow")
+	assert.Equal(t, 50, getInt(args, "missing", 50))
+}
+
+func TestHandler_NumericMessageID(t *testing.T) {
+	handler, storeDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	chatJID := "1234567890@s.whatsapp.net"
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: chatJID}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{ID: "987654321", ChatJID: chatJID, Sender: "me", Timestamp: time.Now()}))
+
+	result, err := handler.HandleTool(ctx, ToolMessageExists, map[string]interface{}{"chat_jid": chatJID, "message_id": float64(987654321)})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, `"exists": true`)
+
+	// A limit sent as a string is honored
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{ID: "987654322", ChatJID: chatJID, Sender: "me", Timestamp: time.Now()}))
+	result, err = handler.HandleTool(ctx, ToolListMessages, map[string]interface{}{"chat_jid": chatJID, "limit": "1"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	var messages []store.Message
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &messages))
+	assert.Len(t, messages, 1)
+
+	// An ID too long for a float64 is rejected rather than rounded
+	result, err = handler.HandleTool(ctx, ToolMessageExists, map[string]interface{}{"chat_jid": chatJID, "message_id": float64(12345678901234567890)})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "message_id")
+}
+
+func TestHandler_SendMessage_InvalidJID(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+
+	result, err := handler.handleSendMessage(context.Background(), map[string]interface{}{
+		"recipient": "garbage",
+		"message":   "hi",
+	})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrInvalidJID)
+}
+
+// fakeBridge is an in-memory Bridge that records which methods were called.
+type fakeBridge struct {
+	mu    sync.Mutex
+	state state.State
+	calls []string
+
+	lastViewOnce bool
+	lastCaption  string
+	lastCard     whatsapp.ContactCard
+	lastContacts []string
+	failJIDs     map[string]bool
+	business     bool
+	lastMentions []string
+	lastQuote    []string
+	chatSettings types.LocalChatSettings
+
+	lastHistoryCount int
+	lastInviteCode   string
+	lastRefresh      bool
+	lastDisappearing time.Duration
+
+	newslettersErr      error
+	lastNewsletterCount int
+
+	ban              *bridge.BanInfo
+	syncProgress     bridge.SyncProgress
+	registeredPhones map[string]bool
+	pingLatency      time.Duration
+	sendDelay        time.Duration
+}
+
+func newFakeBridge() *fakeBridge {
+	return &fakeBridge{state: state.StateReady}
+}
+
+func (f *fakeBridge) record(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, name)
+}
+
+func (f *fakeBridge) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func setupTestHandlerWithBridge(t *testing.T) (*Handler, *fakeBridge) {
+	handler, _ := setupTestHandler(t)
+	fb := newFakeBridge()
+	handler.bridge = fb
+	return handler, fb
+}
+
+func (f *fakeBridge) CurrentState() state.State {
+	return f.state
+}
+
+func (f *fakeBridge) IsReady() bool {
+	return f.state == state.StateReady
+}
+
+func (f *fakeBridge) IsConnected() bool {
+	return f.state == state.StateReady
+}
+
+func (f *fakeBridge) IsBusiness() bool {
+	return f.business
+}
+
+func (f *fakeBridge) Ping(ctx context.Context) (time.Duration, error) {
+	f.record("Ping")
+	return f.pingLatency, nil
+}
+
+func (f *fakeBridge) RetryFailedStores(ctx context.Context, limit int) (int, int, error) {
+	f.record("RetryFailedStores")
+	return 0, 0, nil
+}
+
+func (f *fakeBridge) DroppedEvents() int64 {
+	return 0
+}
+
+func (f *fakeBridge) SyncProgress() bridge.SyncProgress {
+	p := f.syncProgress
+	p.State = f.state
+	return p
+}
+
+func (f *fakeBridge) TemporaryBan() (bridge.BanInfo, bool) {
+	if f.state != state.StateTemporaryBan {
+		return bridge.BanInfo{}, false
+	}
+	if f.ban == nil {
+		return bridge.BanInfo{}, true
+	}
+	return *f.ban, true
+}
+
+// fakeDevices is the device list of the fake account: the primary phone,
+// this bridge and one other companion.
+var fakeDevices = []whatsapp.DeviceInfo{
+	{JID: "1234567890@s.whatsapp.net", DeviceID: 0, IsPrimary: true, Platform: "android"},
+	{JID: "1234567890:3@s.whatsapp.net", DeviceID: 3},
+	{JID: "1234567890:12@s.whatsapp.net", DeviceID: 12, IsCurrent: true},
+}
+
+func (f *fakeBridge) GetLinkedDevices(ctx context.Context) ([]whatsapp.DeviceInfo, error) {
+	f.record("GetLinkedDevices")
+	return fakeDevices, nil
+}
+
+func (f *fakeBridge) SendMessage(ctx context.Context, jid string, text string, mentions []string) (bridge.SendMessageResult, error) {
+	f.record("SendMessage")
+	f.mu.Lock()
+	f.lastMentions = mentions
+	delay := f.sendDelay
+	f.mu.Unlock()
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return bridge.SendMessageResult{}, ctx.Err()
+		}
+	}
+	return bridge.SendMessageResult{ID: "msg-" + jid, Timestamp: time.Now().UTC()}, nil
+}
+
+func (f *fakeBridge) SendQuotedMessage(ctx context.Context, jid, text string, mentions []string, quotedChatJID, quotedID, quotedSender, quotedText string) (bridge.SendMessageResult, error) {
+	f.record("SendQuotedMessage")
+	f.mu.Lock()
+	f.lastQuote = []string{quotedChatJID, quotedID, quotedSender, quotedText}
+	f.mu.Unlock()
+	return bridge.SendMessageResult{}, nil
+}
+
+func (f *fakeBridge) SendBroadcast(ctx context.Context, recipients []string, text string) ([]string, []error, error) {
+	f.record("SendBroadcast")
+	ids := make([]string, len(recipients))
+	errs := make([]error, len(recipients))
+	for i, jid := range recipients {
+		if f.failJIDs[jid] {
+			errs[i] = errors.New("recipient unreachable")
+			continue
+		}
+		ids[i] = "msg-" + jid
+	}
+	return ids, errs, nil
+}
+
+func (f *fakeBridge) SendBatch(ctx context.Context, jids, texts []string, continueOnError bool) ([]string, []error, error) {
+	f.record("SendBatch")
+	ids := make([]string, len(jids))
+	errs := make([]error, len(jids))
+	stopped := false
+	for i, jid := range jids {
+		switch {
+		case stopped:
+			errs[i] = errors.New("not sent")
+		case f.failJIDs[jid]:
+			errs[i] = errors.New("recipient unreachable")
+			stopped = !continueOnError
+		default:
+			ids[i] = "msg-" + jid
+		}
+	}
+	return ids, errs, nil
+}
+
+func (f *fakeBridge) ReplyToMessage(ctx context.Context, chatJID, messageID, text string) (bridge.SendMessageResult, error) {
+	f.record("ReplyToMessage")
+	return bridge.SendMessageResult{}, nil
+}
+
+func (f *fakeBridge) ForwardMessage(ctx context.Context, sourceChatJID, messageID, targetJID string) (bridge.SendMessageResult, error) {
+	f.record("ForwardMessage")
+	return bridge.SendMessageResult{}, nil
+}
+
+func (f *fakeBridge) EditMessage(ctx context.Context, chatJID, messageID, newContent string) error {
+	f.record("EditMessage")
+	return nil
+}
+
+func (f *fakeBridge) DeleteMessage(ctx context.Context, chatJID, messageID string, forEveryone bool) error {
+	f.record("DeleteMessage")
+	return nil
+}
+
+func (f *fakeBridge) ReactToMessage(ctx context.Context, chatJID, messageID, emoji string) error {
+	f.record("ReactToMessage")
+	return nil
+}
+
+func (f *fakeBridge) SendImage(ctx context.Context, jid, imagePath, caption string, viewOnce bool) (bridge.SendMessageResult, error) {
+	f.record("SendImage")
+	f.mu.Lock()
+	f.lastViewOnce = viewOnce
+	f.mu.Unlock()
+	return bridge.SendMessageResult{}, nil
+}
+
+func (f *fakeBridge) SendVideo(ctx context.Context, jid, videoPath, caption string, viewOnce bool) (bridge.SendMessageResult, error) {
+	f.record("SendVideo")
+	f.mu.Lock()
+	f.lastViewOnce = viewOnce
+	f.mu.Unlock()
+	return bridge.SendMessageResult{}, nil
+}
+
+func (f *fakeBridge) SendGIF(ctx context.Context, jid, gifPath, caption string) (bridge.SendMessageResult, error) {
+	f.record("SendGIF")
+	return bridge.SendMessageResult{}, nil
+}
+
+func (f *fakeBridge) SendAudio(ctx context.Context, jid, audioPath string, asVoice bool) (bridge.SendMessageResult, error) {
+	f.record("SendAudio")
+	return bridge.SendMessageResult{}, nil
+}
+
+func (f *fakeBridge) SendDocument(ctx context.Context, jid, filePath, filename, caption string) (bridge.SendMessageResult, error) {
+	f.record("SendDocument")
+	f.mu.Lock()
+	f.lastCaption = caption
+	f.mu.Unlock()
+	return bridge.SendMessageResult{}, nil
+}
+
+func (f *fakeBridge) SendLocation(ctx context.Context, jid string, lat, lon float64, name, address string) (bridge.SendMessageResult, error) {
+	f.record("SendLocation")
+	return bridge.SendMessageResult{}, nil
+}
+
+func (f *fakeBridge) SendLiveLocation(ctx context.Context, jid string, lat, lon float64, durationSec int) (bridge.SendMessageResult, error) {
+	f.record("SendLiveLocation")
+	return bridge.SendMessageResult{}, nil
+}
+
+func (f *fakeBridge) SendContactCard(ctx context.Context, jid, contactJID string) (bridge.SendMessageResult, error) {
+	f.record("SendContactCard")
+	return bridge.SendMessageResult{}, nil
+}
+
+func (f *fakeBridge) SendContactCards(ctx context.Context, jid string, contactJIDs []string) (bridge.SendMessageResult, error) {
+	f.record("SendContactCards")
+	f.mu.Lock()
+	f.lastContacts = contactJIDs
+	f.mu.Unlock()
+	return bridge.SendMessageResult{ID: "cards-" + jid}, nil
+}
+
+func (f *fakeBridge) SendVCard(ctx context.Context, jid string, card whatsapp.ContactCard) (bridge.SendMessageResult, error) {
+	f.record("SendVCard")
+	f.mu.Lock()
+	f.lastCard = card
+	f.mu.Unlock()
+	return bridge.SendMessageResult{ID: "card-" + jid}, nil
+}
+
+func (f *fakeBridge) DownloadMedia(ctx context.Context, chatJID, messageID, savePath string) (string, error) {
+	f.record("DownloadMedia")
+	return "", nil
+}
+
+func (f *fakeBridge) ArchiveChat(ctx context.Context, jid string, archive bool) error {
+	f.record("ArchiveChat")
+	return nil
+}
+
+func (f *fakeBridge) PinChat(ctx context.Context, jid string, pin bool) error {
+	f.record("PinChat")
+	return nil
+}
+
+func (f *fakeBridge) SetDisappearingTimer(ctx context.Context, jid string, duration time.Duration) error {
+	f.record("SetDisappearingTimer")
+	f.lastDisappearing = duration
+	return nil
+}
+
+func (f *fakeBridge) MuteChat(ctx context.Context, jid string, mute bool, duration string) error {
+	f.record("MuteChat")
+	return nil
+}
+
+func (f *fakeBridge) ArchiveChats(ctx context.Context, jids []string, archive bool) ([]error, error) {
+	f.record("ArchiveChats")
+	return f.bulkErrors(jids), nil
+}
+
+func (f *fakeBridge) MuteChats(ctx context.Context, jids []string, mute bool, duration string) ([]error, error) {
+	f.record("MuteChats")
+	return f.bulkErrors(jids), nil
+}
+
+func (f *fakeBridge) MarkAllRead(ctx context.Context, jids []string) ([]error, error) {
+	f.record("MarkAllRead")
+	return f.bulkErrors(jids), nil
+}
+
+func (f *fakeBridge) bulkErrors(jids []string) []error {
+	errs := make([]error, len(jids))
+	for i, jid := range jids {
+		if f.failJIDs[jid] {
+			errs[i] = errors.New("chat not found")
+		}
+	}
+	return errs
+}
+
+func (f *fakeBridge) MarkChatRead(ctx context.Context, jid string) error {
+	f.record("MarkChatRead")
+	return nil
+}
+
+func (f *fakeBridge) MarkChatUnread(ctx context.Context, jid string) error {
+	f.record("MarkChatUnread")
+	return nil
+}
+
+func (f *fakeBridge) DeleteChat(ctx context.Context, jid string) error {
+	f.record("DeleteChat")
+	return nil
+}
+
+func (f *fakeBridge) GetChatSettings(ctx context.Context, jid string) (types.LocalChatSettings, error) {
+	f.record("GetChatSettings")
+	return f.chatSettings, nil
+}
+
+func (f *fakeBridge) RequestHistorySync(ctx context.Context, chatJID string, count int) error {
+	f.record("RequestHistorySync")
+	f.lastHistoryCount = count
+	return nil
+}
+
+func (f *fakeBridge) AddChatLabel(ctx context.Context, chatJID, labelID string) error {
+	f.record("AddChatLabel")
+	return nil
+}
+
+func (f *fakeBridge) RemoveChatLabel(ctx context.Context, chatJID, labelID string) error {
+	f.record("RemoveChatLabel")
+	return nil
+}
+
+func (f *fakeBridge) BlockContact(ctx context.Context, jid string, block bool) error {
+	f.record("BlockContact")
+	return nil
+}
+
+func (f *fakeBridge) CheckPhoneRegistered(ctx context.Context, phone string) (bool, error) {
+	f.record("CheckPhoneRegistered")
+	return f.registeredPhones[phone], nil
+}
+
+func (f *fakeBridge) CreateGroup(ctx context.Context, name string, participants []string) (string, error) {
+	f.record("CreateGroup")
+	return "", nil
+}
+
+func (f *fakeBridge) GetGroupInfo(ctx context.Context, jid string) (*types.GroupInfo, error) {
+	f.record("GetGroupInfo")
+	admin := types.NewJID("2222222222", types.DefaultUserServer)
+	lid := types.NewJID("98765432101234", types.HiddenUserServer)
+	return &types.GroupInfo{
+		JID:          types.NewJID("120363000000000000", types.GroupServer),
+		OwnerJID:     types.NewJID("1111111111", types.DefaultUserServer),
+		GroupName:    types.GroupName{Name: "Book Club"},
+		GroupTopic:   types.GroupTopic{Topic: "Monthly reads"},
+		GroupCreated: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		GroupLocked:  types.GroupLocked{IsLocked: true},
+		GroupEphemeral: types.GroupEphemeral{
+			IsEphemeral:       true,
+			DisappearingTimer: 604800,
+		},
+		MemberAddMode: types.GroupMemberAddModeAdmin,
+		Participants: []types.GroupParticipant{
+			{JID: types.NewJID("1111111111", types.DefaultUserServer), IsAdmin: true, IsSuperAdmin: true},
+			{JID: admin, PhoneNumber: admin, IsAdmin: true},
+			{JID: lid, LID: lid, PhoneNumber: types.NewJID("3333333333", types.DefaultUserServer)},
+		},
+	}, nil
+}
+
+func (f *fakeBridge) LeaveGroup(ctx context.Context, jid string) error {
+	f.record("LeaveGroup")
+	return nil
+}
+
+func (f *fakeBridge) AddGroupMembers(ctx context.Context, groupJID string, participants []string) error {
+	f.record("AddGroupMembers")
+	return nil
+}
+
+func (f *fakeBridge) RemoveGroupMembers(ctx context.Context, groupJID string, participants []string) error {
+	f.record("RemoveGroupMembers")
+	return nil
+}
+
+func (f *fakeBridge) PromoteAdmin(ctx context.Context, groupJID string, participants []string) error {
+	f.record("PromoteAdmin")
+	return nil
+}
+
+func (f *fakeBridge) DemoteAdmin(ctx context.Context, groupJID string, participants []string) error {
+	f.record("DemoteAdmin")
+	return nil
+}
+
+func (f *fakeBridge) SetGroupName(ctx context.Context, groupJID, name string) error {
+	f.record("SetGroupName")
+	return nil
+}
+
+func (f *fakeBridge) SetGroupTopic(ctx context.Context, groupJID, topic string) error {
+	f.record("SetGroupTopic")
+	return nil
+}
+
+func (f *fakeBridge) SetGroupPhoto(ctx context.Context, groupJID, imagePath string) error {
+	f.record("SetGroupPhoto")
+	return nil
+}
+
+func (f *fakeBridge) RemoveGroupPhoto(ctx context.Context, groupJID string) error {
+	f.record("RemoveGroupPhoto")
+	return nil
+}
+
+func (f *fakeBridge) SetGroupAnnounce(ctx context.Context, groupJID string, announce bool) error {
+	f.record("SetGroupAnnounce")
+	return nil
+}
+
+func (f *fakeBridge) SetGroupLocked(ctx context.Context, groupJID string, locked bool) error {
+	f.record("SetGroupLocked")
+	return nil
+}
+
+func (f *fakeBridge) GetInviteLink(ctx context.Context, groupJID string, refresh bool) (bridge.InviteLink, error) {
+	f.record("GetInviteLink")
+	f.mu.Lock()
+	f.lastRefresh = refresh
+	f.mu.Unlock()
+	return bridge.InviteLink{
+		Link:      "https://chat.whatsapp.com/AbCdEfGhIjK",
+		FetchedAt: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC),
+		Cached:    !refresh,
+	}, nil
+}
+
+func (f *fakeBridge) RevokeInviteLink(ctx context.Context, groupJID string) (string, error) {
+	f.record("RevokeInviteLink")
+	return "", nil
+}
+
+func (f *fakeBridge) JoinViaInvite(ctx context.Context, inviteLink string) (string, error) {
+	f.record("JoinViaInvite")
+	return "", nil
+}
+
+func (f *fakeBridge) GetCommonGroups(ctx context.Context, jid string) ([]string, error) {
+	f.record("GetCommonGroups")
+	return []string{"120363000000000001@g.us"}, nil
+}
+
+func (f *fakeBridge) GetGroupInfoFromLink(ctx context.Context, inviteLink string) (*types.GroupInfo, error) {
+	f.record("GetGroupInfoFromLink")
+	f.lastInviteCode = inviteLink
+	owner, _ := types.ParseJID("1234567890@s.whatsapp.net")
+	return &types.GroupInfo{
+		JID:              types.NewJID("120363000000000000", types.GroupServer),
+		OwnerJID:         owner,
+		GroupName:        types.GroupName{Name: "Book Club"},
+		GroupTopic:       types.GroupTopic{Topic: "Monthly reads"},
+		ParticipantCount: 12,
+	}, nil
+}
+
+func (f *fakeBridge) GetGroupJoinRequests(ctx context.Context, groupJID string) ([]types.GroupParticipantRequest, error) {
+	f.record("GetGroupJoinRequests")
+	return nil, nil
+}
+
+func (f *fakeBridge) ApproveGroupJoinRequest(ctx context.Context, groupJID string, participants []string) error {
+	f.record("ApproveGroupJoinRequest")
+	return nil
+}
+
+func (f *fakeBridge) RejectGroupJoinRequest(ctx context.Context, groupJID string, participants []string) error {
+	f.record("RejectGroupJoinRequest")
+	return nil
+}
+
+func (f *fakeBridge) SubscribePresence(ctx context.Context, jid string) error {
+	f.record("SubscribePresence")
+	return nil
+}
+
+func (f *fakeBridge) SubscribeGroupPresence(ctx context.Context, groupJID string) ([]string, []error, error) {
+	f.record("SubscribeGroupPresence")
+	return []string{"1111111111@s.whatsapp.net", "2222222222@s.whatsapp.net"},
+		[]error{nil, errors.New("not allowed")}, nil
+}
+
+func (f *fakeBridge) SendTyping(ctx context.Context, jid string) error {
+	f.record("SendTyping")
+	return nil
+}
+
+func (f *fakeBridge) SendRecording(ctx context.Context, jid string) error {
+	f.record("SendRecording")
+	return nil
+}
+
+func (f *fakeBridge) SetOnline(ctx context.Context) error {
+	f.record("SetOnline")
+	return nil
+}
+
+func (f *fakeBridge) SetOffline(ctx context.Context) error {
+	f.record("SetOffline")
+	return nil
+}
+
+func (f *fakeBridge) PostTextStatus(ctx context.Context, text, backgroundColor string) error {
+	f.record("PostTextStatus")
+	return nil
+}
+
+func (f *fakeBridge) PostImageStatus(ctx context.Context, imagePath, caption string) error {
+	f.record("PostImageStatus")
+	return nil
+}
+
+func (f *fakeBridge) DeleteStatus(ctx context.Context, statusID string) error {
+	f.record("DeleteStatus")
+	return nil
+}
+
+func (f *fakeBridge) ReplyToStatus(ctx context.Context, statusID, senderJID, text string) (bridge.SendMessageResult, error) {
+	f.record("ReplyToStatus")
+	f.mu.Lock()
+	f.lastQuote = []string{senderJID, statusID}
+	f.mu.Unlock()
+	return bridge.SendMessageResult{ID: "msg-" + senderJID}, nil
+}
+
+func (f *fakeBridge) GetSubscribedNewsletters(ctx context.Context) ([]*types.NewsletterMetadata, error) {
+	f.record("GetSubscribedNewsletters")
+	if f.newslettersErr != nil {
+		return nil, f.newslettersErr
+	}
+	return []*types.NewsletterMetadata{{
+		ID: types.NewJID("120363000000000001", types.NewsletterServer),
+		ThreadMeta: types.NewsletterThreadMetadata{
+			Name:            types.NewsletterText{Text: "Release notes"},
+			SubscriberCount: 1200,
+		},
+		ViewerMeta: &types.NewsletterViewerMetadata{Role: types.NewsletterRoleSubscriber},
+	}}, nil
+}
+
+func (f *fakeBridge) GetNewsletterMessages(ctx context.Context, newsletterJID string, count int) ([]bridge.NewsletterPost, error) {
+	f.record("GetNewsletterMessages")
+	f.mu.Lock()
+	f.lastNewsletterCount = count
+	f.mu.Unlock()
+	return []bridge.NewsletterPost{{ServerID: 101, ID: "101", Type: "text", Content: "v2 is out", Views: 42}}, nil
+}
+
+// Dry-run Tests
+
+func TestHandler_DryRun_SendMessage(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+
+	result, err := handler.HandleTool(context.Background(), ToolSendMessage, map[string]interface{}{
+		"recipient": "+1 234 567 890",
+		"message":   "hello",
+		"dry_run":   true,
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+	assert.Equal(t, true, got["would_send"])
+	assert.Equal(t, "1234567890@s.whatsapp.net", got["resolved_jid"])
+	assert.Empty(t, fb.Calls())
+}
+
+func TestHandler_DryRun_SendImage(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+
+	imagePath := filepath.Join(t.TempDir(), "photo.png")
+	png := []byte("\x89PNG\r\n\x1a\n0000000000")
+	require.NoError(t, os.WriteFile(imagePath, png, 0600))
+
+	result, err := handler.HandleTool(context.Background(), ToolSendImage, map[string]interface{}{
+		"recipient":  "1234567890@s.whatsapp.net",
+		"image_path": imagePath,
+		"dry_run":    true,
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+	assert.Equal(t, "image/png", got["mime_type"])
+	assert.Equal(t, float64(len(png)), got["file_size"])
+	assert.Empty(t, fb.Calls())
+}
+
+func TestHandler_DryRun_SendFileWhileDisconnected(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	fb.state = state.StateDisconnected
+
+	imagePath := filepath.Join(t.TempDir(), "photo.png")
+	require.NoError(t, os.WriteFile(imagePath, []byte("\x89PNG\r\n\x1a\n0000000000"), 0600))
+
+	result, err := handler.HandleTool(context.Background(), ToolSendFile, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"file_path": imagePath,
+		"dry_run":   true,
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+	assert.Equal(t, true, got["would_send"])
+	assert.Equal(t, "image", got["send_as"])
+	assert.Empty(t, fb.Calls())
+}
+
+func TestHandler_DryRun_InvalidInputs(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	result, err := handler.HandleTool(ctx, ToolSendDocument, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"file_path": filepath.Join(t.TempDir(), "missing.pdf"),
+		"dry_run":   true,
+	})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	result, err = handler.HandleTool(ctx, ToolSendMessage, map[string]interface{}{
+		"recipient": "garbage",
+		"message":   "hello",
+		"dry_run":   true,
+	})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrInvalidJID)
+
+	assert.Empty(t, fb.Calls())
+}
+
+// sparseFile creates a file that reports size bytes without using the disk.
+func sparseFile(t *testing.T, name string, size int64) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, f.Truncate(size))
+	require.NoError(t, f.Close())
+	return path
+}
+
+func TestHandler_SendMedia_TooLarge(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	// Images are capped at 16 MiB regardless of the global limit
+	result, err := handler.HandleTool(ctx, ToolSendImage, map[string]interface{}{
+		"recipient":  "1234567890@s.whatsapp.net",
+		"image_path": sparseFile(t, "big.png", 17<<20),
+	})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrInvalidInput)
+	assert.Contains(t, result.Content[0].Text, "16.0 MiB limit for image media")
+
+	// Documents are capped by MaxMediaBytes
+	handler.config.MaxMediaBytes = 1 << 20
+	doc := sparseFile(t, "report.pdf", 2<<20)
+	result, err = handler.HandleTool(ctx, ToolSendDocument, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"file_path": doc,
+		"dry_run":   true,
+	})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "1.0 MiB limit for document media")
+	assert.Empty(t, fb.Calls())
+
+	handler.config.MaxMediaBytes = 4 << 20
+	result, err = handler.HandleTool(ctx, ToolSendDocument, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"file_path": doc,
+	})
+	require.NoError(t, err)
+	assert.False(t, result.IsError, result.Content[0].Text)
+	assert.Equal(t, []string{"SendDocument"}, fb.Calls())
+}
+
+func TestHandler_SendDocument_Caption(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+	doc := sparseFile(t, "report.pdf", 1024)
+
+	result, err := handler.HandleTool(ctx, ToolSendDocument, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"file_path": doc,
+		"caption":   "Q3 numbers",
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Equal(t, "Q3 numbers", fb.lastCaption)
+
+	// The caption is optional
+	result, err = handler.HandleTool(ctx, ToolSendDocument, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"file_path": doc,
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Empty(t, fb.lastCaption)
+	assert.Equal(t, []string{"SendDocument", "SendDocument"}, fb.Calls())
+}
+
+func TestHandler_SendContactCard_RawVCard(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	result, err := handler.HandleTool(ctx, ToolSendContactCard, map[string]interface{}{
+		"recipient":    "1234567890@s.whatsapp.net",
+		"name":         "Dr. Jane Doe",
+		"phone":        "+44 20 7946 0958",
+		"organization": "City Clinic",
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, `"message_id": "card-1234567890@s.whatsapp.net"`)
+	assert.Equal(t, whatsapp.ContactCard{
+		Name:         "Dr. Jane Doe",
+		Phone:        "442079460958",
+		Organization: "City Clinic",
+	}, fb.lastCard)
+	assert.Equal(t, []string{"SendVCard"}, fb.Calls())
+}
+
+func TestHandler_SendContactCard_ModeValidation(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name:    "both modes",
+			args:    map[string]interface{}{"contact_jid": "+1987654321", "name": "Jane", "phone": "+1987654321"},
+			wantErr: "not both",
+		},
+		{
+			name:    "neither mode",
+			args:    map[string]interface{}{},
+			wantErr: "is required",
+		},
+		{
+			name:    "name without phone",
+			args:    map[string]interface{}{"name": "Jane"},
+			wantErr: "name and phone are both required",
+		},
+		{
+			name:    "extra field without name and phone",
+			args:    map[string]interface{}{"email": "jane@example.com"},
+			wantErr: "name and phone are both required",
+		},
+		{
+			name:    "bad phone",
+			args:    map[string]interface{}{"name": "Jane", "phone": "call me"},
+			wantErr: "not a valid international phone number",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args["recipient"] = "1234567890@s.whatsapp.net"
+			result, err := handler.HandleTool(ctx, ToolSendContactCard, tt.args)
+			require.NoError(t, err)
+			require.True(t, result.IsError)
+			assert.Contains(t, result.Content[0].Text, ErrInvalidInput)
+			assert.Contains(t, result.Content[0].Text, tt.wantErr)
+		})
+	}
+	assert.Empty(t, fb.Calls())
+}
+
+func TestHandler_SendContacts(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	result, err := handler.HandleTool(ctx, ToolSendContacts, map[string]interface{}{
+		"recipient":    "1234567890@s.whatsapp.net",
+		"contact_jids": []interface{}{"+1 111 111 1111", "2222222222@s.whatsapp.net"},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, `"message_id": "cards-1234567890@s.whatsapp.net"`)
+	assert.Contains(t, result.Content[0].Text, `"count": 2`)
+	assert.Equal(t, []string{"11111111111@s.whatsapp.net", "2222222222@s.whatsapp.net"}, fb.lastContacts)
+
+	// An empty list is rejected before anything is sent
+	result, err = handler.HandleTool(ctx, ToolSendContacts, map[string]interface{}{
+		"recipient":    "1234567890@s.whatsapp.net",
+		"contact_jids": []interface{}{},
+	})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrInvalidInput)
+	assert.Contains(t, result.Content[0].Text, "at least one contact")
+
+	result, err = handler.HandleTool(ctx, ToolSendContacts, map[string]interface{}{
+		"recipient":    "1234567890@s.whatsapp.net",
+		"contact_jids": []interface{}{"2222222222@s.whatsapp.net", "not a jid"},
+	})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrInvalidJID)
+
+	assert.Equal(t, []string{"SendContactCards"}, fb.Calls())
+}
+
+func TestHandler_SendLiveLocation(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	invalid := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"latitude too high", map[string]interface{}{"latitude": 90.5, "longitude": 0.0}, "latitude must be between -90 and 90"},
+		{"latitude too low", map[string]interface{}{"latitude": -91.0, "longitude": 0.0}, "latitude must be between -90 and 90"},
+		{"longitude too high", map[string]interface{}{"latitude": 0.0, "longitude": 180.1}, "longitude must be between -180 and 180"},
+		{"longitude too low", map[string]interface{}{"latitude": 0.0, "longitude": -200.0}, "longitude must be between -180 and 180"},
+		{"zero duration", map[string]interface{}{"latitude": 0.0, "longitude": 0.0, "duration_seconds": 0.0}, "duration_seconds must be between 1 and 28800"},
+		{"duration too long", map[string]interface{}{"latitude": 0.0, "longitude": 0.0, "duration_seconds": 28801.0}, "duration_seconds must be between 1 and 28800"},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.args["recipient"] = "1234567890@s.whatsapp.net"
+			result, err := handler.HandleTool(ctx, ToolSendLiveLocation, tc.args)
+			require.NoError(t, err)
+			assert.True(t, result.IsError)
+			assert.Contains(t, result.Content[0].Text, ErrInvalidInput)
+			assert.Contains(t, result.Content[0].Text, tc.want)
+		})
+	}
+	assert.Empty(t, fb.Calls())
+
+	result, err := handler.HandleTool(ctx, ToolSendLiveLocation, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"latitude":  -90.0,
+		"longitude": 180.0,
+	})
+	require.NoError(t, err)
+	assert.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, `"duration_seconds": 900`)
+	assert.Equal(t, []string{"SendLiveLocation"}, fb.Calls())
+}
+
+func TestHandler_SendFile_ChoosesMethod(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0600))
+		return path
+	}
+	png := write("photo.png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"))
+	mp4 := write("clip.mp4", []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"))
+	mp3 := write("song.mp3", []byte("ID3\x03\x00\x00\x00\x00\x00\x00"))
+	ogg := write("voice.ogg", []byte("OggS\x00\x02\x00\x00\x00\x00"))
+	pdf := write("report.pdf", []byte("%PDF-1.7\n"))
+	txt := write("notes.txt", []byte("plain text"))
+
+	tests := []struct {
+		name     string
+		path     string
+		force    bool
+		wantCall string
+		wantAs   string
+	}{
+		{"png", png, false, "SendImage", "image"},
+		{"mp4", mp4, false, "SendVideo", "video"},
+		{"mp3", mp3, false, "SendAudio", "audio"},
+		{"ogg", ogg, false, "SendAudio", "audio"},
+		{"pdf", pdf, false, "SendDocument", "document"},
+		{"text", txt, false, "SendDocument", "document"},
+		{"forced", png, true, "SendDocument", "document"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, fb := setupTestHandlerWithBridge(t)
+			result, err := handler.HandleTool(context.Background(), ToolSendFile, map[string]interface{}{
+				"recipient":      "1234567890@s.whatsapp.net",
+				"file_path":      tt.path,
+				"force_document": tt.force,
+			})
+			require.NoError(t, err)
+			require.False(t, result.IsError, result.Content[0].Text)
+			assert.Equal(t, []string{tt.wantCall}, fb.Calls())
+			assert.Contains(t, result.Content[0].Text, `"sent_as": "`+tt.wantAs+`"`)
+		})
+	}
+}
+
+func TestHandler_SendFile_OversizedFallsBackToDocument(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+
+	// A PNG header padded past the 16 MiB image limit
+	path := sparseFile(t, "huge.png", 17<<20)
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("\x89PNG\r\n\x1a\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	result, err := handler.HandleTool(context.Background(), ToolSendFile, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"file_path": path,
+		"dry_run":   true,
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, `"mime_type": "image/png"`)
+	assert.Contains(t, result.Content[0].Text, `"send_as": "document"`)
+	assert.Empty(t, fb.Calls())
+}
+
+func TestHandler_DryRun_AllowedWhenNotReady(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	fb.state = state.StateConnecting
+
+	result, err := handler.HandleTool(context.Background(), ToolSendMessage, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"message":   "hello",
+		"dry_run":   true,
+	})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Empty(t, fb.Calls())
+}
+
+func TestHandler_SendMessage_CallsBridge(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+
+	result, err := handler.HandleTool(context.Background(), ToolSendMessage, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"message":   "hello",
+	})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, []string{"SendMessage"}, fb.Calls())
+}
+
+func TestHandler_SendMessage_ReturnsServerTimestamp(t *testing.T) {
+	handler, _ := setupTestHandlerWithBridge(t)
+
+	result, err := handler.HandleTool(context.Background(), ToolSendMessage, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"message":   "hello",
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var sent struct {
+		MessageID string    `json:"message_id"`
+		Timestamp time.Time `json:"timestamp"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &sent))
+	assert.Equal(t, "msg-1234567890@s.whatsapp.net", sent.MessageID)
+	assert.False(t, sent.Timestamp.IsZero())
+	assert.Equal(t, time.UTC, sent.Timestamp.Location())
+}
+
+func TestHandler_SendGIF_MimeValidation(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	gifPath := filepath.Join(dir, "funny.gif")
+	require.NoError(t, os.WriteFile(gifPath, []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00"), 0600))
+
+	result, err := handler.HandleTool(ctx, ToolSendGIF, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"gif_path":  gifPath,
+	})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "MP4")
+	assert.Empty(t, fb.Calls())
+
+	mp4Path := filepath.Join(dir, "funny.mp4")
+	mp4 := []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom")
+	require.NoError(t, os.WriteFile(mp4Path, mp4, 0600))
+
+	result, err = handler.HandleTool(ctx, ToolSendGIF, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"gif_path":  mp4Path,
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Equal(t, []string{"SendGIF"}, fb.Calls())
+}
+
+func TestHandler_SendImage_ViewOnce(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	imagePath := filepath.Join(t.TempDir(), "photo.png")
+	require.NoError(t, os.WriteFile(imagePath, []byte("\x89PNG\r\n\x1a\n0000000000"), 0600))
+
+	result, err := handler.HandleTool(ctx, ToolSendImage, map[string]interface{}{
+		"recipient":  "1234567890@s.whatsapp.net",
+		"image_path": imagePath,
+		"view_once":  true,
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.True(t, fb.lastViewOnce)
+
+	result, err = handler.HandleTool(ctx, ToolSendImage, map[string]interface{}{
+		"recipient":  "1234567890@s.whatsapp.net",
+		"image_path": imagePath,
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.False(t, fb.lastViewOnce, "view_once must default to false")
+	assert.Equal(t, []string{"SendImage", "SendImage"}, fb.Calls())
+}
+
+func TestHandler_SendBroadcast_PartialFailure(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	fb.failJIDs = map[string]bool{"2222222222@s.whatsapp.net": true}
+
+	result, err := handler.HandleTool(context.Background(), ToolSendBroadcast, map[string]interface{}{
+		"recipients": []interface{}{"+1111111111", "2222222222@s.whatsapp.net", "garbage"},
+		"message":    "hello all",
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var got struct {
+		Success bool              `json:"success"`
+		Sent    int               `json:"sent"`
+		Failed  int               `json:"failed"`
+		Results []broadcastResult `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+
+	assert.False(t, got.Success)
+	assert.Equal(t, 1, got.Sent)
+	assert.Equal(t, 2, got.Failed)
+	require.Len(t, got.Results, 3)
+
+	assert.Equal(t, broadcastResult{JID: "1111111111@s.whatsapp.net", MessageID: "msg-1111111111@s.whatsapp.net"}, got.Results[0])
+	assert.Equal(t, "2222222222@s.whatsapp.net", got.Results[1].JID)
+	assert.Equal(t, "recipient unreachable", got.Results[1].Error)
+	assert.Equal(t, "garbage", got.Results[2].JID)
+	assert.Contains(t, got.Results[2].Error, "Invalid JID")
+
+	assert.Equal(t, []string{"SendBroadcast"}, fb.Calls())
+}
+
+func TestHandler_BulkChatOperations_PartialFailure(t *testing.T) {
+	for _, tool := range []string{ToolArchiveChats, ToolMuteChats} {
+		t.Run(tool, func(t *testing.T) {
+			handler, fb := setupTestHandlerWithBridge(t)
+			fb.failJIDs = map[string]bool{"2222222222@s.whatsapp.net": true}
+
+			result, err := handler.HandleTool(context.Background(), tool, map[string]interface{}{
+				"jids": []interface{}{"+1111111111", "2222222222@s.whatsapp.net", "garbage", "123456789@g.us"},
+			})
+			require.NoError(t, err)
+			require.False(t, result.IsError, result.Content[0].Text)
+
+			var got struct {
+				Success   bool             `json:"success"`
+				Succeeded int              `json:"succeeded"`
+				Failed    int              `json:"failed"`
+				Results   []bulkChatResult `json:"results"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+
+			assert.False(t, got.Success)
+			assert.Equal(t, 2, got.Succeeded)
+			assert.Equal(t, 2, got.Failed)
+			require.Len(t, got.Results, 4)
+			assert.Equal(t, bulkChatResult{JID: "1111111111@s.whatsapp.net", Success: true}, got.Results[0])
+			assert.Equal(t, bulkChatResult{JID: "2222222222@s.whatsapp.net", Error: "chat not found"}, got.Results[1])
+			assert.Equal(t, "garbage", got.Results[2].JID)
+			assert.Contains(t, got.Results[2].Error, "Invalid JID")
+			assert.Equal(t, bulkChatResult{JID: "123456789@g.us", Success: true}, got.Results[3])
+
+			// One bridge call for the whole list
+			assert.Len(t, fb.Calls(), 1)
+		})
+	}
+}
+
+func TestHandler_MarkReadHonorsReadReceiptPolicy(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+	handler.config.SendReadReceipts = false
+
+	chatJID := "1234567890@s.whatsapp.net"
+	require.NoError(t, handler.store.Chats.Upsert(ctx, &store.Chat{JID: chatJID, UnreadCount: 2}))
+
+	for _, tool := range []string{ToolMarkChatRead, ToolMarkAllRead} {
+		result, err := handler.HandleTool(ctx, tool, map[string]interface{}{"jid": chatJID})
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].Text)
+		assert.Contains(t, result.Content[0].Text, `"skipped": true`, tool)
+	}
+	assert.Empty(t, fb.Calls(), "no read receipts while the policy is off")
+
+	chat, err := handler.store.Chats.GetByJID(ctx, chatJID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, chat.UnreadCount)
+
+	result, err := handler.HandleTool(ctx, ToolMarkChatRead, map[string]interface{}{"jid": chatJID, "force": true})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.NotContains(t, result.Content[0].Text, "skipped")
+	assert.Equal(t, []string{"MarkChatRead"}, fb.Calls())
+}
+
+func TestHandler_MarkAllRead(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	chats := []store.Chat{
+		{JID: "1111111111@s.whatsapp.net", UnreadCount: 3, LastMessageTime: now},
+		{JID: "2222222222@s.whatsapp.net", UnreadCount: 1, LastMessageTime: now.Add(-time.Minute)},
+		{JID: "120363000000000001@g.us", UnreadCount: store.UnreadMarked, LastMessageTime: now.Add(-2 * time.Minute)},
+		{JID: "3333333333@s.whatsapp.net", LastMessageTime: now.Add(-3 * time.Minute)},
+	}
+	for i := range chats {
+		require.NoError(t, handler.store.Chats.Upsert(ctx, &chats[i]))
+	}
+
+	type response struct {
+		Success   bool             `json:"success"`
+		Succeeded int              `json:"succeeded"`
+		Failed    int              `json:"failed"`
+		More      bool             `json:"more"`
+		Results   []bulkChatResult `json:"results"`
+	}
+	call := func(args map[string]interface{}) response {
+		t.Helper()
+		result, err := handler.HandleTool(ctx, ToolMarkAllRead, args)
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].Text)
+		var got response
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+		return got
+	}
+
+	// The limit takes the most recent unread chats and flags the rest
+	got := call(map[string]interface{}{"limit": 2})
+	assert.True(t, got.Success)
+	assert.Equal(t, 2, got.Succeeded)
+	assert.True(t, got.More)
+
+	got = call(map[string]interface{}{})
+	assert.True(t, got.Success)
+	assert.False(t, got.More)
+	assert.Equal(t, []bulkChatResult{{JID: "120363000000000001@g.us", Success: true}}, got.Results)
+
+	for _, chat := range chats {
+		stored, err := handler.store.Chats.GetByJID(ctx, chat.JID)
+		require.NoError(t, err)
+		assert.Zero(t, stored.UnreadCount, chat.JID)
+	}
+	assert.Equal(t, []string{"MarkAllRead", "MarkAllRead"}, fb.Calls())
+
+	// A chat WhatsApp refused keeps its count
+	require.NoError(t, handler.store.Chats.IncrementUnread(ctx, "2222222222@s.whatsapp.net"))
+	fb.failJIDs = map[string]bool{"2222222222@s.whatsapp.net": true}
+	got = call(map[string]interface{}{})
+	assert.False(t, got.Success)
+	assert.Equal(t, 1, got.Failed)
+	stored, err := handler.store.Chats.GetByJID(ctx, "2222222222@s.whatsapp.net")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.UnreadCount)
+}
+
+// failingResetChats fails ResetUnread for one chat.
+type failingResetChats struct {
+	store.ChatRepository
+	jid string
+}
+
+func (c failingResetChats) ResetUnread(ctx context.Context, jid string) error {
+	if jid == c.jid {
+		return errors.New("disk I/O error")
+	}
+	return c.ChatRepository.ResetUnread(ctx, jid)
+}
+
+func TestHandler_MarkAllRead_StoreFailureKeepsSummary(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	for i, jid := range []string{"1111111111@s.whatsapp.net", "2222222222@s.whatsapp.net"} {
+		chat := store.Chat{JID: jid, UnreadCount: 2, LastMessageTime: now.Add(-time.Duration(i) * time.Minute)}
+		require.NoError(t, handler.store.Chats.Upsert(ctx, &chat))
+	}
+	handler.store.Chats = failingResetChats{ChatRepository: handler.store.Chats, jid: "1111111111@s.whatsapp.net"}
+
+	result, err := handler.HandleTool(ctx, ToolMarkAllRead, map[string]interface{}{})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var got struct {
+		Succeeded int              `json:"succeeded"`
+		Results   []bulkChatResult `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+	assert.Equal(t, 2, got.Succeeded, "both chats were marked read on WhatsApp")
+	require.Len(t, got.Results, 2)
+	assert.Contains(t, got.Results[0].Error, "disk I/O error")
+	assert.Empty(t, got.Results[1].Error)
+
+	stored, err := handler.store.Chats.GetByJID(ctx, "2222222222@s.whatsapp.net")
+	require.NoError(t, err)
+	assert.Zero(t, stored.UnreadCount, "later chats are still reset")
+	assert.Equal(t, []string{"MarkAllRead"}, fb.Calls())
+}
+
+func TestHandler_BulkChatOperations_TooManyChats(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+
+	jids := make([]interface{}, maxBulkChats+1)
+	for i := range jids {
+		jids[i] = fmt.Sprintf("%d@s.whatsapp.net", 1000000000+i)
+	}
+	result, err := handler.HandleTool(context.Background(), ToolArchiveChats, map[string]interface{}{"jids": jids})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "INVALID_INPUT")
+	assert.Empty(t, fb.Calls())
+}
+
+func TestHandler_ReplyToStatus(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	t.Run("missing status", func(t *testing.T) {
+		result, err := handler.HandleTool(ctx, ToolReplyToStatus, map[string]interface{}{
+			"status_id": "unknown",
+			"text":      "nice!",
+		})
+		require.NoError(t, err)
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].Text, "NOT_FOUND")
+		assert.NotContains(t, fb.Calls(), "ReplyToStatus")
+	})
+
+	t.Run("replies to the status owner", func(t *testing.T) {
+		require.NoError(t, handler.store.Status.Store(ctx, &store.StatusUpdate{
+			ID:        "status-1",
+			SenderJID: "1111111111@s.whatsapp.net",
+			Content:   "at the beach",
+			PostedAt:  time.Now(),
+			ExpiresAt: time.Now().Add(24 * time.Hour),
+		}))
+
+		result, err := handler.HandleTool(ctx, ToolReplyToStatus, map[string]interface{}{
+			"status_id": "status-1",
+			"text":      "nice!",
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].Text)
+		assert.Contains(t, result.Content[0].Text, "msg-1111111111@s.whatsapp.net")
+		assert.Equal(t, []string{"1111111111@s.whatsapp.net", "status-1"}, fb.lastQuote)
+	})
+}
+
+func TestHandler_Labels_UnsupportedOnPersonalAccount(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	result, err := handler.HandleTool(ctx, ToolListLabels, map[string]interface{}{})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrUnsupported)
+	assert.Contains(t, result.Content[0].Text, "non-business account")
+
+	result, err = handler.HandleTool(ctx, ToolLabelChat, map[string]interface{}{
+		"jid":      "1234567890@s.whatsapp.net",
+		"label_id": "1",
+	})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrUnsupported)
+	assert.Empty(t, fb.Calls())
+
+	// Business accounts reach the bridge
+	fb.business = true
+	result, err = handler.HandleTool(ctx, ToolUnlabelChat, map[string]interface{}{
+		"jid":      "1234567890@s.whatsapp.net",
+		"label_id": "1",
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Equal(t, []string{"RemoveChatLabel"}, fb.Calls())
+}
+
+func TestHandler_SendMessage_SchemaValidation(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	result, err := handler.HandleTool(ctx, ToolSendMessage, map[string]interface{}{
+		"mentions": "1234567890",
+		"dry_run":  "yes",
+		"context":  map[string]interface{}{"quoted_chat_jid": true},
+	})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+
+	mcpErr, ok := result.StructuredContent.(*MCPError)
+	require.True(t, ok)
+	assert.Equal(t, ErrInvalidInput, mcpErr.Code)
+	assert.Equal(t, "invalid arguments: missing required fields: recipient, message, context.quoted_message_id; "+
+		"wrong types: context.quoted_chat_jid (expected string), dry_run (expected boolean), mentions (expected array)", mcpErr.Message)
+	assert.Empty(t, fb.Calls())
+
+	// Array items and integers are checked too
+	result, err = handler.HandleTool(ctx, ToolSendMessage, map[string]interface{}{
+		"recipient": "1234567890",
+		"message":   []interface{}{"hi"},
+		"mentions":  []interface{}{"1234567890", true},
+	})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "mentions[1] (expected string), message (expected string)")
+
+	result, err = handler.HandleTool(ctx, ToolListChats, map[string]interface{}{"limit": 2.5})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "limit (expected integer)")
+
+	result, err = handler.HandleTool(ctx, ToolListChats, map[string]interface{}{"limit": "ten"})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "limit (expected integer)")
+
+	result, err = handler.HandleTool(ctx, ToolListChats, map[string]interface{}{"limit": 1e300})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "limit (expected integer)")
+}
+
+func TestHandler_Newsletters(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	result, err := handler.HandleTool(ctx, ToolListNewsletters, map[string]interface{}{})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, `"name": "Release notes"`)
+	assert.Contains(t, result.Content[0].Text, `"role": "subscriber"`)
+
+	fetch := func(args map[string]interface{}) (bool, string) {
+		result, err := handler.HandleTool(ctx, ToolGetNewsletterMessages, args)
+		require.NoError(t, err)
+		return result.IsError, result.Content[0].Text
+	}
+
+	isErr, text := fetch(map[string]interface{}{"jid": "120363000000000001@newsletter"})
+	require.False(t, isErr, text)
+	assert.Contains(t, text, "v2 is out")
+	assert.Equal(t, defaultNewsletterMessages, fb.lastNewsletterCount)
+
+	// Argument validation never reaches the bridge
+	fb.mu.Lock()
+	fb.calls = nil
+	fb.mu.Unlock()
+	isErr, text = fetch(map[string]interface{}{})
+	assert.True(t, isErr)
+	assert.Contains(t, text, ErrInvalidInput)
+
+	isErr, text = fetch(map[string]interface{}{"jid": "120363000000000000@g.us"})
+	assert.True(t, isErr)
+	assert.Contains(t, text, ErrInvalidJID)
+
+	for _, count := range []interface{}{0, 101} {
+		isErr, text = fetch(map[string]interface{}{"jid": "120363000000000001@newsletter", "count": count})
+		assert.True(t, isErr)
+		assert.Contains(t, text, "count must be between 1 and 100")
+	}
+	assert.Empty(t, fb.Calls())
+
+	// Accounts without channels get UNSUPPORTED
+	fb.newslettersErr = bridge.ErrNewslettersUnsupported
+	result, err = handler.HandleTool(ctx, ToolListNewsletters, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrUnsupported)
+}
+
+func TestChatUpdateNotifier(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+	chatJID := "1234567890@s.whatsapp.net"
+
+	input := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"whatsapp://chat/` + chatJID + `"}}` + "\n")
+	output := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := mcp.NewServer(input, output, handler, logger)
+	require.NoError(t, server.Run(context.Background()))
+	require.NotContains(t, output.String(), `"error"`)
+
+	notify := ChatUpdateNotifier(server, logger)
+	output.Reset()
+	notify(bridge.NewEvent(bridge.EventMessage, bridge.MessagePayload{ID: "M1", ChatJID: chatJID, Content: "hi"}))
+
+	var note struct {
+		Method string `json:"method"`
+		Params struct {
+			URI string `json:"uri"`
+		} `json:"params"`
+	}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &note), output.String())
+	assert.Equal(t, "notifications/resources/updated", note.Method)
+	assert.Equal(t, "whatsapp://chat/"+chatJID, note.Params.URI)
+
+	// Messages in other chats are not reported
+	output.Reset()
+	notify(bridge.NewEvent(bridge.EventMessage, bridge.MessagePayload{ID: "M2", ChatJID: "999@s.whatsapp.net"}))
+	assert.Zero(t, output.Len())
+}
+
+func TestHandler_ListLinkedDevices(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+
+	result, err := handler.HandleTool(context.Background(), ToolListLinkedDevices, map[string]interface{}{})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Equal(t, []string{"GetLinkedDevices"}, fb.Calls())
+
+	var resp struct {
+		Count   int                      `json:"count"`
+		Devices []map[string]interface{} `json:"devices"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &resp))
+	assert.Equal(t, 3, resp.Count)
+	require.Len(t, resp.Devices, 3)
+	assert.Equal(t, map[string]interface{}{
+		"jid": "1234567890@s.whatsapp.net", "device_id": float64(0), "is_primary": true, "is_current": false, "platform": "android",
+	}, resp.Devices[0])
+	assert.Equal(t, true, resp.Devices[2]["is_current"])
+	assert.NotContains(t, resp.Devices[1], "platform", "unknown platforms are omitted")
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("lorem ipsum ", 30)
+
+	tests := []struct {
+		name    string
+		content string
+		query   string
+		want    string
+	}{
+		{"short content is kept whole", "Meeting at noon", "meeting", "Meeting at noon"},
+		{"match at start", "Invoice attached. " + long, "invoice", ""},
+		{"match in middle", long + "the INVOICE is due" + long, "invoice", ""},
+		{"match at end", long + "see invoice", "Invoice", ""},
+		{"multi-byte runes are not split", strings.Repeat("é", 100) + "invoice" + strings.Repeat("ü", 100), "invoice", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, start, end := snippet(tt.content, tt.query)
+			if tt.want != "" {
+				assert.Equal(t, tt.want, text)
+			}
+			assert.True(t, utf8.ValidString(text), text)
+			assert.LessOrEqual(t, len(strings.Trim(text, ellipsis)), snippetMaxLen)
+			assert.True(t, strings.EqualFold(tt.query, text[start:end]), "highlight %q in %q", text[start:end], text)
+		})
+	}
+
+	// Ellipses mark the cut ends
+	text, _, _ := snippet("Invoice attached. "+long, "invoice")
+	assert.True(t, strings.HasPrefix(text, "Invoice"))
+	assert.True(t, strings.HasSuffix(text, ellipsis))
+	text, _, _ = snippet(long+"see invoice", "invoice")
+	assert.True(t, strings.HasPrefix(text, ellipsis))
+	assert.True(t, strings.HasSuffix(text, "see invoice"))
+	text, start, _ := snippet(long+"the INVOICE is due"+long, "invoice")
+	assert.True(t, strings.HasPrefix(text, ellipsis) && strings.HasSuffix(text, ellipsis))
+	assert.InDelta(t, len(text)/2, start, 12, "match should sit near the middle")
+
+	// No literal match: start of content, empty highlight
+	text, start, end := snippet(long, "lor_m")
+	assert.Equal(t, 0, start)
+	assert.Equal(t, 0, end)
+	assert.True(t, strings.HasPrefix(text, "lorem"))
+}
+
+func TestHandler_SearchMessages(t *testing.T) {
+	handler, storeDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	chatJID := "1234567890@s.whatsapp.net"
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: chatJID}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{ID: "M1", ChatJID: chatJID, Sender: chatJID, Content: "Your invoice is ready", Timestamp: time.Now()}))
+
+	result, err := handler.HandleTool(ctx, ToolSearchMessages, map[string]interface{}{"query": "INVOICE"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var resp struct {
+		Count   int         `json:"count"`
+		Results []searchHit `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &resp))
+	require.Equal(t, 1, resp.Count)
+	hit := resp.Results[0]
+	assert.Equal(t, "M1", hit.ID)
+	assert.Equal(t, "Your invoice is ready", hit.Snippet)
+	assert.Equal(t, "invoice", hit.Snippet[hit.MatchStart:hit.MatchEnd])
+}
+
+func TestHandler_SendMessage_Mentions(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	result, err := handler.HandleTool(ctx, ToolSendMessage, map[string]interface{}{
+		"recipient": "120363000000000000@g.us",
+		"message":   "hello",
+		"mentions":  []interface{}{"+1234567890", "447700900123@s.whatsapp.net"},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Equal(t, []string{"1234567890@s.whatsapp.net", "447700900123@s.whatsapp.net"}, fb.lastMentions)
+
+	result, err = handler.HandleTool(ctx, ToolSendMessage, map[string]interface{}{
+		"recipient": "120363000000000000@g.us",
+		"message":   "hello",
+		"mentions":  []interface{}{"not-a-number"},
+	})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrInvalidJID)
+}
+
+func TestHandler_SendMessage_Quote(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	sourceJID := "120363000000000000@g.us"
+	require.NoError(t, handler.store.Chats.Upsert(ctx, &store.Chat{JID: sourceJID, IsGroup: true}))
+	require.NoError(t, handler.store.Messages.Store(ctx, &store.Message{
+		ID: "Q1", ChatJID: sourceJID, Sender: "447700900123@s.whatsapp.net", Content: "meeting at 3", Timestamp: time.Now(),
+	}))
+
+	// Found: the quote is resolved from the other chat
+	result, err := handler.HandleTool(ctx, ToolSendMessage, map[string]interface{}{
+		"recipient": "1234567890",
+		"message":   "see this",
+		"context":   map[string]interface{}{"quoted_message_id": "Q1", "quoted_chat_jid": sourceJID},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.NotContains(t, result.Content[0].Text, "warning")
+	assert.Equal(t, []string{"SendQuotedMessage"}, fb.Calls())
+	assert.Equal(t, []string{sourceJID, "Q1", "447700900123@s.whatsapp.net", "meeting at 3"}, fb.lastQuote)
+
+	// Not found: sent without the quote, with a warning
+	result, err = handler.HandleTool(ctx, ToolSendMessage, map[string]interface{}{
+		"recipient": "1234567890",
+		"message":   "see this",
+		"context":   map[string]interface{}{"quoted_message_id": "MISSING", "quoted_chat_jid": sourceJID},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, "sent without quote")
+	assert.Equal(t, []string{"SendQuotedMessage", "SendMessage"}, fb.Calls())
+
+	// The quoted message ID is required
+	result, err = handler.HandleTool(ctx, ToolSendMessage, map[string]interface{}{
+		"recipient": "1234567890",
+		"message":   "see this",
+		"context":   map[string]interface{}{"quoted_chat_jid": sourceJID},
+	})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrInvalidInput)
+}
+
+func TestHandler_MarkChatUnread(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	result, err := handler.HandleTool(ctx, ToolMarkChatUnread, map[string]interface{}{"jid": "+1234567890"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, "marked as unread")
+	assert.Equal(t, []string{"MarkChatUnread"}, fb.Calls())
+
+	result, err = handler.HandleTool(ctx, ToolMarkChatUnread, map[string]interface{}{"jid": "not a jid"})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrInvalidJID)
+}
+
+func TestHandler_GetChatSettings_StoredMute(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	mutedUntil := time.Now().Add(8 * time.Hour).UTC().Truncate(time.Second)
+	require.NoError(t, handler.store.Chats.Upsert(ctx, &store.Chat{
+		JID:         "1234567890@s.whatsapp.net",
+		Muted:       true,
+		MutedUntil:  &mutedUntil,
+		Pinned:      true,
+		UnreadCount: 3,
+	}))
+
+	result, err := handler.HandleTool(ctx, ToolGetChatSettings, map[string]interface{}{
+		"jid": "1234567890@s.whatsapp.net",
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var got chatSettings
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+	assert.True(t, got.Muted)
+	require.NotNil(t, got.MutedUntil)
+	assert.True(t, mutedUntil.Equal(*got.MutedUntil))
+	assert.True(t, got.Pinned)
+	assert.False(t, got.Archived)
+	assert.Equal(t, 3, got.UnreadCount)
+	assert.Equal(t, "store", got.Source)
+
+	// Live app-state settings take precedence when whatsmeow has them
+	fb.chatSettings = types.LocalChatSettings{Found: true, Archived: true}
+	result, err = handler.HandleTool(ctx, ToolGetChatSettings, map[string]interface{}{
+		"jid": "1234567890@s.whatsapp.net",
+	})
+	require.NoError(t, err)
+	got = chatSettings{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+	assert.False(t, got.Muted)
+	assert.Nil(t, got.MutedUntil)
+	assert.True(t, got.Archived)
+	assert.Equal(t, 3, got.UnreadCount)
+	assert.Equal(t, "live", got.Source)
+}
+
+func TestHandler_RequestHistorySync_CountBounds(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	for _, count := range []float64{0, -5} {
+		result, err := handler.HandleTool(ctx, ToolRequestHistorySync, map[string]interface{}{
+			"chat_jid": "1234567890@s.whatsapp.net",
+			"count":    count,
+		})
+		require.NoError(t, err)
+		assert.True(t, result.IsError, "count %v should be rejected", count)
+		assert.Contains(t, result.Content[0].Text, ErrInvalidInput)
+	}
+	assert.NotContains(t, fb.Calls(), "RequestHistorySync")
+
+	result, err := handler.HandleTool(ctx, ToolRequestHistorySync, map[string]interface{}{
+		"chat_jid": "1234567890@s.whatsapp.net",
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Equal(t, defaultHistorySyncCount, fb.lastHistoryCount)
+
+	// Oversized requests are capped
+	result, err = handler.HandleTool(ctx, ToolRequestHistorySync, map[string]interface{}{
+		"chat_jid": "1234567890@s.whatsapp.net",
+		"count":    float64(10000),
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Equal(t, maxHistorySyncCount, fb.lastHistoryCount)
+}
+
+func TestHandler_ExportImportContacts_RoundTrip(t *testing.T) {
+	src, srcDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	require.NoError(t, srcDB.Contacts.Upsert(ctx, &store.Contact{JID: "1234567890@s.whatsapp.net", Name: "Alice", IsSaved: true}))
+	require.NoError(t, srcDB.Contacts.Upsert(ctx, &store.Contact{JID: "447700900123@s.whatsapp.net", PushName: "Bob", Blocked: true}))
+
+	path := filepath.Join(t.TempDir(), "contacts.json")
+	result, err := src.HandleTool(ctx, ToolExportContacts, map[string]interface{}{"save_path": path})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	dst, dstDB := setupTestHandler(t)
+	result, err = dst.HandleTool(ctx, ToolImportContacts, map[string]interface{}{"path": path})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var summary struct {
+		Imported int `json:"imported"`
+		Skipped  int `json:"skipped"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &summary))
+	assert.Equal(t, 2, summary.Imported)
+	assert.Equal(t, 0, summary.Skipped)
+
+	alice, err := dstDB.Contacts.GetByJID(ctx, "1234567890@s.whatsapp.net")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", alice.Name)
+	assert.True(t, alice.IsSaved)
+
+	bob, err := dstDB.Contacts.GetByJID(ctx, "447700900123@s.whatsapp.net")
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", bob.PushName)
+	assert.True(t, bob.Blocked)
+}
+
+func TestHandler_ImportContacts_SkipsBadRows(t *testing.T) {
+	handler, storeDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	data := `[
+		{"jid": "1234567890@s.whatsapp.net", "name": "Alice"},
+		{"jid": "not a jid", "name": "Broken"},
+		{"jid": "+447700900123", "name": "Bob"}
+	]`
+	result, err := handler.HandleTool(ctx, ToolImportContacts, map[string]interface{}{"data": data})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var summary struct {
+		Imported int      `json:"imported"`
+		Skipped  int      `json:"skipped"`
+		Errors   []string `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &summary))
+	assert.Equal(t, 2, summary.Imported)
+	assert.Equal(t, 1, summary.Skipped)
+	require.Len(t, summary.Errors, 1)
+	assert.Contains(t, summary.Errors[0], "entry 1")
+
+	count, err := storeDB.Contacts.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Phone numbers are normalized to JIDs on import
+	_, err = storeDB.Contacts.GetByJID(ctx, "447700900123@s.whatsapp.net")
+	assert.NoError(t, err)
+
+	// Input that is not an array is rejected outright
+	result, err = handler.HandleTool(ctx, ToolImportContacts, map[string]interface{}{"data": `{"jid": "x"}`})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestHandler_ErrorResultIsStructured(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	fb.state = state.StateConnecting
+
+	result, err := handler.HandleTool(context.Background(), ToolSendMessage, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"message":   "hello",
+	})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+
+	// Clients see the error as a JSON object next to the legacy text block
+	raw, err := json.Marshal(result)
+	require.NoError(t, err)
+
+	var wire struct {
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		StructuredContent struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+			Retry   bool   `json:"retry"`
+		} `json:"structuredContent"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &wire))
+	assert.Equal(t, ErrNotReady, wire.StructuredContent.Code)
+	assert.True(t, wire.StructuredContent.Retry)
+	assert.NotEmpty(t, wire.StructuredContent.Message)
+
+	require.Len(t, wire.Content, 1)
+	assert.Contains(t, wire.Content[0].Text, ErrNotReady)
+}
+
+func TestHandler_TemporaryBanError(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	liftAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	fb.state = state.StateTemporaryBan
+	fb.ban = &bridge.BanInfo{Code: 101, Reason: "too many messages", ExpiresAt: &liftAt}
+
+	result, err := handler.HandleTool(context.Background(), ToolSendMessage, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"message":   "hello",
+	})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+
+	mcpErr, ok := result.StructuredContent.(*MCPError)
+	require.True(t, ok)
+	assert.Equal(t, ErrTemporaryBan, mcpErr.Code)
+	assert.False(t, mcpErr.Retry)
+	require.NotNil(t, mcpErr.RetryAfter)
+	assert.True(t, liftAt.Equal(*mcpErr.RetryAfter))
+	assert.Contains(t, mcpErr.Message, "too many messages")
+	assert.Contains(t, mcpErr.Message, "2026-03-01T12:00:00Z")
+	assert.Empty(t, fb.Calls(), "nothing should be sent while banned")
+
+	// Without a known lift time the error is still ban-specific
+	fb.ban = nil
+	result, err = handler.HandleTool(context.Background(), ToolSendMessage, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"message":   "hello",
+	})
+	require.NoError(t, err)
+	mcpErr, ok = result.StructuredContent.(*MCPError)
+	require.True(t, ok)
+	assert.Equal(t, ErrTemporaryBan, mcpErr.Code)
+	assert.Nil(t, mcpErr.RetryAfter)
+}
+
+func TestHandler_AuditLog(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	_, err := handler.HandleTool(ctx, ToolSendMessage, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"message":   "secret plans",
+	})
+	require.NoError(t, err)
+	_, err = handler.HandleTool(ctx, ToolListChats, map[string]interface{}{})
+	require.NoError(t, err)
+
+	// A failed mutating call is audited with its error code
+	fb.state = state.StateConnecting
+	_, err = handler.HandleTool(ctx, ToolLeaveGroup, map[string]interface{}{"jid": "120363000000000000@g.us"})
+	require.NoError(t, err)
+
+	result, err := handler.HandleTool(ctx, ToolGetAuditLog, map[string]interface{}{})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.NotContains(t, result.Content[0].Text, "secret plans")
+
+	var out struct {
+		Entries []store.AuditEntry `json:"entries"`
+		Count   int                `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &out))
+	require.Equal(t, 2, out.Count, "list_chats and get_audit_log are read-only")
+
+	assert.Equal(t, ToolLeaveGroup, out.Entries[0].Tool)
+	assert.False(t, out.Entries[0].Success)
+	assert.Equal(t, ErrNotReady, out.Entries[0].ErrorCode)
+	assert.Equal(t, "120363000000000000@g.us", out.Entries[0].TargetJID)
+
+	assert.Equal(t, ToolSendMessage, out.Entries[1].Tool)
+	assert.Equal(t, "1234567890@s.whatsapp.net", out.Entries[1].TargetJID)
+	assert.True(t, out.Entries[1].Success)
+
+	result, err = handler.HandleTool(ctx, ToolGetAuditLog, map[string]interface{}{"tool": ToolSendMessage})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &out))
+	assert.Equal(t, 1, out.Count)
+
+	result, err = handler.HandleTool(ctx, ToolGetAuditLog, map[string]interface{}{"until": "yesterday"})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestHandler_AuditLog_SkipsDryRun(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	result, err := handler.HandleTool(ctx, ToolSendMessage, map[string]interface{}{
+		"recipient": "1234567890@s.whatsapp.net",
+		"message":   "hello",
+		"dry_run":   true,
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Empty(t, fb.Calls())
+
+	entries, err := handler.store.Audit.List(ctx, store.AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, entries, "a dry run sends nothing and is not audited")
+}
+
+func TestHandler_AuditLog_DownloadMedia(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	// Downloads write to disk, so even a refused one is audited
+	fb.state = state.StateDisconnected
+	_, err := handler.HandleTool(ctx, ToolDownloadMedia, map[string]interface{}{
+		"chat_jid":   "1234567890@s.whatsapp.net",
+		"message_id": "IMG1",
+	})
+	require.NoError(t, err)
+
+	entries, err := handler.store.Audit.List(ctx, store.AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ToolDownloadMedia, entries[0].Tool)
+	assert.Equal(t, "1234567890@s.whatsapp.net", entries[0].TargetJID)
+	assert.False(t, entries[0].Success)
+}
+
+func TestSendFailed(t *testing.T) {
+	err := fmt.Errorf("failed to send message: %w", &bridge.CooldownError{JID: "123@s.whatsapp.net", Remaining: 30 * time.Second})
+	mcpErr := sendFailed(err, NewMessageFailedError)
+	assert.Equal(t, ErrRateLimited, mcpErr.Code)
+	assert.True(t, mcpErr.Retry)
+	require.NotNil(t, mcpErr.RetryAfter)
+	assert.WithinDuration(t, time.Now().Add(30*time.Second), *mcpErr.RetryAfter, time.Second)
+	assert.Contains(t, mcpErr.Message, "30s")
+
+	mcpErr = sendFailed(errors.New("boom"), NewMessageFailedError)
+	assert.Equal(t, ErrMessageFailed, mcpErr.Code)
+}
+
+func TestHandler_ListPendingMedia(t *testing.T) {
+	handler, storeDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	for _, jid := range []string{"1111111111@s.whatsapp.net", "2222222222@s.whatsapp.net"} {
+		require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: jid}))
+	}
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{ID: "IMG1", ChatJID: "1111111111@s.whatsapp.net", Sender: "me", MediaType: "image", FileLength: 100, Timestamp: time.Now()}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{ID: "IMG2", ChatJID: "2222222222@s.whatsapp.net", Sender: "me", MediaType: "image", FileLength: 250, Timestamp: time.Now()}))
+
+	type response struct {
+		Media      []store.PendingMedia `json:"media"`
+		Count      int                  `json:"count"`
+		TotalBytes uint64               `json:"total_bytes"`
+	}
+	result, err := handler.HandleTool(ctx, ToolListPendingMedia, map[string]interface{}{})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	var got response
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+	assert.Equal(t, 2, got.Count)
+	assert.Equal(t, uint64(350), got.TotalBytes)
+
+	result, err = handler.HandleTool(ctx, ToolListPendingMedia, map[string]interface{}{"chat_jid": "2222222222"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	got = response{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+	require.Len(t, got.Media, 1)
+	assert.Equal(t, "IMG2", got.Media[0].MessageID)
+
+	result, err = handler.HandleTool(ctx, ToolListPendingMedia, map[string]interface{}{"limit": 1000})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestHandler_GetMediaThumbnail(t *testing.T) {
+	handler, storeDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	chatJID := "1234567890@s.whatsapp.net"
+	thumb := []byte("\xff\xd8\xff fake jpeg")
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: chatJID}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{
+		ID: "IMG1", ChatJID: chatJID, Sender: chatJID, Timestamp: time.Now(), MediaType: "image", Thumbnail: thumb,
+	}))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{
+		ID: "TXT1", ChatJID: chatJID, Sender: chatJID, Timestamp: time.Now(), Content: "no media",
+	}))
+
+	result, err := handler.HandleTool(ctx, ToolGetMediaThumbnail, map[string]interface{}{"chat_jid": chatJID, "message_id": "IMG1"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, "image", result.Content[0].Type)
+	assert.Equal(t, "image/jpeg", result.Content[0].MimeType)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(thumb), result.Content[0].Data)
+
+	for _, id := range []string{"TXT1", "MISSING"} {
+		result, err = handler.HandleTool(ctx, ToolGetMediaThumbnail, map[string]interface{}{"chat_jid": chatJID, "message_id": id})
+		require.NoError(t, err)
+		assert.True(t, result.IsError, id)
+		assert.Contains(t, result.Content[0].Text, ErrNotFound)
+	}
+
+	// A bare phone number finds the stored chat
+	result, err = handler.HandleTool(ctx, ToolGetMediaThumbnail, map[string]interface{}{"chat_jid": "1234567890", "message_id": "IMG1"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(thumb), result.Content[0].Data)
+
+	result, err = handler.HandleTool(ctx, ToolGetMediaThumbnail, map[string]interface{}{"chat_jid": "garbage", "message_id": "IMG1"})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrInvalidJID)
+
+	result, err = handler.HandleTool(ctx, ToolGetMediaThumbnail, map[string]interface{}{"message_id": "IMG1"})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrInvalidInput)
+}
+
+func TestHandler_GetPresence(t *testing.T) {
+	handler, _ := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	result, err := handler.HandleTool(ctx, ToolGetPresence, map[string]interface{}{"jid": "+1234567890"})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrNotFound)
+	assert.Contains(t, result.Content[0].Text, "subscribe_presence")
+
+	require.NoError(t, handler.store.Presence.SetOnline(ctx, "1234567890@s.whatsapp.net", true, time.Time{}))
+
+	result, err = handler.HandleTool(ctx, ToolGetPresence, map[string]interface{}{"jid": "+1234567890"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var got store.Presence
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+	assert.Equal(t, "1234567890@s.whatsapp.net", got.JID)
+	assert.True(t, got.Online)
+	assert.Nil(t, got.LastSeen)
+	assert.False(t, got.Composing)
+}
+
+func TestHandler_DrainWaitsForInFlightSend(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	fb.sendDelay = 100 * time.Millisecond
+	ctx := context.Background()
+
+	type outcome struct {
+		result *mcp.CallToolResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := handler.HandleTool(ctx, ToolSendMessage, map[string]interface{}{
+			"recipient": "+1234567890",
+			"message":   "hello",
+		})
+		done <- outcome{result, err}
+	}()
+	require.Eventually(t, func() bool { return len(fb.Calls()) > 0 }, time.Second, time.Millisecond)
+
+	start := time.Now()
+	require.True(t, handler.Drain(2*time.Second))
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	select {
+	case got := <-done:
+		require.NoError(t, got.err)
+		assert.False(t, got.result.IsError, got.result.Content[0].Text)
+	default:
+		t.Fatal("Drain returned before the in-flight send finished")
+	}
+
+	// Calls after the drain has started are refused
+	result, err := handler.HandleTool(ctx, ToolGetBridgeStatus, nil)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrNotReady)
+	assert.Contains(t, result.Content[0].Text, "shutting_down")
+}
+
+func TestHandler_DrainGivesUpAfterGracePeriod(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	fb.sendDelay = time.Second
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go handler.HandleTool(ctx, ToolSendMessage, map[string]interface{}{
+		"recipient": "+1234567890",
+		"message":   "hello",
+	})
+	require.Eventually(t, func() bool { return len(fb.Calls()) > 0 }, time.Second, time.Millisecond)
+
+	assert.False(t, handler.Drain(20*time.Millisecond))
+}
+
+func TestHandler_GetChatParticipantsPresence(t *testing.T) {
+	handler, storeDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	groupJID := "120363000000000001@g.us"
+	alice := "1111111111@s.whatsapp.net"
+	bob := "2222222222@s.whatsapp.net"
+	require.NoError(t, storeDB.Groups.Upsert(ctx, &store.Group{JID: groupJID, Name: "Book Club"}))
+	require.NoError(t, storeDB.Groups.UpdateParticipants(ctx, groupJID, []store.GroupParticipant{
+		{GroupJID: groupJID, UserJID: alice, Role: "admin"},
+		{GroupJID: groupJID, UserJID: bob, Role: "member"},
+	}))
+
+	lastSeen := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	require.NoError(t, storeDB.Presence.SetOnline(ctx, alice, true, time.Time{}))
+	require.NoError(t, storeDB.Presence.SetComposing(ctx, alice, true))
+	require.NoError(t, storeDB.Presence.SetOnline(ctx, bob, false, lastSeen))
+
+	type response struct {
+		JID          string                `json:"jid"`
+		Source       string                `json:"source"`
+		Participants []participantPresence `json:"participants"`
+		Count        int                   `json:"count"`
+		Online       int                   `json:"online"`
+		Warning      string                `json:"warning"`
+	}
+	call := func() response {
+		t.Helper()
+		result, err := handler.HandleTool(ctx, ToolGetChatParticipantsPresence, map[string]interface{}{"jid": groupJID})
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].Text)
+		var got response
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+		return got
+	}
+
+	got := call()
+	assert.Equal(t, groupJID, got.JID)
+	assert.Equal(t, "store", got.Source)
+	assert.Equal(t, 2, got.Count)
+	assert.Equal(t, 1, got.Online)
+	assert.Empty(t, got.Warning)
+
+	byJID := map[string]participantPresence{}
+	for _, p := range got.Participants {
+		byJID[p.JID] = p
+	}
+	require.Len(t, byJID, 2)
+	assert.True(t, byJID[alice].Known)
+	assert.True(t, byJID[alice].Online)
+	assert.True(t, byJID[alice].Composing)
+	assert.Nil(t, byJID[alice].LastSeen)
+	assert.True(t, byJID[bob].Known)
+	assert.False(t, byJID[bob].Online)
+	require.NotNil(t, byJID[bob].LastSeen)
+	assert.True(t, lastSeen.Equal(*byJID[bob].LastSeen))
+
+	// A participant nobody has heard from is listed but flagged
+	carol := "3333333333@s.whatsapp.net"
+	require.NoError(t, storeDB.Groups.UpdateParticipants(ctx, groupJID, []store.GroupParticipant{
+		{GroupJID: groupJID, UserJID: alice, Role: "admin"},
+		{GroupJID: groupJID, UserJID: bob, Role: "member"},
+		{GroupJID: groupJID, UserJID: carol, Role: "member"},
+	}))
+	got = call()
+	assert.Equal(t, 3, got.Count)
+	assert.Contains(t, got.Warning, "1 of 3")
+
+	// Contact JIDs are not groups
+	result, err := handler.HandleTool(ctx, ToolGetChatParticipantsPresence, map[string]interface{}{"jid": alice})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestHandler_GetChatParticipantsPresence_Subscribes(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	require.NoError(t, handler.store.Presence.SetOnline(ctx, "1111111111@s.whatsapp.net", true, time.Time{}))
+
+	result, err := handler.HandleTool(ctx, ToolGetChatParticipantsPresence, map[string]interface{}{"jid": "120363000000000001@g.us"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, `"source": "live"`)
+	assert.Contains(t, result.Content[0].Text, `"subscribed": 1`)
+	assert.Contains(t, result.Content[0].Text, `"subscribe_failed": 1`)
+	assert.Contains(t, result.Content[0].Text, "only for contacts who share it")
+	assert.Equal(t, []string{"SubscribeGroupPresence"}, fb.Calls())
+}
+
+func TestHandler_SendMessages(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	batch := []interface{}{
+		map[string]interface{}{"recipient": "+1111111111", "message": "one"},
+		map[string]interface{}{"recipient": "2222222222@s.whatsapp.net", "message": "two"},
+		map[string]interface{}{"recipient": "3333333333@s.whatsapp.net", "message": "three"},
+	}
+
+	type response struct {
+		Success bool          `json:"success"`
+		Sent    int           `json:"sent"`
+		Failed  int           `json:"failed"`
+		Results []batchResult `json:"results"`
+	}
+	send := func(args map[string]interface{}) response {
+		t.Helper()
+		result, err := handler.HandleTool(ctx, ToolSendMessages, args)
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].Text)
+		var got response
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+		return got
+	}
+
+	got := send(map[string]interface{}{"messages": batch})
+	assert.True(t, got.Success)
+	assert.Equal(t, 3, got.Sent)
+	assert.Equal(t, "1111111111@s.whatsapp.net", got.Results[0].Recipient)
+	assert.Equal(t, "msg-1111111111@s.whatsapp.net", got.Results[0].MessageID)
+
+	fb.failJIDs = map[string]bool{"2222222222@s.whatsapp.net": true}
+
+	got = send(map[string]interface{}{"messages": batch})
+	assert.False(t, got.Success)
+	assert.Equal(t, 2, got.Sent)
+	assert.Equal(t, 1, got.Failed)
+	assert.False(t, got.Results[1].Success)
+	assert.Equal(t, "recipient unreachable", got.Results[1].Error)
+	assert.True(t, got.Results[2].Success)
+
+	got = send(map[string]interface{}{"messages": batch, "continue_on_error": false})
+	assert.Equal(t, 1, got.Sent)
+	assert.Equal(t, 2, got.Failed)
+	assert.False(t, got.Results[2].Success)
+
+	// A malformed entry rejects the whole batch before anything is sent
+	result, err := handler.HandleTool(ctx, ToolSendMessages, map[string]interface{}{
+		"messages": []interface{}{
+			map[string]interface{}{"recipient": "+1111111111", "message": "one"},
+			map[string]interface{}{"recipient": "+1111111111"},
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "messages[1].message")
+}
+
+func TestHandler_GetGroupInviteInfo_MalformedLink(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	for _, link := range []string{
+		"https://example.com/AbCdEfGhIjKlMnOpQrStUv",
+		"https://chat.whatsapp.com/",
+		"https://chat.whatsapp.com/short",
+		"not a link at all",
+	} {
+		result, err := handler.HandleTool(ctx, ToolGetGroupInviteInfo, map[string]interface{}{
+			"invite_link": link,
+		})
+		require.NoError(t, err)
+		assert.True(t, result.IsError, "%q should be rejected", link)
+		assert.Contains(t, result.Content[0].Text, ErrInvalidInput)
+	}
+	assert.NotContains(t, fb.Calls(), "GetGroupInfoFromLink")
+}
+
+func TestHandler_GetGroupInfo_DTO(t *testing.T) {
+	handler, _ := setupTestHandlerWithBridge(t)
+
+	result, err := handler.HandleTool(context.Background(), ToolGetGroupInfo, map[string]interface{}{
+		"jid": "120363000000000000@g.us",
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	// Field names are part of the documented schema
+	var raw map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &raw))
+	for _, field := range []string{"jid", "name", "topic", "owner", "created_at", "participants", "settings"} {
+		assert.Contains(t, raw, field)
+	}
+	var settings map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw["settings"], &settings))
+	assert.Equal(t, map[string]interface{}{
+		"announce_only":          false,
+		"locked":                 true,
+		"join_approval_required": false,
+		"admins_only_add":        true,
+		"disappearing_seconds":   float64(604800),
+		"is_community":           false,
+	}, settings)
+
+	var info GroupInfoDTO
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &info))
+	assert.Equal(t, "120363000000000000@g.us", info.JID)
+	assert.Equal(t, "Book Club", info.Name)
+	assert.Equal(t, "1111111111@s.whatsapp.net", info.Owner)
+	require.NotNil(t, info.CreatedAt)
+	assert.Equal(t, []GroupParticipantDTO{
+		{JID: "1111111111@s.whatsapp.net", Role: "superadmin"},
+		{JID: "2222222222@s.whatsapp.net", Role: "admin"},
+		{JID: "98765432101234@lid", Phone: "3333333333@s.whatsapp.net", Role: "member"},
+	}, info.Participants)
+}
+
+func TestHandler_GetGroupInviteInfo(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+
+	result, err := handler.HandleTool(context.Background(), ToolGetGroupInviteInfo, map[string]interface{}{
+		"invite_link": "https://chat.whatsapp.com/AbCdEfGhIjKlMnOpQrStUv",
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Equal(t, "AbCdEfGhIjKlMnOpQrStUv", fb.lastInviteCode)
+
+	var info map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &info))
+	assert.Equal(t, "Book Club", info["name"])
+	assert.Equal(t, "Monthly reads", info["topic"])
+	assert.Equal(t, float64(12), info["participant_count"])
+	assert.Equal(t, "1234567890@s.whatsapp.net", info["creator"])
+}
+
+func TestHandler_JoinRequests_RequiredArgs(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		tool string
+		args map[string]interface{}
+		code string
+	}{
+		{ToolListJoinRequests, map[string]interface{}{}, ErrInvalidInput},
+		{ToolListJoinRequests, map[string]interface{}{"group_jid": "1234567890@s.whatsapp.net"}, ErrInvalidJID},
+		{ToolApproveJoinRequest, map[string]interface{}{"participants": []interface{}{"1234567890"}}, ErrInvalidInput},
+		{ToolApproveJoinRequest, map[string]interface{}{"group_jid": "120363000000000000@g.us"}, ErrInvalidInput},
+		{ToolRejectJoinRequest, map[string]interface{}{"group_jid": "120363000000000000@g.us", "participants": []interface{}{}}, ErrInvalidInput},
+		{ToolRejectJoinRequest, map[string]interface{}{"group_jid": "120363000000000000@g.us", "participants": []interface{}{"not-a-jid"}}, ErrInvalidJID},
+	}
+	for _, tc := range cases {
+		result, err := handler.HandleTool(ctx, tc.tool, tc.args)
+		require.NoError(t, err)
+		assert.True(t, result.IsError, "%s %v should be rejected", tc.tool, tc.args)
+		assert.Contains(t, result.Content[0].Text, tc.code)
+	}
+	assert.Empty(t, fb.Calls())
+
+	result, err := handler.HandleTool(ctx, ToolApproveJoinRequest, map[string]interface{}{
+		"group_jid":    "120363000000000000@g.us",
+		"participants": []interface{}{"1234567890"},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Equal(t, []string{"ApproveGroupJoinRequest"}, fb.Calls())
+}
+
+func TestHandler_SetGroupAnnounceLocked_UpdatesStore(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+	groupJID := "120363000000000000@g.us"
+
+	result, err := handler.HandleTool(ctx, ToolSetGroupAnnounce, map[string]interface{}{"group_jid": groupJID})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	result, err = handler.HandleTool(ctx, ToolSetGroupLocked, map[string]interface{}{"group_jid": groupJID, "locked": true})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	group, err := handler.store.Groups.GetByJID(ctx, groupJID)
+	require.NoError(t, err)
+	assert.True(t, group.IsAnnounce)
+	assert.True(t, group.IsLocked)
+
+	result, err = handler.HandleTool(ctx, ToolSetGroupAnnounce, map[string]interface{}{"group_jid": groupJID, "announce": false})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	group, err = handler.store.Groups.GetByJID(ctx, groupJID)
+	require.NoError(t, err)
+	assert.False(t, group.IsAnnounce)
+	assert.True(t, group.IsLocked, "toggling announce must not reset locked")
+	assert.Equal(t, []string{"SetGroupAnnounce", "SetGroupLocked", "SetGroupAnnounce"}, fb.Calls())
+}
+
+func TestHandler_GetSyncProgress(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	percent := 65
+	last := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	fb.state = state.StateSyncing
+	fb.syncProgress = bridge.SyncProgress{
+		InProgress:    true,
+		Batches:       4,
+		Conversations: 12,
+		Messages:      340,
+		Percent:       &percent,
+		SyncType:      "INITIAL_BOOTSTRAP",
+		LastBatchAt:   &last,
+	}
+
+	// Available before the bridge is ready
+	result, err := handler.HandleTool(ctx, ToolGetSyncProgress, map[string]interface{}{})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var got bridge.SyncProgress
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+	assert.Equal(t, state.StateSyncing, got.State)
+	assert.True(t, got.InProgress)
+	assert.Equal(t, 4, got.Batches)
+	assert.Equal(t, 12, got.Conversations)
+	assert.Equal(t, 340, got.Messages)
+	require.NotNil(t, got.Percent)
+	assert.Equal(t, 65, *got.Percent)
+	require.NotNil(t, got.LastBatchAt)
+	assert.True(t, got.LastBatchAt.Equal(last))
+}
+
+func TestHandler_RemoveGroupPhoto(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	result, err := handler.HandleTool(ctx, ToolRemoveGroupPhoto, map[string]interface{}{"group_jid": "120363000000000000@g.us"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, "Group photo removed")
+
+	// Only groups have a group photo
+	result, err = handler.HandleTool(ctx, ToolRemoveGroupPhoto, map[string]interface{}{"group_jid": "1234567890@s.whatsapp.net"})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	result, err = handler.HandleTool(ctx, ToolRemoveGroupPhoto, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	assert.Equal(t, []string{"RemoveGroupPhoto"}, fb.Calls())
+}
+
+func TestHandler_SetDisappearingMessages(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	cases := map[string]time.Duration{
+		"24h": 24 * time.Hour,
+		"7d":  7 * 24 * time.Hour,
+		"90d": 90 * 24 * time.Hour,
+		"off": 0,
+		"OFF": 0,
+	}
+	for duration, want := range cases {
+		fb.lastDisappearing = -1
+		result, err := handler.HandleTool(ctx, ToolSetDisappearingMessages, map[string]interface{}{
+			"jid":      "1234567890",
+			"duration": duration,
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].Text)
+		assert.Equal(t, want, fb.lastDisappearing, "duration %q", duration)
+	}
+
+	calls := len(fb.Calls())
+	for _, duration := range []string{"", "1h", "30d", "forever"} {
+		result, err := handler.HandleTool(ctx, ToolSetDisappearingMessages, map[string]interface{}{
+			"jid":      "1234567890",
+			"duration": duration,
+		})
+		require.NoError(t, err)
+		assert.True(t, result.IsError, "duration %q should be rejected", duration)
+		assert.Contains(t, result.Content[0].Text, ErrInvalidInput)
+	}
+	assert.Len(t, fb.Calls(), calls)
+}
+
+func TestHandler_GetInviteLink_Refresh(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	type response struct {
+		InviteLink string    `json:"invite_link"`
+		FetchedAt  time.Time `json:"fetched_at"`
+		Cached     bool      `json:"cached"`
+	}
+	get := func(args map[string]interface{}) response {
+		t.Helper()
+		result, err := handler.HandleTool(ctx, ToolGetInviteLink, args)
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].Text)
+		var got response
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+		return got
+	}
+
+	got := get(map[string]interface{}{"group_jid": "120363000000000001@g.us"})
+	assert.False(t, fb.lastRefresh)
+	assert.True(t, got.Cached)
+	assert.Equal(t, "https://chat.whatsapp.com/AbCdEfGhIjK", got.InviteLink)
+	assert.False(t, got.FetchedAt.IsZero())
+
+	got = get(map[string]interface{}{"group_jid": "120363000000000001@g.us", "refresh": true})
+	assert.True(t, fb.lastRefresh)
+	assert.False(t, got.Cached)
+}
+
+func TestHandler_GetCommonGroups_StoreFallback(t *testing.T) {
+	handler, storeDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	shared := "1234567890@s.whatsapp.net"
+	other := "1111111111@s.whatsapp.net"
+	groups := map[string][]string{
+		"120363000000000001@g.us": {shared, other},
+		"120363000000000002@g.us": {shared},
+		"120363000000000003@g.us": {other},
+	}
+	names := map[string]string{
+		"120363000000000001@g.us": "Book Club",
+		"120363000000000002@g.us": "Climbing",
+		"120363000000000003@g.us": "Work",
+	}
+	for groupJID, members := range groups {
+		require.NoError(t, storeDB.Groups.Upsert(ctx, &store.Group{JID: groupJID, Name: names[groupJID]}))
+		var participants []store.GroupParticipant
+		for _, m := range members {
+			participants = append(participants, store.GroupParticipant{GroupJID: groupJID, UserJID: m, Role: "member"})
+		}
+		require.NoError(t, storeDB.Groups.UpdateParticipants(ctx, groupJID, participants))
+	}
+
+	result, err := handler.HandleTool(ctx, ToolGetCommonGroups, map[string]interface{}{"jid": "+1 234 567 890"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var resp struct {
+		JID    string        `json:"jid"`
+		Source string        `json:"source"`
+		Groups []commonGroup `json:"groups"`
+		Count  int           `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &resp))
+	assert.Equal(t, shared, resp.JID)
+	assert.Equal(t, "store", resp.Source)
+	assert.Equal(t, 2, resp.Count)
+	assert.Equal(t, []commonGroup{
+		{JID: "120363000000000001@g.us", Name: "Book Club"},
+		{JID: "120363000000000002@g.us", Name: "Climbing"},
+	}, resp.Groups)
+
+	// Group JIDs are not contacts
+	result, err = handler.HandleTool(ctx, ToolGetCommonGroups, map[string]interface{}{"jid": "120363000000000001@g.us"})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, ErrInvalidJID)
+}
+
+func TestHandler_GetCommonGroups_Live(t *testing.T) {
+	handler, fb := setupTestHandlerWithBridge(t)
+	ctx := context.Background()
+
+	require.NoError(t, handler.store.Groups.Upsert(ctx, &store.Group{JID: "120363000000000001@g.us", Name: "Book Club"}))
+
+	result, err := handler.HandleTool(ctx, ToolGetCommonGroups, map[string]interface{}{"jid": "1234567890@s.whatsapp.net"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, `"source": "live"`)
+	assert.Contains(t, result.Content[0].Text, `"name": "Book Club"`)
+	assert.Equal(t, []string{"GetCommonGroups"}, fb.Calls())
+}
+
+func TestHandler_HandleListMessages_SenderName(t *testing.T) {
+	handler, storeDB := setupTestHandler(t)
+	ctx := context.Background()
+
+	chatJID := "1234567890@s.whatsapp.net"
+	require.NoError(t, storeDB.Chats.Upsert(ctx, &store.Chat{JID: chatJID}))
+	require.NoError(t, storeDB.Contacts.UpsertPushName(ctx, chatJID, "Alice"))
+	require.NoError(t, storeDB.Messages.Store(ctx, &store.Message{ID: "in", ChatJID: chatJID, Sender: chatJID, Content: "hi", Timestamp: time.Now()}))
+
+	result, err := handler.HandleTool(ctx, ToolListMessages, map[string]interface{}{"chat_jid": chatJID})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var messages []map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &messages))
+	require.Len(t, messages, 1)
+	assert.Equal(t, "Alice", messages[0]["sender_name"])
+}
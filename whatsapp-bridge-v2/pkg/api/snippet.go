@@ -0,0 +1,92 @@
+package api
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// snippetMaxLen caps the length, in bytes, of a search result snippet,
+// not counting the ellipses added where content was cut.
+const snippetMaxLen = 120
+
+const ellipsis = "…"
+
+// snippet returns a window of content of at most snippetMaxLen bytes
+// centred on the first case-insensitive match of query, along with the
+// byte offsets of the match within the returned snippet. Cuts fall on rune
+// boundaries and are marked with an ellipsis. If query does not occur in
+// content (LIKE wildcards can match text that Index cannot) the snippet is
+// the start of content and the offsets are both zero.
+func snippet(content, query string) (text string, start, end int) {
+	idx := indexFold(content, query)
+	if idx < 0 || query == "" {
+		if len(content) <= snippetMaxLen {
+			return content, 0, 0
+		}
+		return content[:runeFloor(content, snippetMaxLen)] + ellipsis, 0, 0
+	}
+	matchEnd := idx + len(query)
+
+	// Split the room left by the match evenly between both sides, giving
+	// any side's unused share to the other.
+	room := snippetMaxLen - len(query)
+	if room < 0 {
+		room = 0
+	}
+	before, after := room/2, room-room/2
+	if idx < before {
+		after += before - idx
+		before = idx
+	}
+	if tail := len(content) - matchEnd; tail < after {
+		before += after - tail
+		after = tail
+	}
+
+	from := runeCeil(content, max(idx-before, 0))
+	to := runeFloor(content, min(matchEnd+after, len(content)))
+	text = content[from:to]
+	start, end = idx-from, matchEnd-from
+	if from > 0 {
+		text = ellipsis + text
+		start += len(ellipsis)
+		end += len(ellipsis)
+	}
+	if to < len(content) {
+		text += ellipsis
+	}
+	return text, start, end
+}
+
+// indexFold is strings.Index ignoring ASCII case, matching SQLite's LIKE.
+// Only ASCII letters are folded so byte offsets stay valid in s.
+func indexFold(s, substr string) int {
+	return strings.Index(asciiLower(s), asciiLower(substr))
+}
+
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + ('a' - 'A')
+		}
+	}
+	return string(b)
+}
+
+// runeFloor moves i back to the start of the rune containing it.
+func runeFloor(s string, i int) int {
+	for i > 0 && i < len(s) && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
+
+// runeCeil moves i forward to the start of the next rune unless it is
+// already at one.
+func runeCeil(s string, i int) int {
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"log/slog"
+
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/internal/bridge"
+	"github.com/ihiteshgupta/whatsapp-mcp/whatsapp-bridge-v2/pkg/mcp"
+)
+
+// ChatUpdateNotifier returns a bridge event listener that tells the MCP
+// client when a message arrives in, or is sent to, a chat it subscribed to
+// with resources/subscribe. Register it with Bridge.OnEvent.
+func ChatUpdateNotifier(server *mcp.Server, log *slog.Logger) func(bridge.Event) {
+	return func(evt bridge.Event) {
+		msg, ok := evt.Payload.(bridge.MessagePayload)
+		if !ok || evt.Type != bridge.EventMessage {
+			return
+		}
+		if err := server.NotifyResourceUpdated(mcp.ChatResourceURI(msg.ChatJID)); err != nil {
+			log.Warn("failed to send resource update", "chat", msg.ChatJID, "error", err)
+		}
+	}
+}
@@ -6,40 +6,61 @@ import (
 
 // Tool name constants
 const (
-	// Messaging (8)
+	// Messaging (12)
 	ToolSendMessage    = "send_message"
 	ToolReplyToMessage = "reply_to_message"
 	ToolForwardMessage = "forward_message"
 	ToolEditMessage    = "edit_message"
 	ToolDeleteMessage  = "delete_message"
 	ToolReactToMessage = "react_to_message"
+	ToolGetReactions   = "get_reactions"
+	ToolMessageExists  = "message_exists"
 	ToolStarMessage    = "star_message"
 	ToolUnstarMessage  = "unstar_message"
+	ToolSendBroadcast  = "send_broadcast"
+	ToolSendMessages   = "send_messages"
 
-	// Chats (10)
-	ToolListChats     = "list_chats"
-	ToolGetChat       = "get_chat"
-	ToolListMessages  = "list_messages"
-	ToolArchiveChat   = "archive_chat"
-	ToolUnarchiveChat = "unarchive_chat"
-	ToolPinChat       = "pin_chat"
-	ToolUnpinChat     = "unpin_chat"
-	ToolMuteChat      = "mute_chat"
-	ToolUnmuteChat    = "unmute_chat"
-	ToolMarkChatRead  = "mark_chat_read"
-	ToolDeleteChat    = "delete_chat"
+	// Chats (24)
+	ToolListChats               = "list_chats"
+	ToolGetChat                 = "get_chat"
+	ToolGetChatByPhone          = "get_chat_by_phone"
+	ToolGetChatSettings         = "get_chat_settings"
+	ToolGetChatStats            = "get_chat_stats"
+	ToolRequestHistorySync      = "request_history_sync"
+	ToolListMessages            = "list_messages"
+	ToolSearchMessages          = "search_messages"
+	ToolArchiveChat             = "archive_chat"
+	ToolUnarchiveChat           = "unarchive_chat"
+	ToolArchiveChats            = "archive_chats"
+	ToolPinChat                 = "pin_chat"
+	ToolUnpinChat               = "unpin_chat"
+	ToolMuteChat                = "mute_chat"
+	ToolUnmuteChat              = "unmute_chat"
+	ToolMuteChats               = "mute_chats"
+	ToolSetDisappearingMessages = "set_disappearing_messages"
+	ToolMarkChatRead            = "mark_chat_read"
+	ToolMarkChatUnread          = "mark_chat_unread"
+	ToolMarkAllRead             = "mark_all_read"
+	ToolDeleteChat              = "delete_chat"
+	ToolListLabels              = "list_labels"
+	ToolLabelChat               = "label_chat"
+	ToolUnlabelChat             = "unlabel_chat"
 
-	// Contacts (6)
+	// Contacts (9)
 	ToolSearchContacts       = "search_contacts"
+	ToolListContacts         = "list_contacts"
 	ToolGetContact           = "get_contact"
 	ToolBlockContact         = "block_contact"
 	ToolUnblockContact       = "unblock_contact"
 	ToolGetBlockedContacts   = "get_blocked_contacts"
 	ToolCheckPhoneRegistered = "check_phone_registered"
+	ToolExportContacts       = "export_contacts"
+	ToolImportContacts       = "import_contacts"
 
-	// Groups (13)
+	// Groups (21)
 	ToolCreateGroup        = "create_group"
 	ToolGetGroupInfo       = "get_group_info"
+	ToolGetCommonGroups    = "get_common_groups"
 	ToolLeaveGroup         = "leave_group"
 	ToolAddGroupMembers    = "add_group_members"
 	ToolRemoveGroupMembers = "remove_group_members"
@@ -47,42 +68,69 @@ const (
 	ToolDemoteAdmin        = "demote_admin"
 	ToolSetGroupName       = "set_group_name"
 	ToolSetGroupTopic      = "set_group_topic"
+	ToolSetGroupAnnounce   = "set_group_announce"
+	ToolSetGroupLocked     = "set_group_locked"
 	ToolSetGroupPhoto      = "set_group_photo"
+	ToolRemoveGroupPhoto   = "remove_group_photo"
 	ToolGetInviteLink      = "get_invite_link"
 	ToolRevokeInviteLink   = "revoke_invite_link"
 	ToolJoinViaInvite      = "join_via_invite"
+	ToolGetGroupInviteInfo = "get_group_invite_info"
+	ToolListJoinRequests   = "list_join_requests"
+	ToolApproveJoinRequest = "approve_join_request"
+	ToolRejectJoinRequest  = "reject_join_request"
 
-	// Media (7)
-	ToolSendImage       = "send_image"
-	ToolSendVideo       = "send_video"
-	ToolSendAudio       = "send_audio"
-	ToolSendDocument    = "send_document"
-	ToolSendLocation    = "send_location"
-	ToolSendContactCard = "send_contact_card"
-	ToolDownloadMedia   = "download_media"
+	// Media (13)
+	ToolSendImage         = "send_image"
+	ToolSendVideo         = "send_video"
+	ToolSendGIF           = "send_gif"
+	ToolSendAudio         = "send_audio"
+	ToolSendDocument      = "send_document"
+	ToolSendFile          = "send_file"
+	ToolSendLocation      = "send_location"
+	ToolSendLiveLocation  = "send_live_location"
+	ToolSendContactCard   = "send_contact_card"
+	ToolSendContacts      = "send_contacts"
+	ToolDownloadMedia     = "download_media"
+	ToolGetMediaThumbnail = "get_media_thumbnail"
+	ToolListPendingMedia  = "list_pending_media"
 
-	// Presence (5)
-	ToolSubscribePresence = "subscribe_presence"
-	ToolSendTyping        = "send_typing"
-	ToolSendRecording     = "send_recording"
-	ToolSetOnline         = "set_online"
-	ToolSetOffline        = "set_offline"
+	// Presence (7)
+	ToolSubscribePresence           = "subscribe_presence"
+	ToolGetPresence                 = "get_presence"
+	ToolGetChatParticipantsPresence = "get_chat_participants_presence"
+	ToolSendTyping                  = "send_typing"
+	ToolSendRecording               = "send_recording"
+	ToolSetOnline                   = "set_online"
+	ToolSetOffline                  = "set_offline"
 
-	// Status (4)
+	// Status (5)
 	ToolPostTextStatus   = "post_text_status"
 	ToolPostImageStatus  = "post_image_status"
 	ToolGetStatusUpdates = "get_status_updates"
 	ToolDeleteStatus     = "delete_status"
+	ToolReplyToStatus    = "reply_to_status"
 
-	// Bridge (2)
+	// Channels (2)
+	ToolListNewsletters       = "list_newsletters"
+	ToolGetNewsletterMessages = "get_newsletter_messages"
+
+	// Bridge (9)
 	ToolGetBridgeStatus      = "get_bridge_status"
+	ToolPingWhatsApp         = "ping_whatsapp"
 	ToolGetConnectionHistory = "get_connection_history"
+	ToolSelfTest             = "self_test"
+	ToolListLinkedDevices    = "list_linked_devices"
+	ToolGetAuditLog          = "get_audit_log"
+	ToolGetToolUsageStats    = "get_tool_usage_stats"
+	ToolRetryFailedStores    = "retry_failed_stores"
+	ToolGetSyncProgress      = "get_sync_progress"
 )
 
-// GetAllTools returns all 55 tool definitions.
+// GetAllTools returns all 102 tool definitions.
 func GetAllTools() []mcp.Tool {
 	return []mcp.Tool{
-		// ============ MESSAGING (8) ============
+		// ============ MESSAGING (12) ============
 		{
 			Name:        ToolSendMessage,
 			Description: "Send a text message to a WhatsApp contact or group",
@@ -90,20 +138,68 @@ func GetAllTools() []mcp.Tool {
 				"type": "object",
 				"properties": map[string]interface{}{
 					"recipient": prop("string", "Phone number (e.g., +1234567890) or JID of the recipient"),
-					"message":   prop("string", "Text message to send"),
+					"message":   prop("string", "Text message to send. In groups, @<phone> tokens (e.g. @1234567890) become mentions"),
+					"mentions":  propArray("string", "Optional phone numbers or JIDs to mention (groups only)"),
+					"context": map[string]interface{}{
+						"type":        "object",
+						"description": "Optional message to quote, which may be in a different chat. If it is not in the local store the message is sent without the quote and a warning is returned",
+						"properties": map[string]interface{}{
+							"quoted_message_id": prop("string", "ID of the message to quote"),
+							"quoted_chat_jid":   prop("string", "Chat the quoted message belongs to (defaults to the recipient)"),
+						},
+						"required": []string{"quoted_message_id"},
+					},
+					"dry_run":         propBool("Validate inputs and report what would be sent, without sending"),
+					"idempotency_key": prop("string", "Optional key; retrying with the same key within 10 minutes returns the original message_id instead of sending again"),
 				},
 				"required": []string{"recipient", "message"},
 			},
 		},
+		{
+			Name:        ToolSendBroadcast,
+			Description: "Send the same text message to several recipients individually, like a broadcast list. Failures are reported per recipient",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"recipients": propArray("string", "Phone numbers or JIDs of the recipients (max 256)"),
+					"message":    prop("string", "Text message to send"),
+				},
+				"required": []string{"recipients", "message"},
+			},
+		},
+		{
+			Name:        ToolSendMessages,
+			Description: "Send a batch of text messages, each to its own recipient, in order. Results are reported per message",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"messages": map[string]interface{}{
+						"type":        "array",
+						"description": "Messages to send (max 100)",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"recipient": prop("string", "Phone number or JID of the recipient"),
+								"message":   prop("string", "Text message to send"),
+							},
+							"required": []string{"recipient", "message"},
+						},
+					},
+					"continue_on_error": propBool("Keep sending after a failure (default: true). When false, the remaining messages are skipped"),
+				},
+				"required": []string{"messages"},
+			},
+		},
 		{
 			Name:        ToolReplyToMessage,
 			Description: "Reply to a specific message in a chat",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
-					"chat_jid":   prop("string", "JID of the chat"),
-					"message_id": prop("string", "ID of the message to reply to"),
-					"message":    prop("string", "Reply message text"),
+					"chat_jid":        prop("string", "JID of the chat"),
+					"message_id":      prop("string", "ID of the message to reply to"),
+					"message":         prop("string", "Reply message text"),
+					"idempotency_key": prop("string", "Optional key; retrying with the same key within 10 minutes returns the original message_id instead of sending again"),
 				},
 				"required": []string{"chat_jid", "message_id", "message"},
 			},
@@ -117,6 +213,7 @@ func GetAllTools() []mcp.Tool {
 					"source_chat_jid": prop("string", "JID of the source chat"),
 					"message_id":      prop("string", "ID of the message to forward"),
 					"target_jid":      prop("string", "JID of the target chat"),
+					"idempotency_key": prop("string", "Optional key; retrying with the same key within 10 minutes returns the original message_id instead of sending again"),
 				},
 				"required": []string{"source_chat_jid", "message_id", "target_jid"},
 			},
@@ -142,24 +239,48 @@ func GetAllTools() []mcp.Tool {
 				"properties": map[string]interface{}{
 					"chat_jid":     prop("string", "JID of the chat"),
 					"message_id":   prop("string", "ID of the message to delete"),
-					"for_everyone": propBool("Delete for everyone (true) or just for me (false)"),
+					"for_everyone": propBool("Delete for everyone (true) or just for me (false), which removes the stored copy"),
 				},
 				"required": []string{"chat_jid", "message_id"},
 			},
 		},
 		{
 			Name:        ToolReactToMessage,
-			Description: "Add an emoji reaction to a message",
+			Description: "Add an emoji reaction to a message, or remove yours with an empty emoji",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
 					"chat_jid":   prop("string", "JID of the chat"),
 					"message_id": prop("string", "ID of the message"),
-					"emoji":      prop("string", "Emoji reaction (e.g., '👍', '❤️', '😂')"),
+					"emoji":      prop("string", "A single emoji (e.g., '👍', '❤️', '😂'), or an empty string to remove your reaction"),
 				},
 				"required": []string{"chat_jid", "message_id", "emoji"},
 			},
 		},
+		{
+			Name:        ToolGetReactions,
+			Description: "List the emoji reactions on a stored message, one per sender. Returns an empty list when the message has no reactions.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"chat_jid":   prop("string", "JID of the chat"),
+					"message_id": prop("string", "ID of the message"),
+				},
+				"required": []string{"chat_jid", "message_id"},
+			},
+		},
+		{
+			Name:        ToolMessageExists,
+			Description: "Check whether a message has been synced to the local store before referring to it, e.g. in reply_to_message or download_media. Returns exists plus is_from_me, has_media and is_deleted, but not the message content",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"chat_jid":   prop("string", "JID of the chat"),
+					"message_id": prop("string", "ID of the message"),
+				},
+				"required": []string{"chat_jid", "message_id"},
+			},
+		},
 		{
 			Name:        ToolStarMessage,
 			Description: "Star a message for later reference",
@@ -185,7 +306,7 @@ func GetAllTools() []mcp.Tool {
 			},
 		},
 
-		// ============ CHATS (10) ============
+		// ============ CHATS (24) ============
 		{
 			Name:        ToolListChats,
 			Description: "List all WhatsApp chats with metadata",
@@ -199,7 +320,29 @@ func GetAllTools() []mcp.Tool {
 		},
 		{
 			Name:        ToolGetChat,
-			Description: "Get details of a specific chat",
+			Description: "Get details of a specific chat. A contact with no messages yet returns a placeholder with exists: false",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"jid": prop("string", "JID of the chat"),
+				},
+				"required": []string{"jid"},
+			},
+		},
+		{
+			Name:        ToolGetChatByPhone,
+			Description: "Look up a chat by phone number: returns the stored chat (or a placeholder with exists: false) and whether the number is on WhatsApp",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"phone": prop("string", "Phone number with country code, e.g. +44 7700 900123"),
+				},
+				"required": []string{"phone"},
+			},
+		},
+		{
+			Name:        ToolGetChatSettings,
+			Description: "Get a chat's current mute, pin, archive and unread state, preferring live app-state data when connected",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -208,19 +351,56 @@ func GetAllTools() []mcp.Tool {
 				"required": []string{"jid"},
 			},
 		},
+		{
+			Name:        ToolGetChatStats,
+			Description: "Summarize a chat's stored messages: total count, counts by media type and by sender, and the first and last message times",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"jid": prop("string", "JID of the chat"),
+				},
+				"required": []string{"jid"},
+			},
+		},
+		{
+			Name:        ToolRequestHistorySync,
+			Description: "Ask the phone for messages older than the oldest stored one in a chat. Results arrive asynchronously and are stored as they sync",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"chat_jid": prop("string", "JID of the chat"),
+					"count":    propInt("Number of older messages to request (default: 50, max: 500)"),
+				},
+				"required": []string{"chat_jid"},
+			},
+		},
 		{
 			Name:        ToolListMessages,
 			Description: "Get messages from a chat",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
-					"chat_jid": prop("string", "JID of the chat"),
-					"limit":    propInt("Maximum number of messages to return (default: 50)"),
-					"before":   prop("string", "Message ID to fetch messages before (for pagination)"),
+					"chat_jid":  prop("string", "JID of the chat"),
+					"limit":     propInt("Maximum number of messages to return (default: 50)"),
+					"before":    prop("string", "Message ID to fetch messages before (for pagination)"),
+					"direction": prop("string", "Filter by direction: all, incoming (received), or outgoing (sent) (default: all)"),
 				},
 				"required": []string{"chat_jid"},
 			},
 		},
+		{
+			Name:        ToolSearchMessages,
+			Description: "Search stored messages by text, in all chats or in one. Each result includes a snippet around the match and the match's byte offsets in the snippet for highlighting",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query":    prop("string", "Text to search for (case-insensitive)"),
+					"chat_jid": prop("string", "Only search this chat (default: all chats)"),
+					"limit":    propInt("Maximum number of results (default: 20)"),
+				},
+				"required": []string{"query"},
+			},
+		},
 		{
 			Name:        ToolArchiveChat,
 			Description: "Archive a chat",
@@ -243,6 +423,18 @@ func GetAllTools() []mcp.Tool {
 				"required": []string{"jid"},
 			},
 		},
+		{
+			Name:        ToolArchiveChats,
+			Description: "Archive or unarchive several chats in one call, reporting the result per chat",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"jids":    propArray("string", "JIDs of the chats (max 100)"),
+					"archive": propBool("Archive the chats, or unarchive them when false (default: true)"),
+				},
+				"required": []string{"jids"},
+			},
+		},
 		{
 			Name:        ToolPinChat,
 			Description: "Pin a chat to the top",
@@ -288,9 +480,46 @@ func GetAllTools() []mcp.Tool {
 				"required": []string{"jid"},
 			},
 		},
+		{
+			Name:        ToolMuteChats,
+			Description: "Mute or unmute several chats in one call, reporting the result per chat",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"jids":     propArray("string", "JIDs of the chats (max 100)"),
+					"mute":     propBool("Mute the chats, or unmute them when false (default: true)"),
+					"duration": prop("string", "Duration to mute (e.g., '8h', '1w', 'forever')"),
+				},
+				"required": []string{"jids"},
+			},
+		},
+		{
+			Name:        ToolSetDisappearingMessages,
+			Description: "Turn disappearing messages on or off for a chat",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"jid":      prop("string", "JID of the chat"),
+					"duration": prop("string", "How long messages last: 24h, 7d, 90d, or off"),
+				},
+				"required": []string{"jid", "duration"},
+			},
+		},
 		{
 			Name:        ToolMarkChatRead,
-			Description: "Mark all messages in a chat as read",
+			Description: "Mark all messages in a chat as read. When the bridge is configured not to send read receipts, this does nothing unless force is set",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"jid":   prop("string", "JID of the chat"),
+					"force": propBool("Send read receipts even if send_read_receipts is off (default: false)"),
+				},
+				"required": []string{"jid"},
+			},
+		},
+		{
+			Name:        ToolMarkChatUnread,
+			Description: "Mark a chat as unread on all devices. Complements mark_chat_read",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -299,6 +528,18 @@ func GetAllTools() []mcp.Tool {
 				"required": []string{"jid"},
 			},
 		},
+		{
+			Name:        ToolMarkAllRead,
+			Description: "Mark many chats as read at once, most recently active first",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"unread_only": propBool("Only chats with unread messages or marked as unread (default: true)"),
+					"limit":       propInt("Maximum number of chats to mark (default and max: 100)"),
+					"force":       propBool("Send read receipts even if send_read_receipts is off (default: false)"),
+				},
+			},
+		},
 		{
 			Name:        ToolDeleteChat,
 			Description: "Delete a chat locally",
@@ -310,8 +551,40 @@ func GetAllTools() []mcp.Tool {
 				"required": []string{"jid"},
 			},
 		},
+		{
+			Name:        ToolListLabels,
+			Description: "List WhatsApp Business labels and the chats they are applied to. Business accounts only",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        ToolLabelChat,
+			Description: "Apply a WhatsApp Business label to a chat. Business accounts only",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"jid":      prop("string", "JID of the chat"),
+					"label_id": prop("string", "ID of the label (from list_labels)"),
+				},
+				"required": []string{"jid", "label_id"},
+			},
+		},
+		{
+			Name:        ToolUnlabelChat,
+			Description: "Remove a WhatsApp Business label from a chat. Business accounts only",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"jid":      prop("string", "JID of the chat"),
+					"label_id": prop("string", "ID of the label (from list_labels)"),
+				},
+				"required": []string{"jid", "label_id"},
+			},
+		},
 
-		// ============ CONTACTS (6) ============
+		// ============ CONTACTS (9) ============
 		{
 			Name:        ToolSearchContacts,
 			Description: "Search contacts by name or phone number",
@@ -324,6 +597,18 @@ func GetAllTools() []mcp.Tool {
 				"required": []string{"query"},
 			},
 		},
+		{
+			Name:        ToolListContacts,
+			Description: "List stored contacts as an address book, a page at a time, optionally with each contact's chat activity",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"limit":         propInt("Maximum number of contacts (default: 50)"),
+					"offset":        propInt("Number of contacts to skip (default: 0)"),
+					"include_chats": propBool("Include has_chat and last_message_time for each contact"),
+				},
+			},
+		},
 		{
 			Name:        ToolGetContact,
 			Description: "Get details of a specific contact",
@@ -376,8 +661,29 @@ func GetAllTools() []mcp.Tool {
 				"required": []string{"phone"},
 			},
 		},
+		{
+			Name:        ToolExportContacts,
+			Description: "Export all stored contacts as a JSON array, inline or to a file",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"save_path": prop("string", "File to write the JSON export to (optional, returns the contacts inline if omitted)"),
+				},
+			},
+		},
+		{
+			Name:        ToolImportContacts,
+			Description: "Import contacts from a JSON array produced by export_contacts. Malformed entries are skipped and reported",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": prop("string", "JSON file to import (either path or data is required)"),
+					"data": prop("string", "JSON array of contacts to import"),
+				},
+			},
+		},
 
-		// ============ GROUPS (13) ============
+		// ============ GROUPS (21) ============
 		{
 			Name:        ToolCreateGroup,
 			Description: "Create a new WhatsApp group",
@@ -392,7 +698,7 @@ func GetAllTools() []mcp.Tool {
 		},
 		{
 			Name:        ToolGetGroupInfo,
-			Description: "Get information about a group",
+			Description: "Get a group's name, topic, owner, creation time, participants with their roles (superadmin, admin, member) and settings",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -401,6 +707,17 @@ func GetAllTools() []mcp.Tool {
 				"required": []string{"jid"},
 			},
 		},
+		{
+			Name:        ToolGetCommonGroups,
+			Description: "List the groups you share with a contact. Uses the live group list when connected, otherwise the stored group participants.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"jid": prop("string", "JID or phone number of the contact"),
+				},
+				"required": []string{"jid"},
+			},
+		},
 		{
 			Name:        ToolLeaveGroup,
 			Description: "Leave a group",
@@ -484,6 +801,30 @@ func GetAllTools() []mcp.Tool {
 				"required": []string{"group_jid", "topic"},
 			},
 		},
+		{
+			Name:        ToolSetGroupAnnounce,
+			Description: "Restrict sending messages in a group to admins (announcement mode)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"group_jid": prop("string", "JID of the group"),
+					"announce":  propBool("Only admins can send messages (default: true)"),
+				},
+				"required": []string{"group_jid"},
+			},
+		},
+		{
+			Name:        ToolSetGroupLocked,
+			Description: "Restrict editing group info to admins",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"group_jid": prop("string", "JID of the group"),
+					"locked":    propBool("Only admins can edit group info (default: true)"),
+				},
+				"required": []string{"group_jid"},
+			},
+		},
 		{
 			Name:        ToolSetGroupPhoto,
 			Description: "Change group profile photo",
@@ -496,13 +837,25 @@ func GetAllTools() []mcp.Tool {
 				"required": []string{"group_jid", "image_path"},
 			},
 		},
+		{
+			Name:        ToolRemoveGroupPhoto,
+			Description: "Remove the group profile photo",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"group_jid": prop("string", "JID of the group"),
+				},
+				"required": []string{"group_jid"},
+			},
+		},
 		{
 			Name:        ToolGetInviteLink,
-			Description: "Get group invite link",
+			Description: "Get group invite link. A recently fetched link is served from the local store",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
 					"group_jid": prop("string", "JID of the group"),
+					"refresh":   propBool("Fetch the link from WhatsApp even if a cached one is fresh (default: false)"),
 				},
 				"required": []string{"group_jid"},
 			},
@@ -529,17 +882,66 @@ func GetAllTools() []mcp.Tool {
 				"required": []string{"invite_link"},
 			},
 		},
+		{
+			Name:        ToolGetGroupInviteInfo,
+			Description: "Look up the group behind an invite link without joining it",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"invite_link": prop("string", "Group invite link (https://chat.whatsapp.com/...) or bare invite code"),
+				},
+				"required": []string{"invite_link"},
+			},
+		},
+		{
+			Name:        ToolListJoinRequests,
+			Description: "List pending requests to join a group that requires admin approval",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"group_jid": prop("string", "JID of the group"),
+				},
+				"required": []string{"group_jid"},
+			},
+		},
+		{
+			Name:        ToolApproveJoinRequest,
+			Description: "Approve pending requests to join a group (admin only)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"group_jid":    prop("string", "JID of the group"),
+					"participants": propArray("string", "JIDs of the requesters to approve"),
+				},
+				"required": []string{"group_jid", "participants"},
+			},
+		},
+		{
+			Name:        ToolRejectJoinRequest,
+			Description: "Reject pending requests to join a group (admin only)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"group_jid":    prop("string", "JID of the group"),
+					"participants": propArray("string", "JIDs of the requesters to reject"),
+				},
+				"required": []string{"group_jid", "participants"},
+			},
+		},
 
-		// ============ MEDIA (7) ============
+		// ============ MEDIA (13) ============
 		{
 			Name:        ToolSendImage,
 			Description: "Send an image to a chat",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
-					"recipient":  prop("string", "Phone number or JID of the recipient"),
-					"image_path": prop("string", "Path to the image file"),
-					"caption":    prop("string", "Optional caption for the image"),
+					"recipient":       prop("string", "Phone number or JID of the recipient"),
+					"image_path":      prop("string", "Path to the image file"),
+					"caption":         prop("string", "Optional caption for the image"),
+					"view_once":       propBool("Send as view-once media that the recipient can open only once (default: false)"),
+					"dry_run":         propBool("Validate inputs and report what would be sent, without sending"),
+					"idempotency_key": prop("string", "Optional key; retrying with the same key within 10 minutes returns the original message_id instead of sending again"),
 				},
 				"required": []string{"recipient", "image_path"},
 			},
@@ -550,22 +952,42 @@ func GetAllTools() []mcp.Tool {
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
-					"recipient":  prop("string", "Phone number or JID of the recipient"),
-					"video_path": prop("string", "Path to the video file"),
-					"caption":    prop("string", "Optional caption for the video"),
+					"recipient":       prop("string", "Phone number or JID of the recipient"),
+					"video_path":      prop("string", "Path to the video file"),
+					"caption":         prop("string", "Optional caption for the video"),
+					"view_once":       propBool("Send as view-once media that the recipient can open only once (default: false)"),
+					"dry_run":         propBool("Validate inputs and report what would be sent, without sending"),
+					"idempotency_key": prop("string", "Optional key; retrying with the same key within 10 minutes returns the original message_id instead of sending again"),
 				},
 				"required": []string{"recipient", "video_path"},
 			},
 		},
+		{
+			Name:        ToolSendGIF,
+			Description: "Send a looping GIF to a chat. The file must be an MP4 video; literal .gif files are rejected",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"recipient":       prop("string", "Phone number or JID of the recipient"),
+					"gif_path":        prop("string", "Path to the MP4 file to play as a GIF"),
+					"caption":         prop("string", "Optional caption for the GIF"),
+					"dry_run":         propBool("Validate inputs and report what would be sent, without sending"),
+					"idempotency_key": prop("string", "Optional key; retrying with the same key within 10 minutes returns the original message_id instead of sending again"),
+				},
+				"required": []string{"recipient", "gif_path"},
+			},
+		},
 		{
 			Name:        ToolSendAudio,
 			Description: "Send an audio file or voice message",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
-					"recipient":  prop("string", "Phone number or JID of the recipient"),
-					"audio_path": prop("string", "Path to the audio file"),
-					"as_voice":   propBool("Send as voice message (true) or audio file (false)"),
+					"recipient":       prop("string", "Phone number or JID of the recipient"),
+					"audio_path":      prop("string", "Path to the audio file"),
+					"as_voice":        propBool("Send as voice message (true) or audio file (false)"),
+					"dry_run":         propBool("Validate inputs and report what would be sent, without sending"),
+					"idempotency_key": prop("string", "Optional key; retrying with the same key within 10 minutes returns the original message_id instead of sending again"),
 				},
 				"required": []string{"recipient", "audio_path"},
 			},
@@ -576,9 +998,29 @@ func GetAllTools() []mcp.Tool {
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
-					"recipient": prop("string", "Phone number or JID of the recipient"),
-					"file_path": prop("string", "Path to the document file"),
-					"filename":  prop("string", "Optional filename to display"),
+					"recipient":       prop("string", "Phone number or JID of the recipient"),
+					"file_path":       prop("string", "Path to the document file"),
+					"filename":        prop("string", "Optional filename to display"),
+					"caption":         prop("string", "Optional caption shown below the document"),
+					"dry_run":         propBool("Validate inputs and report what would be sent, without sending"),
+					"idempotency_key": prop("string", "Optional key; retrying with the same key within 10 minutes returns the original message_id instead of sending again"),
+				},
+				"required": []string{"recipient", "file_path"},
+			},
+		},
+		{
+			Name:        ToolSendFile,
+			Description: "Send a file, choosing image, video, audio or document from its content. Files too large for their media type are sent as documents",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"recipient":       prop("string", "Phone number or JID of the recipient"),
+					"file_path":       prop("string", "Path to the file"),
+					"caption":         prop("string", "Optional caption, used when sent as an image, video or document"),
+					"filename":        prop("string", "Optional filename to display, used when sent as a document"),
+					"force_document":  propBool("Always send as a document, keeping the original file untouched"),
+					"dry_run":         propBool("Validate inputs and report what would be sent, including send_as, without sending"),
+					"idempotency_key": prop("string", "Optional key; retrying with the same key within 10 minutes returns the original message_id instead of sending again"),
 				},
 				"required": []string{"recipient", "file_path"},
 			},
@@ -589,25 +1031,63 @@ func GetAllTools() []mcp.Tool {
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
-					"recipient": prop("string", "Phone number or JID of the recipient"),
-					"latitude":  propNumber("Latitude coordinate"),
-					"longitude": propNumber("Longitude coordinate"),
-					"name":      prop("string", "Optional location name"),
-					"address":   prop("string", "Optional address"),
+					"recipient":       prop("string", "Phone number or JID of the recipient"),
+					"latitude":        propNumber("Latitude coordinate"),
+					"longitude":       propNumber("Longitude coordinate"),
+					"name":            prop("string", "Optional location name"),
+					"address":         prop("string", "Optional address"),
+					"dry_run":         propBool("Validate inputs and report what would be sent, without sending"),
+					"idempotency_key": prop("string", "Optional key; retrying with the same key within 10 minutes returns the original message_id instead of sending again"),
+				},
+				"required": []string{"recipient", "latitude", "longitude"},
+			},
+		},
+		{
+			Name:        ToolSendLiveLocation,
+			Description: "Share a live location with a chat. Calling again for the same chat before the duration ends sends a position update",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"recipient":        prop("string", "Phone number or JID of the recipient"),
+					"latitude":         propNumber("Latitude coordinate"),
+					"longitude":        propNumber("Longitude coordinate"),
+					"duration_seconds": propInt("How long to share the location, in seconds (default 900, max 28800)"),
+					"dry_run":          propBool("Validate inputs and report what would be sent, without sending"),
+					"idempotency_key":  prop("string", "Optional key; retrying with the same key within 10 minutes returns the original message_id instead of sending again"),
 				},
 				"required": []string{"recipient", "latitude", "longitude"},
 			},
 		},
 		{
 			Name:        ToolSendContactCard,
-			Description: "Send a contact card to a chat",
+			Description: "Send a contact card to a chat, either for an existing contact (contact_jid) or for any number (name and phone)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"recipient":       prop("string", "Phone number or JID of the recipient"),
+					"contact_jid":     prop("string", "JID of the contact to share. Use this or name and phone"),
+					"name":            prop("string", "Name on the card, for a number that is not a WhatsApp contact"),
+					"phone":           prop("string", "Phone number in international format, with name"),
+					"organization":    prop("string", "Optional organization, with name and phone"),
+					"email":           prop("string", "Optional email address, with name and phone"),
+					"dry_run":         propBool("Validate inputs and report what would be sent, without sending"),
+					"idempotency_key": prop("string", "Optional key; retrying with the same key within 10 minutes returns the original message_id instead of sending again"),
+				},
+				"required": []string{"recipient"},
+			},
+		},
+		{
+			Name:        ToolSendContacts,
+			Description: "Send several contact cards in one message",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
-					"recipient":   prop("string", "Phone number or JID of the recipient"),
-					"contact_jid": prop("string", "JID of the contact to share"),
+					"recipient":       prop("string", "Phone number or JID of the recipient"),
+					"contact_jids":    propArray("string", "Phone numbers or JIDs of the contacts to share (max 50)"),
+					"dry_run":         propBool("Validate inputs and report what would be sent, without sending"),
+					"idempotency_key": prop("string", "Optional key; retrying with the same key within 10 minutes returns the original message_id instead of sending again"),
 				},
-				"required": []string{"recipient", "contact_jid"},
+				"required": []string{"recipient", "contact_jids"},
 			},
 		},
 		{
@@ -623,8 +1103,31 @@ func GetAllTools() []mcp.Tool {
 				"required": []string{"chat_jid", "message_id"},
 			},
 		},
+		{
+			Name:        ToolGetMediaThumbnail,
+			Description: "Get the small JPEG preview of an image, video or document message as an image, without downloading the media",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"chat_jid":   prop("string", "JID of the chat"),
+					"message_id": prop("string", "ID of the media message"),
+				},
+				"required": []string{"chat_jid", "message_id"},
+			},
+		},
+		{
+			Name:        ToolListPendingMedia,
+			Description: "List media messages whose files have not been downloaded yet, newest first, with their sizes, so they can be fetched with download_media",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"chat_jid": prop("string", "Only list media from this chat (default: all chats)"),
+					"limit":    propInt("Maximum number of messages to return (default: 50, max: 500)"),
+				},
+			},
+		},
 
-		// ============ PRESENCE (5) ============
+		// ============ PRESENCE (7) ============
 		{
 			Name:        ToolSubscribePresence,
 			Description: "Subscribe to presence updates for a contact",
@@ -636,6 +1139,29 @@ func GetAllTools() []mcp.Tool {
 				"required": []string{"jid"},
 			},
 		},
+		{
+			Name:        ToolGetPresence,
+			Description: "Get a contact's last known online, last seen and typing state. Requires a prior subscribe_presence",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"jid": prop("string", "JID of the contact"),
+				},
+				"required": []string{"jid"},
+			},
+		},
+		{
+			Name:        ToolGetChatParticipantsPresence,
+			Description: "Get the online, last seen and typing state of every participant of a group. Subscribes to each participant first; presence only arrives for contacts who share it",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"jid":       prop("string", "JID of the group"),
+					"subscribe": propBool("Subscribe to each participant's presence before reading (default: true)"),
+				},
+				"required": []string{"jid"},
+			},
+		},
 		{
 			Name:        ToolSendTyping,
 			Description: "Send typing indicator to a chat",
@@ -675,7 +1201,7 @@ func GetAllTools() []mcp.Tool {
 			},
 		},
 
-		// ============ STATUS (4) ============
+		// ============ STATUS (5) ============
 		{
 			Name:        ToolPostTextStatus,
 			Description: "Post a text status update",
@@ -721,8 +1247,43 @@ func GetAllTools() []mcp.Tool {
 				"required": []string{"status_id"},
 			},
 		},
+		{
+			Name:        ToolReplyToStatus,
+			Description: "Reply privately to a contact's status. The reply is sent to the chat with the status owner, quoting the status",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"status_id":       prop("string", "ID of the status, as returned by get_status_updates"),
+					"text":            prop("string", "Text of the reply"),
+					"idempotency_key": prop("string", "Optional key; retrying with the same key within 10 minutes returns the original message_id instead of sending again"),
+				},
+				"required": []string{"status_id", "text"},
+			},
+		},
+
+		// ============ CHANNELS (2) ============
+		{
+			Name:        ToolListNewsletters,
+			Description: "List the WhatsApp channels (newsletters) the account follows",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        ToolGetNewsletterMessages,
+			Description: "Fetch the latest posts of a WhatsApp channel and store them locally",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"jid":   prop("string", "Channel JID (ends in @newsletter)"),
+					"count": propInt("Number of posts to fetch (default: 20, max: 100)"),
+				},
+				"required": []string{"jid"},
+			},
+		},
 
-		// ============ BRIDGE (2) ============
+		// ============ BRIDGE (9) ============
 		{
 			Name:        ToolGetBridgeStatus,
 			Description: "Get the current health status of the WhatsApp bridge",
@@ -731,6 +1292,14 @@ func GetAllTools() []mcp.Tool {
 				"properties": map[string]interface{}{},
 			},
 		},
+		{
+			Name:        ToolPingWhatsApp,
+			Description: "Measure the round-trip latency to the WhatsApp server. The result is also shown in get_bridge_status",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
 		{
 			Name:        ToolGetConnectionHistory,
 			Description: "Get the state transition history of the bridge",
@@ -738,9 +1307,66 @@ func GetAllTools() []mcp.Tool {
 				"type": "object",
 				"properties": map[string]interface{}{
 					"limit": propInt("Maximum number of transitions to return (default: 20)"),
+					"since": prop("string", "Only return transitions at or after this time (RFC3339)"),
+					"state": prop("string", "Only return transitions into this state (e.g., reconnecting)"),
+				},
+			},
+		},
+		{
+			Name:        ToolSelfTest,
+			Description: "Run a quick diagnostic: database, WhatsApp connection, bridge state, disk space and last received message",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        ToolListLinkedDevices,
+			Description: "List the devices linked to this WhatsApp account (primary phone, this bridge and other companions) to audit the session",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        ToolGetAuditLog,
+			Description: "Get the audit log of mutating tool calls (tool, target JID, time and outcome; message content is never recorded), newest first",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"limit": propInt("Maximum number of entries to return (default: 50)"),
+					"since": prop("string", "Only return calls at or after this time (RFC3339)"),
+					"until": prop("string", "Only return calls before this time (RFC3339)"),
+					"tool":  prop("string", "Only return calls to this tool (e.g., send_message)"),
+				},
+			},
+		},
+		{
+			Name:        ToolGetToolUsageStats,
+			Description: "Get call counts and average latency per tool since the bridge started, busiest tool first",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        ToolRetryFailedStores,
+			Description: "Store again the received messages that failed to save to the local database, oldest first",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"limit": propInt("Maximum number of messages to retry (default: 100)"),
 				},
 			},
 		},
+		{
+			Name:        ToolGetSyncProgress,
+			Description: "Report startup and history sync progress: the bridge state, whether a sync is still running, how many conversations and messages have been synced, and WhatsApp's completion estimate",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
 	}
 }
 
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// toolSchemas maps each tool name to its input schema.
+var toolSchemas = func() map[string]map[string]interface{} {
+	schemas := make(map[string]map[string]interface{})
+	for _, tool := range GetAllTools() {
+		schemas[tool.Name] = tool.InputSchema
+	}
+	return schemas
+}()
+
+// validateArgs checks args against the input schema of the named tool:
+// required fields must be present and every known field must have the
+// declared type, recursing into objects and arrays. All problems are
+// reported together in one INVALID_INPUT error so a caller can fix them in
+// a single retry. Value checks (JID format, ranges) stay in the handlers.
+func validateArgs(name string, args map[string]interface{}) *MCPError {
+	schema, ok := toolSchemas[name]
+	if !ok {
+		return nil
+	}
+
+	var missing, mistyped []string
+	checkObject(schema, args, "", &missing, &mistyped)
+	if len(missing) == 0 && len(mistyped) == 0 {
+		return nil
+	}
+
+	var parts []string
+	if len(missing) > 0 {
+		parts = append(parts, "missing required fields: "+strings.Join(missing, ", "))
+	}
+	if len(mistyped) > 0 {
+		parts = append(parts, "wrong types: "+strings.Join(mistyped, ", "))
+	}
+	return NewInvalidInputError("invalid arguments: " + strings.Join(parts, "; "))
+}
+
+// checkObject validates obj against an object schema, prefixing field
+// names with path for nested values.
+func checkObject(schema map[string]interface{}, obj map[string]interface{}, path string, missing, mistyped *[]string) {
+	for _, field := range requiredFields(schema) {
+		if obj[field] == nil {
+			*missing = append(*missing, path+field)
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]interface{})
+	fields := make([]string, 0, len(props))
+	for field := range props {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		value := obj[field]
+		if value == nil {
+			continue
+		}
+		propSchema, _ := props[field].(map[string]interface{})
+		checkValue(propSchema, value, path+field, missing, mistyped)
+	}
+}
+
+// checkValue validates a single value against its schema.
+func checkValue(schema map[string]interface{}, value interface{}, path string, missing, mistyped *[]string) {
+	typeName, _ := schema["type"].(string)
+	if typeName == "" {
+		return
+	}
+	if !hasType(value, typeName) {
+		*mistyped = append(*mistyped, fmt.Sprintf("%s (expected %s)", path, typeName))
+		return
+	}
+
+	switch typeName {
+	case "object":
+		checkObject(schema, value.(map[string]interface{}), path+".", missing, mistyped)
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range value.([]interface{}) {
+			checkValue(items, item, fmt.Sprintf("%s[%d]", path, i), missing, mistyped)
+		}
+	}
+}
+
+// hasType reports whether value, as decoded from JSON, matches a JSON
+// schema type. Integers arrive as float64, so whole numbers a float64 holds
+// exactly are accepted.
+// Loosely typed clients send IDs as numbers and counts as strings, so
+// whole numbers that survived decoding exactly pass as strings and integer
+// strings as integers, matching what getString and getInt accept.
+func hasType(value interface{}, typeName string) bool {
+	switch typeName {
+	case "string":
+		switch v := value.(type) {
+		case string, int:
+			return true
+		case float64:
+			return isExactInteger(v)
+		}
+		return false
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		switch value.(type) {
+		case float64, int:
+			return true
+		}
+		return false
+	case "integer":
+		switch v := value.(type) {
+		case int:
+			return true
+		case float64:
+			return isExactInteger(v)
+		case string:
+			_, err := strconv.Atoi(strings.TrimSpace(v))
+			return err == nil
+		}
+		return false
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+func requiredFields(schema map[string]interface{}) []string {
+	switch v := schema["required"].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		fields := make([]string, 0, len(v))
+		for _, f := range v {
+			if s, ok := f.(string); ok {
+				fields = append(fields, s)
+			}
+		}
+		return fields
+	}
+	return nil
+}
@@ -39,6 +39,10 @@ const (
 
 // MCP Protocol types
 
+// SupportedProtocolVersions lists the MCP revisions the server speaks,
+// oldest first. Revisions are dates, so they sort as strings.
+var SupportedProtocolVersions = []string{"2024-11-05", "2025-03-26", "2025-06-18"}
+
 // InitializeParams contains the parameters for the initialize request.
 type InitializeParams struct {
 	ProtocolVersion string         `json:"protocolVersion"`
@@ -112,9 +116,12 @@ type CallToolParams struct {
 }
 
 // CallToolResult contains the result of tools/call.
+// StructuredContent carries the same result as a JSON object for clients
+// that read it programmatically instead of parsing the text blocks.
 type CallToolResult struct {
-	Content []ContentBlock `json:"content"`
-	IsError bool           `json:"isError,omitempty"`
+	Content           []ContentBlock `json:"content"`
+	StructuredContent interface{}    `json:"structuredContent,omitempty"`
+	IsError           bool           `json:"isError,omitempty"`
 }
 
 // ContentBlock represents a content block in tool results.
@@ -158,6 +165,18 @@ type ReadResourceResult struct {
 	Contents []ResourceContent `json:"contents"`
 }
 
+// SubscribeParams contains the parameters for resources/subscribe and
+// resources/unsubscribe.
+type SubscribeParams struct {
+	URI string `json:"uri"`
+}
+
+// ResourceUpdatedParams contains the parameters for the
+// notifications/resources/updated notification.
+type ResourceUpdatedParams struct {
+	URI string `json:"uri"`
+}
+
 // ResourceContent represents the content of a resource.
 type ResourceContent struct {
 	URI      string `json:"uri"`
@@ -3,11 +3,25 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
+// chatResourcePrefix is the URI scheme of the per-chat resources clients can
+// subscribe to; the chat JID follows it.
+const chatResourcePrefix = "whatsapp://chat/"
+
+// ChatResourceURI returns the resource URI of the chat with the given JID.
+func ChatResourceURI(jid string) string {
+	return chatResourcePrefix + jid
+}
+
 // ToolHandler is the interface for handling tool calls.
 type ToolHandler interface {
 	GetTools() []Tool
@@ -21,15 +35,23 @@ type Server struct {
 	log         *slog.Logger
 	initialized bool
 
+	// callTimeout bounds each tools/call; zero means no limit.
+	callTimeout time.Duration
+
+	// subscriptions holds the resource URIs the client subscribed to.
+	subMu         sync.Mutex
+	subscriptions map[string]bool
+
 	serverInfo Implementation
 }
 
 // NewServer creates a new MCP server.
 func NewServer(reader io.Reader, writer io.Writer, handler ToolHandler, log *slog.Logger) *Server {
 	return &Server{
-		transport: NewTransport(reader, writer, log),
-		handler:   handler,
-		log:       log,
+		transport:     NewTransport(reader, writer, log),
+		handler:       handler,
+		log:           log,
+		subscriptions: make(map[string]bool),
 		serverInfo: Implementation{
 			Name:    "whatsapp-bridge-v2",
 			Version: "2.0.0",
@@ -37,6 +59,13 @@ func NewServer(reader io.Reader, writer io.Writer, handler ToolHandler, log *slo
 	}
 }
 
+// SetToolCallTimeout limits how long a single tool call may run. Calls that
+// exceed it are answered with a TIMEOUT error and their context is cancelled.
+// Zero disables the limit.
+func (s *Server) SetToolCallTimeout(d time.Duration) {
+	s.callTimeout = d
+}
+
 // Run starts the server message loop.
 func (s *Server) Run(ctx context.Context) error {
 	s.log.Info("MCP server starting")
@@ -86,6 +115,10 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) error {
 		return s.handleResourcesList(req)
 	case "resources/read":
 		return s.handleResourcesRead(req)
+	case "resources/subscribe":
+		return s.handleResourcesSubscribe(req, true)
+	case "resources/unsubscribe":
+		return s.handleResourcesSubscribe(req, false)
 	default:
 		return s.transport.SendError(req.ID, MethodNotFound, fmt.Sprintf("Unknown method: %s", req.Method), nil)
 	}
@@ -99,20 +132,29 @@ func (s *Server) handleInitialize(req *Request) error {
 		}
 	}
 
+	version, ok := negotiateProtocolVersion(params.ProtocolVersion)
+	if !ok {
+		return s.transport.SendError(req.ID, InvalidParams, "Unsupported protocol version", map[string]interface{}{
+			"requested": params.ProtocolVersion,
+			"supported": SupportedProtocolVersions,
+		})
+	}
+
 	s.log.Info("Client initializing",
 		"client", params.ClientInfo.Name,
 		"version", params.ClientInfo.Version,
 		"protocol", params.ProtocolVersion,
+		"negotiated", version,
 	)
 
 	result := InitializeResult{
-		ProtocolVersion: "2024-11-05",
+		ProtocolVersion: version,
 		Capabilities: ServerCapabilities{
 			Tools: &ToolsCapability{
 				ListChanged: false,
 			},
 			Resources: &ResourcesCapability{
-				Subscribe:   false,
+				Subscribe:   true,
 				ListChanged: false,
 			},
 		},
@@ -122,6 +164,27 @@ func (s *Server) handleInitialize(req *Request) error {
 	return s.transport.SendResult(req.ID, result)
 }
 
+// negotiateProtocolVersion picks the protocol revision to answer a client
+// requesting the given one with: the same revision when supported, else the
+// newest supported revision not newer than it. A client that names no
+// revision gets the newest. Revisions that are not dates, or predate every
+// supported one, are rejected.
+func negotiateProtocolVersion(requested string) (string, bool) {
+	latest := SupportedProtocolVersions[len(SupportedProtocolVersions)-1]
+	if requested == "" {
+		return latest, true
+	}
+	if _, err := time.Parse("2006-01-02", requested); err != nil {
+		return "", false
+	}
+	for i := len(SupportedProtocolVersions) - 1; i >= 0; i-- {
+		if v := SupportedProtocolVersions[i]; v <= requested {
+			return v, true
+		}
+	}
+	return "", false
+}
+
 func (s *Server) handleToolsList(req *Request) error {
 	tools := s.handler.GetTools()
 	result := ListToolsResult{Tools: tools}
@@ -134,11 +197,27 @@ func (s *Server) handleToolsCall(ctx context.Context, req *Request) error {
 		return s.transport.SendError(req.ID, InvalidParams, "Invalid tool call params", nil)
 	}
 
-	s.log.Info("Tool call", "name", params.Name)
+	start := time.Now()
+	result, err := s.callTool(ctx, params.Name, params.Arguments)
+	duration := time.Since(start)
+
+	if errors.Is(err, context.DeadlineExceeded) && s.callTimeout > 0 {
+		s.log.Warn("Tool call timed out",
+			"name", params.Name,
+			"duration", duration,
+			"timeout", s.callTimeout,
+			"arg_keys", argKeys(params.Arguments),
+		)
+		return s.transport.SendResult(req.ID, timeoutResult(params.Name, s.callTimeout))
+	}
 
-	result, err := s.handler.HandleTool(ctx, params.Name, params.Arguments)
 	if err != nil {
-		s.log.Error("Tool call failed", "name", params.Name, "error", err)
+		s.log.Error("Tool call failed",
+			"name", params.Name,
+			"duration", duration,
+			"arg_keys", argKeys(params.Arguments),
+			"error", err,
+		)
 		// Return error as tool result, not JSON-RPC error
 		return s.transport.SendResult(req.ID, &CallToolResult{
 			Content: []ContentBlock{TextContent(fmt.Sprintf("Error: %s", err.Error()))},
@@ -146,9 +225,75 @@ func (s *Server) handleToolsCall(ctx context.Context, req *Request) error {
 		})
 	}
 
+	s.log.Info("Tool call",
+		"name", params.Name,
+		"duration", duration,
+		"is_error", result != nil && result.IsError,
+		"arg_keys", argKeys(params.Arguments),
+	)
+
 	return s.transport.SendResult(req.ID, result)
 }
 
+// callTool runs a tool with the per-call timeout applied. A handler that does
+// not return once its context is cancelled is abandoned so the server keeps
+// serving requests; the timeout is reported as context.DeadlineExceeded.
+func (s *Server) callTool(ctx context.Context, name string, args map[string]interface{}) (*CallToolResult, error) {
+	if s.callTimeout <= 0 {
+		return s.handler.HandleTool(ctx, name, args)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.callTimeout)
+	defer cancel()
+
+	type outcome struct {
+		result *CallToolResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := s.handler.HandleTool(ctx, name, args)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case o := <-done:
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, ctx.Err()
+		}
+		return o.result, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// timeoutResult reports an expired tool call in the same shape as other
+// tool errors.
+func timeoutResult(name string, timeout time.Duration) *CallToolResult {
+	data := map[string]interface{}{
+		"code":    "TIMEOUT",
+		"message": fmt.Sprintf("Tool call %s timed out after %s", name, timeout),
+		"retry":   true,
+	}
+	text, _ := json.Marshal(data)
+	return &CallToolResult{
+		Content:           []ContentBlock{TextContent(string(text))},
+		StructuredContent: data,
+		IsError:           true,
+	}
+}
+
+// argKeys returns the sorted argument names of a tool call. Values are
+// deliberately left out of logs since they may contain message content.
+func argKeys(args map[string]interface{}) []string {
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (s *Server) handleResourcesList(req *Request) error {
 	// Return empty list for now - can be expanded later
 	result := ListResourcesResult{Resources: []Resource{}}
@@ -164,3 +309,41 @@ func (s *Server) handleResourcesRead(req *Request) error {
 	// Return not found for now
 	return s.transport.SendError(req.ID, -32002, fmt.Sprintf("Resource not found: %s", params.URI), nil)
 }
+
+// handleResourcesSubscribe adds or removes a subscription. Only chat URIs
+// (whatsapp://chat/{jid}) can be subscribed to; unsubscribing from a URI
+// that was never subscribed is not an error.
+func (s *Server) handleResourcesSubscribe(req *Request, subscribe bool) error {
+	var params SubscribeParams
+	if req.Params != nil {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return s.transport.SendError(req.ID, InvalidParams, "Invalid subscribe params", nil)
+		}
+	}
+	if !strings.HasPrefix(params.URI, chatResourcePrefix) || len(params.URI) == len(chatResourcePrefix) {
+		return s.transport.SendError(req.ID, InvalidParams, fmt.Sprintf("Unsupported resource URI: %q", params.URI), nil)
+	}
+
+	s.subMu.Lock()
+	if subscribe {
+		s.subscriptions[params.URI] = true
+	} else {
+		delete(s.subscriptions, params.URI)
+	}
+	s.subMu.Unlock()
+
+	s.log.Debug("resource subscription changed", "uri", params.URI, "subscribed", subscribe)
+	return s.transport.SendResult(req.ID, map[string]interface{}{})
+}
+
+// NotifyResourceUpdated sends notifications/resources/updated for uri if
+// the client is subscribed to it, and does nothing otherwise.
+func (s *Server) NotifyResourceUpdated(uri string) error {
+	s.subMu.Lock()
+	subscribed := s.subscriptions[uri]
+	s.subMu.Unlock()
+	if !subscribed {
+		return nil
+	}
+	return s.transport.SendNotification("notifications/resources/updated", ResourceUpdatedParams{URI: uri})
+}
@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"strings"
 	"testing"
+	"time"
 )
 
 // mockHandler implements ToolHandler for testing.
@@ -65,6 +66,63 @@ func TestServerInitialize(t *testing.T) {
 	_ = server // Verify server was created
 }
 
+func TestServerInitialize_NegotiatesProtocolVersion(t *testing.T) {
+	tests := []struct {
+		requested string
+		want      string // empty means the request is rejected
+	}{
+		{"2025-03-26", "2025-03-26"},
+		{"2024-11-05", "2024-11-05"},
+		{"2025-01-01", "2024-11-05"},
+		{"2099-01-01", "2025-06-18"},
+		{"", "2025-06-18"},
+		{"2024-01-01", ""},
+		{"1.0", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.requested, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			server := NewServer(&bytes.Buffer{}, output, &mockHandler{}, logger)
+
+			req := &Request{
+				JSONRPC: "2.0",
+				ID:      json.RawMessage(`1`),
+				Method:  "initialize",
+				Params:  json.RawMessage(`{"protocolVersion": "` + tt.requested + `", "clientInfo": {"name": "test"}}`),
+			}
+			if err := server.handleRequest(context.Background(), req); err != nil {
+				t.Fatalf("initialize error = %v", err)
+			}
+
+			var resp struct {
+				Result *InitializeResult `json:"result"`
+				Error  *Error            `json:"error"`
+			}
+			if err := json.Unmarshal(output.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response %q: %v", output.String(), err)
+			}
+
+			if tt.want == "" {
+				if resp.Error == nil || resp.Error.Code != InvalidParams {
+					t.Fatalf("expected invalid params, got %s", output.String())
+				}
+				if !strings.Contains(output.String(), `"supported":["2024-11-05"`) {
+					t.Errorf("expected the supported versions in the error, got %s", output.String())
+				}
+				return
+			}
+			if resp.Result == nil {
+				t.Fatalf("expected a result, got %s", output.String())
+			}
+			if resp.Result.ProtocolVersion != tt.want {
+				t.Errorf("ProtocolVersion = %q, want %q", resp.Result.ProtocolVersion, tt.want)
+			}
+		})
+	}
+}
+
 func TestJSONRPCMessageParsing(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -188,3 +246,143 @@ func TestTextContent(t *testing.T) {
 		t.Errorf("Text = %v, want 'test message'", content.Text)
 	}
 }
+
+func TestHandleToolsCallLogsLatency(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	output := &bytes.Buffer{}
+	server := NewServer(&bytes.Buffer{}, output, &mockHandler{}, logger)
+
+	req := &Request{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage(`7`),
+		Method:  "tools/call",
+		Params:  json.RawMessage(`{"name":"send_message","arguments":{"recipient":"123@s.whatsapp.net","message":"secret text"}}`),
+	}
+	if err := server.handleToolsCall(context.Background(), req); err != nil {
+		t.Fatalf("handleToolsCall() error = %v", err)
+	}
+
+	line := logs.String()
+	if !strings.Contains(line, "name=send_message") {
+		t.Errorf("log line missing tool name: %s", line)
+	}
+	if !strings.Contains(line, "duration=") {
+		t.Errorf("log line missing duration: %s", line)
+	}
+	if !strings.Contains(line, "is_error=false") {
+		t.Errorf("log line missing outcome: %s", line)
+	}
+	if !strings.Contains(line, "arg_keys=\"[message recipient]\"") {
+		t.Errorf("log line missing argument keys: %s", line)
+	}
+	if strings.Contains(line, "secret text") {
+		t.Errorf("log line leaked argument values: %s", line)
+	}
+}
+
+// slowHandler simulates a client operation that hangs. It ignores
+// cancellation unless honorCtx is set.
+type slowHandler struct {
+	mockHandler
+	honorCtx bool
+}
+
+func (h *slowHandler) HandleTool(ctx context.Context, name string, args map[string]interface{}) (*CallToolResult, error) {
+	if h.honorCtx {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	time.Sleep(5 * time.Second)
+	return &CallToolResult{Content: []ContentBlock{TextContent("too late")}}, nil
+}
+
+func TestHandleToolsCallTimeout(t *testing.T) {
+	for _, honorCtx := range []bool{true, false} {
+		output := &bytes.Buffer{}
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		server := NewServer(&bytes.Buffer{}, output, &slowHandler{honorCtx: honorCtx}, logger)
+		server.SetToolCallTimeout(50 * time.Millisecond)
+
+		req := &Request{
+			JSONRPC: "2.0",
+			ID:      json.RawMessage(`3`),
+			Method:  "tools/call",
+			Params:  json.RawMessage(`{"name":"send_message","arguments":{}}`),
+		}
+
+		start := time.Now()
+		if err := server.handleToolsCall(context.Background(), req); err != nil {
+			t.Fatalf("handleToolsCall() error = %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Fatalf("handleToolsCall() blocked for %s (honorCtx=%v)", elapsed, honorCtx)
+		}
+
+		var resp struct {
+			Result struct {
+				IsError           bool `json:"isError"`
+				StructuredContent struct {
+					Code  string `json:"code"`
+					Retry bool   `json:"retry"`
+				} `json:"structuredContent"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(output.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response %q: %v", output.String(), err)
+		}
+		if !resp.Result.IsError {
+			t.Errorf("isError = false, want true (honorCtx=%v)", honorCtx)
+		}
+		if resp.Result.StructuredContent.Code != "TIMEOUT" {
+			t.Errorf("code = %q, want TIMEOUT (honorCtx=%v)", resp.Result.StructuredContent.Code, honorCtx)
+		}
+	}
+}
+
+func TestResourcesSubscribe(t *testing.T) {
+	output := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := NewServer(&bytes.Buffer{}, output, &mockHandler{}, logger)
+
+	subscribe := func(method, uri string) {
+		t.Helper()
+		output.Reset()
+		req := &Request{JSONRPC: "2.0", ID: json.RawMessage(`1`), Method: method, Params: json.RawMessage(`{"uri":"` + uri + `"}`)}
+		if err := server.handleRequest(context.Background(), req); err != nil {
+			t.Fatalf("%s error = %v", method, err)
+		}
+	}
+	uri := ChatResourceURI("123@s.whatsapp.net")
+
+	subscribe("resources/subscribe", "https://example.com/")
+	if !strings.Contains(output.String(), `"code":-32602`) {
+		t.Errorf("expected invalid params for a non-chat URI, got %s", output.String())
+	}
+
+	subscribe("resources/subscribe", uri)
+	if strings.Contains(output.String(), `"error"`) {
+		t.Fatalf("subscribe failed: %s", output.String())
+	}
+	output.Reset()
+	if err := server.NotifyResourceUpdated(uri); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output.String(), `"method":"notifications/resources/updated"`) {
+		t.Errorf("expected an update notification, got %q", output.String())
+	}
+
+	// Other chats and unsubscribed chats stay quiet
+	output.Reset()
+	if err := server.NotifyResourceUpdated(ChatResourceURI("456@s.whatsapp.net")); err != nil {
+		t.Fatal(err)
+	}
+	subscribe("resources/unsubscribe", uri)
+	output.Reset()
+	if err := server.NotifyResourceUpdated(uri); err != nil {
+		t.Fatal(err)
+	}
+	if output.Len() != 0 {
+		t.Errorf("expected no notification, got %q", output.String())
+	}
+}